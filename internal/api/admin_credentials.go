@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// credentialSummary is the redacted view of one credential returned by GET /admin/credentials.
+// It never carries tokens, attributes, or metadata.
+type credentialSummary struct {
+	ID            string     `json:"id"`
+	Label         string     `json:"label,omitempty"`
+	Status        string     `json:"status"`
+	StatusMessage string     `json:"status_message,omitempty"`
+	Disabled      bool       `json:"disabled"`
+	Failing       bool       `json:"failing"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+}
+
+// providerCredentials aggregates the credentials loaded for one provider.
+type providerCredentials struct {
+	Provider    string              `json:"provider"`
+	Loaded      int                 `json:"loaded"`
+	Disabled    int                 `json:"disabled"`
+	Failing     int                 `json:"failing"`
+	NextExpiry  *time.Time          `json:"next_expiry,omitempty"`
+	Credentials []credentialSummary `json:"credentials"`
+}
+
+// credentialFailing reports whether a credential is currently out of rotation because of
+// upstream errors rather than operator action.
+func credentialFailing(auth *coreauth.Auth) bool {
+	if auth.Disabled {
+		return false
+	}
+	return auth.Unavailable || auth.Status == coreauth.StatusError
+}
+
+// summarizeCredentials groups auths by provider and sorts providers and credentials by name.
+func summarizeCredentials(auths []*coreauth.Auth) []providerCredentials {
+	byProvider := make(map[string]*providerCredentials)
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		group, ok := byProvider[auth.Provider]
+		if !ok {
+			group = &providerCredentials{Provider: auth.Provider, Credentials: []credentialSummary{}}
+			byProvider[auth.Provider] = group
+		}
+		summary := credentialSummary{
+			ID:            auth.ID,
+			Label:         auth.Label,
+			Status:        string(auth.Status),
+			StatusMessage: auth.StatusMessage,
+			Disabled:      auth.Disabled,
+			Failing:       credentialFailing(auth),
+		}
+		if expiry, okExpiry := auth.ExpirationTime(); okExpiry {
+			expiry = expiry.UTC()
+			summary.ExpiresAt = &expiry
+			if !auth.Disabled && (group.NextExpiry == nil || expiry.Before(*group.NextExpiry)) {
+				group.NextExpiry = &expiry
+			}
+		}
+		group.Loaded++
+		if summary.Disabled {
+			group.Disabled++
+		}
+		if summary.Failing {
+			group.Failing++
+		}
+		group.Credentials = append(group.Credentials, summary)
+	}
+
+	out := make([]providerCredentials, 0, len(byProvider))
+	for _, group := range byProvider {
+		sort.Slice(group.Credentials, func(i, j int) bool { return group.Credentials[i].ID < group.Credentials[j].ID })
+		out = append(out, *group)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// listCredentials reports the loaded credentials per provider with their health and the
+// next upcoming expiry. Token values are never included.
+func (s *Server) listCredentials(c *gin.Context) {
+	var auths []*coreauth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": summarizeCredentials(auths)})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gin "github.com/gin-gonic/gin"
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAdminCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("MANAGEMENT_PASSWORD", "admin-secret")
+
+	tmpDir := t.TempDir()
+	cfg := &proxyconfig.Config{AuthDir: tmpDir}
+	manager := auth.NewManager(nil, nil, nil)
+	server := NewServer(cfg, manager, sdkaccess.NewManager(), filepath.Join(tmpDir, "config.yaml"))
+
+	soon := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	later := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	for _, a := range []*auth.Auth{
+		{ID: "codex-a", Provider: "codex", Status: auth.StatusActive, Metadata: map[string]any{"access_token": "secret-token-a", "expired": later.Format(time.RFC3339)}},
+		{ID: "codex-b", Provider: "codex", Status: auth.StatusError, StatusMessage: "unauthorized", Metadata: map[string]any{"access_token": "secret-token-b", "expired": soon.Format(time.RFC3339)}},
+		{ID: "codex-c", Provider: "codex", Status: auth.StatusDisabled, Disabled: true},
+		{ID: "copilot-a", Provider: "copilot", Status: auth.StatusActive, Attributes: map[string]string{"api_key": "secret-key"}},
+	} {
+		if _, err := manager.Register(context.Background(), a); err != nil {
+			t.Fatalf("register %s: %v", a.ID, err)
+		}
+	}
+
+	get := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/credentials", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get(""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without management key, got %d", rr.Code)
+	}
+
+	rr := get("admin-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "secret-") {
+		t.Fatalf("response leaks credential values: %s", rr.Body.String())
+	}
+
+	var body struct {
+		Providers []providerCredentials `json:"providers"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Providers) != 2 || body.Providers[0].Provider != "codex" || body.Providers[1].Provider != "copilot" {
+		t.Fatalf("providers = %+v, want codex and copilot", body.Providers)
+	}
+	codex := body.Providers[0]
+	if codex.Loaded != 3 || codex.Disabled != 1 || codex.Failing != 1 {
+		t.Fatalf("codex counts = loaded %d disabled %d failing %d, want 3/1/1", codex.Loaded, codex.Disabled, codex.Failing)
+	}
+	if codex.NextExpiry == nil || !codex.NextExpiry.Equal(soon) {
+		t.Fatalf("codex next expiry = %v, want %v", codex.NextExpiry, soon)
+	}
+	if got := codex.Credentials[1]; got.ID != "codex-b" || !got.Failing || got.StatusMessage != "unauthorized" {
+		t.Fatalf("codex-b summary = %+v", got)
+	}
+	copilot := body.Providers[1]
+	if copilot.Loaded != 1 || copilot.Failing != 0 || copilot.NextExpiry != nil {
+		t.Fatalf("copilot summary = %+v", copilot)
+	}
+}
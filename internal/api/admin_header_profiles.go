@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+)
+
+// listCopilotHeaderProfiles reports which Copilot header profile each known model resolves
+// to under the current configuration, per copilot-api-key entry.
+func (s *Server) listCopilotHeaderProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": executor.CopilotHeaderProfiles(s.cfg)})
+}
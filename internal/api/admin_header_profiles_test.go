@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	gin "github.com/gin-gonic/gin"
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAdminCopilotHeaderProfiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("MANAGEMENT_PASSWORD", "admin-secret")
+
+	tmpDir := t.TempDir()
+	cfg := &proxyconfig.Config{AuthDir: tmpDir, CopilotKey: []proxyconfig.CopilotKey{{Account: "octocat"}}}
+	server := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), filepath.Join(tmpDir, "config.yaml"))
+
+	get := func() []executor.CopilotHeaderProfileReport {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/admin/copilot/header-profiles", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+		}
+		var body struct {
+			Keys []executor.CopilotHeaderProfileReport `json:"keys"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return body.Keys
+	}
+	profileOf := func(report executor.CopilotHeaderProfileReport, model string) string {
+		for _, entry := range report.Models {
+			if entry.Model == model {
+				return entry.Profile
+			}
+		}
+		return ""
+	}
+
+	keys := get()
+	if len(keys) != 1 || keys[0].Account != "octocat" {
+		t.Fatalf("keys = %+v, want the octocat entry", keys)
+	}
+	if got := profileOf(keys[0], "gpt-4.1"); got != "cli" {
+		t.Fatalf("gpt-4.1 profile = %q, want built-in cli", got)
+	}
+
+	server.UpdateClients(&proxyconfig.Config{AuthDir: tmpDir, CopilotKey: []proxyconfig.CopilotKey{
+		{Account: "octocat", VSCodeChatHeaderModels: []string{"gpt-4.1"}, CLIHeaderModels: []string{"o3-mini"}},
+	}})
+	keys = get()
+	if got := profileOf(keys[0], "gpt-4.1"); got != "vscode-chat" {
+		t.Fatalf("gpt-4.1 profile after override = %q, want vscode-chat", got)
+	}
+	if got := profileOf(keys[0], "o3-mini"); got != "cli" {
+		t.Fatalf("o3-mini profile = %q, want cli from cli-header-models", got)
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// selfTestTimeout bounds a whole POST /admin/selftest run.
+const selfTestTimeout = 60 * time.Second
+
+// runSelfTest exercises every enabled credential with a read-only upstream call and reports
+// per-credential pass/fail with latency. It answers 503 when any credential fails so CI can
+// gate on the status code alone.
+func (s *Server) runSelfTest(c *gin.Context) {
+	results := []coreauth.SelfTestResult{}
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), selfTestTimeout)
+		defer cancel()
+		results = s.handlers.AuthManager.SelfTest(ctx)
+	}
+	passed := true
+	for _, result := range results {
+		if result.Status == coreauth.SelfTestFail {
+			passed = false
+		}
+	}
+	status := http.StatusOK
+	if !passed {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"passed": passed, "results": results})
+}
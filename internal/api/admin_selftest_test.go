@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	gin "github.com/gin-gonic/gin"
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAdminSelfTest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("MANAGEMENT_PASSWORD", "admin-secret")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4.1"}]}`))
+	}))
+	defer upstream.Close()
+
+	tmpDir := t.TempDir()
+	cfg := &proxyconfig.Config{AuthDir: tmpDir, CopilotKey: []proxyconfig.CopilotKey{
+		{Account: "copilot-good", BaseURL: upstream.URL, Token: "good-token"},
+		{Account: "copilot-bad", BaseURL: upstream.URL, Token: "revoked-token"},
+	}}
+	manager := auth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor.NewCopilotExecutor(cfg))
+	server := NewServer(cfg, manager, sdkaccess.NewManager(), filepath.Join(tmpDir, "config.yaml"))
+
+	register := func(a *auth.Auth) {
+		if _, err := manager.Register(context.Background(), a); err != nil {
+			t.Fatalf("register %s: %v", a.ID, err)
+		}
+	}
+	register(&auth.Auth{ID: "copilot-good", Provider: "copilot", Status: auth.StatusActive})
+
+	post := func() (*httptest.ResponseRecorder, []auth.SelfTestResult) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/selftest", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		var body struct {
+			Results []auth.SelfTestResult `json:"results"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response: %v; body = %s", err, rr.Body.String())
+		}
+		return rr, body.Results
+	}
+
+	rr, results := post()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if len(results) != 1 || results[0].Status != auth.SelfTestPass || results[0].Models != 1 {
+		t.Fatalf("results = %+v, want one passing credential with 1 model", results)
+	}
+
+	register(&auth.Auth{ID: "copilot-bad", Provider: "copilot", Status: auth.StatusActive})
+	register(&auth.Auth{ID: "gemini-a", Provider: "gemini", Status: auth.StatusActive})
+	rr, results = post()
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 when a credential fails; body = %s", rr.Code, rr.Body.String())
+	}
+	want := map[string]string{"copilot-bad": auth.SelfTestFail, "copilot-good": auth.SelfTestPass, "gemini-a": auth.SelfTestSkipped}
+	if len(results) != len(want) {
+		t.Fatalf("results = %+v", results)
+	}
+	for _, result := range results {
+		if result.Status != want[result.AuthID] {
+			t.Fatalf("%s status = %s, want %s (error %q)", result.AuthID, result.Status, want[result.AuthID], result.Error)
+		}
+	}
+}
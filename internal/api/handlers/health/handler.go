@@ -0,0 +1,202 @@
+// Package health provides liveness, readiness and diagnostic endpoints for the
+// API server so that orchestrators and dashboards can observe process state.
+package health
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// CredentialCounter reports the number of usable credentials keyed by provider.
+// Providers that are known but currently have no usable credentials should be
+// reported with a zero count.
+type CredentialCounter func() map[string]int
+
+// Handler serves the /health endpoints.
+type Handler struct {
+	ready       atomic.Bool
+	draining    atomic.Int64
+	startedAt   time.Time
+	credentials CredentialCounter
+	modelCount  func() int
+	upstreams   atomic.Pointer[UpstreamProber]
+}
+
+// NewHandler creates a health handler. Credential counts are derived from the
+// provided auth manager; a nil manager reports no providers.
+func NewHandler(manager *coreauth.Manager) *Handler {
+	return &Handler{
+		startedAt:   time.Now(),
+		credentials: AuthManagerCredentialCounter(manager),
+		modelCount:  registry.GetGlobalRegistry().GetRegisteredModelCount,
+	}
+}
+
+// AuthManagerCredentialCounter builds a CredentialCounter backed by the auth manager.
+// Disabled auth entries still register their provider but do not add to its count.
+func AuthManagerCredentialCounter(manager *coreauth.Manager) CredentialCounter {
+	return func() map[string]int {
+		counts := make(map[string]int)
+		if manager == nil {
+			return counts
+		}
+		for _, auth := range manager.List() {
+			if auth == nil {
+				continue
+			}
+			provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+			if provider == "" {
+				continue
+			}
+			if _, ok := counts[provider]; !ok {
+				counts[provider] = 0
+			}
+			if auth.Disabled || auth.Status == coreauth.StatusDisabled {
+				continue
+			}
+			counts[provider]++
+		}
+		return counts
+	}
+}
+
+// SetReady toggles the readiness flag reported by /health/ready and /health/deep.
+func (h *Handler) SetReady(ready bool) {
+	if h == nil {
+		return
+	}
+	h.ready.Store(ready)
+}
+
+// Ready reports whether the server is currently accepting traffic.
+func (h *Handler) Ready() bool {
+	if h == nil {
+		return false
+	}
+	return h.ready.Load()
+}
+
+// BeginShutdown marks the server as draining: readiness flips to false and
+// /health/ready reports "draining" so load balancers stop routing new traffic
+// while in-flight requests finish. Liveness is unaffected.
+func (h *Handler) BeginShutdown() {
+	if h == nil {
+		return
+	}
+	h.ready.Store(false)
+	h.draining.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// DrainingSince returns when BeginShutdown was first called, if it has been.
+func (h *Handler) DrainingSince() (time.Time, bool) {
+	if h == nil {
+		return time.Time{}, false
+	}
+	since := h.draining.Load()
+	if since == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, since), true
+}
+
+// SetUpstreamProber installs the active upstream probe consulted by /health/ready and
+// /health/deep. Passing nil disables it.
+func (h *Handler) SetUpstreamProber(p *UpstreamProber) {
+	if h == nil {
+		return
+	}
+	h.upstreams.Store(p)
+}
+
+// RegisterRoutes attaches the health endpoints to the provided router.
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/health", h.Health)
+	router.GET("/health/live", h.Live)
+	router.GET("/health/ready", h.ReadyCheck)
+	router.GET("/health/deep", h.Deep)
+}
+
+// Health returns a basic status payload.
+func (h *Handler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Live reports that the process is running.
+func (h *Handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadyCheck returns 200 when the server is ready and 503 otherwise. While
+// draining for shutdown the status is "draining"; when an upstream probe is
+// installed and every upstream failed its last check it is "upstreams_unreachable".
+func (h *Handler) ReadyCheck(c *gin.Context) {
+	if since, ok := h.DrainingSince(); ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":         "draining",
+			"ready":          false,
+			"draining_since": since.UTC().Format(time.RFC3339),
+		})
+		return
+	}
+	if !h.Ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "ready": false})
+		return
+	}
+	if p := h.upstreams.Load(); p.AllUnreachable() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "upstreams_unreachable", "ready": false, "upstreams": p.Results()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "ready": true})
+}
+
+// Deep returns a detailed diagnostic payload. It always responds with 200 and
+// flags "degraded" when the server is not ready, any provider lacks credentials,
+// or a probed upstream is unreachable.
+func (h *Handler) Deep(c *gin.Context) {
+	ready := h.Ready()
+
+	credentials := map[string]int{}
+	if h.credentials != nil {
+		if counts := h.credentials(); counts != nil {
+			credentials = counts
+		}
+	}
+
+	degraded := !ready
+	for _, count := range credentials {
+		if count <= 0 {
+			degraded = true
+			break
+		}
+	}
+
+	models := 0
+	if h.modelCount != nil {
+		models = h.modelCount()
+	}
+
+	payload := gin.H{
+		"ready":          ready,
+		"degraded":       degraded,
+		"credentials":    credentials,
+		"models":         models,
+		"uptime_seconds": int64(time.Since(h.startedAt).Seconds()),
+	}
+	if p := h.upstreams.Load(); p != nil {
+		upstreams := p.Results()
+		for _, result := range upstreams {
+			if !result.Reachable {
+				payload["degraded"] = true
+				break
+			}
+		}
+		payload["upstreams"] = upstreams
+	}
+	c.JSON(http.StatusOK, payload)
+}
@@ -0,0 +1,166 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestHandler(counts map[string]int, models int) *Handler {
+	return &Handler{
+		startedAt:   time.Now().Add(-5 * time.Second),
+		credentials: func() map[string]int { return counts },
+		modelCount:  func() int { return models },
+	}
+}
+
+func performDeep(t *testing.T, h *Handler) map[string]any {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	h.RegisterRoutes(engine)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/health/deep", nil)
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	return body
+}
+
+func TestDeep_ResponseShape(t *testing.T) {
+	h := newTestHandler(map[string]int{"copilot": 2, "codex": 1}, 7)
+	h.SetReady(true)
+
+	body := performDeep(t, h)
+
+	if ready, ok := body["ready"].(bool); !ok || !ready {
+		t.Fatalf("expected ready=true, got %v", body["ready"])
+	}
+	if degraded, ok := body["degraded"].(bool); !ok || degraded {
+		t.Fatalf("expected degraded=false, got %v", body["degraded"])
+	}
+	creds, ok := body["credentials"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected credentials object, got %T", body["credentials"])
+	}
+	if creds["copilot"] != float64(2) || creds["codex"] != float64(1) {
+		t.Fatalf("unexpected credentials: %v", creds)
+	}
+	if body["models"] != float64(7) {
+		t.Fatalf("expected models=7, got %v", body["models"])
+	}
+	uptime, ok := body["uptime_seconds"].(float64)
+	if !ok || uptime < 5 {
+		t.Fatalf("expected uptime_seconds >= 5, got %v", body["uptime_seconds"])
+	}
+}
+
+func TestDeep_DegradedFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		ready    bool
+		counts   map[string]int
+		degraded bool
+	}{
+		{name: "ready with credentials", ready: true, counts: map[string]int{"copilot": 1}, degraded: false},
+		{name: "not ready", ready: false, counts: map[string]int{"copilot": 1}, degraded: true},
+		{name: "provider without credentials", ready: true, counts: map[string]int{"copilot": 1, "codex": 0}, degraded: true},
+		{name: "no providers", ready: true, counts: nil, degraded: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(tt.counts, 0)
+			h.SetReady(tt.ready)
+
+			body := performDeep(t, h)
+			if got := body["degraded"]; got != tt.degraded {
+				t.Fatalf("expected degraded=%v, got %v", tt.degraded, got)
+			}
+		})
+	}
+}
+
+func TestDeep_DegradedTogglesWithReadiness(t *testing.T) {
+	h := newTestHandler(map[string]int{"copilot": 1}, 0)
+
+	if body := performDeep(t, h); body["degraded"] != true {
+		t.Fatalf("expected degraded before ready, got %v", body["degraded"])
+	}
+	h.SetReady(true)
+	if body := performDeep(t, h); body["degraded"] != false {
+		t.Fatalf("expected not degraded after ready, got %v", body["degraded"])
+	}
+	h.SetReady(false)
+	if body := performDeep(t, h); body["degraded"] != true {
+		t.Fatalf("expected degraded after readiness dropped, got %v", body["degraded"])
+	}
+}
+
+func performGet(t *testing.T, h *Handler, path string) (int, map[string]any) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	h.RegisterRoutes(engine)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	return w.Code, body
+}
+
+func TestBeginShutdown_ReadyReportsDraining(t *testing.T) {
+	h := newTestHandler(nil, 0)
+	h.SetReady(true)
+
+	if code, _ := performGet(t, h, "/health/ready"); code != http.StatusOK {
+		t.Fatalf("expected 200 before shutdown, got %d", code)
+	}
+
+	h.BeginShutdown()
+
+	if h.Ready() {
+		t.Fatal("expected readiness to be false after BeginShutdown")
+	}
+	if _, ok := h.DrainingSince(); !ok {
+		t.Fatal("expected draining timestamp to be recorded")
+	}
+	code, body := performGet(t, h, "/health/ready")
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", code)
+	}
+	if body["status"] != "draining" {
+		t.Fatalf("expected status=draining, got %v", body["status"])
+	}
+	if _, ok := body["draining_since"].(string); !ok {
+		t.Fatalf("expected draining_since, got %v", body["draining_since"])
+	}
+}
+
+func TestBeginShutdown_LivenessUnaffected(t *testing.T) {
+	h := newTestHandler(nil, 0)
+	h.SetReady(true)
+	h.BeginShutdown()
+
+	code, body := performGet(t, h, "/health/live")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 from liveness while draining, got %d", code)
+	}
+	if body["status"] != "ok" {
+		t.Fatalf("expected status=ok, got %v", body["status"])
+	}
+}
@@ -0,0 +1,195 @@
+package health
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// UpstreamTarget names an upstream endpoint checked by the UpstreamProber.
+type UpstreamTarget struct {
+	Name string
+	URL  string
+	// ProxyURL routes the probe through the same proxy the executor uses for this upstream.
+	ProxyURL string
+}
+
+// ProbeResult is the outcome of the most recent check of one upstream.
+type ProbeResult struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Reachable bool      `json:"reachable"`
+	Status    int       `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// UpstreamProber periodically sends a HEAD request to each target and caches the last
+// result. Any HTTP response below 500 counts as reachable: auth failures and 404s still
+// prove the endpoint answers, which is all readiness needs to know.
+//
+// At most one probe per target is in flight at a time; a round skips any target whose
+// previous probe has not returned yet. Rounds are spaced by the interval plus a random
+// jitter so many instances started together do not probe in lockstep.
+type UpstreamProber struct {
+	targets []UpstreamTarget
+	clients map[string]*http.Client
+	jitter  time.Duration
+
+	mu       sync.RWMutex
+	results  map[string]ProbeResult
+	inFlight map[string]bool
+}
+
+// NewUpstreamProber creates a prober for targets using timeout per request. Targets that
+// share a proxy share one HTTP client.
+func NewUpstreamProber(targets []UpstreamTarget, timeout time.Duration) *UpstreamProber {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	clients := make(map[string]*http.Client)
+	for _, target := range targets {
+		if _, ok := clients[target.ProxyURL]; !ok {
+			clients[target.ProxyURL] = util.SetProxy(&sdkconfig.SDKConfig{ProxyURL: target.ProxyURL}, &http.Client{Timeout: timeout})
+		}
+	}
+	return &UpstreamProber{
+		targets:  targets,
+		clients:  clients,
+		results:  make(map[string]ProbeResult, len(targets)),
+		inFlight: make(map[string]bool, len(targets)),
+	}
+}
+
+// SetJitter sets the maximum random delay added to each probe interval. It must be called
+// before Start.
+func (p *UpstreamProber) SetJitter(jitter time.Duration) {
+	if p == nil || jitter < 0 {
+		return
+	}
+	p.jitter = jitter
+}
+
+// jitteredInterval returns interval plus a random delay in [0, jitter].
+func jitteredInterval(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int63n(int64(jitter)+1))
+}
+
+// Start probes immediately and then every interval plus jitter until ctx is cancelled.
+func (p *UpstreamProber) Start(ctx context.Context, interval time.Duration) {
+	if p == nil || len(p.targets) == 0 || interval <= 0 {
+		return
+	}
+	go func() {
+		go p.Probe(ctx)
+		timer := time.NewTimer(jitteredInterval(interval, p.jitter))
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				// Rounds run in the background so a slow upstream never delays the schedule;
+				// the in-flight guard in Probe keeps each target to one request at a time.
+				go p.Probe(ctx)
+				timer.Reset(jitteredInterval(interval, p.jitter))
+			}
+		}
+	}()
+}
+
+// Probe checks every target once, concurrently, and stores the results. Targets whose
+// previous probe is still in flight are skipped.
+func (p *UpstreamProber) Probe(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, target := range p.targets {
+		if !p.acquire(target.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func(target UpstreamTarget) {
+			defer wg.Done()
+			result := p.check(ctx, target)
+			p.mu.Lock()
+			p.results[target.Name] = result
+			delete(p.inFlight, target.Name)
+			p.mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+}
+
+// acquire marks name as in flight, reporting false when a probe for it is already running.
+func (p *UpstreamProber) acquire(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[name] {
+		return false
+	}
+	p.inFlight[name] = true
+	return true
+}
+
+func (p *UpstreamProber) check(ctx context.Context, target UpstreamTarget) ProbeResult {
+	result := ProbeResult{Name: target.Name, URL: target.URL, CheckedAt: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target.URL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := p.clients[target.ProxyURL].Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	_ = resp.Body.Close()
+	result.Status = resp.StatusCode
+	result.Reachable = resp.StatusCode < http.StatusInternalServerError
+	return result
+}
+
+// Results returns the cached results sorted by target name.
+func (p *UpstreamProber) Results() []ProbeResult {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	out := make([]ProbeResult, 0, len(p.results))
+	for _, result := range p.results {
+		out = append(out, result)
+	}
+	p.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// AllUnreachable reports whether every target has been probed and none was reachable.
+// It is false before the first probe completes so startup is not blocked on the network.
+func (p *UpstreamProber) AllUnreachable() bool {
+	if p == nil || len(p.targets) == 0 {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.results) < len(p.targets) {
+		return false
+	}
+	for _, result := range p.results {
+		if result.Reachable {
+			return false
+		}
+	}
+	return true
+}
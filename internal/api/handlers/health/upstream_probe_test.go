@@ -0,0 +1,187 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// downUpstreamURL returns the URL of a server that has already been shut down.
+func downUpstreamURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	return url
+}
+
+func TestUpstreamProber_ReadyReflectsReachability(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected HEAD probe, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer up.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name       string
+		targets    []UpstreamTarget
+		wantStatus int
+	}{
+		{
+			name:       "one upstream reachable",
+			targets:    []UpstreamTarget{{Name: "up", URL: up.URL}, {Name: "down", URL: downUpstreamURL()}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "all upstreams unreachable",
+			targets:    []UpstreamTarget{{Name: "down", URL: downUpstreamURL()}, {Name: "failing", URL: failing.URL}},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(nil, 0)
+			h.SetReady(true)
+			prober := NewUpstreamProber(tt.targets, time.Second)
+			h.SetUpstreamProber(prober)
+			prober.Probe(context.Background())
+
+			code, body := performGet(t, h, "/health/ready")
+			if code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %v)", code, tt.wantStatus, body)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && body["status"] != "upstreams_unreachable" {
+				t.Fatalf("expected upstreams_unreachable status, got %v", body["status"])
+			}
+
+			deep := performDeep(t, h)
+			upstreams, ok := deep["upstreams"].([]any)
+			if !ok || len(upstreams) != len(tt.targets) {
+				t.Fatalf("expected %d upstream results in deep payload, got %v", len(tt.targets), deep["upstreams"])
+			}
+			if deep["degraded"] != true {
+				t.Fatalf("expected degraded with an unreachable upstream, got %v", deep["degraded"])
+			}
+		})
+	}
+}
+
+func TestUpstreamProber_NotProbedYetStaysReady(t *testing.T) {
+	h := newTestHandler(nil, 0)
+	h.SetReady(true)
+	h.SetUpstreamProber(NewUpstreamProber([]UpstreamTarget{{Name: "down", URL: downUpstreamURL()}}, time.Second))
+
+	if code, _ := performGet(t, h, "/health/ready"); code != http.StatusOK {
+		t.Fatalf("expected 200 before the first probe completes, got %d", code)
+	}
+}
+
+func TestUpstreamProber_StartCachesResults(t *testing.T) {
+	hits := make(chan struct{}, 8)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits <- struct{}{}
+	}))
+	defer up.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prober := NewUpstreamProber([]UpstreamTarget{{Name: "up", URL: up.URL}}, time.Second)
+	prober.Start(ctx, 20*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-hits:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected probe %d to reach the upstream", i+1)
+		}
+	}
+	results := prober.Results()
+	if len(results) != 1 || !results[0].Reachable || results[0].Status != http.StatusOK {
+		t.Fatalf("unexpected cached results: %+v", results)
+	}
+}
+
+func TestUpstreamProber_SkipsTargetWithProbeInFlight(t *testing.T) {
+	var active, maxActive, hits atomic.Int32
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		hits.Add(1)
+		<-release
+	}))
+	defer slow.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prober := NewUpstreamProber([]UpstreamTarget{{Name: "slow", URL: slow.URL}}, 5*time.Second)
+	prober.Start(ctx, 5*time.Millisecond)
+
+	// Let many rounds fire while the first probe is still blocked upstream.
+	deadline := time.After(2 * time.Second)
+	for hits.Load() == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("expected the first probe to reach the upstream")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("upstream received %d probes while one was in flight, want 1", got)
+	}
+	close(release)
+	cancel()
+	if got := maxActive.Load(); got != 1 {
+		t.Fatalf("max concurrent probes = %d, want 1", got)
+	}
+}
+
+func TestJitteredInterval_StaysWithinBounds(t *testing.T) {
+	interval, jitter := 100*time.Millisecond, 30*time.Millisecond
+	for i := 0; i < 1000; i++ {
+		got := jitteredInterval(interval, jitter)
+		if got < interval || got > interval+jitter {
+			t.Fatalf("jitteredInterval = %s, want within [%s, %s]", got, interval, interval+jitter)
+		}
+	}
+	if got := jitteredInterval(interval, 0); got != interval {
+		t.Fatalf("jitteredInterval without jitter = %s, want %s", got, interval)
+	}
+}
+
+func TestUpstreamProber_UsesTargetProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == "upstream.invalid" {
+			proxied.Add(1)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer proxy.Close()
+
+	prober := NewUpstreamProber([]UpstreamTarget{{Name: "copilot-0", URL: "http://upstream.invalid/models", ProxyURL: proxy.URL}}, time.Second)
+	prober.Probe(context.Background())
+
+	results := prober.Results()
+	if len(results) != 1 || !results[0].Reachable {
+		t.Fatalf("expected the upstream to be reachable through the proxy, got %+v", results)
+	}
+	if proxied.Load() != 1 {
+		t.Fatalf("proxy saw %d probe requests, want 1", proxied.Load())
+	}
+}
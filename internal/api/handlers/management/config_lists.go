@@ -1251,6 +1251,15 @@ func (h *Handler) PutCopilotKeys(c *gin.Context) {
 		entry.VSCodeChatHeaderModels = config.NormalizeExcludedModels(entry.VSCodeChatHeaderModels)
 		filtered = append(filtered, entry)
 	}
+	probe := &config.Config{CopilotKey: filtered}
+	if err := probe.ResolveCopilotKeyTokens(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := probe.ValidateCopilotBaseURLs(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	h.cfg.CopilotKey = filtered
 	h.cfg.SanitizeCopilotKeys()
 	h.persist(c)
@@ -1273,6 +1282,16 @@ func (h *Handler) PatchCopilotKey(c *gin.Context) {
 	value.HeaderProfile = strings.TrimSpace(value.HeaderProfile)
 	value.CLIHeaderModels = config.NormalizeExcludedModels(value.CLIHeaderModels)
 	value.VSCodeChatHeaderModels = config.NormalizeExcludedModels(value.VSCodeChatHeaderModels)
+	probe := &config.Config{CopilotKey: []config.CopilotKey{value}}
+	if err := probe.ResolveCopilotKeyTokens(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := probe.ValidateCopilotBaseURLs(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	value = probe.CopilotKey[0]
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	healthHandlers "github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/health"
+	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultHealthProbeInterval = 60 * time.Second
+	defaultHealthProbeTimeout  = 5 * time.Second
+)
+
+// defaultCodexProbeURL is the Codex endpoint used when a key sets no base-url, matching the
+// Codex executor.
+const defaultCodexProbeURL = "https://chatgpt.com/backend-api/codex"
+
+// upstreamProbeTargets lists the distinct Copilot and Codex endpoints referenced by cfg.
+// Each key is probed at its effective base URL through its effective proxy, so the probe
+// takes the same route as the executor.
+func upstreamProbeTargets(cfg *config.Config) []healthHandlers.UpstreamTarget {
+	if cfg == nil {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	var targets []healthHandlers.UpstreamTarget
+	add := func(name, url, keyProxy string) {
+		proxyURL := strings.TrimSpace(keyProxy)
+		if proxyURL == "" {
+			proxyURL = strings.TrimSpace(cfg.ProxyURL)
+		}
+		dedupe := url + "|" + proxyURL
+		if _, ok := seen[dedupe]; ok {
+			return
+		}
+		seen[dedupe] = struct{}{}
+		targets = append(targets, healthHandlers.UpstreamTarget{Name: name, URL: url, ProxyURL: proxyURL})
+	}
+	for i := range cfg.CopilotKey {
+		base := copilotauth.ResolveBaseURL(cfg.CopilotKey[i].BaseURL, copilotauth.AccountType(cfg.CopilotKey[i].AccountType))
+		add(fmt.Sprintf("copilot-%d", i), base+"/models", cfg.CopilotKey[i].ProxyURL)
+	}
+	for i := range cfg.CodexKey {
+		base := strings.TrimRight(strings.TrimSpace(cfg.CodexKey[i].BaseURL), "/")
+		if base == "" {
+			base = defaultCodexProbeURL
+		}
+		add(fmt.Sprintf("codex-%d", i), base, cfg.CodexKey[i].ProxyURL)
+	}
+	return targets
+}
+
+func parseProbeDuration(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// applyHealthProbe starts, restarts, or stops the upstream probe to match cfg. The probe is
+// only restarted when its settings or target list change.
+func (s *Server) applyHealthProbe(cfg *config.Config) {
+	var targets []healthHandlers.UpstreamTarget
+	if cfg != nil && cfg.HealthProbe.Enabled {
+		targets = upstreamProbeTargets(cfg)
+	}
+	interval, timeout := defaultHealthProbeInterval, defaultHealthProbeTimeout
+	var jitter time.Duration
+	if cfg != nil {
+		interval = parseProbeDuration(cfg.HealthProbe.Interval, defaultHealthProbeInterval)
+		timeout = parseProbeDuration(cfg.HealthProbe.Timeout, defaultHealthProbeTimeout)
+		jitter = parseProbeDuration(cfg.HealthProbe.Jitter, 0)
+	}
+	key := fmt.Sprintf("%v|%s|%s|%s", targets, interval, timeout, jitter)
+
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	if key == s.probeKey {
+		return
+	}
+	s.probeKey = key
+	if s.probeCancel != nil {
+		s.probeCancel()
+		s.probeCancel = nil
+	}
+	if len(targets) == 0 {
+		s.health.SetUpstreamProber(nil)
+		return
+	}
+	prober := healthHandlers.NewUpstreamProber(targets, timeout)
+	prober.SetJitter(jitter)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.probeCancel = cancel
+	s.health.SetUpstreamProber(prober)
+	prober.Start(ctx, interval)
+	log.Infof("health probe enabled for %d upstream(s) every %s", len(targets), interval)
+}
+
+// stopHealthProbe cancels the running upstream probe, if any.
+func (s *Server) stopHealthProbe() {
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	if s.probeCancel != nil {
+		s.probeCancel()
+		s.probeCancel = nil
+	}
+}
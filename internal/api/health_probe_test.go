@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestUpstreamProbeTargets_EffectiveBaseURLAndProxy(t *testing.T) {
+	cfg := &config.Config{
+		CopilotKey: []config.CopilotKey{
+			{AccountType: "individual", BaseURL: "http://copilot.mock/ ", ProxyURL: "http://key-proxy:8080"},
+			{AccountType: "individual"},
+		},
+		CodexKey: []config.CodexKey{{APIKey: "k"}},
+	}
+	cfg.ProxyURL = "http://global-proxy:3128"
+
+	targets := upstreamProbeTargets(cfg)
+	if len(targets) != 3 {
+		t.Fatalf("expected 3 targets, got %+v", targets)
+	}
+	if targets[0].URL != "http://copilot.mock/models" || targets[0].ProxyURL != "http://key-proxy:8080" {
+		t.Fatalf("unexpected override target: %+v", targets[0])
+	}
+	if targets[1].URL != "https://api.individual.githubcopilot.com/models" || targets[1].ProxyURL != "http://global-proxy:3128" {
+		t.Fatalf("unexpected default Copilot target: %+v", targets[1])
+	}
+	if targets[2].URL != defaultCodexProbeURL || targets[2].ProxyURL != "http://global-proxy:3128" {
+		t.Fatalf("unexpected default Codex target: %+v", targets[2])
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// DefaultMaxDecompressedBodyBytes caps how large a compressed request body may expand.
+const DefaultMaxDecompressedBodyBytes = 64 << 20
+
+var errDecompressedBodyTooLarge = errors.New("decompressed request body too large")
+
+// RequestDecompressionMiddleware transparently decodes gzip and deflate request bodies so
+// handlers, translators, and executors always see plain JSON. Bodies that expand beyond
+// maxBytes are rejected with 413 to guard against decompression bombs.
+func RequestDecompressionMiddleware(maxBytes int64) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDecompressedBodyBytes
+	}
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		if c.Request.Body == nil || (encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate") {
+			c.Next()
+			return
+		}
+
+		compressed, err := io.ReadAll(c.Request.Body)
+		_ = c.Request.Body.Close()
+		if err != nil {
+			abortWithOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+			return
+		}
+		body, err := decompressBody(encoding, compressed, maxBytes)
+		if errors.Is(err, errDecompressedBodyTooLarge) {
+			abortWithOpenAIError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("decompressed request body exceeds %d bytes", maxBytes))
+			return
+		}
+		if err != nil {
+			abortWithOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("failed to decode %s request body: %v", encoding, err))
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		c.Next()
+	}
+}
+
+func decompressBody(encoding string, compressed []byte, maxBytes int64) ([]byte, error) {
+	var reader io.ReadCloser
+	if encoding == "deflate" {
+		// HTTP deflate is zlib-wrapped, but some clients send raw DEFLATE streams.
+		if zr, errZlib := zlib.NewReader(bytes.NewReader(compressed)); errZlib == nil {
+			reader = zr
+		} else {
+			reader = flate.NewReader(bytes.NewReader(compressed))
+		}
+	} else {
+		gr, errGzip := gzip.NewReader(bytes.NewReader(compressed))
+		if errGzip != nil {
+			return nil, errGzip
+		}
+		reader = gr
+	}
+	defer func() { _ = reader.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, errDecompressedBodyTooLarge
+	}
+	return body, nil
+}
+
+func abortWithOpenAIError(c *gin.Context, status int, message string) {
+	c.Data(status, "application/json", interfaces.OpenAIErrorBody(status, message, "", ""))
+	c.Abort()
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newDecompressionEngine(maxBytes int64, seen *[]byte) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestDecompressionMiddleware(maxBytes))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		*seen, _ = io.ReadAll(c.Request.Body)
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRequestDecompressionMiddleware(t *testing.T) {
+	payload := []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hi"}]}`)
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{name: "gzip", encoding: "gzip", body: gzipBytes(t, payload)},
+		{name: "deflate", encoding: "deflate", body: deflated.Bytes()},
+		{name: "identity", encoding: "", body: payload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []byte
+			engine := newDecompressionEngine(0, &seen)
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+			if !bytes.Equal(seen, payload) {
+				t.Fatalf("handler saw %q, want %q", seen, payload)
+			}
+		})
+	}
+}
+
+func TestRequestDecompressionMiddleware_RejectsOversizedBody(t *testing.T) {
+	var seen []byte
+	engine := newDecompressionEngine(1024, &seen)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(gzipBytes(t, bytes.Repeat([]byte("a"), 4096))))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+	if seen != nil {
+		t.Fatal("handler must not run for an oversized body")
+	}
+}
+
+func TestRequestDecompressionMiddleware_RejectsCorruptBody(t *testing.T) {
+	var seen []byte
+	engine := newDecompressionEngine(0, &seen)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader([]byte("not gzip")))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// SetConfigReloadHandler installs the function used by POST /admin/reload-config to
+// re-read the config file. The service wires this to its file watcher so that auth
+// synthesis, modules, and executors all observe the reloaded configuration.
+func (s *Server) SetConfigReloadHandler(fn func() error) {
+	if s == nil {
+		return
+	}
+	s.configReload = fn
+}
+
+// reloadConfig re-reads the config file on demand and responds with the top-level
+// fields that changed.
+func (s *Server) reloadConfig(c *gin.Context) {
+	var oldCfg *config.Config
+	if len(s.oldConfigYaml) > 0 {
+		_ = yaml.Unmarshal(s.oldConfigYaml, &oldCfg)
+	}
+
+	reload := s.configReload
+	if reload == nil {
+		reload = s.reloadConfigFromFile
+	}
+	if err := reload(); err != nil {
+		log.Errorf("admin reload-config failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Compare YAML snapshots on both sides so nil and empty collections are not reported.
+	var newCfg *config.Config
+	if snapshot, errMarshal := yaml.Marshal(s.cfg); errMarshal == nil {
+		_ = yaml.Unmarshal(snapshot, &newCfg)
+	}
+	changed := diff.ChangedConfigFields(oldCfg, newCfg)
+	if changed == nil {
+		changed = []string{}
+	}
+	log.Infof("config reloaded via admin endpoint, %d field(s) changed", len(changed))
+	c.JSON(http.StatusOK, gin.H{"status": "reloaded", "changed": changed})
+}
+
+// reloadConfigFromFile loads the config file and applies it to the server directly. It is
+// used when no watcher-backed reload handler has been installed.
+func (s *Server) reloadConfigFromFile() error {
+	cfg, err := config.LoadConfig(s.configFilePath)
+	if err != nil {
+		return err
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	s.UpdateClients(cfg)
+	return nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gin "github.com/gin-gonic/gin"
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func writeReloadTestConfig(t *testing.T, path, authDir string, metricsEnabled bool) {
+	t.Helper()
+	data := fmt.Sprintf("port: 0\nauth-dir: %q\nmetrics-enabled: %t\nremote-management:\n  disable-control-panel: true\n", authDir, metricsEnabled)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestReloadConfigTogglesMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("MANAGEMENT_PASSWORD", "admin-secret")
+	defer metrics.SetEnabled(false)
+
+	tmpDir := t.TempDir()
+	authDir := filepath.Join(tmpDir, "auth")
+	if err := os.MkdirAll(authDir, 0o700); err != nil {
+		t.Fatalf("failed to create auth dir: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	writeReloadTestConfig(t, configPath, authDir, false)
+	cfg, err := proxyconfig.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	server := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), configPath)
+
+	scrape := func() int {
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rr.Code
+	}
+	reload := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload-config", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if code := scrape(); code != http.StatusNotFound {
+		t.Fatalf("expected /metrics disabled initially, got %d", code)
+	}
+
+	writeReloadTestConfig(t, configPath, authDir, true)
+	if rr := reload(""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without management key, got %d", rr.Code)
+	}
+	if scrape() != http.StatusNotFound {
+		t.Fatal("unauthenticated reload must not apply the new config")
+	}
+
+	rr := reload("admin-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("reload status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Status  string   `json:"status"`
+		Changed []string `json:"changed"`
+	}
+	if err = json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Changed) != 1 || body.Changed[0] != "metrics-enabled" {
+		t.Fatalf("changed = %v, want [metrics-enabled]", body.Changed)
+	}
+	if code := scrape(); code != http.StatusOK {
+		t.Fatalf("expected /metrics enabled after reload, got %d", code)
+	}
+
+	writeReloadTestConfig(t, configPath, authDir, false)
+	if rr = reload("admin-secret"); rr.Code != http.StatusOK {
+		t.Fatalf("second reload status = %d", rr.Code)
+	}
+	if code := scrape(); code != http.StatusNotFound {
+		t.Fatalf("expected /metrics disabled after second reload, got %d", code)
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/access"
+	healthHandlers "github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/health"
 	managementHandlers "github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/management"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
@@ -26,6 +27,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -151,9 +153,23 @@ type Server struct {
 	// management handler
 	mgmt *managementHandlers.Handler
 
+	// health serves the /health endpoints and tracks readiness.
+	health *healthHandlers.Handler
+
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
+	// metricsModule serves Prometheus metrics and tracks the metrics-enabled flag.
+	metricsModule *metrics.Module
+
+	// configReload forces a reload of the config file; set by the service that owns the watcher.
+	configReload func() error
+
+	// probeMu guards the active upstream probe lifecycle.
+	probeMu     sync.Mutex
+	probeKey    string
+	probeCancel context.CancelFunc
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
@@ -205,6 +221,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
+	// Decode compressed request bodies before anything reads the payload.
+	engine.Use(middleware.RequestDecompressionMiddleware(middleware.DefaultMaxDecompressedBodyBytes))
 
 	// Add request logging middleware (positioned after recovery, before auth)
 	// Resolve logs directory relative to the configuration file directory.
@@ -251,6 +269,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	s.applyAccessConfig(nil, cfg)
 	if authManager != nil {
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
+		authManager.SetCircuitBreakerConfig(cfg.CircuitBreaker.FailureThreshold, cfg.CircuitBreaker.CooldownDuration())
 	}
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
@@ -265,6 +284,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	s.mgmt.SetLogDirectory(logDir)
 	s.localPassword = optionState.localPassword
+	s.health = healthHandlers.NewHandler(authManager)
+	s.applyHealthProbe(cfg)
 
 	// Setup routes
 	s.setupRoutes()
@@ -281,6 +302,11 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		log.Errorf("Failed to register Amp module: %v", err)
 	}
 
+	s.metricsModule = metrics.New()
+	if err := modules.RegisterModule(ctx, s.metricsModule); err != nil {
+		log.Errorf("Failed to register metrics module: %v", err)
+	}
+
 	// Apply additional router configurators from options
 	if optionState.routerConfigurator != nil {
 		optionState.routerConfigurator(engine, s.handlers, cfg)
@@ -306,26 +332,49 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	return s
 }
 
+// batchesDir returns the directory holding /v1/batches state: the configured batches.dir,
+// or a "batches" directory next to the config file.
+func (s *Server) batchesDir() string {
+	if dir := strings.TrimSpace(s.cfg.Batches.Dir); dir != "" {
+		return dir
+	}
+	if s.configFilePath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(s.configFilePath), "batches")
+}
+
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	s.health.RegisterRoutes(s.engine)
+	s.engine.POST("/admin/reload-config", s.mgmt.Middleware(), s.reloadConfig)
+	s.engine.GET("/admin/credentials", s.mgmt.Middleware(), s.listCredentials)
+	s.engine.POST("/admin/selftest", s.mgmt.Middleware(), s.runSelfTest)
+	s.engine.GET("/admin/copilot/header-profiles", s.mgmt.Middleware(), s.listCopilotHeaderProfiles)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiBatchesHandlers := openai.NewOpenAIBatchesAPIHandler(s.handlers, s.batchesDir(), s.cfg.Batches)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
 	v1.Use(AuthMiddleware(s.accessManager))
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
+		v1.GET("/models/*model", openaiHandlers.OpenAIModel)
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.POST("/tokenize", openaiHandlers.Tokenize)
+		v1.POST("/batches", openaiBatchesHandlers.CreateBatch)
+		v1.GET("/batches/:id", openaiBatchesHandlers.GetBatch)
+		v1.GET("/batches/:id/results", openaiBatchesHandlers.GetBatchResults)
 	}
 
 	// Gemini compatible API routes
@@ -804,6 +853,7 @@ func (s *Server) Start() error {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
 		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
+		s.health.SetReady(true)
 		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
 			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
 		}
@@ -811,6 +861,7 @@ func (s *Server) Start() error {
 	}
 
 	log.Debugf("Starting API server on %s", s.server.Addr)
+	s.health.SetReady(true)
 	if errServe := s.server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start HTTP server: %v", errServe)
 	}
@@ -828,6 +879,8 @@ func (s *Server) Start() error {
 //   - error: An error if the server fails to stop
 func (s *Server) Stop(ctx context.Context) error {
 	log.Debug("Stopping API server...")
+	s.health.BeginShutdown()
+	s.stopHealthProbe()
 
 	if s.keepAliveEnabled {
 		select {
@@ -941,6 +994,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
+		s.handlers.AuthManager.SetCircuitBreakerConfig(cfg.CircuitBreaker.FailureThreshold, cfg.CircuitBreaker.CooldownDuration())
 	}
 
 	// Update log level dynamically when debug flag changes
@@ -986,6 +1040,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.applyAccessConfig(oldCfg, cfg)
+	s.applyHealthProbe(cfg)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
@@ -1016,6 +1071,12 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		log.Warnf("amp module is nil, skipping config update")
 	}
 
+	if s.metricsModule != nil {
+		if err := s.metricsModule.OnConfigUpdated(cfg); err != nil {
+			log.Errorf("failed to update metrics module config: %v", err)
+		}
+	}
+
 	// Count client sources from configuration and auth store.
 	tokenStore := sdkAuth.GetTokenStore()
 	if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok {
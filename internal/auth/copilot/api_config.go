@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	copilotshared "github.com/router-for-me/CLIProxyAPI/v6/internal/copilot"
 )
@@ -44,6 +45,15 @@ var ValidAccountTypes = copilotshared.ValidAccountTypes
 
 const DefaultAccountType = copilotshared.DefaultAccountType
 
+// ResolveBaseURL returns override without its trailing slash, falling back to the
+// endpoint for accountType when override is empty.
+func ResolveBaseURL(override string, accountType AccountType) string {
+	if base := strings.TrimRight(strings.TrimSpace(override), "/"); base != "" {
+		return base
+	}
+	return CopilotBaseURL(accountType)
+}
+
 func CopilotBaseURL(accountType AccountType) string {
 	switch accountType {
 	case AccountTypeBusiness:
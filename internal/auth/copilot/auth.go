@@ -333,6 +333,7 @@ type CopilotLimits struct {
 type CopilotSupports struct {
 	ToolCalls         bool `json:"tool_calls"`
 	ParallelToolCalls bool `json:"parallel_tool_calls"`
+	Vision            bool `json:"vision"`
 }
 
 // CopilotModelsResponse represents the response from the Copilot models endpoint.
@@ -6,12 +6,15 @@ package config
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	copilotshared "github.com/router-for-me/CLIProxyAPI/v6/internal/copilot"
 	"golang.org/x/crypto/bcrypt"
@@ -41,6 +44,10 @@ type Config struct {
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
+	// LogLevels overrides the log level for individual modules, keyed by module name
+	// (e.g. "copilot": "warn"). Modules without an entry follow the global level.
+	LogLevels map[string]string `yaml:"log-levels,omitempty" json:"log-levels,omitempty"`
+
 	// CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -54,6 +61,34 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// MetricsEnabled exposes Prometheus metrics on GET /metrics and enables metric recording.
+	MetricsEnabled bool `yaml:"metrics-enabled" json:"metrics-enabled"`
+
+	// MetricsNormalizeModel de-aliases model labels (lowercase, "copilot-" prefix stripped)
+	// so that aliases of one model do not create separate metric series.
+	MetricsNormalizeModel bool `yaml:"metrics-normalize-model,omitempty" json:"metrics-normalize-model,omitempty"`
+
+	// MetricsCopilotKeyLabel counts Copilot requests per copilot-api-key entry, labelled with a
+	// hashed identifier of the entry. Off by default to keep series counts low.
+	MetricsCopilotKeyLabel bool `yaml:"metrics-copilot-key-label,omitempty" json:"metrics-copilot-key-label,omitempty"`
+
+	// MetricsCredentialsDebounce delays changes to the available credentials gauge until a
+	// credential has held its new state this long, as a Go duration. Empty reports exact counts.
+	MetricsCredentialsDebounce string `yaml:"metrics-credentials-debounce,omitempty" json:"metrics-credentials-debounce,omitempty"`
+
+	// SlowRequestThreshold logs a warning and counts cliproxy_slow_requests_total for
+	// requests that take longer than this Go duration. Empty disables it.
+	SlowRequestThreshold string `yaml:"slow-request-threshold,omitempty" json:"slow-request-threshold,omitempty"`
+
+	// Batches configures the /v1/batches endpoint.
+	Batches BatchesConfig `yaml:"batches,omitempty" json:"batches,omitempty"`
+
+	// HealthProbe configures active upstream reachability checks reported by /health/ready.
+	HealthProbe HealthProbeConfig `yaml:"health-probe,omitempty" json:"health-probe,omitempty"`
+
+	// CircuitBreaker short-circuits providers whose upstream keeps failing.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker,omitempty" json:"circuit-breaker,omitempty"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -84,6 +119,15 @@ type Config struct {
 	// Codex defines a list of Codex API key configurations as specified in the YAML configuration file.
 	CodexKey []CodexKey `yaml:"codex-api-key" json:"codex-api-key"`
 
+	// NoReasoningModels lists Codex base models that reject the reasoning object.
+	// The codex executor drops "reasoning" for these models, even when an alias requested an effort.
+	NoReasoningModels []string `yaml:"no-reasoning-models,omitempty" json:"no-reasoning-models,omitempty"`
+
+	// ReasoningEffortPolicy controls how the codex executor handles a reasoning.effort that
+	// is not among the base model's registered thinking levels: "reject" (default) answers
+	// 400, "clamp" substitutes the nearest supported level.
+	ReasoningEffortPolicy string `yaml:"reasoning-effort-policy,omitempty" json:"reasoning-effort-policy,omitempty"`
+
 	// ClaudeKey defines a list of Claude API key configurations as specified in the YAML configuration file.
 	ClaudeKey []ClaudeKey `yaml:"claude-api-key" json:"claude-api-key"`
 
@@ -94,6 +138,15 @@ type Config struct {
 	// CopilotKey defines GitHub Copilot API configurations.
 	CopilotKey []CopilotKey `yaml:"copilot-api-key" json:"copilot-api-key"`
 
+	// DisableCopilotAliases stops registering a "copilot-" prefixed alias for every Copilot
+	// model. Prefixed model names are still accepted on input.
+	DisableCopilotAliases bool `yaml:"disable-copilot-aliases,omitempty" json:"disable-copilot-aliases,omitempty"`
+
+	// StripRequestHeaders lists client headers the Copilot executor ignores: they neither
+	// reach Copilot nor steer the Copilot header hints. Names match case-insensitively; a
+	// trailing "*" matches a prefix.
+	StripRequestHeaders []string `yaml:"strip-request-headers,omitempty" json:"strip-request-headers,omitempty"`
+
 	// GrokKey defines Grok (X.AI) API configurations using SSO cookies.
 	GrokKey []GrokKey `yaml:"grok-api-key" json:"grok-api-key"`
 
@@ -125,6 +178,21 @@ type Config struct {
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
 	OAuthModelMappings map[string][]ModelNameMapping `yaml:"oauth-model-mappings,omitempty" json:"oauth-model-mappings,omitempty"`
 
+	// ModelPricing maps model IDs to per-million-token prices surfaced on /v1/models.
+	ModelPricing map[string]ModelPrice `yaml:"model-pricing,omitempty" json:"model-pricing,omitempty"`
+
+	// ModelDeprecations marks model IDs as deprecated on /v1/models, with an optional
+	// retirement date and replacement model.
+	ModelDeprecations map[string]ModelDeprecation `yaml:"model-deprecations,omitempty" json:"model-deprecations,omitempty"`
+
+	// ModelSystemRoles maps model IDs to the role ("developer" or "system") their instruction
+	// messages are sent under. Models not listed keep the roles the client sent.
+	ModelSystemRoles map[string]string `yaml:"model-system-roles,omitempty" json:"model-system-roles,omitempty"`
+
+	// StrictModelValidation rejects models registered with MaxCompletionTokens >= ContextLength
+	// instead of only logging a warning.
+	StrictModelValidation bool `yaml:"strict-model-validation,omitempty" json:"strict-model-validation,omitempty"`
+
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
@@ -190,6 +258,84 @@ type TLSConfig struct {
 	Key string `yaml:"key" json:"key"`
 }
 
+// HealthProbeConfig controls the active upstream probe under 'health-probe'.
+type HealthProbeConfig struct {
+	// Enabled turns on periodic HEAD requests to each configured Copilot and Codex upstream.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Interval between probe rounds as a Go duration. Defaults to "60s".
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// Timeout per probe request as a Go duration. Defaults to "5s".
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Jitter is the maximum random delay added to each interval as a Go duration. Defaults to none.
+	Jitter string `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+}
+
+// CircuitBreakerConfig controls the per-provider circuit breaker under 'circuit-breaker'.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive upstream 5xx or timeout failures that
+	// opens a provider's circuit. Zero disables the breaker.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+
+	// Cooldown is how long an open circuit rejects requests before letting a trial request
+	// through, as a Go duration. Defaults to "30s".
+	Cooldown string `yaml:"cooldown,omitempty" json:"cooldown,omitempty"`
+}
+
+// CooldownDuration parses Cooldown, returning zero when it is empty or invalid.
+func (c CircuitBreakerConfig) CooldownDuration() time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(c.Cooldown))
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// MetricsCredentialsDebounceDuration parses MetricsCredentialsDebounce, returning zero when
+// it is empty or invalid.
+func (c *Config) MetricsCredentialsDebounceDuration() time.Duration {
+	if c == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(c.MetricsCredentialsDebounce))
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// SlowRequestThresholdDuration parses SlowRequestThreshold, returning zero when it is empty
+// or invalid.
+func (c *Config) SlowRequestThresholdDuration() time.Duration {
+	if c == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(c.SlowRequestThreshold))
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// BatchesConfig controls the /v1/batches endpoint under 'batches'.
+type BatchesConfig struct {
+	// Dir stores batch state so unfinished batches resume after a restart.
+	// Defaults to a "batches" directory next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// MaxConcurrency bounds how many batch requests execute at once. Defaults to 4.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// MaxItems bounds the number of requests in one batch. Defaults to 1000.
+	MaxItems int `yaml:"max-items,omitempty" json:"max-items,omitempty"`
+
+	// RetentionHours is how long a completed batch and its results are kept, in memory and
+	// on disk, before they are deleted. Defaults to 24.
+	RetentionHours int `yaml:"retention-hours,omitempty" json:"retention-hours,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -230,6 +376,21 @@ type ModelNameMapping struct {
 	Fork  bool   `yaml:"fork,omitempty" json:"fork,omitempty"`
 }
 
+// ModelPrice holds the USD price per million tokens for a model.
+type ModelPrice struct {
+	Input  float64 `yaml:"input" json:"input"`
+	Output float64 `yaml:"output" json:"output"`
+}
+
+// ModelDeprecation describes a deprecated model under model-deprecations.
+type ModelDeprecation struct {
+	// Date is when the model is retired, e.g. "2025-12-31".
+	Date string `yaml:"date,omitempty" json:"date,omitempty"`
+
+	// Replacement names the model clients should move to.
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+}
+
 // AmpModelMapping defines a model name mapping for Amp CLI requests.
 // When Amp requests a model that isn't available locally, this mapping
 // allows routing to an alternative model that IS available.
@@ -373,6 +534,12 @@ type CodexKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// RequestTimeout bounds a non-streaming upstream call as a Go duration (e.g. "2m").
+	RequestTimeout string `yaml:"request-timeout,omitempty" json:"request-timeout,omitempty"`
+
+	// StreamIdleTimeout aborts a streaming response after this long without upstream data.
+	StreamIdleTimeout string `yaml:"stream-idle-timeout,omitempty" json:"stream-idle-timeout,omitempty"`
 }
 
 // CodexModel describes a mapping between an alias and the actual upstream model name.
@@ -394,6 +561,28 @@ type CopilotKey struct {
 	// Defaults to "individual" if not specified.
 	AccountType string `yaml:"account-type" json:"account-type"`
 
+	// Account scopes this entry to one Copilot credential, matched case-insensitively
+	// against the auth ID, GitHub username or email. Entries without an account apply
+	// to credentials that have no dedicated entry.
+	Account string `yaml:"account,omitempty" json:"account,omitempty"`
+
+	// BaseURL overrides the Copilot API endpoint derived from the account type (e.g. a mock
+	// server or a regional endpoint). Header handling is unchanged. It applies to every
+	// credential the entry serves, scoped or not.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// TokenEnv names an environment variable holding a Copilot API token. When set, the
+	// token is sent as the bearer token instead of exchanging the credential's GitHub token.
+	TokenEnv string `yaml:"token-env,omitempty" json:"token-env,omitempty"`
+
+	// TokenBase64 is a base64-encoded Copilot API token, used like TokenEnv.
+	// At most one of TokenEnv and TokenBase64 may be set.
+	TokenBase64 string `yaml:"token-base64,omitempty" json:"token-base64,omitempty"`
+
+	// Token is the Copilot API token resolved from TokenEnv or TokenBase64 at config load.
+	// It is never written back to the config file.
+	Token string `yaml:"-" json:"-"`
+
 	// ProxyURL overrides the global proxy setting for Copilot requests if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
@@ -407,13 +596,160 @@ type CopilotKey struct {
 	// VSCodeChatHeaderModels lists model IDs that should always use the "vscode-chat" header profile.
 	VSCodeChatHeaderModels []string `yaml:"vscode-chat-header-models,omitempty" json:"vscode-chat-header-models,omitempty"`
 
+	// StainlessHeaders overrides the X-Stainless-* client identity headers sent to Copilot.
+	// Keys may omit the "X-Stainless-" prefix (e.g. "OS", "Package-Version"); unset
+	// headers keep their built-in defaults.
+	StainlessHeaders map[string]string `yaml:"stainless-headers,omitempty" json:"stainless-headers,omitempty"`
+
+	// VSCodeChatHeaders overrides the client version headers sent with the "vscode-chat"
+	// profile. Supported keys: Editor-Version, Editor-Plugin-Version, Copilot-Integration-Id;
+	// unset keys keep the built-in values.
+	VSCodeChatHeaders map[string]string `yaml:"vscode-chat-headers,omitempty" json:"vscode-chat-headers,omitempty"`
+
+	// VSCodeSessionID and VSCodeMachineID set the VScode-SessionId and VScode-MachineId
+	// headers sent with the "vscode-chat" profile. "auto" uses a random UUID generated once
+	// per proxy process; empty keeps the all-zero UUID.
+	VSCodeSessionID string `yaml:"vscode-session-id,omitempty" json:"vscode-session-id,omitempty"`
+	VSCodeMachineID string `yaml:"vscode-machine-id,omitempty" json:"vscode-machine-id,omitempty"`
+
+	// OrgID is sent as the Copilot-Organization header for enterprise seats that require it.
+	OrgID string `yaml:"org-id,omitempty" json:"org-id,omitempty"`
+
+	// UserAgent overrides the User-Agent sent with this key's Copilot requests. Empty keeps
+	// the shared Copilot CLI default.
+	UserAgent string `yaml:"user-agent,omitempty" json:"user-agent,omitempty"`
+
+	// ExtraHeaders are static headers applied after every built-in Copilot header, so they
+	// can override defaults. Authorization is never overridden.
+	ExtraHeaders map[string]string `yaml:"extra-headers,omitempty" json:"extra-headers,omitempty"`
+
+	// InteractionType overrides the X-Interaction-Type header. Defaults to "conversation-agent".
+	InteractionType string `yaml:"interaction-type,omitempty" json:"interaction-type,omitempty"`
+
+	// OpenAIIntent overrides the Openai-Intent header. Defaults to "conversation-agent".
+	OpenAIIntent string `yaml:"openai-intent,omitempty" json:"openai-intent,omitempty"`
+
+	// HintScanMaxBytes caps the payload size scanned for initiator and vision hints. Larger
+	// payloads are treated as agent calls without vision. Defaults to 32 MiB.
+	HintScanMaxBytes int `yaml:"hint-scan-max-bytes,omitempty" json:"hint-scan-max-bytes,omitempty"`
+
 	// AgentInitiatorPersist, when true, forces subsequent Copilot requests sharing the
 	// same prompt_cache_key to send X-Initiator=agent after the first call. Default false.
 	AgentInitiatorPersist bool `yaml:"agent-initiator-persist" json:"agent-initiator-persist"`
 
+	// InitiatorCacheSize caps how many prompt_cache_key entries are remembered for
+	// AgentInitiatorPersist; least recently used keys are evicted first. Default 4096.
+	InitiatorCacheSize int `yaml:"initiator-cache-size,omitempty" json:"initiator-cache-size,omitempty"`
+
 	// ForceAgentCall, when true, forces every Copilot request to be treated as an agent call
 	// regardless of request payload (X-Initiator: agent). Default false.
 	ForceAgentCall bool `yaml:"force-agent-call" json:"force-agent-call"`
+
+	// ForceAgentCallModels lists model IDs that are always treated as agent calls
+	// (X-Initiator: agent). ForceAgentCall still applies to every model when set.
+	ForceAgentCallModels []string `yaml:"force-agent-call-models,omitempty" json:"force-agent-call-models,omitempty"`
+
+	// PriorityQueueConcurrency, when greater than zero, caps in-flight Copilot requests per
+	// credential and queues the excess so user-initiated requests dispatch ahead of agent
+	// requests. Default 0 (disabled).
+	PriorityQueueConcurrency int `yaml:"priority-queue-concurrency,omitempty" json:"priority-queue-concurrency,omitempty"`
+
+	// MaxConcurrent, when greater than zero, caps simultaneous in-flight requests on each
+	// credential using this entry. Requests over the limit wait up to MaxConcurrentWait for
+	// a free slot and are otherwise answered with 429. Default 0 (unlimited).
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MaxConcurrentWait bounds how long a request over MaxConcurrent queues for a slot, as a
+	// Go duration (e.g. "5s"). Empty or zero rejects over-limit requests immediately.
+	MaxConcurrentWait string `yaml:"max-concurrent-wait,omitempty" json:"max-concurrent-wait,omitempty"`
+
+	// CoalesceRequests, when true, shares one upstream call between byte-identical
+	// non-streaming requests (same credential, model, body and hint headers) that are in
+	// flight at the same time. Only deterministic requests qualify: temperature 0 and no
+	// tools. Every waiter receives the same response or error; usage is recorded once, for
+	// the request that made the upstream call. Default false.
+	CoalesceRequests bool `yaml:"coalesce-requests,omitempty" json:"coalesce-requests,omitempty"`
+
+	// FilterUnsupportedParams, when true, strips top-level request fields that the target
+	// model does not list in its supported parameters before forwarding. Default false.
+	FilterUnsupportedParams bool `yaml:"filter-unsupported-params,omitempty" json:"filter-unsupported-params,omitempty"`
+
+	// ToolChoiceRequiredModels lists model IDs that accept tool_choice "required". When set,
+	// "required" is downgraded to "auto" for every other model. Empty leaves tool_choice untouched.
+	ToolChoiceRequiredModels []string `yaml:"tool-choice-required-models,omitempty" json:"tool-choice-required-models,omitempty"`
+
+	// MaxRetries is how many times a request answered with 429 or 503 is retried before the
+	// error is returned to the client. Default 0 (no retries).
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+
+	// RetryBaseDelay is the initial backoff as a Go duration (e.g. "500ms"); it doubles on each
+	// retry. A Retry-After header from upstream takes precedence. Default "500ms".
+	RetryBaseDelay string `yaml:"retry-base-delay,omitempty" json:"retry-base-delay,omitempty"`
+
+	// LogBodies captures the transformed request and upstream response bodies for
+	// credentials using this entry. Tokens and inline image data are redacted.
+	LogBodies bool `yaml:"log-bodies,omitempty" json:"log-bodies,omitempty"`
+
+	// LogBodiesMaxSizeMB is the rotation size of the body log file. Defaults to 10.
+	LogBodiesMaxSizeMB int `yaml:"log-bodies-max-size-mb,omitempty" json:"log-bodies-max-size-mb,omitempty"`
+
+	// VisionFallback controls requests that carry images for a model whose registry entry does
+	// not advertise vision support: "strip" drops the image parts and forwards the rest, and
+	// "reject" answers 400 without contacting upstream. Empty forwards the request unchanged.
+	VisionFallback string `yaml:"vision-fallback,omitempty" json:"vision-fallback,omitempty"`
+
+	// NormalizeErrors, when true, rewraps upstream error bodies that are not OpenAI error
+	// envelopes into {"error":{...}}, keeping the upstream status, message and code.
+	NormalizeErrors bool `yaml:"normalize-errors,omitempty" json:"normalize-errors,omitempty"`
+
+	// InlineRemoteImages, when true, downloads http(s) image_url parts and forwards them as
+	// base64 data URLs, for models that reject remote image references. Loopback, private,
+	// link-local and other non-public addresses are never fetched. Default false.
+	InlineRemoteImages bool `yaml:"inline-remote-images,omitempty" json:"inline-remote-images,omitempty"`
+
+	// InlineImageMaxSizeMB caps the size of each downloaded image; larger images are
+	// rejected with 400. Defaults to 5.
+	InlineImageMaxSizeMB int `yaml:"inline-image-max-size-mb,omitempty" json:"inline-image-max-size-mb,omitempty"`
+
+	// InlineImageTimeout bounds each image download as a Go duration. Defaults to "10s".
+	InlineImageTimeout string `yaml:"inline-image-timeout,omitempty" json:"inline-image-timeout,omitempty"`
+
+	// InlineImageMaxCount caps how many distinct images one request may download; requests
+	// with more are rejected with 400. Defaults to 8.
+	InlineImageMaxCount int `yaml:"inline-image-max-count,omitempty" json:"inline-image-max-count,omitempty"`
+
+	// RequestTimeout bounds a non-streaming upstream call as a Go duration (e.g. "2m").
+	// Empty or zero leaves the request bounded only by the client context.
+	RequestTimeout string `yaml:"request-timeout,omitempty" json:"request-timeout,omitempty"`
+
+	// StreamIdleTimeout aborts a streaming response when no data arrives from upstream for
+	// this long (e.g. "60s"). It resets on every chunk, so long generations are not cut off.
+	StreamIdleTimeout string `yaml:"stream-idle-timeout,omitempty" json:"stream-idle-timeout,omitempty"`
+
+	// RoutingRules rewrite the requested model before dispatch when a request matches. Rules
+	// are evaluated in order and the first match wins.
+	RoutingRules []CopilotRoutingRule `yaml:"routing-rules,omitempty" json:"routing-rules,omitempty"`
+}
+
+// CopilotRoutingRule routes matching Copilot requests to Target. Every condition that is set
+// must hold; a rule without conditions matches every request for its Models.
+type CopilotRoutingRule struct {
+	// Models restricts the rule to requested model IDs matching these case-insensitive globs
+	// (without the "copilot-" prefix). Empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// HasVision matches requests that do (true) or do not (false) carry images.
+	HasVision *bool `yaml:"has-vision,omitempty" json:"has-vision,omitempty"`
+
+	// Agent matches requests whose payload does (true) or does not (false) look like an
+	// agent continuation (tools, tool results or assistant turns).
+	Agent *bool `yaml:"agent,omitempty" json:"agent,omitempty"`
+
+	// MinPromptTokens matches requests whose estimated prompt is at least this many tokens.
+	MinPromptTokens int `yaml:"min-prompt-tokens,omitempty" json:"min-prompt-tokens,omitempty"`
+
+	// Target is the model the request is sent to instead.
+	Target string `yaml:"target" json:"target"`
 }
 
 // GrokKey represents the configuration for Grok (X.AI) API access.
@@ -687,10 +1023,19 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
+	cfg.SanitizeCodexReasoning()
 
 	// Sanitize Copilot keys: normalize account type
 	cfg.SanitizeCopilotKeys()
 
+	// Resolve Copilot token references from the environment or base64 values
+	if err = cfg.ResolveCopilotKeyTokens(); err != nil {
+		return nil, err
+	}
+	if err = cfg.ValidateCopilotBaseURLs(); err != nil {
+		return nil, err
+	}
+
 	// Sanitize Grok keys: normalize token types and trim whitespace
 	cfg.SanitizeGrokKeys()
 	cfg.SanitizeGrokConfig()
@@ -731,6 +1076,26 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize global OAuth model name mappings.
 	cfg.SanitizeOAuthModelMappings()
 
+	// Normalize model pricing keys and drop unusable entries.
+	cfg.SanitizeModelPricing()
+
+	// Normalize model deprecation keys.
+	cfg.SanitizeModelDeprecations()
+
+	// Normalize per-model system roles and drop unknown roles.
+	cfg.SanitizeModelSystemRoles()
+
+	// Normalize model aliases and drop empty or self-referencing entries.
+	cfg.SanitizeModelAliases()
+
+	// Normalize per-model rate limits and drop non-positive entries.
+	cfg.SanitizeModelRateLimits()
+
+	// Normalize per-module log levels and reject unknown level names.
+	if err = cfg.ValidateLogLevels(); err != nil {
+		return nil, err
+	}
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -823,6 +1188,118 @@ func (cfg *Config) SanitizeOAuthModelMappings() {
 	cfg.OAuthModelMappings = out
 }
 
+// SanitizeModelAliases lower-cases and trims alias keys, trims targets, and drops
+// entries that are empty or map an alias onto itself.
+func (cfg *Config) SanitizeModelAliases() {
+	if cfg == nil || len(cfg.ModelAliases) == 0 {
+		return
+	}
+	out := make(map[string]string, len(cfg.ModelAliases))
+	for rawAlias, rawTarget := range cfg.ModelAliases {
+		alias := strings.ToLower(strings.TrimSpace(rawAlias))
+		target := strings.TrimSpace(rawTarget)
+		if alias == "" || target == "" || strings.EqualFold(alias, target) {
+			continue
+		}
+		out[alias] = target
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.ModelAliases = out
+}
+
+// SanitizeModelRateLimits lower-cases and trims model keys and drops non-positive limits.
+func (cfg *Config) SanitizeModelRateLimits() {
+	if cfg == nil || len(cfg.ModelRateLimits) == 0 {
+		return
+	}
+	out := make(map[string]int, len(cfg.ModelRateLimits))
+	for rawModel, rpm := range cfg.ModelRateLimits {
+		model := strings.ToLower(strings.TrimSpace(rawModel))
+		if model == "" || rpm <= 0 {
+			continue
+		}
+		out[model] = rpm
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.ModelRateLimits = out
+}
+
+// SanitizeModelDeprecations lower-cases and trims model keys and trims the date and
+// replacement values.
+func (cfg *Config) SanitizeModelDeprecations() {
+	if cfg == nil || len(cfg.ModelDeprecations) == 0 {
+		return
+	}
+	out := make(map[string]ModelDeprecation, len(cfg.ModelDeprecations))
+	for rawModel, deprecation := range cfg.ModelDeprecations {
+		model := strings.ToLower(strings.TrimSpace(rawModel))
+		if model == "" {
+			continue
+		}
+		deprecation.Date = strings.TrimSpace(deprecation.Date)
+		deprecation.Replacement = strings.TrimSpace(deprecation.Replacement)
+		out[model] = deprecation
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.ModelDeprecations = out
+}
+
+// SanitizeModelSystemRoles lower-cases and trims model keys and roles, dropping entries whose
+// role is neither "developer" nor "system".
+func (cfg *Config) SanitizeModelSystemRoles() {
+	if cfg == nil || len(cfg.ModelSystemRoles) == 0 {
+		return
+	}
+	out := make(map[string]string, len(cfg.ModelSystemRoles))
+	for rawModel, rawRole := range cfg.ModelSystemRoles {
+		model := strings.ToLower(strings.TrimSpace(rawModel))
+		role := strings.ToLower(strings.TrimSpace(rawRole))
+		if model == "" || (role != "developer" && role != "system") {
+			continue
+		}
+		out[model] = role
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.ModelSystemRoles = out
+}
+
+// SanitizeModelPricing lower-cases and trims model keys, clamps negative prices to zero,
+// and drops entries without any price.
+func (cfg *Config) SanitizeModelPricing() {
+	if cfg == nil || len(cfg.ModelPricing) == 0 {
+		return
+	}
+	out := make(map[string]ModelPrice, len(cfg.ModelPricing))
+	for rawModel, price := range cfg.ModelPricing {
+		model := strings.ToLower(strings.TrimSpace(rawModel))
+		if model == "" {
+			continue
+		}
+		if price.Input < 0 {
+			price.Input = 0
+		}
+		if price.Output < 0 {
+			price.Output = 0
+		}
+		if price.Input == 0 && price.Output == 0 {
+			continue
+		}
+		out[model] = price
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.ModelPricing = out
+}
+
 // SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
 // not actionable, specifically those missing a BaseURL. It trims whitespace before
 // evaluation and preserves the relative order of remaining entries.
@@ -846,6 +1323,21 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 	cfg.OpenAICompatibility = out
 }
 
+// SanitizeCodexReasoning trims the no-reasoning model list and normalizes the reasoning
+// effort policy, clearing values other than "clamp" and "reject".
+func (cfg *Config) SanitizeCodexReasoning() {
+	if cfg == nil {
+		return
+	}
+	for i := range cfg.NoReasoningModels {
+		cfg.NoReasoningModels[i] = strings.TrimSpace(cfg.NoReasoningModels[i])
+	}
+	cfg.ReasoningEffortPolicy = strings.ToLower(strings.TrimSpace(cfg.ReasoningEffortPolicy))
+	if cfg.ReasoningEffortPolicy != "clamp" && cfg.ReasoningEffortPolicy != "reject" {
+		cfg.ReasoningEffortPolicy = ""
+	}
+}
+
 // SanitizeCodexKeys removes Codex API key entries missing a BaseURL.
 // It trims whitespace and preserves order for remaining entries.
 func (cfg *Config) SanitizeCodexKeys() {
@@ -859,6 +1351,8 @@ func (cfg *Config) SanitizeCodexKeys() {
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
 		e.ExcludedModels = NormalizeExcludedModels(e.ExcludedModels)
+		e.RequestTimeout = strings.TrimSpace(e.RequestTimeout)
+		e.StreamIdleTimeout = strings.TrimSpace(e.StreamIdleTimeout)
 		if e.BaseURL == "" {
 			continue
 		}
@@ -877,6 +1371,27 @@ func (cfg *Config) SanitizeCopilotKeys() {
 		entry := &cfg.CopilotKey[i]
 		entry.AccountType = strings.TrimSpace(strings.ToLower(entry.AccountType))
 		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Account = strings.TrimSpace(entry.Account)
+		entry.OrgID = strings.TrimSpace(entry.OrgID)
+		entry.UserAgent = strings.TrimSpace(entry.UserAgent)
+		rules := entry.RoutingRules[:0]
+		for _, rule := range entry.RoutingRules {
+			rule.Target = strings.TrimSpace(rule.Target)
+			if rule.Target == "" {
+				continue
+			}
+			for j := range rule.Models {
+				rule.Models[j] = strings.ToLower(strings.TrimSpace(rule.Models[j]))
+			}
+			if rule.MinPromptTokens < 0 {
+				rule.MinPromptTokens = 0
+			}
+			rules = append(rules, rule)
+		}
+		entry.RoutingRules = rules
+		entry.VSCodeSessionID = strings.TrimSpace(entry.VSCodeSessionID)
+		entry.VSCodeMachineID = strings.TrimSpace(entry.VSCodeMachineID)
+		entry.ExtraHeaders = NormalizeHeaders(entry.ExtraHeaders)
 		validation := copilotshared.ValidateAccountType(entry.AccountType)
 		if validation.Valid {
 			entry.AccountType = string(validation.AccountType)
@@ -897,7 +1412,133 @@ func (cfg *Config) SanitizeCopilotKeys() {
 		for j := range entry.VSCodeChatHeaderModels {
 			entry.VSCodeChatHeaderModels[j] = strings.TrimSpace(entry.VSCodeChatHeaderModels[j])
 		}
+		for j := range entry.ForceAgentCallModels {
+			entry.ForceAgentCallModels[j] = strings.TrimSpace(entry.ForceAgentCallModels[j])
+		}
+		for j := range entry.ToolChoiceRequiredModels {
+			entry.ToolChoiceRequiredModels[j] = strings.TrimSpace(entry.ToolChoiceRequiredModels[j])
+		}
+
+		if entry.PriorityQueueConcurrency < 0 {
+			entry.PriorityQueueConcurrency = 0
+		}
+		if entry.MaxConcurrent < 0 {
+			entry.MaxConcurrent = 0
+		}
+		entry.MaxConcurrentWait = strings.TrimSpace(entry.MaxConcurrentWait)
+		if entry.InitiatorCacheSize < 0 {
+			entry.InitiatorCacheSize = 0
+		}
+		if entry.MaxRetries < 0 {
+			entry.MaxRetries = 0
+		}
+		if entry.InlineImageMaxSizeMB < 0 {
+			entry.InlineImageMaxSizeMB = 0
+		}
+		if entry.InlineImageMaxCount < 0 {
+			entry.InlineImageMaxCount = 0
+		}
+		entry.RetryBaseDelay = strings.TrimSpace(entry.RetryBaseDelay)
+		entry.RequestTimeout = strings.TrimSpace(entry.RequestTimeout)
+		entry.StreamIdleTimeout = strings.TrimSpace(entry.StreamIdleTimeout)
+		entry.VisionFallback = strings.ToLower(strings.TrimSpace(entry.VisionFallback))
+		entry.InlineImageTimeout = strings.TrimSpace(entry.InlineImageTimeout)
+		entry.InteractionType = strings.TrimSpace(entry.InteractionType)
+		entry.OpenAIIntent = strings.TrimSpace(entry.OpenAIIntent)
+		if entry.HintScanMaxBytes < 0 {
+			entry.HintScanMaxBytes = 0
+		}
+		if entry.LogBodiesMaxSizeMB < 0 {
+			entry.LogBodiesMaxSizeMB = 0
+		}
+	}
+}
+
+// ResolveCopilotKeyTokens resolves TokenEnv and TokenBase64 into Token for every Copilot
+// entry. An unset environment variable, invalid base64 or both references on one entry is
+// reported as an error so a misconfigured secret fails loudly at startup.
+func (cfg *Config) ResolveCopilotKeyTokens() error {
+	if cfg == nil {
+		return nil
+	}
+	for i := range cfg.CopilotKey {
+		entry := &cfg.CopilotKey[i]
+		entry.TokenEnv = strings.TrimSpace(entry.TokenEnv)
+		entry.TokenBase64 = strings.TrimSpace(entry.TokenBase64)
+		entry.Token = ""
+		switch {
+		case entry.TokenEnv != "" && entry.TokenBase64 != "":
+			return fmt.Errorf("copilot-api-key[%d]: token-env and token-base64 are mutually exclusive", i)
+		case entry.TokenEnv != "":
+			value, ok := os.LookupEnv(entry.TokenEnv)
+			value = strings.TrimSpace(value)
+			if !ok || value == "" {
+				return fmt.Errorf("copilot-api-key[%d]: environment variable %s referenced by token-env is not set", i, entry.TokenEnv)
+			}
+			entry.Token = value
+		case entry.TokenBase64 != "":
+			decoded, err := base64.StdEncoding.DecodeString(entry.TokenBase64)
+			if err != nil {
+				return fmt.Errorf("copilot-api-key[%d]: invalid token-base64: %w", i, err)
+			}
+			value := strings.TrimSpace(string(decoded))
+			if value == "" {
+				return fmt.Errorf("copilot-api-key[%d]: token-base64 decodes to an empty token", i)
+			}
+			entry.Token = value
+		}
+	}
+	return nil
+}
+
+// validLogLevels lists the level names accepted under log-levels.
+var validLogLevels = map[string]struct{}{
+	"panic": {}, "fatal": {}, "error": {}, "warn": {}, "warning": {}, "info": {}, "debug": {}, "trace": {},
+}
+
+// ValidateLogLevels lower-cases and trims module names and levels, drops entries with an
+// empty module or level, and rejects unknown level names.
+func (cfg *Config) ValidateLogLevels() error {
+	if cfg == nil || len(cfg.LogLevels) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(cfg.LogLevels))
+	for module, level := range cfg.LogLevels {
+		module = strings.ToLower(strings.TrimSpace(module))
+		level = strings.ToLower(strings.TrimSpace(level))
+		if module == "" || level == "" {
+			continue
+		}
+		if _, ok := validLogLevels[level]; !ok {
+			return fmt.Errorf("log-levels: unknown level %q for module %q", level, module)
+		}
+		out[module] = level
+	}
+	cfg.LogLevels = out
+	return nil
+}
+
+// ValidateCopilotBaseURLs trims every Copilot BaseURL and rejects values that are not
+// absolute http(s) URLs with a host.
+func (cfg *Config) ValidateCopilotBaseURLs() error {
+	if cfg == nil {
+		return nil
+	}
+	for i := range cfg.CopilotKey {
+		entry := &cfg.CopilotKey[i]
+		entry.BaseURL = strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			continue
+		}
+		parsed, err := url.Parse(entry.BaseURL)
+		if err != nil {
+			return fmt.Errorf("copilot-api-key[%d]: invalid base-url %q: %w", i, entry.BaseURL, err)
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("copilot-api-key[%d]: base-url %q must be an absolute http or https URL", i, entry.BaseURL)
+		}
 	}
+	return nil
 }
 
 // SanitizeGrokKeys normalizes Grok configurations.
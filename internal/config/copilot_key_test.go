@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func writeCopilotKeyConfig(t *testing.T, entry string) string {
+	t.Helper()
+	path := t.TempDir() + "/config.yaml"
+	cfgYAML := "port: 8317\ncopilot-api-key:\n  - account-type: individual\n" + entry
+	if err := os.WriteFile(path, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("failed to write temp config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigOptional_CopilotTokenEnv(t *testing.T) {
+	t.Setenv("CLIPROXY_TEST_COPILOT_TOKEN", " env-token\n")
+	path := writeCopilotKeyConfig(t, "    token-env: CLIPROXY_TEST_COPILOT_TOKEN\n")
+
+	cfg, err := LoadConfigOptional(path, false)
+	if err != nil {
+		t.Fatalf("LoadConfigOptional error: %v", err)
+	}
+	if got := cfg.CopilotKey[0].Token; got != "env-token" {
+		t.Fatalf("token = %q, want env-token", got)
+	}
+}
+
+func TestLoadConfigOptional_CopilotTokenBase64(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("b64-token"))
+	path := writeCopilotKeyConfig(t, "    token-base64: "+encoded+"\n")
+
+	cfg, err := LoadConfigOptional(path, false)
+	if err != nil {
+		t.Fatalf("LoadConfigOptional error: %v", err)
+	}
+	if got := cfg.CopilotKey[0].Token; got != "b64-token" {
+		t.Fatalf("token = %q, want b64-token", got)
+	}
+}
+
+func TestLoadConfigOptional_CopilotTokenInvalidReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr string
+	}{
+		{name: "unset env", entry: "    token-env: CLIPROXY_TEST_COPILOT_TOKEN_UNSET\n", wantErr: "CLIPROXY_TEST_COPILOT_TOKEN_UNSET"},
+		{name: "invalid base64", entry: "    token-base64: \"not base64!\"\n", wantErr: "invalid token-base64"},
+		{name: "both set", entry: "    token-env: HOME\n    token-base64: dG9rZW4=\n", wantErr: "mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigOptional(writeCopilotKeyConfig(t, tt.entry), false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigOptional_CopilotBaseURL(t *testing.T) {
+	cfg, err := LoadConfigOptional(writeCopilotKeyConfig(t, "    base-url: \" http://127.0.0.1:9999/ \"\n"), false)
+	if err != nil {
+		t.Fatalf("LoadConfigOptional error: %v", err)
+	}
+	if got := cfg.CopilotKey[0].BaseURL; got != "http://127.0.0.1:9999" {
+		t.Fatalf("base-url = %q, want trimmed URL", got)
+	}
+
+	for _, bad := range []string{"ftp://example.com", "example.com/api", "http://"} {
+		if _, err = LoadConfigOptional(writeCopilotKeyConfig(t, "    base-url: \""+bad+"\"\n"), false); err == nil || !strings.Contains(err.Error(), "base-url") {
+			t.Fatalf("base-url %q: error = %v, want a base-url error", bad, err)
+		}
+	}
+}
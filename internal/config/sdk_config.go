@@ -25,6 +25,74 @@ type SDKConfig struct {
 
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
+
+	// ModelAliases maps client-facing model aliases (e.g. "fast") to target model IDs.
+	// Requests for an alias are rewritten to the target before routing, and aliases are
+	// listed on /v1/models alongside their targets.
+	ModelAliases map[string]string `yaml:"model-aliases,omitempty" json:"model-aliases,omitempty"`
+
+	// ServedModels restricts which models are listed and may be invoked. Entries are
+	// case-insensitive globs (e.g. "gpt-5*") matched against the model ID without the
+	// "copilot-" prefix. Empty serves every registered model.
+	ServedModels []string `yaml:"served-models,omitempty" json:"served-models,omitempty"`
+
+	// FallbackModel is substituted when a request names a model that is not registered.
+	// It must itself be a registered model; otherwise the original error is returned.
+	FallbackModel string `yaml:"fallback-model,omitempty" json:"fallback-model,omitempty"`
+
+	// LargerContextFallback retries a request once on a registered sibling model (same
+	// owned_by, larger context_length) when the upstream rejects its prompt as exceeding the
+	// model's context window. Default is off.
+	LargerContextFallback bool `yaml:"larger-context-fallback,omitempty" json:"larger-context-fallback,omitempty"`
+
+	// SystemPromptPrefix is prepended to the system prompt of every Chat Completions and
+	// Responses request. Requests whose system prompt already starts with it are left as-is.
+	SystemPromptPrefix string `yaml:"system-prompt-prefix,omitempty" json:"system-prompt-prefix,omitempty"`
+
+	// ModelRateLimits caps requests per minute for a model, keyed by the model ID after alias
+	// resolution (case-insensitive). Generation and token count requests share the limit;
+	// requests over it are answered with 429.
+	ModelRateLimits map[string]int `yaml:"model-rate-limits,omitempty" json:"model-rate-limits,omitempty"`
+
+	// ModelDefaults maps a model ID after alias resolution (case-insensitive) to request fields
+	// (gjson/sjson paths) written into the payload when the client did not set them.
+	ModelDefaults map[string]map[string]any `yaml:"model-defaults,omitempty" json:"model-defaults,omitempty"`
+
+	// ClampMaxTokens lowers max_tokens, max_completion_tokens and max_output_tokens to the
+	// model's known output limit instead of letting the upstream reject the request.
+	ClampMaxTokens bool `yaml:"clamp-max-tokens,omitempty" json:"clamp-max-tokens,omitempty"`
+
+	// StripReasoningFromResponse lists request paths (e.g. "/v1/chat/completions") whose
+	// client responses have reasoning removed: reasoning_content, reasoning_text and
+	// reasoning_opaque from Chat Completions messages and deltas, and reasoning items from
+	// Responses output. Stream chunks left empty are dropped. Upstream requests are not changed.
+	StripReasoningFromResponse []string `yaml:"strip-reasoning-from-response,omitempty" json:"strip-reasoning-from-response,omitempty"`
+
+	// NFanOutMax enables n > 1 for upstreams that ignore n: non-streaming Chat Completions
+	// requests are sent as n parallel single-choice requests and merged into one response.
+	// Models whose registry entry lists n in SupportedParameters receive n unchanged.
+	// Requests asking for more than NFanOutMax choices, or combining n > 1 with stream, are
+	// rejected with 400. 0 disables fan-out and forwards n unchanged.
+	NFanOutMax int `yaml:"n-fanout-max,omitempty" json:"n-fanout-max,omitempty"`
+
+	// ResponseCache serves repeated deterministic non-streaming requests from memory.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+}
+
+// ResponseCacheConfig configures the in-memory cache of deterministic responses. Only
+// non-streaming requests with temperature 0 and no tools are cached, keyed by the
+// authenticated client, model and payload. Cache hits make no upstream call, so they are
+// not recorded in usage statistics.
+type ResponseCacheConfig struct {
+	// Enabled turns the cache on. Default is off.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// TTLSeconds is how long a cached response is served. <= 0 uses 300.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries bounds the number of cached responses; the least recently used entry is
+	// evicted first. <= 0 uses 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -37,6 +105,11 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// PingSeconds controls how often the server emits SSE ping comments (": ping\n\n") while
+	// waiting for the first upstream chunk, e.g. during long prompt processing. Pings stop
+	// once data flows. <= 0 disables pings. Default is 0.
+	PingSeconds int `yaml:"ping-seconds,omitempty" json:"ping-seconds,omitempty"`
 }
 
 // AccessConfig groups request authentication providers.
@@ -0,0 +1,104 @@
+package interfaces
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// OpenAIError is the OpenAI error envelope returned when the proxy itself rejects a request.
+type OpenAIError struct {
+	Error OpenAIErrorDetail `json:"error"`
+}
+
+// OpenAIErrorDetail mirrors the OpenAI error object. Param and Code are null when unset.
+type OpenAIErrorDetail struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// OpenAIErrorType returns the OpenAI error type for an HTTP status.
+func OpenAIErrorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusForbidden:
+		return "permission_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status >= http.StatusInternalServerError:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// NewOpenAIError builds an OpenAI error envelope typed from status.
+func NewOpenAIError(status int, message, param, code string) OpenAIError {
+	detail := OpenAIErrorDetail{Message: message, Type: OpenAIErrorType(status)}
+	if param != "" {
+		detail.Param = &param
+	}
+	if code != "" {
+		detail.Code = &code
+	}
+	return OpenAIError{Error: detail}
+}
+
+// OpenAIErrorBody marshals NewOpenAIError into a JSON response body.
+func OpenAIErrorBody(status int, message, param, code string) []byte {
+	body, err := json.Marshal(NewOpenAIError(status, message, param, code))
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"error":{"message":%q,"type":"server_error","param":null,"code":null}}`, message))
+	}
+	return body
+}
+
+// NormalizeOpenAIErrorBody rewraps an upstream error body that is not an OpenAI error
+// envelope into one, keeping the upstream message and code where they can be found.
+// Bodies that already carry {"error":{"message":...}} are returned unchanged. When the
+// body has no code, one is derived from status (e.g. "unauthorized").
+func NormalizeOpenAIErrorBody(status int, body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	root := gjson.ParseBytes(trimmed)
+	if root.IsObject() && root.Get("error").IsObject() && root.Get("error.message").Type == gjson.String {
+		return body
+	}
+
+	var message, code, param string
+	if root.IsObject() {
+		message = firstErrorString(root, "error.message", "error", "message", "error_description", "detail", "errors.0.message", "msg")
+		code = firstErrorString(root, "error.code", "code", "errors.0.code", "error_code")
+		param = firstErrorString(root, "error.param", "param")
+	} else if len(trimmed) > 0 && !json.Valid(trimmed) {
+		message = string(trimmed)
+	}
+	if message == "" {
+		message = http.StatusText(status)
+		if message == "" {
+			message = "upstream error"
+		}
+	}
+	if code == "" {
+		code = strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	}
+	return OpenAIErrorBody(status, message, param, code)
+}
+
+// firstErrorString returns the first non-empty string value found at paths.
+func firstErrorString(root gjson.Result, paths ...string) string {
+	for _, path := range paths {
+		if v := root.Get(path); v.Type == gjson.String {
+			if s := strings.TrimSpace(v.String()); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
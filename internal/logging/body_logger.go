@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// defaultBodyLogMaxSizeMB is the rotation size used when none is configured.
+const defaultBodyLogMaxSizeMB = 10
+
+// BodyRecord is a captured upstream exchange: the transformed request sent to the
+// provider and the raw response body it returned.
+type BodyRecord struct {
+	Time      time.Time   `json:"time"`
+	Provider  string      `json:"provider"`
+	Model     string      `json:"model"`
+	Initiator string      `json:"initiator,omitempty"`
+	Headers   http.Header `json:"headers,omitempty"`
+	Request   string      `json:"request"`
+	Response  string      `json:"response"`
+}
+
+// BodySink receives captured request/response bodies. Records passed to a sink have
+// already been redacted with RedactBodyRecord.
+type BodySink interface {
+	WriteBodyRecord(record BodyRecord) error
+}
+
+var (
+	bearerTokenPattern   = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	base64DataURLPattern = regexp.MustCompile(`data:([A-Za-z0-9.+/-]+);base64,[A-Za-z0-9+/=_-]+`)
+)
+
+// RedactBody replaces bearer tokens, the given secrets and base64 data URLs in a body.
+// Data URLs keep their media type: "data:image/png;base64,<redacted>".
+func RedactBody(body string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			body = strings.ReplaceAll(body, secret, "<redacted>")
+		}
+	}
+	body = bearerTokenPattern.ReplaceAllString(body, "${1}<redacted>")
+	return base64DataURLPattern.ReplaceAllString(body, "data:${1};base64,<redacted>")
+}
+
+// RedactBodyRecord returns a copy of record with credentials and inline image data removed
+// from headers and bodies. secrets lists additional raw values, such as the upstream token.
+func RedactBodyRecord(record BodyRecord, secrets ...string) BodyRecord {
+	if record.Headers != nil {
+		headers := make(http.Header, len(record.Headers))
+		for key, values := range record.Headers {
+			redacted := make([]string, len(values))
+			for i, value := range values {
+				redacted[i] = redactHeaderValue(key, value, secrets)
+			}
+			headers[key] = redacted
+		}
+		record.Headers = headers
+	}
+	record.Request = RedactBody(record.Request, secrets...)
+	record.Response = RedactBody(record.Response, secrets...)
+	return record
+}
+
+// redactHeaderValue hides credential headers entirely, keeping only the auth scheme.
+func redactHeaderValue(key, value string, secrets []string) string {
+	if strings.EqualFold(key, "Authorization") {
+		if scheme, _, ok := strings.Cut(strings.TrimSpace(value), " "); ok {
+			return scheme + " <redacted>"
+		}
+		return "<redacted>"
+	}
+	if util.MaskSensitiveHeaderValue(key, value) != value {
+		return "<redacted>"
+	}
+	return RedactBody(value, secrets...)
+}
+
+// FileBodySink writes body records as JSON lines to a size-rotated file.
+type FileBodySink struct {
+	mu     sync.Mutex
+	writer *lumberjack.Logger
+}
+
+// NewFileBodySink creates a sink writing to path, rotating once the file exceeds
+// maxSizeMB megabytes. A non-positive size uses the default of 10 MB.
+func NewFileBodySink(path string, maxSizeMB int) (*FileBodySink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("logging: failed to create body log directory: %w", err)
+	}
+	return &FileBodySink{writer: &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    bodyLogMaxSize(maxSizeMB),
+		MaxBackups: 3,
+	}}, nil
+}
+
+// SetMaxSize changes the rotation size for subsequent writes. A non-positive size uses
+// the default of 10 MB.
+func (s *FileBodySink) SetMaxSize(maxSizeMB int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer.MaxSize = bodyLogMaxSize(maxSizeMB)
+}
+
+// MaxSize returns the current rotation size in megabytes.
+func (s *FileBodySink) MaxSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.MaxSize
+}
+
+func bodyLogMaxSize(maxSizeMB int) int {
+	if maxSizeMB <= 0 {
+		return defaultBodyLogMaxSizeMB
+	}
+	return maxSizeMB
+}
+
+// DefaultBodyLogPath returns the body log location inside the writable logs directory.
+func DefaultBodyLogPath() string {
+	logDir := "logs"
+	if base := util.WritablePath(); base != "" {
+		logDir = filepath.Join(base, "logs")
+	}
+	return filepath.Join(logDir, "bodies.log")
+}
+
+// WriteBodyRecord implements BodySink.
+func (s *FileBodySink) WriteBodyRecord(record BodyRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
+
+// Close releases the underlying file.
+func (s *FileBodySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Close()
+}
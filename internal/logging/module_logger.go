@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// stdOutput forwards writes to the standard logger's current output so module loggers
+// follow log file rotation and reconfiguration.
+type stdOutput struct{}
+
+func (stdOutput) Write(p []byte) (int, error) {
+	return log.StandardLogger().Out.Write(p)
+}
+
+// stdFormatter formats entries with the standard logger's current formatter.
+type stdFormatter struct{}
+
+func (stdFormatter) Format(entry *log.Entry) ([]byte, error) {
+	return log.StandardLogger().Formatter.Format(entry)
+}
+
+// NewModuleLogger returns the logger for module. When cfg sets a level for the module under
+// log-levels, the returned logger filters at that level but shares the standard logger's
+// output, formatter, and hooks; otherwise the standard logger itself is returned.
+func NewModuleLogger(cfg *config.Config, module string) *log.Logger {
+	if cfg == nil {
+		return log.StandardLogger()
+	}
+	name, ok := cfg.LogLevels[module]
+	if !ok {
+		return log.StandardLogger()
+	}
+	level, err := log.ParseLevel(name)
+	if err != nil {
+		return log.StandardLogger()
+	}
+	std := log.StandardLogger()
+	return &log.Logger{
+		Out:          stdOutput{},
+		Formatter:    stdFormatter{},
+		Hooks:        std.Hooks,
+		Level:        level,
+		ReportCaller: std.ReportCaller,
+		ExitFunc:     std.ExitFunc,
+	}
+}
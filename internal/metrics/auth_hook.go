@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// AuthHook observes auth manager events and feeds the credential expiry and availability
+// gauges and the rotation counter. It implements coreauth.Hook.
+type AuthHook struct {
+	coreauth.NoopHook
+
+	mu            sync.Mutex
+	lastRefreshed map[string]time.Time
+	lookup        func(id string) (*coreauth.Auth, bool)
+}
+
+// NewAuthHook constructs a hook that records credential expiry and rotation metrics.
+func NewAuthHook() *AuthHook {
+	return &AuthHook{lastRefreshed: make(map[string]time.Time)}
+}
+
+// SetAuthLookup installs the function OnResult uses to read an auth's state after a result
+// is recorded, typically the owning manager's GetByID.
+func (h *AuthHook) SetAuthLookup(lookup func(id string) (*coreauth.Auth, bool)) {
+	h.mu.Lock()
+	h.lookup = lookup
+	h.mu.Unlock()
+}
+
+// OnAuthRegistered implements coreauth.Hook.
+func (h *AuthHook) OnAuthRegistered(_ context.Context, auth *coreauth.Auth) {
+	h.observe(auth, false)
+}
+
+// OnAuthUpdated implements coreauth.Hook.
+// A rotation is counted when the auth's LastRefreshedAt advances.
+func (h *AuthHook) OnAuthUpdated(_ context.Context, auth *coreauth.Auth) {
+	h.observe(auth, true)
+}
+
+// OnResult implements coreauth.Hook.
+// Results change an auth's status without an update event, so availability is re-read here.
+func (h *AuthHook) OnResult(_ context.Context, result coreauth.Result) {
+	h.mu.Lock()
+	lookup := h.lookup
+	h.mu.Unlock()
+	if lookup == nil || result.AuthID == "" {
+		return
+	}
+	auth, ok := lookup(result.AuthID)
+	if !ok || auth == nil || auth.Disabled {
+		return
+	}
+	ObserveCredentialState(auth.Provider, auth.ID, credentialAvailable(auth))
+}
+
+func (h *AuthHook) observe(auth *coreauth.Auth, updated bool) {
+	if auth == nil || auth.ID == "" {
+		return
+	}
+	if auth.Disabled {
+		// Disabled covers removed credentials, which the manager keeps as disabled entries.
+		ForgetCredential(auth.ID)
+		ClearCredentialExpiry(auth.Provider, auth.ID)
+		h.mu.Lock()
+		delete(h.lastRefreshed, auth.ID)
+		h.mu.Unlock()
+		return
+	}
+	ObserveCredentialState(auth.Provider, auth.ID, credentialAvailable(auth))
+	if expiry, ok := auth.ExpirationTime(); ok {
+		SetCredentialExpiry(auth.Provider, auth.ID, expiry)
+	}
+
+	h.mu.Lock()
+	previous, seen := h.lastRefreshed[auth.ID]
+	if !auth.LastRefreshedAt.IsZero() {
+		h.lastRefreshed[auth.ID] = auth.LastRefreshedAt
+	}
+	h.mu.Unlock()
+	if updated && seen && auth.LastRefreshedAt.After(previous) {
+		RecordCredentialRotation(auth.Provider)
+	}
+}
+
+// credentialAvailable reports whether auth can currently serve requests.
+func credentialAvailable(auth *coreauth.Auth) bool {
+	if auth.Disabled || auth.Unavailable {
+		return false
+	}
+	return auth.Status != coreauth.StatusError && auth.Status != coreauth.StatusDisabled
+}
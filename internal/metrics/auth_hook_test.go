@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthHook_RecordsExpiryAndRotation(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	hook := NewAuthHook()
+	defer ClearCredentialExpiry("hook-provider", "hook-cred")
+	auth := &coreauth.Auth{
+		ID:              "hook-cred",
+		Provider:        "hook-provider",
+		LastRefreshedAt: time.Now().Add(-time.Hour),
+		Metadata:        map[string]any{"expired": time.Now().Add(30 * time.Minute).Format(time.RFC3339)},
+	}
+	hook.OnAuthRegistered(context.Background(), auth)
+
+	expiry := testutil.ToFloat64(expirySeries(t, "hook-provider", "hook-cred"))
+	if expiry <= 25*60 || expiry > 30*60 {
+		t.Fatalf("expiry seconds = %v, want about 1800", expiry)
+	}
+	if got := testutil.ToFloat64(credentialRotations.WithLabelValues("hook-provider")); got != 0 {
+		t.Fatalf("rotations after register = %v, want 0", got)
+	}
+
+	refreshed := auth.Clone()
+	refreshed.LastRefreshedAt = time.Now()
+	refreshed.Metadata = map[string]any{"expired": time.Now().Add(2 * time.Hour).Format(time.RFC3339)}
+	hook.OnAuthUpdated(context.Background(), refreshed)
+	hook.OnAuthUpdated(context.Background(), refreshed)
+
+	if got := testutil.ToFloat64(credentialRotations.WithLabelValues("hook-provider")); got != 1 {
+		t.Fatalf("rotations = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(expirySeries(t, "hook-provider", "hook-cred")); got <= 110*60 {
+		t.Fatalf("expiry seconds after refresh = %v, want about 7200", got)
+	}
+}
+
+// expirySeries returns a collector holding only the expiry series of one credential.
+func expirySeries(t *testing.T, provider, credID string) prometheus.Collector {
+	t.Helper()
+	credentialExpiry.mu.RLock()
+	expiry, ok := credentialExpiry.expiries[expiryKey{provider: provider, credID: credID}]
+	credentialExpiry.mu.RUnlock()
+	if !ok {
+		t.Fatalf("no expiry recorded for %s/%s", provider, credID)
+	}
+	return &expiryCollector{
+		desc:     credentialExpiry.desc,
+		now:      credentialExpiry.now,
+		expiries: map[expiryKey]time.Time{{provider: provider, credID: credID}: expiry},
+	}
+}
+
+func TestCredentialMetrics_Scrape(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	now := time.Unix(1_700_000_000, 0)
+	previousNow := credentialExpiry.now
+	credentialExpiry.now = func() time.Time { return now }
+	defer func() { credentialExpiry.now = previousNow }()
+
+	SetCredentialExpiry("scrape-provider", "scrape-cred", now.Add(120*time.Second))
+	defer ClearCredentialExpiry("scrape-provider", "scrape-cred")
+	RecordCredentialRotation("scrape-provider")
+
+	scrape := func(seconds string) {
+		t.Helper()
+		expected := `
+# HELP cliproxy_credential_expiry_seconds Seconds until a credential expires, computed at scrape time from its last known expiry.
+# TYPE cliproxy_credential_expiry_seconds gauge
+cliproxy_credential_expiry_seconds{cred_id="scrape-cred",provider="scrape-provider"} ` + seconds + `
+`
+		if err := testutil.CollectAndCompare(expirySeries(t, "scrape-provider", "scrape-cred"), strings.NewReader(expected), "cliproxy_credential_expiry_seconds"); err != nil {
+			t.Fatalf("unexpected expiry scrape: %v", err)
+		}
+	}
+	scrape("120")
+	// Without another update the value counts down as time passes.
+	now = now.Add(90 * time.Second)
+	scrape("30")
+	now = now.Add(60 * time.Second)
+	scrape("-30")
+
+	if got := testutil.ToFloat64(credentialRotations.WithLabelValues("scrape-provider")); got != 1 {
+		t.Fatalf("rotations = %v, want 1", got)
+	}
+}
+
+func TestAuthHook_TracksAvailabilityThroughMarkResult(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+	defer credentialsAvailable.DeleteLabelValues("result-provider")
+
+	hook := NewAuthHook()
+	manager := coreauth.NewManager(nil, nil, hook)
+	hook.SetAuthLookup(manager.GetByID)
+	gauge := func() float64 { return testutil.ToFloat64(credentialsAvailable.WithLabelValues("result-provider")) }
+
+	ctx := context.Background()
+	for _, id := range []string{"result-a", "result-b"} {
+		if _, err := manager.Register(ctx, &coreauth.Auth{ID: id, Provider: "result-provider", Status: coreauth.StatusActive}); err != nil {
+			t.Fatalf("Register(%s): %v", id, err)
+		}
+	}
+	if got := gauge(); got != 2 {
+		t.Fatalf("available after register = %v, want 2", got)
+	}
+
+	manager.MarkResult(ctx, coreauth.Result{AuthID: "result-b", Provider: "result-provider", Model: "m", Error: &coreauth.Error{Message: "boom", HTTPStatus: 500}})
+	if got := gauge(); got != 1 {
+		t.Fatalf("available after a failed result = %v, want 1", got)
+	}
+	manager.MarkResult(ctx, coreauth.Result{AuthID: "result-b", Provider: "result-provider", Model: "m", Success: true})
+	if got := gauge(); got != 2 {
+		t.Fatalf("available after recovery = %v, want 2", got)
+	}
+
+	removed, _ := manager.GetByID("result-a")
+	removed.Disabled = true
+	removed.Status = coreauth.StatusDisabled
+	if _, err := manager.Update(ctx, removed); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := gauge(); got != 1 {
+		t.Fatalf("available after removal = %v, want 1", got)
+	}
+	credentials.mu.Lock()
+	_, tracked := credentials.states["result-a"]
+	credentials.mu.Unlock()
+	if tracked {
+		t.Fatal("removed credential is still tracked")
+	}
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentials tracks per-credential availability behind the credentials_available gauge.
+var credentials = newCredentialTracker()
+
+// SetCredentialsDebounce sets how long a credential must hold a new availability state
+// before the gauge reflects it. Zero reports every change immediately.
+func SetCredentialsDebounce(d time.Duration) {
+	credentials.setDebounce(d, time.Now())
+}
+
+// ObserveCredentialState records the availability of one credential and updates the
+// available credentials gauge for its provider, subject to the configured debounce.
+func ObserveCredentialState(provider, credID string, available bool) {
+	credentials.observe(provider, credID, available, time.Now())
+}
+
+// ForgetCredential stops tracking a removed or disabled credential, so it no longer counts
+// toward its provider's gauge.
+func ForgetCredential(credID string) {
+	credentials.forget(credID)
+}
+
+type credentialState struct {
+	provider string
+	// reported is the state counted by the gauge; pending is the latest observed state,
+	// held since pendingSince.
+	reported     bool
+	pending      bool
+	pendingSince time.Time
+}
+
+type credentialTracker struct {
+	mu       sync.Mutex
+	debounce time.Duration
+	states   map[string]*credentialState
+	timer    *time.Timer
+}
+
+func newCredentialTracker() *credentialTracker {
+	return &credentialTracker{states: make(map[string]*credentialState)}
+}
+
+func (t *credentialTracker) setDebounce(d time.Duration, now time.Time) {
+	if d < 0 {
+		d = 0
+	}
+	t.mu.Lock()
+	t.debounce = d
+	t.mu.Unlock()
+	t.flush(now)
+}
+
+func (t *credentialTracker) observe(provider, credID string, available bool, now time.Time) {
+	provider = strings.TrimSpace(provider)
+	credID = strings.TrimSpace(credID)
+	if credID == "" {
+		return
+	}
+	t.mu.Lock()
+	state, ok := t.states[credID]
+	if !ok {
+		// A credential's first state has nothing to flap from and is reported at once.
+		state = &credentialState{provider: provider, reported: available, pending: available, pendingSince: now}
+		t.states[credID] = state
+		t.publishLocked(provider)
+	} else if state.pending != available {
+		state.pending = available
+		state.pendingSince = now
+	}
+	if state.provider != provider {
+		previous := state.provider
+		state.provider = provider
+		t.publishLocked(previous)
+	}
+	t.promoteLocked(now)
+	t.mu.Unlock()
+}
+
+func (t *credentialTracker) forget(credID string) {
+	credID = strings.TrimSpace(credID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.states[credID]
+	if !ok {
+		return
+	}
+	delete(t.states, credID)
+	t.publishLocked(state.provider)
+}
+
+// flush promotes pending states that have outlasted the debounce.
+func (t *credentialTracker) flush(now time.Time) {
+	t.mu.Lock()
+	t.promoteLocked(now)
+	t.mu.Unlock()
+}
+
+func (t *credentialTracker) promoteLocked(now time.Time) {
+	changed := make(map[string]struct{})
+	var next time.Duration
+	for _, state := range t.states {
+		if state.reported == state.pending {
+			continue
+		}
+		if held := now.Sub(state.pendingSince); held >= t.debounce {
+			state.reported = state.pending
+			changed[state.provider] = struct{}{}
+		} else if remaining := t.debounce - held; next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	for provider := range changed {
+		t.publishLocked(provider)
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if next > 0 {
+		t.timer = time.AfterFunc(next, func() { t.flush(time.Now()) })
+	}
+}
+
+func (t *credentialTracker) publishLocked(provider string) {
+	count := 0
+	for _, state := range t.states {
+		if state.provider == provider && state.reported {
+			count++
+		}
+	}
+	SetCredentialsCount(provider, count)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCredentialTracker_ExactWithoutDebounce(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+	defer credentialsAvailable.DeleteLabelValues("exact-provider")
+
+	tracker := newCredentialTracker()
+	now := time.Now()
+	tracker.observe("exact-provider", "exact-a", true, now)
+	tracker.observe("exact-provider", "exact-b", true, now)
+	tracker.observe("exact-provider", "exact-b", false, now)
+
+	if got := testutil.ToFloat64(credentialsAvailable.WithLabelValues("exact-provider")); got != 1 {
+		t.Fatalf("available = %v, want 1", got)
+	}
+
+	SetCredentialsCount("exact-provider", 7)
+	if got := testutil.ToFloat64(credentialsAvailable.WithLabelValues("exact-provider")); got != 7 {
+		t.Fatalf("available after SetCredentialsCount = %v, want 7", got)
+	}
+}
+
+func TestCredentialTracker_DebouncesFlaps(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+	defer credentialsAvailable.DeleteLabelValues("flap-provider")
+
+	tracker := newCredentialTracker()
+	start := time.Now()
+	tracker.setDebounce(time.Minute, start)
+	tracker.observe("flap-provider", "flap-a", true, start)
+	tracker.observe("flap-provider", "flap-b", true, start)
+	gauge := func() float64 { return testutil.ToFloat64(credentialsAvailable.WithLabelValues("flap-provider")) }
+	if got := gauge(); got != 2 {
+		t.Fatalf("initial available = %v, want 2", got)
+	}
+
+	// flap-b fails and recovers within the debounce window: the gauge never moves.
+	for i := 0; i < 6; i++ {
+		at := start.Add(time.Duration(i*10) * time.Second)
+		tracker.observe("flap-provider", "flap-b", i%2 == 1, at)
+		tracker.flush(at.Add(5 * time.Second))
+		if got := gauge(); got != 2 {
+			t.Fatalf("available during flap %d = %v, want 2", i, got)
+		}
+	}
+
+	// flap-b stays down; the drop is reported once it has held for the debounce.
+	down := start.Add(2 * time.Minute)
+	tracker.observe("flap-provider", "flap-b", false, down)
+	tracker.flush(down.Add(59 * time.Second))
+	if got := gauge(); got != 2 {
+		t.Fatalf("available before debounce elapsed = %v, want 2", got)
+	}
+	tracker.flush(down.Add(time.Minute))
+	if got := gauge(); got != 1 {
+		t.Fatalf("available after debounce = %v, want 1", got)
+	}
+
+	// Recovery is debounced the same way.
+	up := down.Add(5 * time.Minute)
+	tracker.observe("flap-provider", "flap-b", true, up)
+	tracker.flush(up.Add(30 * time.Second))
+	if got := gauge(); got != 1 {
+		t.Fatalf("available during recovery = %v, want 1", got)
+	}
+	tracker.flush(up.Add(time.Minute))
+	if got := gauge(); got != 2 {
+		t.Fatalf("available after recovery = %v, want 2", got)
+	}
+}
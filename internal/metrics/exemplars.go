@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceExemplar returns exemplar labels for the span carried by ctx, or nil when ctx has
+// no valid span context, e.g. because tracing is not in use.
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	if ctx == nil {
+		return nil
+	}
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return prometheus.Labels{
+		"trace_id": spanCtx.TraceID().String(),
+		"span_id":  spanCtx.SpanID().String(),
+	}
+}
+
+// observeWithExemplar observes value, attaching the trace from ctx as an exemplar when
+// there is one.
+func observeWithExemplar(ctx context.Context, observer prometheus.Observer, value float64) {
+	if exemplar := traceExemplar(ctx); exemplar != nil {
+		if eo, ok := observer.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(value, exemplar)
+			return
+		}
+	}
+	observer.Observe(value)
+}
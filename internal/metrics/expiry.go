@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// expiryKey identifies one credential_expiry_seconds series.
+type expiryKey struct {
+	provider string
+	credID   string
+}
+
+// expiryCollector exports the seconds until each credential expires. It stores expiry
+// instants and computes the remaining time on every scrape, so the value keeps counting
+// down between token refreshes instead of freezing at the last update.
+type expiryCollector struct {
+	desc *prometheus.Desc
+	now  func() time.Time
+
+	mu       sync.RWMutex
+	expiries map[expiryKey]time.Time
+}
+
+func newExpiryCollector(name, help string) *expiryCollector {
+	return &expiryCollector{
+		desc:     prometheus.NewDesc(name, help, []string{"provider", "cred_id"}, nil),
+		now:      time.Now,
+		expiries: make(map[expiryKey]time.Time),
+	}
+}
+
+func (c *expiryCollector) set(provider, credID string, expiry time.Time) {
+	c.mu.Lock()
+	c.expiries[expiryKey{provider: provider, credID: credID}] = expiry
+	c.mu.Unlock()
+}
+
+func (c *expiryCollector) delete(provider, credID string) {
+	c.mu.Lock()
+	delete(c.expiries, expiryKey{provider: provider, credID: credID})
+	c.mu.Unlock()
+}
+
+// Describe implements prometheus.Collector.
+func (c *expiryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *expiryCollector) Collect(ch chan<- prometheus.Metric) {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, expiry := range c.expiries {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, expiry.Sub(now).Seconds(), key.provider, key.credID)
+	}
+}
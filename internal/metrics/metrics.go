@@ -0,0 +1,302 @@
+// Package metrics exposes Prometheus collectors for the proxy and the routing
+// module that serves them on /metrics. Recording helpers are safe to call from
+// any package and become no-ops while metrics are disabled.
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const namespace = "cliproxy"
+
+var (
+	enabled         atomic.Bool
+	normalizeModel  atomic.Bool
+	copilotKeyLabel atomic.Bool
+	slowThreshold   atomic.Int64
+
+	registry = prometheus.NewRegistry()
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Completed upstream requests, partitioned by model and provider.",
+	}, []string{"model", "provider"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of completed upstream requests, partitioned by model and provider. Carries trace exemplars when tracing is active.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"model", "provider"})
+
+	slowRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "slow_requests_total",
+		Help:      "Completed upstream requests that took longer than slow-request-threshold, partitioned by model and provider.",
+	}, []string{"model", "provider"})
+
+	requestsByProfile = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_by_profile_total",
+		Help:      "Copilot upstream requests, partitioned by the header profile applied (cli or vscode-chat).",
+	}, []string{"profile"})
+
+	contextUtilization = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "context_utilization_ratio",
+		Help:      "Prompt tokens divided by the model context window, observed per request.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 1},
+	}, []string{"model"})
+
+	tokensTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_total",
+		Help:      "Tokens reported by upstream usage, partitioned by model and type (input/output).",
+	}, []string{"model", "type"})
+
+	costTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cost_usd_total",
+		Help:      "Estimated spend in USD derived from token usage and configured model pricing.",
+	}, []string{"model", "type"})
+
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Errors and recovery events observed by the proxy, partitioned by type.",
+	}, []string{"type"})
+
+	credentialExpiry = newExpiryCollector(prometheus.BuildFQName(namespace, "", "credential_expiry_seconds"),
+		"Seconds until a credential expires, computed at scrape time from its last known expiry.")
+
+	credentialRotations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "credential_rotations_total",
+		Help:      "Credential token refreshes, partitioned by provider.",
+	}, []string{"provider"})
+
+	translationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "translation_errors_total",
+		Help:      "Request or response translations that failed or produced empty output, partitioned by direction and format pair.",
+	}, []string{"direction", "format"})
+
+	copilotKeyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "copilot_key_requests_total",
+		Help:      "Copilot upstream requests, partitioned by the hashed copilot-api-key entry that served them. Recorded when metrics-copilot-key-label is on.",
+	}, []string{"key"})
+
+	credentialsAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "credentials_available",
+		Help:      "Credentials that are enabled and not failing, partitioned by provider. Debounced when metrics-credentials-debounce is set.",
+	}, []string{"provider"})
+
+	credentialInflight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "credential_inflight_requests",
+		Help:      "Upstream requests currently in flight on a credential with a concurrency limit.",
+	}, []string{"provider", "cred_id"})
+)
+
+func init() {
+	registry.MustRegister(requestsTotal, requestDuration, slowRequestsTotal, requestsByProfile, contextUtilization, tokensTotal, costTotal, errorsTotal, credentialExpiry, credentialRotations, credentialInflight, translationErrors, credentialsAvailable, copilotKeyRequests)
+}
+
+// Registry returns the Prometheus registry holding all proxy collectors.
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// SetEnabled toggles metric recording.
+func SetEnabled(value bool) {
+	enabled.Store(value)
+}
+
+// Enabled reports whether metric recording is active. Callers should check it
+// before doing expensive work solely to feed a metric.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// SetNormalizeModel toggles de-aliasing of model labels. When enabled, model names
+// are lowercased and the "copilot-" alias prefix is stripped before labelling so
+// that aliases of the same model share one series.
+func SetNormalizeModel(value bool) {
+	normalizeModel.Store(value)
+}
+
+// SetCopilotKeyLabel toggles the per-key Copilot request counter.
+func SetCopilotKeyLabel(value bool) {
+	copilotKeyLabel.Store(value)
+}
+
+// SetSlowRequestThreshold sets the duration above which requests are logged and counted
+// as slow. Zero disables slow-request detection.
+func SetSlowRequestThreshold(d time.Duration) {
+	slowThreshold.Store(int64(d))
+}
+
+// modelLabel returns the model label value, normalized when SetNormalizeModel is on.
+func modelLabel(model string) string {
+	model = strings.TrimSpace(model)
+	if !normalizeModel.Load() {
+		return model
+	}
+	return strings.TrimPrefix(strings.ToLower(model), "copilot-")
+}
+
+// RecordRequest increments the request counter for a model and provider.
+func RecordRequest(model, provider string) {
+	if !Enabled() {
+		return
+	}
+	requestsTotal.WithLabelValues(modelLabel(model), strings.TrimSpace(provider)).Inc()
+}
+
+// RecordRequestDuration observes a completed request's duration, with the trace carried by
+// ctx as an exemplar, and logs a warning and increments the slow request counter when it
+// exceeds the slow-request threshold. The warning is logged even while metric recording is
+// disabled.
+func RecordRequestDuration(ctx context.Context, model, provider string, duration time.Duration) {
+	if Enabled() && duration > 0 {
+		observeWithExemplar(ctx, requestDuration.WithLabelValues(modelLabel(model), strings.TrimSpace(provider)), duration.Seconds())
+	}
+	threshold := time.Duration(slowThreshold.Load())
+	if threshold <= 0 || duration <= threshold {
+		return
+	}
+	log.WithFields(log.Fields{
+		"model":     model,
+		"provider":  provider,
+		"duration":  duration.String(),
+		"threshold": threshold.String(),
+	}).Warn("slow request")
+	if !Enabled() {
+		return
+	}
+	slowRequestsTotal.WithLabelValues(modelLabel(model), strings.TrimSpace(provider)).Inc()
+}
+
+// RecordHeaderProfile increments the Copilot request counter for a header profile.
+// Callers pass one of the fixed profile names so the label stays low-cardinality.
+func RecordHeaderProfile(profile string) {
+	if !Enabled() {
+		return
+	}
+	requestsByProfile.WithLabelValues(strings.TrimSpace(profile)).Inc()
+}
+
+// RecordCopilotKeyRequest increments the request counter for a Copilot key identifier.
+// Callers pass a hashed identifier, never the key's token.
+func RecordCopilotKeyRequest(key string) {
+	if !Enabled() || !copilotKeyLabel.Load() {
+		return
+	}
+	copilotKeyRequests.WithLabelValues(strings.TrimSpace(key)).Inc()
+}
+
+// ObserveContextUtilization records how much of a model's context window a prompt uses.
+// Observations are skipped when the context length is unknown; ratios are clamped to [0, 1].
+func ObserveContextUtilization(model string, promptTokens, contextLength int) {
+	if !Enabled() || contextLength <= 0 || promptTokens < 0 {
+		return
+	}
+	ratio := float64(promptTokens) / float64(contextLength)
+	if ratio > 1 {
+		ratio = 1
+	}
+	contextUtilization.WithLabelValues(modelLabel(model)).Observe(ratio)
+}
+
+// RecordTokens adds upstream-reported input and output token counts for a model.
+func RecordTokens(model string, inputTokens, outputTokens int) {
+	if !Enabled() {
+		return
+	}
+	model = modelLabel(model)
+	if inputTokens > 0 {
+		tokensTotal.WithLabelValues(model, "input").Add(float64(inputTokens))
+	}
+	if outputTokens > 0 {
+		tokensTotal.WithLabelValues(model, "output").Add(float64(outputTokens))
+	}
+}
+
+// RecordCost converts token counts into USD using per-million-token prices and adds the
+// result to the cost counter. Each side is only recorded when its price is known.
+func RecordCost(model string, inputTokens, outputTokens int, inPrice, outPrice float64) {
+	if !Enabled() {
+		return
+	}
+	model = modelLabel(model)
+	if inputTokens > 0 && inPrice > 0 {
+		costTotal.WithLabelValues(model, "input").Add(float64(inputTokens) * inPrice / 1e6)
+	}
+	if outputTokens > 0 && outPrice > 0 {
+		costTotal.WithLabelValues(model, "output").Add(float64(outputTokens) * outPrice / 1e6)
+	}
+}
+
+// RecordError increments the error counter for the given type (e.g. "retry").
+func RecordError(kind string) {
+	if !Enabled() {
+		return
+	}
+	errorsTotal.WithLabelValues(strings.TrimSpace(kind)).Inc()
+}
+
+// RecordTranslationError increments the translation failure counter. direction is "request"
+// or "response" and format is the "from->to" pair of translator formats.
+func RecordTranslationError(direction, format string) {
+	if !Enabled() {
+		return
+	}
+	translationErrors.WithLabelValues(strings.TrimSpace(direction), strings.TrimSpace(format)).Inc()
+}
+
+// SetCredentialExpiry records when a credential expires. The exported gauge reports the
+// seconds remaining at scrape time, so it counts down between refreshes.
+func SetCredentialExpiry(provider, credID string, expiry time.Time) {
+	if !Enabled() {
+		return
+	}
+	credentialExpiry.set(strings.TrimSpace(provider), strings.TrimSpace(credID), expiry)
+}
+
+// ClearCredentialExpiry removes the expiry series for a credential that is no longer active.
+func ClearCredentialExpiry(provider, credID string) {
+	credentialExpiry.delete(strings.TrimSpace(provider), strings.TrimSpace(credID))
+}
+
+// RecordCredentialRotation increments the rotation counter when a provider token is refreshed.
+func RecordCredentialRotation(provider string) {
+	if !Enabled() {
+		return
+	}
+	credentialRotations.WithLabelValues(strings.TrimSpace(provider)).Inc()
+}
+
+// SetCredentialInflight records how many requests are currently in flight on a credential.
+func SetCredentialInflight(provider, credID string, inflight int) {
+	if !Enabled() {
+		return
+	}
+	credentialInflight.WithLabelValues(strings.TrimSpace(provider), strings.TrimSpace(credID)).Set(float64(inflight))
+}
+
+// SetCredentialsCount records the exact number of available credentials for a provider.
+func SetCredentialsCount(provider string, count int) {
+	if !Enabled() {
+		return
+	}
+	credentialsAvailable.WithLabelValues(strings.TrimSpace(provider)).Set(float64(count))
+}
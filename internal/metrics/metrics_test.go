@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	internalregistry "github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestRecordCost_IncrementsByPriceProduct(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	RecordCost("cost-test-model", 2000, 500, 3, 15)
+
+	if got := testutil.ToFloat64(costTotal.WithLabelValues("cost-test-model", "input")); !approxEqual(got, 0.006) {
+		t.Fatalf("input cost = %v, want 0.006", got)
+	}
+	if got := testutil.ToFloat64(costTotal.WithLabelValues("cost-test-model", "output")); !approxEqual(got, 0.0075) {
+		t.Fatalf("output cost = %v, want 0.0075", got)
+	}
+}
+
+func TestRecordCost_SkipsUnknownPricing(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	before := testutil.CollectAndCount(costTotal)
+	RecordCost("cost-unpriced-model", 1000, 1000, 0, 0)
+	if after := testutil.CollectAndCount(costTotal); after != before {
+		t.Fatalf("expected no cost series without pricing, series went from %d to %d", before, after)
+	}
+}
+
+func TestUsagePlugin_RecordsTokensAndCost(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	reg := internalregistry.GetGlobalRegistry()
+	reg.RegisterClient("usage-plugin-cost", "openai", []*internalregistry.ModelInfo{{
+		ID:                    "usage-plugin-model",
+		InputPricePerMillion:  1,
+		OutputPricePerMillion: 4,
+	}})
+	defer reg.UnregisterClient("usage-plugin-cost")
+
+	NewUsagePlugin().HandleUsage(context.Background(), coreusage.Record{
+		Model:  "usage-plugin-model",
+		Detail: coreusage.Detail{InputTokens: 1_000_000, OutputTokens: 250_000},
+	})
+
+	if got := testutil.ToFloat64(tokensTotal.WithLabelValues("usage-plugin-model", "output")); got != 250_000 {
+		t.Fatalf("output tokens = %v, want 250000", got)
+	}
+	if got := testutil.ToFloat64(costTotal.WithLabelValues("usage-plugin-model", "input")); !approxEqual(got, 1) {
+		t.Fatalf("input cost = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(costTotal.WithLabelValues("usage-plugin-model", "output")); !approxEqual(got, 1) {
+		t.Fatalf("output cost = %v, want 1", got)
+	}
+}
+
+func TestRecordRequest_NormalizeModelCollapsesAliases(t *testing.T) {
+	SetEnabled(true)
+	SetNormalizeModel(true)
+	defer SetEnabled(false)
+	defer SetNormalizeModel(false)
+
+	before := testutil.CollectAndCount(requestsTotal)
+	RecordRequest("copilot-gpt-5", "copilot")
+	RecordRequest("gpt-5", "copilot")
+
+	if after := testutil.CollectAndCount(requestsTotal); after != before+1 {
+		t.Fatalf("expected aliases to share one series, series went from %d to %d", before, after)
+	}
+	if got := testutil.ToFloat64(requestsTotal.WithLabelValues("gpt-5", "copilot")); got != 2 {
+		t.Fatalf("requests = %v, want 2", got)
+	}
+}
+
+func TestRecordRequest_RawLabelsWhenNormalizeDisabled(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	RecordRequest("copilot-raw-model", "copilot")
+	RecordRequest("raw-model", "copilot")
+
+	if got := testutil.ToFloat64(requestsTotal.WithLabelValues("copilot-raw-model", "copilot")); got != 1 {
+		t.Fatalf("aliased requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(requestsTotal.WithLabelValues("raw-model", "copilot")); got != 1 {
+		t.Fatalf("raw requests = %v, want 1", got)
+	}
+}
+
+func TestUsagePlugin_CountsSlowRequests(t *testing.T) {
+	SetEnabled(true)
+	SetSlowRequestThreshold(20 * time.Millisecond)
+	defer SetEnabled(false)
+	defer SetSlowRequestThreshold(0)
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	// upstream stands in for a provider call, publishing usage the way the executors do.
+	upstream := func(model string, delay time.Duration) coreusage.Record {
+		start := time.Now()
+		time.Sleep(delay)
+		return coreusage.Record{Model: model, Provider: "copilot", RequestedAt: start, Duration: time.Since(start)}
+	}
+	plugin := NewUsagePlugin()
+	plugin.HandleUsage(context.Background(), upstream("slow-test-fast", 0))
+	plugin.HandleUsage(context.Background(), upstream("slow-test-slow", 40*time.Millisecond))
+
+	if got := testutil.ToFloat64(slowRequestsTotal.WithLabelValues("slow-test-slow", "copilot")); got != 1 {
+		t.Fatalf("slow requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(slowRequestsTotal.WithLabelValues("slow-test-fast", "copilot")); got != 0 {
+		t.Fatalf("fast request counted as slow: %v", got)
+	}
+	var warnings []*log.Entry
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == log.WarnLevel && entry.Message == "slow request" {
+			warnings = append(warnings, entry)
+		}
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected one slow request warning, got %d", len(warnings))
+	}
+	if warnings[0].Data["model"] != "slow-test-slow" || warnings[0].Data["provider"] != "copilot" || warnings[0].Data["duration"] == "" {
+		t.Fatalf("unexpected warning fields: %v", warnings[0].Data)
+	}
+}
+
+// durationExemplar returns the exemplar labels attached to the request duration histogram
+// for model, or nil when no bucket carries one.
+func durationExemplar(t *testing.T, model string) map[string]string {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "cliproxy_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := false
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "model" && label.GetValue() == model {
+					matched = true
+				}
+			}
+			if !matched {
+				continue
+			}
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				if exemplar := bucket.GetExemplar(); exemplar != nil {
+					labels := make(map[string]string)
+					for _, label := range exemplar.GetLabel() {
+						labels[label.GetName()] = label.GetValue()
+					}
+					return labels
+				}
+			}
+			return nil
+		}
+	}
+	t.Fatalf("no request duration series for %s", model)
+	return nil
+}
+
+func TestRecordRequestDuration_AttachesTraceExemplar(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	RecordRequestDuration(ctx, "exemplar-traced", "copilot", 2*time.Second)
+
+	exemplar := durationExemplar(t, "exemplar-traced")
+	if exemplar["trace_id"] != traceID.String() || exemplar["span_id"] != spanID.String() {
+		t.Fatalf("exemplar = %v, want trace %s span %s", exemplar, traceID, spanID)
+	}
+
+	RecordRequestDuration(context.Background(), "exemplar-untraced", "copilot", 2*time.Second)
+	if exemplar := durationExemplar(t, "exemplar-untraced"); exemplar != nil {
+		t.Fatalf("exemplar attached without a trace: %v", exemplar)
+	}
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Module implements the RouteModuleV2 interface and serves GET /metrics in the
+// Prometheus text format. The route is always registered so that toggling
+// metrics-enabled via hot reload takes effect without re-registering routes;
+// while disabled it responds with 404.
+type Module struct {
+	registerOnce sync.Once
+	handler      http.Handler
+}
+
+// New creates a metrics routing module.
+func New() *Module {
+	return &Module{
+		// OpenMetrics is negotiated by scrapers that request it and is required for exemplars.
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	}
+}
+
+// Name returns the module identifier.
+func (m *Module) Name() string {
+	return "metrics"
+}
+
+// Register attaches the /metrics route and applies the initial enabled state.
+func (m *Module) Register(ctx modules.Context) error {
+	if ctx.Config != nil {
+		SetEnabled(ctx.Config.MetricsEnabled)
+		SetNormalizeModel(ctx.Config.MetricsNormalizeModel)
+		SetCopilotKeyLabel(ctx.Config.MetricsCopilotKeyLabel)
+		SetCredentialsDebounce(ctx.Config.MetricsCredentialsDebounceDuration())
+		SetSlowRequestThreshold(ctx.Config.SlowRequestThresholdDuration())
+	}
+	m.registerOnce.Do(func() {
+		ctx.Engine.GET("/metrics", m.serve)
+	})
+	return nil
+}
+
+// OnConfigUpdated applies the metrics flags from a reloaded configuration.
+func (m *Module) OnConfigUpdated(cfg *config.Config) error {
+	if cfg == nil {
+		return nil
+	}
+	if Enabled() != cfg.MetricsEnabled {
+		log.Infof("metrics: recording %s", enabledLabel(cfg.MetricsEnabled))
+	}
+	SetEnabled(cfg.MetricsEnabled)
+	SetNormalizeModel(cfg.MetricsNormalizeModel)
+	SetCopilotKeyLabel(cfg.MetricsCopilotKeyLabel)
+	SetCredentialsDebounce(cfg.MetricsCredentialsDebounceDuration())
+	SetSlowRequestThreshold(cfg.SlowRequestThresholdDuration())
+	return nil
+}
+
+func (m *Module) serve(c *gin.Context) {
+	if !Enabled() {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	m.handler.ServeHTTP(c.Writer, c.Request)
+}
+
+func enabledLabel(value bool) string {
+	if value {
+		return "enabled"
+	}
+	return "disabled"
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestModule_MetricsEndpointFollowsConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	m := New()
+	defer SetEnabled(false)
+
+	if err := m.Register(modules.Context{Engine: engine, Config: &config.Config{}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	scrape := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w
+	}
+
+	if w := scrape(); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 while disabled, got %d", w.Code)
+	}
+
+	if err := m.OnConfigUpdated(&config.Config{MetricsEnabled: true}); err != nil {
+		t.Fatalf("OnConfigUpdated: %v", err)
+	}
+	ObserveContextUtilization("module-test-model", 50, 100)
+
+	w := scrape()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 while enabled, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `cliproxy_context_utilization_ratio_count{model="module-test-model"} 1`) {
+		t.Fatalf("expected context utilization series in scrape, got:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+
+	internalregistry "github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func init() {
+	coreusage.RegisterPlugin(NewUsagePlugin())
+}
+
+// UsagePlugin feeds usage records into the request, duration, slow request, token and cost
+// metrics.
+// It implements coreusage.Plugin.
+type UsagePlugin struct{}
+
+// NewUsagePlugin constructs a usage plugin that records token metrics.
+func NewUsagePlugin() *UsagePlugin { return &UsagePlugin{} }
+
+// HandleUsage implements coreusage.Plugin.
+// Cost is only recorded when the model has pricing in the global registry.
+func (p *UsagePlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	RecordRequestDuration(ctx, record.Model, record.Provider, record.Duration)
+	if !Enabled() {
+		return
+	}
+	RecordRequest(record.Model, record.Provider)
+	input := int(record.Detail.InputTokens)
+	output := int(record.Detail.OutputTokens)
+	if input <= 0 && output <= 0 {
+		return
+	}
+	RecordTokens(record.Model, input, output)
+	info := internalregistry.GetGlobalRegistry().GetModelInfo(record.Model)
+	if info == nil {
+		return
+	}
+	RecordCost(record.Model, input, output, info.InputPricePerMillion, info.OutputPricePerMillion)
+}
@@ -22,9 +22,15 @@ func GenerateCopilotAliases(models []*ModelInfo) []*ModelInfo {
 	return result
 }
 
-// GetCopilotModels returns a conservative set of fallback models for GitHub Copilot.
+// GetCopilotModels returns a conservative set of fallback models for GitHub Copilot,
+// including their copilot- prefixed aliases.
 // These are used when dynamic model fetching from the Copilot API fails.
 func GetCopilotModels() []*ModelInfo {
+	return GenerateCopilotAliases(GetCopilotBaseModels())
+}
+
+// GetCopilotBaseModels returns the Copilot fallback models without copilot- aliases.
+func GetCopilotBaseModels() []*ModelInfo {
 	now := time.Now().Unix()
 	baseParams := []string{"temperature", "top_p", "max_tokens", "stream"}
 	paramsWithTools := append([]string{}, append(baseParams, "tools")...)
@@ -284,5 +290,5 @@ func GetCopilotModels() []*ModelInfo {
 		},
 	}
 
-	return GenerateCopilotAliases(baseModels)
+	return baseModels
 }
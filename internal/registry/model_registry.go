@@ -47,6 +47,24 @@ type ModelInfo struct {
 	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
 	// SupportedParameters lists supported parameters
 	SupportedParameters []string `json:"supported_parameters,omitempty"`
+	// SupportsVision indicates the model accepts image inputs
+	SupportsVision bool `json:"supports_vision,omitempty"`
+	// VisionKnown reports whether SupportsVision came from the provider; when false the
+	// model's image support is unknown rather than absent
+	VisionKnown bool `json:"vision_known,omitempty"`
+	// SystemRole is the role the model expects instruction messages under ("developer" or
+	// "system"); empty when unknown
+	SystemRole string `json:"system_role,omitempty"`
+	// InputPricePerMillion is the price in USD per million input tokens
+	InputPricePerMillion float64 `json:"input_price_per_million,omitempty"`
+	// OutputPricePerMillion is the price in USD per million output tokens
+	OutputPricePerMillion float64 `json:"output_price_per_million,omitempty"`
+	// Deprecated marks the model as scheduled for retirement
+	Deprecated bool `json:"deprecated,omitempty"`
+	// DeprecationDate is when a deprecated model is retired
+	DeprecationDate string `json:"deprecation_date,omitempty"`
+	// ReplacementModel names the model that replaces a deprecated one
+	ReplacementModel string `json:"replacement_model,omitempty"`
 
 	// Thinking holds provider-specific reasoning/thinking budget capabilities.
 	// This is optional and currently used for Gemini thinking budget normalization.
@@ -83,6 +101,11 @@ type ModelRegistration struct {
 	Providers map[string]int
 	// SuspendedClients tracks temporarily disabled clients keyed by client ID
 	SuspendedClients map[string]string
+	// Clients lists the IDs of the clients registering this model, sorted
+	Clients []string
+	// InfoSource is the client whose model entry is exposed as Info. When several clients
+	// register the same ID, the entry with the most complete metadata is kept.
+	InfoSource string
 }
 
 // ModelRegistryHook provides optional callbacks for external integrations to track model list changes.
@@ -107,6 +130,8 @@ type ModelRegistry struct {
 	mutex *sync.RWMutex
 	// hook is an optional callback sink for model registration changes
 	hook ModelRegistryHook
+	// strictValidation rejects models with inconsistent token limits instead of only warning
+	strictValidation bool
 }
 
 // Global model registry instance
@@ -137,6 +162,34 @@ func (r *ModelRegistry) SetHook(hook ModelRegistryHook) {
 	r.hook = hook
 }
 
+// SetStrictModelValidation controls whether RegisterClient rejects models whose
+// MaxCompletionTokens is not smaller than their ContextLength. When false such
+// models are registered with a warning.
+func (r *ModelRegistry) SetStrictModelValidation(strict bool) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.strictValidation = strict
+}
+
+// acceptModelLimits reports whether a model's token limits allow registration.
+// Caller must hold r.mutex.
+func (r *ModelRegistry) acceptModelLimits(clientID string, model *ModelInfo) bool {
+	if model.ContextLength <= 0 || model.MaxCompletionTokens <= 0 || model.MaxCompletionTokens < model.ContextLength {
+		return true
+	}
+	if r.strictValidation {
+		log.Warnf("model registry: rejecting model %s from client %s: max_completion_tokens %d >= context_length %d",
+			model.ID, clientID, model.MaxCompletionTokens, model.ContextLength)
+		return false
+	}
+	log.Warnf("model registry: model %s from client %s has max_completion_tokens %d >= context_length %d",
+		model.ID, clientID, model.MaxCompletionTokens, model.ContextLength)
+	return true
+}
+
 const defaultModelRegistryHookTimeout = 5 * time.Second
 
 func (r *ModelRegistry) triggerModelsRegistered(provider, clientID string, models []*ModelInfo) {
@@ -192,6 +245,9 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 		if model == nil || model.ID == "" {
 			continue
 		}
+		if !r.acceptModelLimits(clientID, model) {
+			continue
+		}
 		rawModelIDs = append(rawModelIDs, model.ID)
 		newCounts[model.ID]++
 		if _, exists := newModels[model.ID]; exists {
@@ -229,6 +285,9 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 			clientInfos[id] = cloneModelInfo(m)
 		}
 		r.clientModelInfos[clientID] = clientInfos
+		for _, id := range uniqueModelIDs {
+			r.refreshModelInfo(id, clientID)
+		}
 		if provider != "" {
 			r.clientProviders[clientID] = provider
 		} else {
@@ -323,9 +382,7 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 		addedSet[id] = struct{}{}
 	}
 	for _, id := range uniqueModelIDs {
-		model := newModels[id]
 		if reg, ok := r.models[id]; ok {
-			reg.Info = cloneModelInfo(model)
 			reg.LastUpdated = now
 			if reg.QuotaExceededClients != nil {
 				delete(reg.QuotaExceededClients, clientID)
@@ -362,6 +419,12 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 		clientInfos[id] = cloneModelInfo(m)
 	}
 	r.clientModelInfos[clientID] = clientInfos
+	for _, id := range uniqueModelIDs {
+		r.refreshModelInfo(id, clientID)
+	}
+	for _, id := range removed {
+		r.refreshModelInfo(id, "")
+	}
 	if provider != "" {
 		r.clientProviders[clientID] = provider
 	} else {
@@ -532,6 +595,9 @@ func (r *ModelRegistry) unregisterClientInternal(clientID string) {
 
 	delete(r.clientModels, clientID)
 	delete(r.clientModelInfos, clientID)
+	for _, modelID := range models {
+		r.refreshModelInfo(modelID, "")
+	}
 	if hasProvider {
 		delete(r.clientProviders, clientID)
 	}
@@ -863,6 +929,63 @@ func (r *ModelRegistry) GetModelCount(modelID string) int {
 	return 0
 }
 
+// GetRegisteredModelCount returns the number of distinct model IDs currently backed by
+// at least one registered client.
+func (r *ModelRegistry) GetRegisteredModelCount() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, registration := range r.models {
+		if registration != nil && registration.Count > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ModelsSupporting returns registered models whose SupportedParameters contain param
+// (case-insensitive). Models registered by several clients are returned once, sorted by ID.
+// Parameters:
+//   - param: The parameter name to look for (e.g. "tools")
+//
+// Returns:
+//   - []*ModelInfo: Copies of the matching model metadata
+func (r *ModelRegistry) ModelsSupporting(param string) []*ModelInfo {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	matches := make(map[string]*ModelInfo)
+	for _, infos := range r.clientModelInfos {
+		for id, info := range infos {
+			if info == nil {
+				continue
+			}
+			if _, seen := matches[id]; seen {
+				continue
+			}
+			for _, supported := range info.SupportedParameters {
+				if strings.EqualFold(strings.TrimSpace(supported), param) {
+					matches[id] = info
+					break
+				}
+			}
+		}
+	}
+
+	result := make([]*ModelInfo, 0, len(matches))
+	for _, info := range matches {
+		result = append(result, cloneModelInfo(info))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
 // GetModelProviders returns provider identifiers that currently supply the given model
 // Parameters:
 //   - modelID: The model ID to check
@@ -931,6 +1054,59 @@ func (r *ModelRegistry) GetModelInfo(modelID string) *ModelInfo {
 	return nil
 }
 
+// FindLargerContextModel looks up a sibling of baseID that can fit neededTokens.
+// Candidates must share the base model's OwnedBy, be backed by at least one client,
+// and advertise a ContextLength larger than both the base model and neededTokens.
+// The smallest qualifying context window wins so the fallback stays as close to the
+// requested model as possible; ties are broken by model ID.
+//
+// Parameters:
+//   - baseID: The model ID that overflowed
+//   - neededTokens: The prompt size that must fit in the fallback context window
+//
+// Returns:
+//   - *ModelInfo: A copy of the selected model's info
+//   - bool: True when a suitable model was found
+func (r *ModelRegistry) FindLargerContextModel(baseID string, neededTokens int) (*ModelInfo, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	base, ok := r.models[baseID]
+	if !ok || base == nil || base.Info == nil {
+		return nil, false
+	}
+	ownedBy := strings.TrimSpace(base.Info.OwnedBy)
+	if ownedBy == "" {
+		return nil, false
+	}
+	minContext := base.Info.ContextLength
+	if neededTokens > minContext {
+		minContext = neededTokens
+	}
+
+	var best *ModelInfo
+	for id, registration := range r.models {
+		if id == baseID || registration == nil || registration.Info == nil || registration.Count <= 0 {
+			continue
+		}
+		info := registration.Info
+		if !strings.EqualFold(strings.TrimSpace(info.OwnedBy), ownedBy) {
+			continue
+		}
+		if info.ContextLength <= base.Info.ContextLength || info.ContextLength < minContext {
+			continue
+		}
+		if best == nil || info.ContextLength < best.ContextLength ||
+			(info.ContextLength == best.ContextLength && info.ID < best.ID) {
+			best = info
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return cloneModelInfo(best), true
+}
+
 // convertModelToMap converts ModelInfo to the appropriate format for different handler types
 func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string) map[string]any {
 	if model == nil {
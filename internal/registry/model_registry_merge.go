@@ -0,0 +1,81 @@
+package registry
+
+import "sort"
+
+// modelInfoCompleteness scores how much metadata a model entry carries: the number of
+// non-zero token limits, then the number of supported parameters.
+func modelInfoCompleteness(info *ModelInfo) (limits, params int) {
+	if info == nil {
+		return -1, -1
+	}
+	for _, limit := range []int{info.ContextLength, info.MaxCompletionTokens, info.InputTokenLimit, info.OutputTokenLimit} {
+		if limit > 0 {
+			limits++
+		}
+	}
+	return limits, len(info.SupportedParameters)
+}
+
+// moreCompleteModelInfo reports whether a carries strictly more metadata than b.
+func moreCompleteModelInfo(a, b *ModelInfo) bool {
+	aLimits, aParams := modelInfoCompleteness(a)
+	bLimits, bParams := modelInfoCompleteness(b)
+	if aLimits != bLimits {
+		return aLimits > bLimits
+	}
+	return aParams > bParams
+}
+
+// refreshModelInfo applies the merge policy for a model registered by several clients:
+// the registration exposes the most complete entry among the clients serving it and
+// records those clients. On a tie the entry from preferred (the client being registered)
+// wins, then the entry already exposed, so re-registering refreshes metadata as before.
+func (r *ModelRegistry) refreshModelInfo(modelID, preferred string) {
+	registration, ok := r.models[modelID]
+	if !ok {
+		return
+	}
+	clients := make([]string, 0, len(registration.Clients)+1)
+	for clientID, infos := range r.clientModelInfos {
+		if _, serves := infos[modelID]; serves {
+			clients = append(clients, clientID)
+		}
+	}
+	sort.Strings(clients)
+	registration.Clients = clients
+
+	best := ""
+	for _, clientID := range clients {
+		if best == "" {
+			best = clientID
+			continue
+		}
+		candidate, current := r.clientModelInfos[clientID][modelID], r.clientModelInfos[best][modelID]
+		if moreCompleteModelInfo(candidate, current) {
+			best = clientID
+			continue
+		}
+		if moreCompleteModelInfo(current, candidate) {
+			continue
+		}
+		if clientID == preferred || (best != preferred && clientID == registration.InfoSource) {
+			best = clientID
+		}
+	}
+	if best == "" {
+		return
+	}
+	registration.Info = cloneModelInfo(r.clientModelInfos[best][modelID])
+	registration.InfoSource = best
+}
+
+// GetModelClients returns the IDs of the clients currently registering modelID, sorted.
+func (r *ModelRegistry) GetModelClients(modelID string) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	registration, ok := r.models[modelID]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), registration.Clients...)
+}
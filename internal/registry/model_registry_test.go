@@ -1,8 +1,12 @@
 package registry
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 )
 
 func TestModelRegistry_ConvertModelToMap_IncludesContextWindow(t *testing.T) {
@@ -160,3 +164,216 @@ func TestModelRegistry_PassthruModelRegistration(t *testing.T) {
 		t.Error("expected zai-test-model in available models")
 	}
 }
+
+func TestModelRegistry_FindLargerContextModel(t *testing.T) {
+	reg := GetGlobalRegistry()
+
+	clientID := "test-client-larger-ctx"
+	otherClientID := "test-client-larger-ctx-other"
+	now := time.Now().Unix()
+
+	models := []*ModelInfo{
+		{ID: "ctx-family-small", Object: "model", Created: now, OwnedBy: "ctx-family", ContextLength: 8000},
+		{ID: "ctx-family-medium", Object: "model", Created: now, OwnedBy: "ctx-family", ContextLength: 32000},
+		{ID: "ctx-family-large", Object: "model", Created: now, OwnedBy: "ctx-family", ContextLength: 128000},
+		{ID: "ctx-family-huge", Object: "model", Created: now, OwnedBy: "ctx-family", ContextLength: 1000000},
+	}
+	otherModels := []*ModelInfo{
+		{ID: "ctx-other-medium", Object: "model", Created: now, OwnedBy: "ctx-other", ContextLength: 16000},
+	}
+
+	reg.RegisterClient(clientID, "openai", models)
+	defer reg.UnregisterClient(clientID)
+	reg.RegisterClient(otherClientID, "openai", otherModels)
+	defer reg.UnregisterClient(otherClientID)
+
+	tests := []struct {
+		name         string
+		baseID       string
+		neededTokens int
+		wantID       string
+		wantFound    bool
+	}{
+		{name: "next larger sibling", baseID: "ctx-family-small", neededTokens: 10000, wantID: "ctx-family-medium", wantFound: true},
+		{name: "skips siblings that are still too small", baseID: "ctx-family-small", neededTokens: 64000, wantID: "ctx-family-large", wantFound: true},
+		{name: "needed below base still moves up", baseID: "ctx-family-medium", neededTokens: 1000, wantID: "ctx-family-large", wantFound: true},
+		{name: "largest model has no fallback", baseID: "ctx-family-huge", neededTokens: 2000000, wantFound: false},
+		{name: "nothing fits", baseID: "ctx-family-small", neededTokens: 5000000, wantFound: false},
+		{name: "does not cross owners", baseID: "ctx-other-medium", neededTokens: 20000, wantFound: false},
+		{name: "unknown base", baseID: "ctx-missing", neededTokens: 10, wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, found := reg.FindLargerContextModel(tt.baseID, tt.neededTokens)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v (info=%v)", tt.wantFound, found, info)
+			}
+			if !found {
+				return
+			}
+			if info == nil || info.ID != tt.wantID {
+				t.Fatalf("expected %q, got %v", tt.wantID, info)
+			}
+		})
+	}
+}
+
+func TestModelRegistry_ModelsSupporting(t *testing.T) {
+	reg := GetGlobalRegistry()
+
+	reg.RegisterClient("test-client-supporting-a", "openai", []*ModelInfo{
+		{ID: "supporting-tools", Object: "model", SupportedParameters: []string{"temperature", "tools"}},
+		{ID: "supporting-plain", Object: "model", SupportedParameters: []string{"temperature"}},
+		{ID: "supporting-none", Object: "model"},
+	})
+	defer reg.UnregisterClient("test-client-supporting-a")
+	reg.RegisterClient("test-client-supporting-b", "copilot", []*ModelInfo{
+		{ID: "supporting-tools", Object: "model", SupportedParameters: []string{"tools"}},
+		{ID: "supporting-upper", Object: "model", SupportedParameters: []string{"TOOLS", "top_p"}},
+	})
+	defer reg.UnregisterClient("test-client-supporting-b")
+
+	ids := func(models []*ModelInfo) []string {
+		out := make([]string, 0, len(models))
+		for _, m := range models {
+			if strings.HasPrefix(m.ID, "supporting-") {
+				out = append(out, m.ID)
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		param string
+		want  []string
+	}{
+		{param: "tools", want: []string{"supporting-tools", "supporting-upper"}},
+		{param: "Temperature", want: []string{"supporting-plain", "supporting-tools"}},
+		{param: "top_p", want: []string{"supporting-upper"}},
+		{param: "logprobs", want: []string{}},
+		{param: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			got := ids(reg.ModelsSupporting(tt.param))
+			if len(got) != len(tt.want) {
+				t.Fatalf("ModelsSupporting(%q) = %v, want %v", tt.param, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("ModelsSupporting(%q) = %v, want %v", tt.param, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestModelRegistry_RegisterClient_WarnsOnInconsistentLimits(t *testing.T) {
+	reg := GetGlobalRegistry()
+	hook := test.NewLocal(log.StandardLogger())
+	defer hook.Reset()
+
+	clientID := "limits-warn-client"
+	reg.RegisterClient(clientID, "openai", []*ModelInfo{
+		{ID: "limits-warn-model", ContextLength: 8192, MaxCompletionTokens: 16384},
+		{ID: "limits-ok-model", ContextLength: 8192, MaxCompletionTokens: 4096},
+	})
+	defer reg.UnregisterClient(clientID)
+
+	if reg.GetModelInfo("limits-warn-model") == nil {
+		t.Fatal("expected inconsistent model to be registered outside strict mode")
+	}
+	warned := false
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == log.WarnLevel && strings.Contains(entry.Message, "limits-warn-model") {
+			warned = true
+		}
+		if strings.Contains(entry.Message, "limits-ok-model") {
+			t.Fatalf("unexpected log for consistent model: %s", entry.Message)
+		}
+	}
+	if !warned {
+		t.Fatal("expected a warning for max_completion_tokens >= context_length")
+	}
+}
+
+func TestModelRegistry_RegisterClient_StrictRejectsInconsistentLimits(t *testing.T) {
+	reg := GetGlobalRegistry()
+	reg.SetStrictModelValidation(true)
+	defer reg.SetStrictModelValidation(false)
+
+	clientID := "limits-strict-client"
+	reg.RegisterClient(clientID, "openai", []*ModelInfo{
+		{ID: "limits-strict-bad", ContextLength: 4096, MaxCompletionTokens: 4096},
+		{ID: "limits-strict-good", ContextLength: 4096, MaxCompletionTokens: 1024},
+	})
+	defer reg.UnregisterClient(clientID)
+
+	if reg.GetModelInfo("limits-strict-bad") != nil {
+		t.Fatal("expected strict mode to reject the inconsistent model")
+	}
+	if reg.GetModelInfo("limits-strict-good") == nil {
+		t.Fatal("expected the consistent model to be registered")
+	}
+}
+
+func TestModelRegistry_DuplicateRegistrationKeepsMostCompleteInfo(t *testing.T) {
+	reg := GetGlobalRegistry()
+	const modelID = "test-duplicate-merge"
+	defer reg.UnregisterClient("test-dup-sparse")
+	defer reg.UnregisterClient("test-dup-rich")
+
+	reg.RegisterClient("test-dup-rich", "openai", []*ModelInfo{{
+		ID:                  modelID,
+		DisplayName:         "Rich",
+		ContextLength:       128000,
+		MaxCompletionTokens: 16000,
+		SupportedParameters: []string{"tools", "temperature"},
+	}})
+	reg.RegisterClient("test-dup-sparse", "copilot", []*ModelInfo{{ID: modelID, DisplayName: "Sparse"}})
+
+	info := reg.GetModelInfo(modelID)
+	if info == nil || info.DisplayName != "Rich" || info.ContextLength != 128000 {
+		t.Fatalf("sparse registration shadowed the richer entry: %+v", info)
+	}
+	if clients := reg.GetModelClients(modelID); strings.Join(clients, ",") != "test-dup-rich,test-dup-sparse" {
+		t.Fatalf("clients = %v, want both registrations", clients)
+	}
+	count := 0
+	for _, model := range reg.GetAvailableModels("openai") {
+		if model["id"] == modelID {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("model listed %d times, want once", count)
+	}
+
+	// With equal limits, more supported parameters wins.
+	reg.RegisterClient("test-dup-sparse", "copilot", []*ModelInfo{{
+		ID:                  modelID,
+		DisplayName:         "Sparse+",
+		ContextLength:       64000,
+		MaxCompletionTokens: 8000,
+		SupportedParameters: []string{"tools", "temperature", "top_p"},
+	}})
+	if info := reg.GetModelInfo(modelID); info == nil || info.DisplayName != "Sparse+" {
+		t.Fatalf("more complete re-registration not exposed: %+v", info)
+	}
+
+	// Dropping back to sparse metadata restores the richer entry, which in turn gives way
+	// to the remaining one once its client goes away.
+	reg.RegisterClient("test-dup-sparse", "copilot", []*ModelInfo{{ID: modelID, DisplayName: "Sparse"}})
+	if info := reg.GetModelInfo(modelID); info == nil || info.DisplayName != "Rich" {
+		t.Fatalf("expected the rich entry back, got %+v", info)
+	}
+	reg.UnregisterClient("test-dup-rich")
+	if info := reg.GetModelInfo(modelID); info == nil || info.DisplayName != "Sparse" {
+		t.Fatalf("expected the sparse entry after unregistering the rich client, got %+v", info)
+	}
+	if clients := reg.GetModelClients(modelID); strings.Join(clients, ",") != "test-dup-sparse" {
+		t.Fatalf("clients = %v, want test-dup-sparse", clients)
+	}
+}
@@ -1,5 +1,7 @@
 package registry
 
+import "strings"
+
 // ToOpenAIModelMap converts the canonical registry ModelInfo into an OpenAI-style model
 // JSON object.
 //
@@ -13,6 +15,11 @@ package registry
 //
 // When provider-native limits are available instead (e.g., Gemini's inputTokenLimit /
 // outputTokenLimit), this function falls back to those values.
+//
+// A capabilities object (tools / vision / reasoning) is emitted when the model advertises
+// SupportedParameters, SupportsVision, or thinking support, so UIs can toggle features.
+// A pricing object (USD per million input/output tokens) is emitted when either price is set.
+// A deprecation object (deprecated / date / replacement) is emitted for deprecated models.
 func ToOpenAIModelMap(info *ModelInfo) map[string]any {
 	if info == nil {
 		return nil
@@ -54,5 +61,60 @@ func ToOpenAIModelMap(info *ModelInfo) map[string]any {
 		result["outputTokenLimit"] = info.OutputTokenLimit
 	}
 
+	if capabilities := openAIModelCapabilities(info); capabilities != nil {
+		result["capabilities"] = capabilities
+	}
+
+	if info.InputPricePerMillion > 0 || info.OutputPricePerMillion > 0 {
+		result["pricing"] = map[string]any{
+			"input":  info.InputPricePerMillion,
+			"output": info.OutputPricePerMillion,
+		}
+	}
+
+	if info.Deprecated {
+		deprecation := map[string]any{"deprecated": true}
+		if info.DeprecationDate != "" {
+			deprecation["date"] = info.DeprecationDate
+		}
+		if info.ReplacementModel != "" {
+			deprecation["replacement"] = info.ReplacementModel
+		}
+		result["deprecation"] = deprecation
+	}
+
 	return result
 }
+
+// reasoningParameters are SupportedParameters entries that imply reasoning support.
+var reasoningParameters = map[string]struct{}{
+	"reasoning":        {},
+	"reasoning_effort": {},
+	"thinking":         {},
+}
+
+// openAIModelCapabilities derives the capabilities object from the model metadata.
+// It returns nil when the model carries no capability information.
+func openAIModelCapabilities(info *ModelInfo) map[string]any {
+	if len(info.SupportedParameters) == 0 && !info.SupportsVision && info.Thinking == nil {
+		return nil
+	}
+
+	tools := false
+	reasoning := info.Thinking != nil
+	for _, param := range info.SupportedParameters {
+		param = strings.ToLower(strings.TrimSpace(param))
+		if param == "tools" {
+			tools = true
+		}
+		if _, ok := reasoningParameters[param]; ok {
+			reasoning = true
+		}
+	}
+
+	return map[string]any{
+		"tools":     tools,
+		"vision":    info.SupportsVision,
+		"reasoning": reasoning,
+	}
+}
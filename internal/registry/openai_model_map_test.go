@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToOpenAIModelMap_CapabilitiesFromSupportedParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		info *ModelInfo
+		want map[string]any
+	}{
+		{
+			name: "tools only",
+			info: &ModelInfo{ID: "m", SupportedParameters: []string{"temperature", "tools"}},
+			want: map[string]any{"tools": true, "vision": false, "reasoning": false},
+		},
+		{
+			name: "no tools",
+			info: &ModelInfo{ID: "m", SupportedParameters: []string{"temperature", "top_p"}},
+			want: map[string]any{"tools": false, "vision": false, "reasoning": false},
+		},
+		{
+			name: "reasoning and vision",
+			info: &ModelInfo{ID: "m", SupportedParameters: []string{"tools", "reasoning_effort"}, SupportsVision: true},
+			want: map[string]any{"tools": true, "vision": true, "reasoning": true},
+		},
+		{
+			name: "thinking support implies reasoning",
+			info: &ModelInfo{ID: "m", Thinking: &ThinkingSupport{Min: 128, Max: 1024}},
+			want: map[string]any{"tools": false, "vision": false, "reasoning": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ToOpenAIModelMap(tt.info)["capabilities"]
+			if !ok {
+				t.Fatal("expected capabilities in model map")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("capabilities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToOpenAIModelMap_OmitsCapabilitiesWithoutData(t *testing.T) {
+	result := ToOpenAIModelMap(&ModelInfo{ID: "m", OwnedBy: "test", ContextLength: 1000, MaxCompletionTokens: 100})
+	if _, ok := result["capabilities"]; ok {
+		t.Fatal("expected capabilities to be omitted when no capability data is present")
+	}
+	if result["context_window"] != 1000 || result["max_tokens"] != 100 {
+		t.Fatalf("expected limit aliases to be unchanged, got %v", result)
+	}
+}
+
+func TestToOpenAIModelMap_IncludesPricing(t *testing.T) {
+	result := ToOpenAIModelMap(&ModelInfo{ID: "m", InputPricePerMillion: 1.25, OutputPricePerMillion: 10})
+	got, ok := result["pricing"]
+	if !ok {
+		t.Fatal("expected pricing in model map")
+	}
+	want := map[string]any{"input": 1.25, "output": 10.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pricing = %v, want %v", got, want)
+	}
+}
+
+func TestToOpenAIModelMap_OmitsPricingWhenUnset(t *testing.T) {
+	result := ToOpenAIModelMap(&ModelInfo{ID: "m", OwnedBy: "test"})
+	if _, ok := result["pricing"]; ok {
+		t.Fatal("expected pricing to be omitted when no price is configured")
+	}
+}
+
+func TestToOpenAIModelMap_IncludesDeprecation(t *testing.T) {
+	result := ToOpenAIModelMap(&ModelInfo{ID: "m", Deprecated: true, DeprecationDate: "2025-12-31", ReplacementModel: "m-next"})
+	want := map[string]any{"deprecated": true, "date": "2025-12-31", "replacement": "m-next"}
+	if got := result["deprecation"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("deprecation = %v, want %v", got, want)
+	}
+
+	result = ToOpenAIModelMap(&ModelInfo{ID: "m", Deprecated: true})
+	want = map[string]any{"deprecated": true}
+	if got := result["deprecation"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("deprecation without date or replacement = %v, want %v", got, want)
+	}
+}
+
+func TestToOpenAIModelMap_OmitsDeprecationWhenNotDeprecated(t *testing.T) {
+	result := ToOpenAIModelMap(&ModelInfo{ID: "m", OwnedBy: "test", DeprecationDate: "2025-12-31"})
+	if _, ok := result["deprecation"]; ok {
+		t.Fatal("expected deprecation to be omitted for a model that is not deprecated")
+	}
+}
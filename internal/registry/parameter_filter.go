@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// alwaysAllowedRequestFields are top-level request fields that carry the request itself
+// or a caching hint rather than tunable parameters; they are never stripped by
+// FilterUnsupportedParameters.
+var alwaysAllowedRequestFields = map[string]struct{}{
+	"model":            {},
+	"messages":         {},
+	"input":            {},
+	"instructions":     {},
+	"prompt":           {},
+	"stream":           {},
+	"stream_options":   {},
+	"prompt_cache_key": {},
+}
+
+// companionRequestFields lists fields that are only meaningful alongside a supported
+// parameter and are retained whenever that parameter is supported.
+var companionRequestFields = map[string][]string{
+	"tools": {"tool_choice", "parallel_tool_calls"},
+}
+
+// FilterUnsupportedParameters removes top-level request fields that the model does not
+// list in its SupportedParameters. The payload is returned unchanged when the model is
+// unknown to the global registry or does not advertise any supported parameters.
+//
+// Parameters:
+//   - modelID: The model ID used to look up SupportedParameters
+//   - payload: The JSON request body
+//
+// Returns:
+//   - []byte: The payload with unsupported top-level fields removed
+func FilterUnsupportedParameters(modelID string, payload []byte) []byte {
+	if len(payload) == 0 {
+		return payload
+	}
+	info := GetGlobalRegistry().GetModelInfo(modelID)
+	if info == nil || len(info.SupportedParameters) == 0 {
+		return payload
+	}
+
+	allowed := make(map[string]struct{}, len(info.SupportedParameters)+len(alwaysAllowedRequestFields))
+	for key := range alwaysAllowedRequestFields {
+		allowed[key] = struct{}{}
+	}
+	for _, param := range info.SupportedParameters {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		allowed[param] = struct{}{}
+		for _, companion := range companionRequestFields[param] {
+			allowed[companion] = struct{}{}
+		}
+	}
+
+	root := gjson.ParseBytes(payload)
+	if !root.IsObject() {
+		return payload
+	}
+	var unsupported []string
+	root.ForEach(func(key, _ gjson.Result) bool {
+		if _, ok := allowed[key.String()]; !ok {
+			unsupported = append(unsupported, key.String())
+		}
+		return true
+	})
+
+	out := payload
+	for _, key := range unsupported {
+		if updated, err := sjson.DeleteBytes(out, escapeParameterPath(key)); err == nil {
+			out = updated
+		}
+	}
+	return out
+}
+
+// escapeParameterPath escapes gjson/sjson path metacharacters in a literal key.
+func escapeParameterPath(key string) string {
+	replacer := strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`)
+	return replacer.Replace(key)
+}
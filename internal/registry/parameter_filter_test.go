@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestFilterUnsupportedParameters(t *testing.T) {
+	reg := GetGlobalRegistry()
+	clientID := "test-client-param-filter"
+	reg.RegisterClient(clientID, "copilot", []*ModelInfo{
+		{
+			ID:                  "param-filter-tools",
+			Object:              "model",
+			Created:             time.Now().Unix(),
+			OwnedBy:             "copilot",
+			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "tools"},
+		},
+		{
+			ID:      "param-filter-unrestricted",
+			Object:  "model",
+			Created: time.Now().Unix(),
+			OwnedBy: "copilot",
+		},
+	})
+	defer reg.UnregisterClient(clientID)
+
+	payload := []byte(`{"model":"param-filter-tools","messages":[{"role":"user","content":"hi"}],"stream":true,"temperature":0.2,"logprobs":true,"top_logprobs":3,"tools":[{"type":"function","function":{"name":"f"}}],"tool_choice":"auto","prompt_cache_key":"session-1"}`)
+
+	t.Run("removes unsupported fields", func(t *testing.T) {
+		out := FilterUnsupportedParameters("param-filter-tools", payload)
+		for _, field := range []string{"logprobs", "top_logprobs"} {
+			if gjson.GetBytes(out, field).Exists() {
+				t.Errorf("expected %s to be removed, got %s", field, out)
+			}
+		}
+		for _, field := range []string{"model", "messages", "stream", "temperature", "tools", "tool_choice", "prompt_cache_key"} {
+			if !gjson.GetBytes(out, field).Exists() {
+				t.Errorf("expected %s to be retained, got %s", field, out)
+			}
+		}
+	})
+
+	t.Run("model without supported parameters is untouched", func(t *testing.T) {
+		out := FilterUnsupportedParameters("param-filter-unrestricted", payload)
+		if string(out) != string(payload) {
+			t.Fatalf("expected payload unchanged, got %s", out)
+		}
+	})
+
+	t.Run("unknown model is untouched", func(t *testing.T) {
+		out := FilterUnsupportedParameters("param-filter-missing", payload)
+		if string(out) != string(payload) {
+			t.Fatalf("expected payload unchanged, got %s", out)
+		}
+	})
+}
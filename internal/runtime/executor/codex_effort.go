@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const codexReasoningEffortClamp = "clamp"
+
+// codexEffortOrder ranks reasoning efforts from least to most reasoning.
+var codexEffortOrder = []string{"none", "minimal", "low", "medium", "high", "xhigh"}
+
+func codexEffortRank(effort string) int {
+	for i, candidate := range codexEffortOrder {
+		if candidate == effort {
+			return i
+		}
+	}
+	return -1
+}
+
+// nearestCodexEffort returns the allowed effort closest in rank to effort. Ties resolve to
+// the higher effort so a clamped request never reasons less than both neighbours.
+func nearestCodexEffort(allowed []string, effort string) (string, bool) {
+	rank := codexEffortRank(effort)
+	if rank < 0 {
+		return "", false
+	}
+	best, bestDistance := "", -1
+	for _, candidate := range allowed {
+		candidateRank := codexEffortRank(strings.ToLower(candidate))
+		if candidateRank < 0 {
+			continue
+		}
+		distance := candidateRank - rank
+		if distance < 0 {
+			distance = -distance
+		}
+		if bestDistance < 0 || distance <= bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best, best != ""
+}
+
+// clampCodexReasoningEffort replaces a reasoning.effort the model's registered thinking
+// levels do not include with the nearest level when ReasoningEffortPolicy is "clamp".
+// Under the default "reject" policy the payload is left for ValidateThinkingConfig to
+// reject; effort names outside codexEffortOrder are never clamped.
+func clampCodexReasoningEffort(cfg *config.Config, model string, payload []byte) []byte {
+	if cfg == nil || cfg.ReasoningEffortPolicy != codexReasoningEffortClamp {
+		return payload
+	}
+	effortResult := gjson.GetBytes(payload, "reasoning.effort")
+	if !effortResult.Exists() {
+		return payload
+	}
+	levels := util.GetModelThinkingLevels(model)
+	if len(levels) == 0 {
+		return payload
+	}
+	if _, ok := util.NormalizeReasoningEffortLevel(model, effortResult.String()); ok {
+		return payload
+	}
+	clamped, ok := nearestCodexEffort(levels, strings.ToLower(strings.TrimSpace(effortResult.String())))
+	if !ok {
+		return payload
+	}
+	log.Debugf("codex executor: clamping reasoning effort %q to %q for model %s", effortResult.String(), clamped, model)
+	updated, err := sjson.SetBytes(payload, "reasoning.effort", clamped)
+	if err != nil {
+		return payload
+	}
+	return updated
+}
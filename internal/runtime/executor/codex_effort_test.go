@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCodexExecutor_ReasoningEffortPolicy(t *testing.T) {
+	var upstreamEffort string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamEffort = gjson.GetBytes(body, "reasoning.effort").String()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("codex-effort-client", "codex", []*registry.ModelInfo{
+		registry.LookupStaticModelInfo("gpt-5"),
+		registry.LookupStaticModelInfo("gpt-5.1"),
+	})
+	defer reg.UnregisterClient("codex-effort-client")
+
+	tests := []struct {
+		name       string
+		policy     string
+		model      string
+		effort     string
+		wantEffort string
+		wantStatus int
+	}{
+		{name: "valid effort forwarded", model: "gpt-5", effort: "high", wantEffort: "high"},
+		{name: "invalid effort rejected by default", model: "gpt-5", effort: "xhigh", wantStatus: http.StatusBadRequest},
+		{name: "invalid effort rejected explicitly", policy: "reject", model: "gpt-5.1", effort: "minimal", wantStatus: http.StatusBadRequest},
+		{name: "xhigh clamped to high", policy: "clamp", model: "gpt-5", effort: "xhigh", wantEffort: "high"},
+		{name: "none clamped to minimal", policy: "clamp", model: "gpt-5", effort: "none", wantEffort: "minimal"},
+		{name: "tie clamps upward", policy: "clamp", model: "gpt-5.1", effort: "minimal", wantEffort: "low"},
+		{name: "unknown effort rejected even when clamping", policy: "clamp", model: "gpt-5", effort: "ultra", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreamEffort = ""
+			e := NewCodexExecutor(&config.Config{ReasoningEffortPolicy: tt.policy})
+			auth := &cliproxyauth.Auth{ID: "codex-effort", Attributes: map[string]string{"api_key": "test", "base_url": server.URL}}
+			_, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{
+				Model:   tt.model,
+				Payload: []byte(`{"model":"` + tt.model + `","input":"hello","reasoning":{"effort":"` + tt.effort + `"}}`),
+			}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+			if tt.wantStatus != 0 {
+				var se statusErr
+				if !errors.As(err, &se) || se.StatusCode() != tt.wantStatus {
+					t.Fatalf("expected status %d, got %v", tt.wantStatus, err)
+				}
+				if upstreamEffort != "" {
+					t.Fatal("rejected request must not reach upstream")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if upstreamEffort != tt.wantEffort {
+				t.Fatalf("upstream effort = %q, want %q", upstreamEffort, tt.wantEffort)
+			}
+		})
+	}
+}
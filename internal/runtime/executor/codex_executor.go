@@ -44,6 +44,9 @@ func (e *CodexExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Aut
 		return nil
 	}
 	apiKey, _ := codexCreds(auth)
+	if token, ok := providerCredential(req.Context(), e.Identifier(), auth); ok {
+		apiKey = token
+	}
 	if strings.TrimSpace(apiKey) != "" {
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
@@ -73,10 +76,15 @@ func (e *CodexExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth
 
 func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	apiKey, baseURL := codexCreds(auth)
+	if token, ok := providerCredential(ctx, e.Identifier(), auth); ok {
+		apiKey = token
+	}
 
 	if baseURL == "" {
 		baseURL = "https://chatgpt.com/backend-api/codex"
 	}
+	ctx, span := startExecutorSpan(ctx, e.Identifier(), req.Model, opts.Headers)
+	defer func() { endExecutorSpan(span, err) }()
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
@@ -116,13 +124,16 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	}
 	body = ApplyReasoningEffortMetadata(body, req.Metadata, model, "reasoning.effort", false)
 	body = NormalizeThinkingConfig(body, model, false)
+	body = clampCodexReasoningEffort(e.cfg, model, body)
 	if errValidate := ValidateThinkingConfig(body, model); errValidate != nil {
 		return resp, errValidate
 	}
 	body = applyPayloadConfigWithRoot(e.cfg, model, to.String(), "", body, originalTranslated)
 	body, _ = sjson.SetBytes(body, "model", model)
+	body = stripReasoningForModel(e.cfg, model, body)
 	body, _ = sjson.SetBytes(body, "stream", true)
 	body, _ = sjson.DeleteBytes(body, "previous_response_id")
+	setCodexResolvedHeaders(ctx, req.Model, body)
 
 	url := strings.TrimSuffix(baseURL, "/") + "/responses"
 	httpReq, err := e.cacheHelper(ctx, from, url, req, body)
@@ -130,6 +141,7 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		return resp, err
 	}
 	applyCodexHeaders(httpReq, auth, apiKey)
+	injectTraceContext(ctx, httpReq.Header)
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
@@ -147,9 +159,18 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		AuthType:  authType,
 		AuthValue: authValue,
 	})
+	if isDryRunRequest(opts.Headers) {
+		resp = cliproxyexecutor.Response{Payload: buildDryRunPayload(httpReq, body)}
+		return resp, nil
+	}
+	requestTimeout, _ := e.codexTimeouts(auth)
+	reqCtx, cancelTimeout := withRequestTimeout(ctx, requestTimeout)
+	defer cancelTimeout()
+	httpReq = httpReq.WithContext(reqCtx)
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
+		err = requestTimeoutErr(ctx, err, requestTimeout)
 		recordAPIResponseError(ctx, e.cfg, err)
 		return resp, err
 	}
@@ -159,6 +180,7 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		}
 	}()
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	recordSpanUpstreamStatus(span, httpResp.StatusCode)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
@@ -168,6 +190,7 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	}
 	data, err := io.ReadAll(httpResp.Body)
 	if err != nil {
+		err = requestTimeoutErr(ctx, err, requestTimeout)
 		recordAPIResponseError(ctx, e.cfg, err)
 		return resp, err
 	}
@@ -180,16 +203,22 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		}
 
 		line = bytes.TrimSpace(line[5:])
-		if gjson.GetBytes(line, "type").String() != "response.completed" {
+		// response.incomplete also ends the response, e.g. when max_output_tokens is reached.
+		eventType := gjson.GetBytes(line, "type").String()
+		if eventType != "response.completed" && eventType != "response.incomplete" {
+			continue
+		}
+
+		var param any
+		out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(originalPayload), body, line, &param)
+		if out == "" && eventType == "response.incomplete" {
+			// The source format has no mapping for incomplete responses.
 			continue
 		}
 
 		if detail, ok := parseCodexUsage(line); ok {
 			reporter.publish(ctx, detail)
 		}
-
-		var param any
-		out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(originalPayload), body, line, &param)
 		resp = cliproxyexecutor.Response{Payload: []byte(out)}
 		return resp, nil
 	}
@@ -199,10 +228,20 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 
 func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
 	apiKey, baseURL := codexCreds(auth)
+	if token, ok := providerCredential(ctx, e.Identifier(), auth); ok {
+		apiKey = token
+	}
 
 	if baseURL == "" {
 		baseURL = "https://chatgpt.com/backend-api/codex"
 	}
+	ctx, span := startExecutorSpan(ctx, e.Identifier(), req.Model, opts.Headers)
+	spanHandedOff := false
+	defer func() {
+		if !spanHandedOff {
+			endExecutorSpan(span, err)
+		}
+	}()
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
@@ -241,12 +280,15 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 
 	body = ApplyReasoningEffortMetadata(body, req.Metadata, model, "reasoning.effort", false)
 	body = NormalizeThinkingConfig(body, model, false)
+	body = clampCodexReasoningEffort(e.cfg, model, body)
 	if errValidate := ValidateThinkingConfig(body, model); errValidate != nil {
 		return nil, errValidate
 	}
 	body = applyPayloadConfigWithRoot(e.cfg, model, to.String(), "", body, originalTranslated)
 	body, _ = sjson.DeleteBytes(body, "previous_response_id")
 	body, _ = sjson.SetBytes(body, "model", model)
+	body = stripReasoningForModel(e.cfg, model, body)
+	setCodexResolvedHeaders(ctx, req.Model, body)
 
 	url := strings.TrimSuffix(baseURL, "/") + "/responses"
 	httpReq, err := e.cacheHelper(ctx, from, url, req, body)
@@ -254,6 +296,7 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		return nil, err
 	}
 	applyCodexHeaders(httpReq, auth, apiKey)
+	injectTraceContext(ctx, httpReq.Header)
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
@@ -272,6 +315,10 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		AuthValue: authValue,
 	})
 
+	if isDryRunRequest(opts.Headers) {
+		return dryRunStream(httpReq, body), nil
+	}
+
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
@@ -279,6 +326,7 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		return nil, err
 	}
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	recordSpanUpstreamStatus(span, httpResp.StatusCode)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		data, readErr := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
@@ -293,9 +341,14 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		err = statusErr{code: httpResp.StatusCode, msg: string(data)}
 		return nil, err
 	}
+	_, idleTimeout := e.codexTimeouts(auth)
+	httpResp.Body = newIdleTimeoutBody(httpResp.Body, idleTimeout)
 	out := make(chan cliproxyexecutor.StreamChunk)
 	stream = out
+	spanHandedOff = true
 	go func() {
+		var streamErr error
+		defer func() { endExecutorSpan(span, streamErr) }()
 		defer close(out)
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
@@ -324,6 +377,7 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 			}
 		}
 		if errScan := scanner.Err(); errScan != nil {
+			streamErr = errScan
 			recordAPIResponseError(ctx, e.cfg, errScan)
 			reporter.publishFailure(ctx)
 			out <- cliproxyexecutor.StreamChunk{Err: errScan}
@@ -396,6 +450,8 @@ func resolveCodexAlias(modelName string) (baseModel, effort string, ok bool) {
 		return "gpt-5-codex", "medium", true
 	case "gpt-5-codex-high":
 		return "gpt-5-codex", "high", true
+	case "gpt-5-codex-mini-low":
+		return "gpt-5-codex-mini", "low", true
 	case "gpt-5-codex-mini-medium":
 		return "gpt-5-codex-mini", "medium", true
 	case "gpt-5-codex-mini-high":
@@ -414,6 +470,8 @@ func resolveCodexAlias(modelName string) (baseModel, effort string, ok bool) {
 		return "gpt-5.1-codex", "medium", true
 	case "gpt-5.1-codex-high":
 		return "gpt-5.1-codex", "high", true
+	case "gpt-5.1-codex-mini-low":
+		return "gpt-5.1-codex-mini", "low", true
 	case "gpt-5.1-codex-mini-medium":
 		return "gpt-5.1-codex-mini", "medium", true
 	case "gpt-5.1-codex-mini-high":
@@ -459,26 +517,47 @@ func setReasoningEffortByAlias(payload []byte, baseModel string, effort string)
 	return payload
 }
 
-func tokenizerForCodexModel(model string) (tokenizer.Codec, error) {
-	sanitized := strings.ToLower(strings.TrimSpace(model))
-	switch {
-	case sanitized == "":
-		return tokenizer.Get(tokenizer.Cl100kBase)
-	case strings.HasPrefix(sanitized, "gpt-5"):
-		return tokenizer.ForModel(tokenizer.GPT5)
-	case strings.HasPrefix(sanitized, "gpt-4.1"):
-		return tokenizer.ForModel(tokenizer.GPT41)
-	case strings.HasPrefix(sanitized, "gpt-4o"):
-		return tokenizer.ForModel(tokenizer.GPT4o)
-	case strings.HasPrefix(sanitized, "gpt-4"):
-		return tokenizer.ForModel(tokenizer.GPT4)
-	case strings.HasPrefix(sanitized, "gpt-3.5"), strings.HasPrefix(sanitized, "gpt-3"):
-		return tokenizer.ForModel(tokenizer.GPT35Turbo)
-	default:
-		return tokenizer.Get(tokenizer.Cl100kBase)
+// setCodexResolvedHeaders reports the model and, for effort aliases, the reasoning effort
+// sent upstream as X-Resolved-Model and X-Resolved-Reasoning-Effort response headers.
+func setCodexResolvedHeaders(ctx context.Context, requestedModel string, payload []byte) {
+	if ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	if model := gjson.GetBytes(payload, "model").String(); model != "" {
+		ginCtx.Header("X-Resolved-Model", model)
+	}
+	if _, _, isAlias := resolveCodexAlias(requestedModel); !isAlias {
+		return
+	}
+	if effort := gjson.GetBytes(payload, "reasoning.effort").String(); effort != "" {
+		ginCtx.Header("X-Resolved-Reasoning-Effort", effort)
 	}
 }
 
+// stripReasoningForModel removes the reasoning object when the base model is listed in
+// NoReasoningModels, overriding any effort applied by an alias or metadata.
+func stripReasoningForModel(cfg *config.Config, model string, payload []byte) []byte {
+	if cfg == nil || len(cfg.NoReasoningModels) == 0 {
+		return payload
+	}
+	model = strings.TrimSpace(model)
+	for _, candidate := range cfg.NoReasoningModels {
+		if strings.EqualFold(strings.TrimSpace(candidate), model) {
+			payload, _ = sjson.DeleteBytes(payload, "reasoning")
+			return payload
+		}
+	}
+	return payload
+}
+
+func tokenizerForCodexModel(model string) (tokenizer.Codec, error) {
+	return util.CodexTokenizer(model)
+}
+
 func countCodexInputTokens(enc tokenizer.Codec, body []byte) (int64, error) {
 	if enc == nil {
 		return 0, fmt.Errorf("encoder is nil")
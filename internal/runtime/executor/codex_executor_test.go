@@ -1,8 +1,19 @@
 package executor
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/codex/openai/chat-completions"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	"github.com/tidwall/gjson"
 )
 
@@ -96,6 +107,72 @@ func TestResolveCodexAlias(t *testing.T) {
 			wantEffort:    "xhigh",
 			wantOk:        true,
 		},
+		// GPT-5-codex-mini aliases
+		{
+			name:          "gpt-5-codex-mini-low",
+			modelName:     "gpt-5-codex-mini-low",
+			wantBaseModel: "gpt-5-codex-mini",
+			wantEffort:    "low",
+			wantOk:        true,
+		},
+		{
+			name:          "gpt-5-codex-mini-medium",
+			modelName:     "gpt-5-codex-mini-medium",
+			wantBaseModel: "gpt-5-codex-mini",
+			wantEffort:    "medium",
+			wantOk:        true,
+		},
+		{
+			name:          "gpt-5-codex-mini-high",
+			modelName:     "gpt-5-codex-mini-high",
+			wantBaseModel: "gpt-5-codex-mini",
+			wantEffort:    "high",
+			wantOk:        true,
+		},
+		// GPT-5.1-codex-mini aliases
+		{
+			name:          "gpt-5.1-codex-mini-low",
+			modelName:     "gpt-5.1-codex-mini-low",
+			wantBaseModel: "gpt-5.1-codex-mini",
+			wantEffort:    "low",
+			wantOk:        true,
+		},
+		{
+			name:          "gpt-5.1-codex-mini-medium",
+			modelName:     "gpt-5.1-codex-mini-medium",
+			wantBaseModel: "gpt-5.1-codex-mini",
+			wantEffort:    "medium",
+			wantOk:        true,
+		},
+		{
+			name:          "gpt-5.1-codex-mini-high",
+			modelName:     "gpt-5.1-codex-mini-high",
+			wantBaseModel: "gpt-5.1-codex-mini",
+			wantEffort:    "high",
+			wantOk:        true,
+		},
+		// GPT-5.2-codex aliases
+		{
+			name:          "gpt-5.2-codex-low",
+			modelName:     "gpt-5.2-codex-low",
+			wantBaseModel: "gpt-5.2-codex",
+			wantEffort:    "low",
+			wantOk:        true,
+		},
+		{
+			name:          "gpt-5.2-codex-medium",
+			modelName:     "gpt-5.2-codex-medium",
+			wantBaseModel: "gpt-5.2-codex",
+			wantEffort:    "medium",
+			wantOk:        true,
+		},
+		{
+			name:          "gpt-5.2-codex-high",
+			modelName:     "gpt-5.2-codex-high",
+			wantBaseModel: "gpt-5.2-codex",
+			wantEffort:    "high",
+			wantOk:        true,
+		},
 		// Non-alias models should return false
 		{
 			name:          "base gpt-5 (not an alias)",
@@ -111,6 +188,42 @@ func TestResolveCodexAlias(t *testing.T) {
 			wantEffort:    "",
 			wantOk:        false,
 		},
+		// Efforts outside a family's matrix should return false
+		{
+			name:          "gpt-5-codex-minimal (unsupported effort)",
+			modelName:     "gpt-5-codex-minimal",
+			wantBaseModel: "",
+			wantEffort:    "",
+			wantOk:        false,
+		},
+		{
+			name:          "gpt-5-codex-mini-minimal (unsupported effort)",
+			modelName:     "gpt-5-codex-mini-minimal",
+			wantBaseModel: "",
+			wantEffort:    "",
+			wantOk:        false,
+		},
+		{
+			name:          "gpt-5.1-codex-none (unsupported effort)",
+			modelName:     "gpt-5.1-codex-none",
+			wantBaseModel: "",
+			wantEffort:    "",
+			wantOk:        false,
+		},
+		{
+			name:          "gpt-5.2-codex-none (unsupported effort)",
+			modelName:     "gpt-5.2-codex-none",
+			wantBaseModel: "",
+			wantEffort:    "",
+			wantOk:        false,
+		},
+		{
+			name:          "gpt-5.2-codex-ultra (unsupported effort)",
+			modelName:     "gpt-5.2-codex-ultra",
+			wantBaseModel: "",
+			wantEffort:    "",
+			wantOk:        false,
+		},
 		{
 			name:          "claude model (not codex)",
 			modelName:     "claude-sonnet-4",
@@ -210,6 +323,94 @@ func TestSetReasoningEffortByAlias(t *testing.T) {
 	}
 }
 
+func TestCodexExecutor_ResolvedModelHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	// Level-based effort normalization only keeps efforts for models known to the registry.
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("codex-resolved-client", "codex", []*registry.ModelInfo{registry.LookupStaticModelInfo("gpt-5.1-codex-max")})
+	defer reg.UnregisterClient("codex-resolved-client")
+
+	tests := []struct {
+		name       string
+		model      string
+		wantModel  string
+		wantEffort string
+	}{
+		{name: "alias", model: "gpt-5.1-codex-max-xhigh", wantModel: "gpt-5.1-codex-max", wantEffort: "xhigh"},
+		{name: "bare model", model: "gpt-5", wantModel: "gpt-5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ginCtx, _ := gin.CreateTestContext(recorder)
+			ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+			ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+			e := NewCodexExecutor(&config.Config{})
+			auth := &cliproxyauth.Auth{ID: "codex-resolved", Attributes: map[string]string{"api_key": "test", "base_url": server.URL}}
+			_, err := e.Execute(ctx, auth, cliproxyexecutor.Request{
+				Model:   tt.model,
+				Payload: []byte(`{"model":"` + tt.model + `","input":"hello"}`),
+			}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if got := ginCtx.Writer.Header().Get("X-Resolved-Model"); got != tt.wantModel {
+				t.Errorf("X-Resolved-Model = %q, want %q", got, tt.wantModel)
+			}
+			if got := ginCtx.Writer.Header().Get("X-Resolved-Reasoning-Effort"); got != tt.wantEffort {
+				t.Errorf("X-Resolved-Reasoning-Effort = %q, want %q", got, tt.wantEffort)
+			}
+		})
+	}
+}
+
+func TestCodexExecutor_ExecuteIncompleteResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"partial\"}\n\n"))
+		_, _ = w.Write([]byte("data: {\"type\":\"response.incomplete\",\"response\":{\"id\":\"resp_1\",\"status\":\"incomplete\",\"incomplete_details\":{\"reason\":\"max_output_tokens\"},\"output\":[{\"type\":\"message\",\"content\":[{\"type\":\"output_text\",\"text\":\"partial\"}]}]}}\n\n"))
+	}))
+	defer server.Close()
+
+	e := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-incomplete", Attributes: map[string]string{"api_key": "test", "base_url": server.URL}}
+	payload := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hello"}],"max_tokens":5}`)
+	resp, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-5", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.finish_reason").String(); got != "length" {
+		t.Fatalf("finish_reason = %q, want length; payload %s", got, resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "partial" {
+		t.Fatalf("content = %q, want partial", got)
+	}
+}
+
+func TestStripReasoningForModel(t *testing.T) {
+	cfg := &config.Config{NoReasoningModels: []string{"GPT-5-Codex-Mini"}}
+	payload := []byte(`{"model":"gpt-5-codex-mini","reasoning":{"effort":"high"}}`)
+
+	got := stripReasoningForModel(cfg, "gpt-5-codex-mini", payload)
+	if gjson.GetBytes(got, "reasoning").Exists() {
+		t.Fatalf("expected reasoning to be removed for listed model, got %s", got)
+	}
+
+	payload = []byte(`{"model":"gpt-5-codex","reasoning":{"effort":"high"}}`)
+	got = stripReasoningForModel(cfg, "gpt-5-codex", payload)
+	if effort := gjson.GetBytes(got, "reasoning.effort").String(); effort != "high" {
+		t.Fatalf("expected reasoning to be kept for unlisted model, got %s", got)
+	}
+}
+
 func TestTokenizerForCodexModel(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -281,3 +482,51 @@ func TestTokenizerForCodexModel(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenizerForCodexModel_ReusesCachedEncoding(t *testing.T) {
+	first, err := tokenizerForCodexModel("gpt-5")
+	if err != nil {
+		t.Fatalf("tokenizerForCodexModel: %v", err)
+	}
+	second, _ := tokenizerForCodexModel("gpt-4.1")
+	if first != second {
+		t.Fatal("models sharing o200k_base should reuse the cached codec")
+	}
+
+	empty, _ := tokenizerForCodexModel("")
+	unknown, _ := tokenizerForCodexModel("unknown-model")
+	if empty != unknown || empty.GetName() != "cl100k_base" {
+		t.Fatalf("cl100k_base fallback not cached: %s vs %s", empty.GetName(), unknown.GetName())
+	}
+	if again, _ := tokenizerForCodexModel(""); again != empty {
+		t.Fatal("second fallback call returned a new codec")
+	}
+}
+
+func TestTokenizerForCodexModel_ConcurrentAccess(t *testing.T) {
+	models := []string{"gpt-5", "gpt-4o", "gpt-4", "", "unknown-model"}
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(model string) {
+			defer wg.Done()
+			enc, err := tokenizerForCodexModel(model)
+			if err != nil {
+				t.Errorf("tokenizerForCodexModel(%q): %v", model, err)
+				return
+			}
+			if _, err = enc.Count("hello world"); err != nil {
+				t.Errorf("Count: %v", err)
+			}
+		}(models[i%len(models)])
+	}
+	wg.Wait()
+}
+
+func BenchmarkTokenizerForCodexModel(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := tokenizerForCodexModel("gpt-5"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
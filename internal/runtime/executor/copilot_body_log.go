@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// copilotBodySink is shared across executor instances so the log file survives
+// executor recreation on config reload. It is created on first use.
+var (
+	copilotBodySinkMu sync.Mutex
+	copilotBodySink   *logging.FileBodySink
+)
+
+// bodyLogSink returns the sink for the credential's CopilotKey entry, or nil when
+// body logging is disabled for it. The shared sink is resized to the entry's
+// LogBodiesMaxSizeMB on every use, so a reloaded size applies without a restart.
+func (e *CopilotExecutor) bodyLogSink(auth *cliproxyauth.Auth) logging.BodySink {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil || !entry.LogBodies {
+		return nil
+	}
+	copilotBodySinkMu.Lock()
+	defer copilotBodySinkMu.Unlock()
+	if copilotBodySink == nil {
+		sink, err := logging.NewFileBodySink(logging.DefaultBodyLogPath(), entry.LogBodiesMaxSizeMB)
+		if err != nil {
+			e.log().Warnf("copilot executor: body logging disabled: %v", err)
+			return nil
+		}
+		copilotBodySink = sink
+		return copilotBodySink
+	}
+	copilotBodySink.SetMaxSize(entry.LogBodiesMaxSizeMB)
+	return copilotBodySink
+}
+
+// logCopilotBodies redacts and writes one upstream exchange to sink. A nil sink is a no-op.
+func (e *CopilotExecutor) logCopilotBodies(sink logging.BodySink, model string, headers http.Header, copilotToken string, request, response []byte) {
+	if sink == nil {
+		return
+	}
+	record := logging.BodyRecord{
+		Time:      time.Now().UTC(),
+		Provider:  "copilot",
+		Model:     model,
+		Initiator: headers.Get("X-Initiator"),
+		Headers:   headers.Clone(),
+		Request:   string(request),
+		Response:  string(response),
+	}
+	if err := sink.WriteBodyRecord(logging.RedactBodyRecord(record, copilotToken)); err != nil {
+		e.log().Warnf("copilot executor: write body log: %v", err)
+	}
+}
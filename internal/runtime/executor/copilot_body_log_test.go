@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+type recordingBodySink struct {
+	records []logging.BodyRecord
+}
+
+func (s *recordingBodySink) WriteBodyRecord(record logging.BodyRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestCopilotExecutor_BodyLogSinkRequiresLogBodies(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	if sink := e.bodyLogSink(nil); sink != nil {
+		t.Fatalf("expected no sink when log-bodies is unset, got %T", sink)
+	}
+}
+
+func TestLogCopilotBodies_RedactsBeforeWriting(t *testing.T) {
+	sink := &recordingBodySink{}
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer copilot-secret")
+	headers.Set("X-Initiator", "agent")
+	request := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,/9j/4AAQSkZJRg=="}}]}]}`)
+
+	(&CopilotExecutor{}).logCopilotBodies(sink, "gpt-4.1", headers, "copilot-secret", request, []byte(`{"ok":true}`))
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected one record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.Initiator != "agent" || record.Model != "gpt-4.1" {
+		t.Fatalf("unexpected record metadata: %+v", record)
+	}
+	if strings.Contains(record.Headers.Get("Authorization"), "copilot-secret") {
+		t.Fatalf("expected bearer token to be redacted, got %q", record.Headers.Get("Authorization"))
+	}
+	if !strings.Contains(record.Request, "data:image/jpeg;base64,<redacted>") || strings.Contains(record.Request, "/9j/4AAQ") {
+		t.Fatalf("expected image data to be redacted, got %s", record.Request)
+	}
+	if headers.Get("Authorization") != "Bearer copilot-secret" {
+		t.Fatal("expected outgoing request headers to be left untouched")
+	}
+}
+
+func TestCopilotExecutor_BodyLogSinkFollowsConfiguredSize(t *testing.T) {
+	t.Setenv("WRITABLE_PATH", t.TempDir())
+	copilotBodySinkMu.Lock()
+	previous := copilotBodySink
+	copilotBodySink = nil
+	copilotBodySinkMu.Unlock()
+	t.Cleanup(func() {
+		copilotBodySinkMu.Lock()
+		if copilotBodySink != nil {
+			_ = copilotBodySink.Close()
+		}
+		copilotBodySink = previous
+		copilotBodySinkMu.Unlock()
+	})
+
+	first := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{LogBodies: true, LogBodiesMaxSizeMB: 5}}})
+	sink, ok := first.bodyLogSink(nil).(*logging.FileBodySink)
+	if !ok || sink.MaxSize() != 5 {
+		t.Fatalf("expected a 5 MB sink, got %T", first.bodyLogSink(nil))
+	}
+
+	// A config reload builds a new executor; the shared sink must pick up the new size.
+	reloaded := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{LogBodies: true, LogBodiesMaxSizeMB: 50}}})
+	if got := reloaded.bodyLogSink(nil); got != logging.BodySink(sink) {
+		t.Fatal("expected the sink to be shared across executors")
+	}
+	if got := sink.MaxSize(); got != 50 {
+		t.Fatalf("max size after reload = %d MB, want 50", got)
+	}
+}
@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// copilotCoalesceEnabled reports whether identical in-flight requests share one upstream call.
+func copilotCoalesceEnabled(entry *config.CopilotKey) bool {
+	return entry != nil && entry.CoalesceRequests
+}
+
+// copilotCoalesceEligible reports whether a payload is deterministic enough to share one
+// upstream answer: temperature must be explicitly 0 and no tools may be offered, since
+// sampled or tool-driven replies are expected to differ between callers.
+func copilotCoalesceEligible(payload []byte) bool {
+	temperature := gjson.GetBytes(payload, "temperature")
+	if temperature.Type != gjson.Number || temperature.Float() != 0 {
+		return false
+	}
+	for _, field := range []string{"tools", "functions"} {
+		if tools := gjson.GetBytes(payload, field); tools.IsArray() && len(tools.Array()) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// copilotCoalesceHeaders are the client headers that change how a request is sent upstream
+// (initiator and prompt cache hints), so requests only coalesce when they match.
+var copilotCoalesceHeaders = []string{"X-Initiator", "Force-Copilot-Agent", copilotPromptCacheKeyHeader}
+
+// copilotCoalesceKey identifies a non-streaming request by credential, source format, model,
+// payload and hint headers. Requests only coalesce when all of them match byte for byte.
+func copilotCoalesceKey(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) string {
+	h := sha256.New()
+	if auth != nil {
+		h.Write([]byte(auth.ID))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(opts.SourceFormat.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Model))
+	h.Write([]byte{0})
+	h.Write(req.Payload)
+	for _, name := range copilotCoalesceHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(http.CanonicalHeaderKey(name) + ":" + opts.Headers.Get(name)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// copilotCoalesceCall is one upstream call shared by every request with the same key.
+type copilotCoalesceCall struct {
+	done chan struct{}
+	resp cliproxyexecutor.Response
+	err  error
+}
+
+// copilotCoalescer deduplicates concurrent calls by key. The first caller runs the request;
+// later callers wait for its result. Entries are dropped as soon as the call finishes, so only
+// requests that overlap in time are coalesced. Usage, request logs and upstream metadata are
+// recorded by the leader only: waiters cost no upstream quota and are not billed.
+type copilotCoalescer struct {
+	mu    sync.Mutex
+	calls map[string]*copilotCoalesceCall
+}
+
+// Shared across executor instances so coalescing survives executor recreation on reload.
+var sharedCopilotCoalescer = &copilotCoalescer{calls: make(map[string]*copilotCoalesceCall)}
+
+// do runs fn once per key among concurrent callers. A waiter whose own context is cancelled
+// stops waiting without affecting the shared call; the leader's cancellation, however, ends
+// the call and its error is delivered to every waiter.
+func (c *copilotCoalescer) do(ctx context.Context, key string, fn func() (cliproxyexecutor.Response, error)) (cliproxyexecutor.Response, error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return cloneCoalescedResponse(call.resp), call.err
+		case <-ctx.Done():
+			return cliproxyexecutor.Response{}, ctx.Err()
+		}
+	}
+	call := &copilotCoalesceCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.resp, call.err = fn()
+	return cloneCoalescedResponse(call.resp), call.err
+}
+
+// cloneCoalescedResponse copies the payload so waiters cannot observe each other's mutations.
+func cloneCoalescedResponse(resp cliproxyexecutor.Response) cliproxyexecutor.Response {
+	resp.Payload = bytes.Clone(resp.Payload)
+	return resp
+}
@@ -0,0 +1,230 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// runCoalesced launches n concurrent calls for key against an upstream stub that blocks
+// until every caller is waiting, and returns the results.
+func runCoalesced(t *testing.T, c *copilotCoalescer, n int, key string, upstream func() (cliproxyexecutor.Response, error)) ([]cliproxyexecutor.Response, []error) {
+	t.Helper()
+	release := make(chan struct{})
+	resps := make([]cliproxyexecutor.Response, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resps[i], errs[i] = c.do(context.Background(), key, func() (cliproxyexecutor.Response, error) {
+				<-release
+				return upstream()
+			})
+		}(i)
+	}
+	// Give every goroutine time to join the in-flight call before upstream answers.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return resps, errs
+}
+
+func TestCopilotCoalescer_SharesOneUpstreamCall(t *testing.T) {
+	c := &copilotCoalescer{calls: make(map[string]*copilotCoalesceCall)}
+	var hits atomic.Int32
+	resps, errs := runCoalesced(t, c, 8, "same", func() (cliproxyexecutor.Response, error) {
+		hits.Add(1)
+		return cliproxyexecutor.Response{Payload: []byte(`{"id":"chatcmpl-1"}`)}, nil
+	})
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("upstream hits = %d, want 1", got)
+	}
+	for i := range resps {
+		if errs[i] != nil || string(resps[i].Payload) != `{"id":"chatcmpl-1"}` {
+			t.Fatalf("caller %d got %q, %v", i, resps[i].Payload, errs[i])
+		}
+	}
+	resps[0].Payload[0] = 'x'
+	if resps[1].Payload[0] == 'x' {
+		t.Fatal("callers must receive independent payload copies")
+	}
+	if len(c.calls) != 0 {
+		t.Fatalf("finished calls must be dropped, %d left", len(c.calls))
+	}
+}
+
+func TestCopilotCoalescer_FansOutUpstreamError(t *testing.T) {
+	c := &copilotCoalescer{calls: make(map[string]*copilotCoalesceCall)}
+	var hits atomic.Int32
+	upstreamErr := statusErr{code: 429, msg: "rate limited"}
+	_, errs := runCoalesced(t, c, 4, "same", func() (cliproxyexecutor.Response, error) {
+		hits.Add(1)
+		return cliproxyexecutor.Response{}, upstreamErr
+	})
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("upstream hits = %d, want 1", got)
+	}
+	for i, err := range errs {
+		var se statusErr
+		if !errors.As(err, &se) || se.StatusCode() != 429 {
+			t.Fatalf("caller %d error = %v, want 429", i, err)
+		}
+	}
+}
+
+func TestCopilotCoalescer_SequentialCallsNotCoalesced(t *testing.T) {
+	c := &copilotCoalescer{calls: make(map[string]*copilotCoalesceCall)}
+	var hits int
+	for i := 0; i < 3; i++ {
+		_, _ = c.do(context.Background(), "same", func() (cliproxyexecutor.Response, error) {
+			hits++
+			return cliproxyexecutor.Response{}, nil
+		})
+	}
+	if hits != 3 {
+		t.Fatalf("upstream hits = %d, want 3", hits)
+	}
+}
+
+func TestCopilotCoalesceKey(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "a"}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+	req := cliproxyexecutor.Request{Model: "gpt-4.1", Payload: []byte(`{"messages":[]}`)}
+	base := copilotCoalesceKey(auth, req, opts)
+	if base != copilotCoalesceKey(&cliproxyauth.Auth{ID: "a"}, req, opts) {
+		t.Fatal("identical requests must share a key")
+	}
+	other := req
+	other.Model = "gpt-4o"
+	if base == copilotCoalesceKey(auth, other, opts) {
+		t.Fatal("different models must not share a key")
+	}
+	other = req
+	other.Payload = []byte(`{"messages":[{}]}`)
+	if base == copilotCoalesceKey(auth, other, opts) {
+		t.Fatal("different payloads must not share a key")
+	}
+	if base == copilotCoalesceKey(&cliproxyauth.Auth{ID: "b"}, req, opts) {
+		t.Fatal("different credentials must not share a key")
+	}
+	for _, name := range []string{"X-Initiator", "Force-Copilot-Agent", "X-Prompt-Cache-Key"} {
+		withHeader := opts
+		withHeader.Headers = http.Header{name: {"agent"}}
+		if base == copilotCoalesceKey(auth, req, withHeader) {
+			t.Fatalf("requests differing in %s must not share a key", name)
+		}
+	}
+	unrelated := opts
+	unrelated.Headers = http.Header{"User-Agent": {"curl"}}
+	if base != copilotCoalesceKey(auth, req, unrelated) {
+		t.Fatal("headers that do not affect upstream hints must not split the key")
+	}
+}
+
+func TestCopilotCoalesceEligible(t *testing.T) {
+	for payload, want := range map[string]bool{
+		`{"temperature":0,"messages":[]}`:                   true,
+		`{"temperature":0,"tools":[],"messages":[]}`:        true,
+		`{"messages":[]}`:                                   false,
+		`{"temperature":0.7,"messages":[]}`:                 false,
+		`{"temperature":"0","messages":[]}`:                 false,
+		`{"temperature":0,"tools":[{"type":"function"}]}`:   false,
+		`{"temperature":0,"functions":[{"name":"lookup"}]}`: false,
+	} {
+		if got := copilotCoalesceEligible([]byte(payload)); got != want {
+			t.Errorf("copilotCoalesceEligible(%s) = %v, want %v", payload, got, want)
+		}
+	}
+}
+
+// coalesceUsageCounter counts usage records published for one model.
+type coalesceUsageCounter struct {
+	model string
+	count atomic.Int32
+}
+
+func (c *coalesceUsageCounter) HandleUsage(_ context.Context, record usage.Record) {
+	if record.Model == c.model {
+		c.count.Add(1)
+	}
+}
+
+// TestCopilotExecutor_ExecuteCoalescesDeterministicRequests sends concurrent identical
+// requests through Execute: deterministic ones share a single upstream call billed once,
+// while sampled requests and requests with different hint headers each reach upstream.
+func TestCopilotExecutor_ExecuteCoalescesDeterministicRequests(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4.1-coalesce","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	counter := &coalesceUsageCounter{model: "gpt-4.1-coalesce"}
+	usage.RegisterPlugin(counter)
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "coalesce-auth", BaseURL: srv.URL, CoalesceRequests: true}}})
+	auth := &cliproxyauth.Auth{ID: "coalesce-auth", Metadata: map[string]any{
+		"copilot_token":        "test-copilot-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+	run := func(payload string, headers []http.Header) {
+		t.Helper()
+		hits.Store(0)
+		release = make(chan struct{})
+		var wg sync.WaitGroup
+		for _, header := range headers {
+			wg.Add(1)
+			go func(header http.Header) {
+				defer wg.Done()
+				opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: []byte(payload), Headers: header}
+				if _, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4.1-coalesce", Payload: []byte(payload)}, opts); err != nil {
+					t.Errorf("Execute error: %v", err)
+				}
+			}(header)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+	}
+
+	deterministic := `{"model":"gpt-4.1-coalesce","temperature":0,"messages":[{"role":"user","content":"hi"}]}`
+	run(deterministic, []http.Header{nil, nil, nil, nil})
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("deterministic requests hit upstream %d times, want 1", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for counter.count.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := counter.count.Load(); got != 1 {
+		t.Fatalf("usage records = %d, want 1 for the leader only", got)
+	}
+
+	run(`{"model":"gpt-4.1-coalesce","temperature":0.7,"messages":[{"role":"user","content":"hi"}]}`, []http.Header{nil, nil, nil})
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("sampled requests hit upstream %d times, want 3", got)
+	}
+
+	run(deterministic, []http.Header{nil, {"Force-Copilot-Agent": {"true"}}})
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("requests with different hint headers hit upstream %d times, want 2", got)
+	}
+}
@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// copilotConcurrencyRetryAfter is the Retry-After attached to over-limit 429s. It keeps the
+// credential's cooldown short so the manager only briefly routes around it.
+const copilotConcurrencyRetryAfter = time.Second
+
+// copilotConcurrencyLimiter caps simultaneous in-flight requests on one credential.
+// Waiters are granted freed slots in FIFO order.
+type copilotConcurrencyLimiter struct {
+	mu       sync.Mutex
+	credID   string
+	limit    int
+	inflight int
+	waiting  *list.List
+}
+
+// Shared limiters keyed by auth ID so that in-flight accounting survives executor
+// recreation on config reload.
+var (
+	sharedCopilotLimiterMu sync.Mutex
+	sharedCopilotLimiters  = make(map[string]*copilotConcurrencyLimiter)
+)
+
+func sharedCopilotConcurrencyLimiter(credID string, limit int) *copilotConcurrencyLimiter {
+	sharedCopilotLimiterMu.Lock()
+	defer sharedCopilotLimiterMu.Unlock()
+	l, ok := sharedCopilotLimiters[credID]
+	if !ok {
+		l = &copilotConcurrencyLimiter{credID: credID, limit: limit, waiting: list.New()}
+		sharedCopilotLimiters[credID] = l
+		return l
+	}
+	l.setLimit(limit)
+	return l
+}
+
+// acquire takes a slot, waiting up to wait for one to free up. It returns a 429 when the
+// limit is still reached after wait, or the context error when ctx ends first. The
+// returned release func must be called exactly once.
+func (l *copilotConcurrencyLimiter) acquire(ctx context.Context, wait time.Duration) (func(), error) {
+	l.mu.Lock()
+	if l.inflight < l.limit && l.waiting.Len() == 0 {
+		l.inflight++
+		l.reportLocked()
+		l.mu.Unlock()
+		return l.releaseFunc(), nil
+	}
+	if wait <= 0 {
+		l.mu.Unlock()
+		return nil, l.limitErr()
+	}
+	ready := make(chan struct{})
+	elem := l.waiting.PushBack(ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	var errWait error
+	select {
+	case <-ready:
+		return l.releaseFunc(), nil
+	case <-ctx.Done():
+		errWait = ctx.Err()
+	case <-timer.C:
+		errWait = l.limitErr()
+	}
+
+	l.mu.Lock()
+	select {
+	case <-ready:
+		// Slot was granted concurrently with the timeout; hand it back.
+		l.mu.Unlock()
+		l.release()
+	default:
+		l.waiting.Remove(elem)
+		l.mu.Unlock()
+	}
+	return nil, errWait
+}
+
+func (l *copilotConcurrencyLimiter) limitErr() error {
+	retryAfter := copilotConcurrencyRetryAfter
+	msg := interfaces.OpenAIErrorBody(http.StatusTooManyRequests, fmt.Sprintf("credential %s has reached its limit of %d concurrent requests", l.credID, l.limit), "", "concurrency_limit_exceeded")
+	return statusErr{code: http.StatusTooManyRequests, msg: string(msg), retryAfter: &retryAfter}
+}
+
+func (l *copilotConcurrencyLimiter) releaseFunc() func() {
+	var once sync.Once
+	return func() { once.Do(l.release) }
+}
+
+func (l *copilotConcurrencyLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight > 0 {
+		l.inflight--
+	}
+	l.dispatchLocked()
+	l.reportLocked()
+}
+
+func (l *copilotConcurrencyLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.dispatchLocked()
+	l.reportLocked()
+}
+
+// dispatchLocked grants free slots to waiters in arrival order. Caller must hold l.mu.
+func (l *copilotConcurrencyLimiter) dispatchLocked() {
+	for l.inflight < l.limit {
+		front := l.waiting.Front()
+		if front == nil {
+			return
+		}
+		ready := l.waiting.Remove(front).(chan struct{})
+		l.inflight++
+		close(ready)
+	}
+}
+
+// reportLocked publishes the in-flight count. Caller must hold l.mu.
+func (l *copilotConcurrencyLimiter) reportLocked() {
+	metrics.SetCredentialInflight("copilot", l.credID, l.inflight)
+}
+
+// acquireConcurrencySlot enforces the credential's CopilotKey.MaxConcurrent. When no
+// limit is configured it returns immediately with a no-op release func.
+func (e *CopilotExecutor) acquireConcurrencySlot(ctx context.Context, auth *cliproxyauth.Auth) (func(), error) {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil || entry.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+	credID := "default"
+	if auth != nil && strings.TrimSpace(auth.ID) != "" {
+		credID = strings.TrimSpace(auth.ID)
+	}
+	return sharedCopilotConcurrencyLimiter(credID, entry.MaxConcurrent).acquire(ctx, parseTimeoutSetting(entry.MaxConcurrentWait))
+}
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// credentialInflight returns the cliproxy_credential_inflight_requests value for a copilot credential.
+func credentialInflight(t *testing.T, credID string) float64 {
+	t.Helper()
+	families, err := metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "cliproxy_credential_inflight_requests" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "cred_id" && label.GetValue() == credID {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("no inflight series for %s", credID)
+	return 0
+}
+
+func TestCopilotMaxConcurrentRejectsOverLimit(t *testing.T) {
+	metrics.SetEnabled(true)
+	defer metrics.SetEnabled(false)
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{MaxConcurrent: 2}}})
+	auth := &cliproxyauth.Auth{ID: "max-concurrent-reject"}
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, err := e.acquireDispatchSlot(context.Background(), auth, false)
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		releases = append(releases, release)
+	}
+	if got := credentialInflight(t, auth.ID); got != 2 {
+		t.Fatalf("inflight gauge = %v, want 2", got)
+	}
+
+	_, err := e.acquireDispatchSlot(context.Background(), auth, false)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the limit, got %v", err)
+	}
+	if se.RetryAfter() == nil {
+		t.Fatal("expected a Retry-After on the over-limit error")
+	}
+	if got := gjson.Get(se.Error(), "error.code").String(); got != "concurrency_limit_exceeded" {
+		t.Fatalf("error code = %q, body = %s", got, se.Error())
+	}
+
+	releases[0]()
+	releases[0]() // release is idempotent
+	release, err := e.acquireDispatchSlot(context.Background(), auth, false)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+	releases[1]()
+	if got := credentialInflight(t, auth.ID); got != 0 {
+		t.Fatalf("inflight gauge = %v, want 0", got)
+	}
+}
+
+func TestCopilotMaxConcurrentQueuesWithinWait(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{MaxConcurrent: 1, MaxConcurrentWait: "2s"}}})
+	auth := &cliproxyauth.Auth{ID: "max-concurrent-queue"}
+
+	release, err := e.acquireDispatchSlot(context.Background(), auth, false)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		releaseQueued, errQueued := e.acquireDispatchSlot(context.Background(), auth, false)
+		if errQueued == nil {
+			releaseQueued()
+		}
+		acquired <- errQueued
+	}()
+
+	select {
+	case err = <-acquired:
+		t.Fatalf("queued request finished while the slot was held: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	select {
+	case err = <-acquired:
+		if err != nil {
+			t.Fatalf("queued request failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued request was not granted the freed slot")
+	}
+}
+
+func TestCopilotMaxConcurrentWaitTimesOut(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{MaxConcurrent: 1, MaxConcurrentWait: "30ms"}}})
+	auth := &cliproxyauth.Auth{ID: "max-concurrent-timeout"}
+
+	release, err := e.acquireDispatchSlot(context.Background(), auth, false)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	start := time.Now()
+	_, err = e.acquireDispatchSlot(context.Background(), auth, false)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the wait, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("rejected after %v, want at least the 30ms wait", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = e.acquireDispatchSlot(ctx, auth, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCopilotNormalizeErrorBody(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{NormalizeErrors: true}}})
+
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		wantCode    string
+		wantType    string
+	}{
+		{
+			name:        "github api shape",
+			status:      http.StatusUnauthorized,
+			body:        `{"message":"Bad credentials","documentation_url":"https://docs.github.com/rest"}`,
+			wantMessage: "Bad credentials",
+			wantCode:    "unauthorized",
+			wantType:    "authentication_error",
+		},
+		{
+			name:        "string error with code",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"model gpt-9 is not supported","code":"model_not_supported"}`,
+			wantMessage: "model gpt-9 is not supported",
+			wantCode:    "model_not_supported",
+			wantType:    "invalid_request_error",
+		},
+		{
+			name:        "errors array",
+			status:      http.StatusForbidden,
+			body:        `{"errors":[{"message":"access to this endpoint is forbidden","code":"forbidden_by_policy"}]}`,
+			wantMessage: "access to this endpoint is forbidden",
+			wantCode:    "forbidden_by_policy",
+			wantType:    "permission_error",
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusBadGateway,
+			body:        "upstream connect error or disconnect/reset before headers",
+			wantMessage: "upstream connect error or disconnect/reset before headers",
+			wantCode:    "bad_gateway",
+			wantType:    "server_error",
+		},
+		{
+			name:        "empty body",
+			status:      http.StatusServiceUnavailable,
+			body:        "",
+			wantMessage: "Service Unavailable",
+			wantCode:    "service_unavailable",
+			wantType:    "server_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := e.normalizeErrorBody(nil, tt.status, []byte(tt.body))
+			if got := gjson.GetBytes(out, "error.message").String(); got != tt.wantMessage {
+				t.Fatalf("message = %q, want %q; body = %s", got, tt.wantMessage, out)
+			}
+			if got := gjson.GetBytes(out, "error.code").String(); got != tt.wantCode {
+				t.Fatalf("code = %q, want %q; body = %s", got, tt.wantCode, out)
+			}
+			if got := gjson.GetBytes(out, "error.type").String(); got != tt.wantType {
+				t.Fatalf("type = %q, want %q; body = %s", got, tt.wantType, out)
+			}
+		})
+	}
+
+	envelope := `{"error":{"message":"quota exceeded","type":"rate_limit_error","code":"rate_limited"}}`
+	if out := e.normalizeErrorBody(nil, http.StatusTooManyRequests, []byte(envelope)); string(out) != envelope {
+		t.Fatalf("OpenAI envelope changed: %s", out)
+	}
+
+	disabled := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	raw := `{"message":"Bad credentials"}`
+	if out := disabled.normalizeErrorBody(nil, http.StatusUnauthorized, []byte(raw)); string(out) != raw {
+		t.Fatalf("body changed while normalize-errors is off: %s", out)
+	}
+}
+
+func TestCopilotExecuteNormalizesUpstreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials","documentation_url":"https://docs.github.com/rest"}`))
+	}))
+	defer srv.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "normalize-errors-auth", BaseURL: srv.URL, NormalizeErrors: true}}})
+	auth := &cliproxyauth.Auth{ID: "normalize-errors-auth", Metadata: map[string]any{
+		"copilot_token":        "test-copilot-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+	payload := []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hi"}]}`)
+
+	_, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4.1", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("expected upstream 401 to be preserved, got %v", err)
+	}
+	if got := gjson.Get(se.Error(), "error.message").String(); got != "Bad credentials" {
+		t.Fatalf("error body = %s, want an OpenAI envelope", se.Error())
+	}
+}
@@ -18,6 +18,7 @@ func TestMergeEssentialCopilotModels(t *testing.T) {
 		existingModels []*registry.ModelInfo
 		expectAdded    []string
 		expectNotAdded []string
+		expectOrder    []string
 	}{
 		{
 			name:           "adds gemini-3-flash-preview when missing",
@@ -46,6 +47,7 @@ func TestMergeEssentialCopilotModels(t *testing.T) {
 				{ID: "gemini-2.5-pro", OwnedBy: "copilot"},
 			},
 			expectAdded: []string{"gemini-3-flash-preview"},
+			expectOrder: []string{"gemini-3-flash-preview", "claude-sonnet-4", "gemini-2.5-pro", "gpt-5"},
 		},
 		{
 			name: "preserves existing models when adding essential",
@@ -54,12 +56,23 @@ func TestMergeEssentialCopilotModels(t *testing.T) {
 				{ID: "gpt-4o", OwnedBy: "copilot"},
 			},
 			expectAdded: []string{"gemini-3-flash-preview", "gpt-5", "gpt-4o"},
+			expectOrder: []string{"gemini-3-flash-preview", "gpt-4o", "gpt-5"},
+		},
+		{
+			name: "dynamic entry for an essential ID sorts with the essentials",
+			existingModels: []*registry.ModelInfo{
+				{ID: "gpt-5", OwnedBy: "copilot"},
+				{ID: "Gemini-3-Flash-Preview", OwnedBy: "copilot"},
+				{ID: "claude-sonnet-4", OwnedBy: "copilot"},
+			},
+			expectNotAdded: []string{"gemini-3-flash-preview"},
+			expectOrder:    []string{"Gemini-3-Flash-Preview", "claude-sonnet-4", "gpt-5"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := mergeEssentialCopilotModels(tt.existingModels, now)
+			result := (&CopilotExecutor{}).mergeEssentialCopilotModels(tt.existingModels, now)
 
 			// Build set of result model IDs
 			resultIDs := make(map[string]bool)
@@ -74,6 +87,17 @@ func TestMergeEssentialCopilotModels(t *testing.T) {
 				}
 			}
 
+			if tt.expectOrder != nil {
+				if len(result) != len(tt.expectOrder) {
+					t.Fatalf("expected %d models, got %d", len(tt.expectOrder), len(result))
+				}
+				for i, want := range tt.expectOrder {
+					if result[i].ID != want {
+						t.Errorf("position %d: expected %s, got %s", i, want, result[i].ID)
+					}
+				}
+			}
+
 			// Check that models that should NOT be added (duplicates) don't appear twice
 			for _, notExpected := range tt.expectNotAdded {
 				count := 0
@@ -94,7 +118,7 @@ func TestMergeEssentialCopilotModels(t *testing.T) {
 // models have correct attributes.
 func TestMergeEssentialCopilotModels_ModelAttributes(t *testing.T) {
 	now := time.Now().Unix()
-	result := mergeEssentialCopilotModels([]*registry.ModelInfo{}, now)
+	result := (&CopilotExecutor{}).mergeEssentialCopilotModels([]*registry.ModelInfo{}, now)
 
 	// Find gemini-3-flash-preview in result
 	var geminiFlash *registry.ModelInfo
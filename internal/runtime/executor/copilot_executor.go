@@ -8,12 +8,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -22,6 +26,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CopilotExecutor handles requests to GitHub Copilot API.
@@ -32,7 +38,9 @@ type CopilotExecutor struct {
 	mu             sync.Mutex
 	tokenCache     map[string]*cachedToken
 	modelMu        sync.Mutex
-	initiatorCount map[string]uint64
+	initiatorCount *copilotInitiatorCache
+	// logger honours the "copilot" entry under log-levels.
+	logger *log.Logger
 }
 
 // cachedToken stores the Copilot token and its expiration time.
@@ -61,12 +69,25 @@ func NewCopilotExecutor(cfg *config.Config) *CopilotExecutor {
 	return &CopilotExecutor{
 		cfg:            cfg,
 		tokenCache:     make(map[string]*cachedToken),
-		initiatorCount: make(map[string]uint64),
+		initiatorCount: newCopilotInitiatorCache(defaultCopilotInitiatorCacheSize),
+		logger:         logging.NewModuleLogger(cfg, copilotLogModule),
 	}
 }
 
+// copilotLogModule is the log-levels key that controls the Copilot executor's logger.
+const copilotLogModule = "copilot"
+
 func (e *CopilotExecutor) Identifier() string { return "copilot" }
 
+// log returns the executor's module logger, falling back to the standard logger for
+// executors built without NewCopilotExecutor.
+func (e *CopilotExecutor) log() *log.Logger {
+	if e == nil || e.logger == nil {
+		return log.StandardLogger()
+	}
+	return e.logger
+}
+
 func (e *CopilotExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
 	if req == nil {
 		return nil
@@ -88,7 +109,8 @@ func (e *CopilotExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.A
 	}
 
 	incoming := req.Header.Clone()
-	e.applyCopilotHeaders(req, copilotToken, payload, incoming)
+	profile := e.applyCopilotHeaders(req, auth, copilotToken, payload, incoming)
+	metrics.RecordHeaderProfile(string(profile))
 
 	var attrs map[string]string
 	if auth != nil {
@@ -151,8 +173,11 @@ var essentialCopilotModels = []struct {
 }
 
 // mergeEssentialCopilotModels adds essential models that may not be returned by /models
-// but are known to work with Copilot. Only adds models that aren't already present.
-func mergeEssentialCopilotModels(models []*registry.ModelInfo, now int64) []*registry.ModelInfo {
+// but are known to work with Copilot. Only adds models that aren't already present
+// (compared case-insensitively); a dynamic entry for an essential ID is kept as returned.
+// The result is ordered deterministically: essential IDs first, then dynamic models, each
+// group sorted by ID.
+func (e *CopilotExecutor) mergeEssentialCopilotModels(models []*registry.ModelInfo, now int64) []*registry.ModelInfo {
 	existing := make(map[string]bool, len(models))
 	for _, m := range models {
 		existing[strings.ToLower(m.ID)] = true
@@ -160,10 +185,13 @@ func mergeEssentialCopilotModels(models []*registry.ModelInfo, now int64) []*reg
 
 	paramsWithTools := []string{"temperature", "top_p", "max_tokens", "stream", "tools"}
 
+	essentialIDs := make(map[string]bool, len(essentialCopilotModels))
 	for _, em := range essentialCopilotModels {
+		essentialIDs[strings.ToLower(em.ID)] = true
 		if existing[strings.ToLower(em.ID)] {
 			continue
 		}
+		existing[strings.ToLower(em.ID)] = true
 		models = append(models, &registry.ModelInfo{
 			ID:                  em.ID,
 			Object:              "model",
@@ -176,9 +204,19 @@ func mergeEssentialCopilotModels(models []*registry.ModelInfo, now int64) []*reg
 			MaxCompletionTokens: em.MaxCompletionTokens,
 			SupportedParameters: paramsWithTools,
 		})
-		log.Debugf("copilot executor: added essential model %s", em.ID)
+		e.log().Debugf("copilot executor: added essential model %s", em.ID)
 	}
 
+	sort.SliceStable(models, func(i, j int) bool {
+		left, right := strings.ToLower(models[i].ID), strings.ToLower(models[j].ID)
+		if essentialIDs[left] != essentialIDs[right] {
+			return essentialIDs[left]
+		}
+		if left != right {
+			return left < right
+		}
+		return models[i].ID < models[j].ID
+	})
 	return models
 }
 
@@ -201,14 +239,42 @@ func sanitizeCopilotPayload(body []byte, model string) []byte {
 	return body
 }
 
-func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
-	copilotToken, accountType, err := e.getCopilotToken(ctx, auth)
+// filterUnsupportedParameters drops request fields the model does not advertise when
+// filter-unsupported-params is enabled on the entry serving auth. The copilot- alias is
+// consulted first so the lookup is not shadowed by same-named models from other providers.
+func (e *CopilotExecutor) filterUnsupportedParameters(auth *cliproxyauth.Auth, model string, body []byte) []byte {
+	if entry := e.copilotKeyForAuth(auth); entry == nil || !entry.FilterUnsupportedParams {
+		return body
+	}
+	modelID := registry.CopilotModelPrefix + model
+	if registry.GetGlobalRegistry().GetModelInfo(modelID) == nil {
+		modelID = model
+	}
+	return registry.FilterUnsupportedParameters(modelID, body)
+}
+
+func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	req = e.applyCopilotRoutingRules(auth, req, opts)
+	if copilotCoalesceEnabled(e.copilotKeyForAuth(auth)) && !isDryRunRequest(opts.Headers) && copilotCoalesceEligible(req.Payload) {
+		return sharedCopilotCoalescer.do(ctx, copilotCoalesceKey(auth, req, opts), func() (cliproxyexecutor.Response, error) {
+			return e.execute(ctx, auth, req, opts)
+		})
+	}
+	return e.execute(ctx, auth, req, opts)
+}
+
+func (e *CopilotExecutor) execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	dryRun := isDryRunRequest(opts.Headers)
+	copilotToken, accountType, err := e.copilotRequestToken(ctx, auth, dryRun)
 	if err != nil {
 		return resp, err
 	}
 
 	apiModel := stripCopilotPrefix(req.Model)
 
+	ctx, span := startExecutorSpan(ctx, e.Identifier(), apiModel, opts.Headers)
+	defer func() { endExecutorSpan(span, err) }()
+
 	translatorModel := req.Model
 	if !strings.HasPrefix(strings.ToLower(req.Model), "copilot-") && strings.HasPrefix(strings.ToLower(apiModel), "gemini") {
 		translatorModel = "copilot-" + apiModel
@@ -223,14 +289,27 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), false)
 	body = applyPayloadConfigWithRoot(e.cfg, apiModel, to.String(), "", body, nil)
 	body = sanitizeCopilotPayload(body, apiModel)
+	body = e.filterUnsupportedParameters(auth, apiModel, body)
+	body = e.normalizeToolChoice(auth, apiModel, body)
+	body, err = e.applyVisionFallback(auth, apiModel, body)
+	if err != nil {
+		return resp, err
+	}
+	if !dryRun {
+		body, err = e.inlineRemoteImages(ctx, auth, body)
+		if err != nil {
+			return resp, err
+		}
+	}
 	body, _ = sjson.SetBytes(body, "stream", false)
+	e.observeCopilotContextUtilization(apiModel, body)
 
 	// Inject cached Gemini reasoning for models that require it
 	if strings.HasPrefix(strings.ToLower(apiModel), "gemini") {
-		body = e.reasoningCache(auth).InjectReasoning(body)
+		body = e.reasoningCache(auth).InjectReasoning(e.log(), body)
 	}
 
-	baseURL := copilotauth.CopilotBaseURL(accountType)
+	baseURL := e.copilotBaseURL(auth, accountType)
 	url := baseURL + "/chat/completions"
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -238,7 +317,10 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 		return resp, err
 	}
 
-	e.applyCopilotHeaders(httpReq, copilotToken, req.Payload, opts.Headers)
+	profile := e.applyCopilotHeaders(httpReq, auth, copilotToken, req.Payload, opts.Headers)
+	e.annotateCopilotSpan(span, auth, httpReq.Header, apiModel)
+	e.traceCopilotKey(span, auth, apiModel)
+	injectTraceContext(ctx, httpReq.Header)
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -258,34 +340,57 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 		AuthValue: authValue,
 	})
 
+	if dryRun {
+		resp = cliproxyexecutor.Response{Payload: buildDryRunPayload(httpReq, body)}
+		return resp, nil
+	}
+	metrics.RecordHeaderProfile(string(profile))
+
+	releaseSlot, err := e.acquireDispatchSlot(ctx, auth, httpReq.Header.Get("X-Initiator") == "agent")
+	if err != nil {
+		return resp, err
+	}
+	defer releaseSlot()
+
+	requestTimeout, _ := e.copilotTimeouts(auth)
+	reqCtx, cancelTimeout := withRequestTimeout(ctx, requestTimeout)
+	defer cancelTimeout()
+	httpReq = httpReq.WithContext(reqCtx)
+
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, err := httpClient.Do(httpReq)
+	httpResp, err := e.doWithRetry(reqCtx, auth, httpClient, httpReq)
 	if err != nil {
+		err = requestTimeoutErr(ctx, err, requestTimeout)
 		recordAPIResponseError(ctx, e.cfg, err)
 		return resp, err
 	}
 	defer func() {
 		if errClose := httpResp.Body.Close(); errClose != nil {
-			log.Errorf("copilot executor: close response body error: %v", errClose)
+			e.log().Errorf("copilot executor: close response body error: %v", errClose)
 		}
 	}()
 
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	recordSpanUpstreamStatus(span, httpResp.StatusCode)
 
+	bodySink := e.bodyLogSink(auth)
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
-		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = copilotStatusErr(httpResp.StatusCode, string(b))
+		e.logCopilotBodies(bodySink, apiModel, httpReq.Header, copilotToken, body, b)
+		e.log().Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		err = copilotStatusErr(httpResp.StatusCode, string(e.normalizeErrorBody(auth, httpResp.StatusCode, b)))
 		return resp, err
 	}
 
 	data, err := io.ReadAll(httpResp.Body)
 	if err != nil {
+		err = requestTimeoutErr(ctx, err, requestTimeout)
 		recordAPIResponseError(ctx, e.cfg, err)
 		return resp, err
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
+	e.logCopilotBodies(bodySink, apiModel, httpReq.Header, copilotToken, body, data)
 
 	// Parse usage from response
 	reporter.publish(ctx, parseOpenAIUsage(data))
@@ -297,13 +402,23 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 }
 
 func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
-	copilotToken, accountType, err := e.getCopilotToken(ctx, auth)
+	req = e.applyCopilotRoutingRules(auth, req, opts)
+	dryRun := isDryRunRequest(opts.Headers)
+	copilotToken, accountType, err := e.copilotRequestToken(ctx, auth, dryRun)
 	if err != nil {
 		return nil, err
 	}
 
 	apiModel := stripCopilotPrefix(req.Model)
 
+	ctx, span := startExecutorSpan(ctx, e.Identifier(), apiModel, opts.Headers)
+	spanHandedOff := false
+	defer func() {
+		if !spanHandedOff {
+			endExecutorSpan(span, err)
+		}
+	}()
+
 	translatorModel := req.Model
 	if !strings.HasPrefix(strings.ToLower(req.Model), "copilot-") && strings.HasPrefix(strings.ToLower(apiModel), "gemini") {
 		translatorModel = "copilot-" + apiModel
@@ -318,14 +433,28 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), true)
 	body = applyPayloadConfigWithRoot(e.cfg, apiModel, to.String(), "", body, nil)
 	body = sanitizeCopilotPayload(body, apiModel)
+	body = e.filterUnsupportedParameters(auth, apiModel, body)
+	body = e.normalizeToolChoice(auth, apiModel, body)
+	body, err = e.applyVisionFallback(auth, apiModel, body)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		body, err = e.inlineRemoteImages(ctx, auth, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	body, usageInjected := requestStreamUsage(body)
 	body, _ = sjson.SetBytes(body, "stream", true)
+	e.observeCopilotContextUtilization(apiModel, body)
 
 	// Inject cached Gemini reasoning for models that require it
 	if strings.HasPrefix(strings.ToLower(apiModel), "gemini") {
-		body = e.reasoningCache(auth).InjectReasoning(body)
+		body = e.reasoningCache(auth).InjectReasoning(e.log(), body)
 	}
 
-	baseURL := copilotauth.CopilotBaseURL(accountType)
+	baseURL := e.copilotBaseURL(auth, accountType)
 	url := baseURL + "/chat/completions"
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -333,7 +462,10 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 		return nil, err
 	}
 
-	e.applyCopilotHeaders(httpReq, copilotToken, req.Payload, opts.Headers)
+	profile := e.applyCopilotHeaders(httpReq, auth, copilotToken, req.Payload, opts.Headers)
+	e.annotateCopilotSpan(span, auth, httpReq.Header, apiModel)
+	e.traceCopilotKey(span, auth, apiModel)
+	injectTraceContext(ctx, httpReq.Header)
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -353,40 +485,70 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 		AuthValue: authValue,
 	})
 
+	if dryRun {
+		return dryRunStream(httpReq, body), nil
+	}
+	metrics.RecordHeaderProfile(string(profile))
+
+	releaseSlot, err := e.acquireDispatchSlot(ctx, auth, httpReq.Header.Get("X-Initiator") == "agent")
+	if err != nil {
+		return nil, err
+	}
+
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, err := httpClient.Do(httpReq)
+	httpResp, err := e.doWithRetry(ctx, auth, httpClient, httpReq)
 	if err != nil {
+		releaseSlot()
 		recordAPIResponseError(ctx, e.cfg, err)
 		return nil, err
 	}
 
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	recordSpanUpstreamStatus(span, httpResp.StatusCode)
 
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		defer releaseSlot()
 		data, readErr := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
-			log.Errorf("copilot executor: close response body error: %v", errClose)
+			e.log().Errorf("copilot executor: close response body error: %v", errClose)
 		}
 		if readErr != nil {
 			recordAPIResponseError(ctx, e.cfg, readErr)
 			return nil, readErr
 		}
 		appendAPIResponseChunk(ctx, e.cfg, data)
-		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
-		err = copilotStatusErr(httpResp.StatusCode, string(data))
+		e.logCopilotBodies(e.bodyLogSink(auth), apiModel, httpReq.Header, copilotToken, body, data)
+		e.log().Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		err = copilotStatusErr(httpResp.StatusCode, string(e.normalizeErrorBody(auth, httpResp.StatusCode, data)))
 		return nil, err
 	}
 
+	_, idleTimeout := e.copilotTimeouts(auth)
+	httpResp.Body = newIdleTimeoutBody(httpResp.Body, idleTimeout)
+
 	out := make(chan cliproxyexecutor.StreamChunk)
 	stream = out
+	spanHandedOff = true
 	go func() {
+		var streamErr error
+		defer func() { endExecutorSpan(span, streamErr) }()
 		defer close(out)
+		defer releaseSlot()
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
-				log.Errorf("copilot executor: close response body error: %v", errClose)
+				e.log().Errorf("copilot executor: close response body error: %v", errClose)
 			}
 		}()
 
+		bodySink := e.bodyLogSink(auth)
+		var captured bytes.Buffer
+		if bodySink != nil {
+			defer func() {
+				e.logCopilotBodies(bodySink, apiModel, httpReq.Header, copilotToken, body, captured.Bytes())
+			}()
+		}
+
+		streamUsage := newCopilotStreamUsage(e.log(), apiModel)
 		isGemini := strings.HasPrefix(strings.ToLower(apiModel), "gemini")
 		scanner := bufio.NewScanner(httpResp.Body)
 		bufSize := e.cfg.ScannerBufferSize
@@ -398,17 +560,26 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			appendAPIResponseChunk(ctx, e.cfg, line)
+			if bodySink != nil {
+				captured.Write(line)
+				captured.WriteByte('\n')
+			}
 
 			// Parse usage from final chunk if present
 			if bytes.HasPrefix(line, dataTag) {
 				data := bytes.TrimSpace(line[5:])
-				if gjson.GetBytes(data, "usage").Exists() {
-					reporter.publish(ctx, parseOpenAIUsage(data))
+				if detail, ok := streamUsage.observe(data); ok {
+					reporter.publish(ctx, detail)
+					// Usage was requested by the proxy, not the client; keep it off the wire
+					// for OpenAI clients that did not opt in.
+					if usageInjected && from == to && isUsageOnlyChunk(data) {
+						continue
+					}
 				}
 
 				// Cache Gemini reasoning data for subsequent requests
 				if isGemini {
-					e.reasoningCache(auth).CacheReasoning(data)
+					e.reasoningCache(auth).CacheReasoning(e.log(), data)
 				}
 			}
 
@@ -418,17 +589,31 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 			}
 		}
 		if errScan := scanner.Err(); errScan != nil {
+			streamErr = errScan
 			recordAPIResponseError(ctx, e.cfg, errScan)
 			reporter.publishFailure(ctx)
 			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+			return
+		}
+		if detail, ok := streamUsage.estimate(body); ok {
+			reporter.publish(ctx, detail)
 		}
 	}()
 
 	return stream, nil
 }
 
+// annotateCopilotSpan records the routing decisions made while building the upstream request.
+func (e *CopilotExecutor) annotateCopilotSpan(span trace.Span, auth *cliproxyauth.Auth, headers http.Header, model string) {
+	span.SetAttributes(
+		attribute.String("initiator", headers.Get("X-Initiator")),
+		attribute.String("header_profile", string(copilotHeaderProfileForModel(e.copilotKeyForAuth(auth), model))),
+		attribute.Bool("vision", headers.Get("Copilot-Vision-Request") == "true"),
+	)
+}
+
 func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
-	log.Debugf("copilot executor: refresh called")
+	e.log().Debugf("copilot executor: refresh called")
 	if auth == nil {
 		return nil, statusErr{code: 500, msg: "copilot executor: auth is nil (copilot_refresh_auth_nil)"}
 	}
@@ -447,7 +632,7 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 	}
 
 	if githubToken == "" {
-		log.Debug("copilot executor: no github_token in metadata, skipping refresh")
+		e.log().Debug("copilot executor: no github_token in metadata, skipping refresh")
 		return auth, nil
 	}
 
@@ -482,7 +667,7 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 			}
 		}
 
-		log.Warnf("copilot executor: token refresh failed [cause: %s]: %v", cause, err)
+		e.log().Warnf("copilot executor: token refresh failed [cause: %s]: %v", cause, err)
 		return nil, statusErr{code: code, msg: fmt.Sprintf("copilot token refresh failed (%s): %v", cause, err)}
 	}
 
@@ -503,7 +688,7 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 	auth.Metadata["copilot_token_expiry"] = time.Unix(tokenResp.ExpiresAt, 0).Format(time.RFC3339)
 	auth.Metadata["type"] = "copilot"
 
-	log.Debug("Copilot token refreshed successfully")
+	e.log().Debug("Copilot token refreshed successfully")
 	return auth, nil
 }
 
@@ -518,6 +703,16 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 //
 // Note on metadata: auth.Metadata is used as a runtime cache and may be updated from
 // CopilotTokenStorage. Both are kept in sync when tokens are refreshed.
+// copilotRequestToken returns the bearer token and account type for a request. A dry run
+// never exchanges or refreshes a token: the preview redacts it anyway, so a placeholder
+// keeps the dry run free of network side effects.
+func (e *CopilotExecutor) copilotRequestToken(ctx context.Context, auth *cliproxyauth.Auth, dryRun bool) (string, copilotauth.AccountType, error) {
+	if dryRun {
+		return dryRunRedacted, copilotauth.ResolveAccountType(auth), nil
+	}
+	return e.getCopilotToken(ctx, auth)
+}
+
 func (e *CopilotExecutor) getCopilotToken(ctx context.Context, auth *cliproxyauth.Auth) (string, copilotauth.AccountType, error) {
 	if auth == nil {
 		return "", "", statusErr{code: 500, msg: "copilot executor: auth is nil (copilot_auth_nil)"}
@@ -527,6 +722,15 @@ func (e *CopilotExecutor) getCopilotToken(ctx context.Context, auth *cliproxyaut
 	githubToken := copilotauth.ResolveGitHubToken(auth)
 	accountType := copilotauth.ResolveAccountType(auth)
 
+	// 0. A token from the credential provider or the CopilotKey entry scoped to this
+	// credential is used as-is
+	if token, ok := providerCredential(ctx, e.Identifier(), auth); ok {
+		return token, accountType, nil
+	}
+	if entry := e.copilotKeyScopedToAuth(auth); entry != nil && entry.Token != "" {
+		return entry.Token, accountType, nil
+	}
+
 	// 1. Check Memory Cache
 	if token, valid := e.getValidCachedToken(githubToken); valid {
 		return token, accountType, nil
@@ -595,11 +799,24 @@ func (e *CopilotExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Au
 	to := sdktranslator.FromString("openai")
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), false)
 
+	count, err := countCopilotPromptTokens(apiModel, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+
+	usageJSON := fmt.Sprintf(`{"usage":{"input_tokens":%d,"output_tokens":0}}`, count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, int64(count), []byte(usageJSON))
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
+
+// countCopilotPromptTokens estimates the prompt size of an OpenAI Chat Completions body
+// by tokenizing the text content of its messages.
+func countCopilotPromptTokens(model string, body []byte) (int, error) {
 	// Use tiktoken for token counting via tokenizerForCodexModel helper.
 	// This provides OpenAI-compatible token estimates.
-	enc, err := tokenizerForCodexModel(apiModel)
+	enc, err := tokenizerForCodexModel(model)
 	if err != nil {
-		return cliproxyexecutor.Response{}, fmt.Errorf("copilot executor: tokenizer init failed: %w", err)
+		return 0, fmt.Errorf("copilot executor: tokenizer init failed: %w", err)
 	}
 
 	// Extract messages and count tokens
@@ -623,12 +840,30 @@ func (e *CopilotExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Au
 	text := strings.Join(textParts, "\n")
 	count, err := enc.Count(text)
 	if err != nil {
-		return cliproxyexecutor.Response{}, fmt.Errorf("copilot executor: token counting failed: %w", err)
+		return 0, fmt.Errorf("copilot executor: token counting failed: %w", err)
 	}
+	return count, nil
+}
 
-	usageJSON := fmt.Sprintf(`{"usage":{"input_tokens":%d,"output_tokens":0}}`, count)
-	translated := sdktranslator.TranslateTokenCount(ctx, to, from, int64(count), []byte(usageJSON))
-	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+// observeCopilotContextUtilization records the prompt-to-context-window ratio for the
+// request when metrics are enabled and the model advertises a context length.
+func (e *CopilotExecutor) observeCopilotContextUtilization(model string, body []byte) {
+	if !metrics.Enabled() {
+		return
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(model)
+	if info == nil {
+		info = registry.GetGlobalRegistry().GetModelInfo(registry.CopilotModelPrefix + model)
+	}
+	if info == nil || info.ContextLength <= 0 {
+		return
+	}
+	count, err := countCopilotPromptTokens(model, body)
+	if err != nil {
+		e.log().Debugf("copilot executor: context utilization skipped: %v", err)
+		return
+	}
+	metrics.ObserveContextUtilization(model, count, info.ContextLength)
 }
 
 func getCachedCopilotModels(authID string) []*registry.ModelInfo {
@@ -664,7 +899,7 @@ func EvictCopilotModelCache(authID string) {
 func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
 	// 1. Check Cache
 	if models := getCachedCopilotModels(auth.ID); models != nil {
-		return models
+		return withCopilotAliases(cfg, models)
 	}
 
 	// 2. Resolve Tokens
@@ -689,7 +924,7 @@ func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Au
 	}
 
 	if err != nil || modelsResp == nil {
-		log.Warnf("copilot executor: failed to fetch models for auth %s: %v", auth.ID, err)
+		e.log().Warnf("copilot executor: failed to fetch models for auth %s: %v", auth.ID, err)
 		return nil
 	}
 
@@ -722,6 +957,8 @@ func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Au
 			params = append(params, "tools")
 		}
 		modelInfo.SupportedParameters = params
+		modelInfo.SupportsVision = m.Capabilities.Supports.Vision
+		modelInfo.VisionKnown = true
 		desc := fmt.Sprintf("%s model via GitHub Copilot", m.Vendor)
 		if m.Preview {
 			desc += " (Preview)"
@@ -731,11 +968,19 @@ func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Au
 	}
 
 	// 5. Merge essential models that Copilot supports but may not return in /models
-	models = mergeEssentialCopilotModels(models, now)
+	models = e.mergeEssentialCopilotModels(models, now)
 
-	models = registry.GenerateCopilotAliases(models)
+	// Cache the bare models so toggling alias generation takes effect without a refetch.
 	setCachedCopilotModels(auth.ID, models)
-	return models
+	return withCopilotAliases(cfg, models)
+}
+
+// withCopilotAliases appends copilot- prefixed aliases unless disabled by configuration.
+func withCopilotAliases(cfg *config.Config, models []*registry.ModelInfo) []*registry.ModelInfo {
+	if cfg != nil && cfg.DisableCopilotAliases {
+		return models
+	}
+	return registry.GenerateCopilotAliases(models)
 }
 
 // FetchCopilotModels retrieves available models from the Copilot API using the supplied auth.
@@ -743,12 +988,21 @@ func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Au
 func FetchCopilotModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
 	// Use shared cache - check before creating executor
 	if models := getCachedCopilotModels(auth.ID); models != nil {
-		return models
+		return withCopilotAliases(cfg, models)
 	}
 	e := NewCopilotExecutor(cfg)
 	return e.FetchModels(ctx, auth, cfg)
 }
 
+// normalizeErrorBody rewraps a non-OpenAI Copilot error body into the OpenAI error envelope
+// when the credential's CopilotKey enables normalize-errors.
+func (e *CopilotExecutor) normalizeErrorBody(auth *cliproxyauth.Auth, status int, body []byte) []byte {
+	if entry := e.copilotKeyForAuth(auth); entry == nil || !entry.NormalizeErrors {
+		return body
+	}
+	return interfaces.NormalizeOpenAIErrorBody(status, body)
+}
+
 // copilotStatusErr creates a statusErr with appropriate retry timing for Copilot.
 // For 429 errors, it sets a longer retry delay (30 seconds) since Copilot quota
 // limits typically require more time to recover than standard rate limits.
@@ -4,13 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
 )
 
 // TestStripCopilotPrefix verifies that the copilot- prefix is correctly stripped from model names.
@@ -215,6 +223,66 @@ func TestCopilotExecutor_getCopilotToken_MissingToken(t *testing.T) {
 	}
 }
 
+// TestCopilotExecutor_getCopilotToken_ConfiguredToken tests that a token resolved on the
+// CopilotKey entry is used without a GitHub token exchange.
+func TestCopilotExecutor_getCopilotToken_ConfiguredToken(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "test-auth", Token: "configured-token"}}})
+	auth := &cliproxyauth.Auth{ID: "test-auth", Metadata: map[string]any{}}
+
+	token, _, err := e.getCopilotToken(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "configured-token" {
+		t.Fatalf("token = %q, want configured-token", token)
+	}
+}
+
+// TestCopilotExecutor_getCopilotToken_ConfiguredTokenIsScoped tests that configured tokens
+// only replace the token of the credential their entry is scoped to.
+func TestCopilotExecutor_getCopilotToken_ConfiguredTokenIsScoped(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", Token: "alice-token", BaseURL: "https://alice.example.com"},
+		{Account: "bob", Token: "bob-token", BaseURL: "https://bob.example.com"},
+	}})
+	oauth := &cliproxyauth.Auth{ID: "carol", Metadata: map[string]any{
+		"copilot_token":        "carol-oauth-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+
+	token, accountType, err := e.getCopilotToken(context.Background(), oauth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "carol-oauth-token" {
+		t.Fatalf("token = %q, want the credential's own OAuth token", token)
+	}
+	if got := e.copilotBaseURL(oauth, accountType); got != copilotauth.CopilotBaseURL(accountType) {
+		t.Fatalf("base URL = %q, another account's override leaked", got)
+	}
+
+	token, _, err = e.getCopilotToken(context.Background(), &cliproxyauth.Auth{ID: "bob", Metadata: map[string]any{}})
+	if err != nil || token != "bob-token" {
+		t.Fatalf("bob: token = %q, err = %v; want bob-token", token, err)
+	}
+}
+
+// TestCopilotExecutor_copilotBaseURL_UnscopedEntry tests that an entry without an Account
+// still redirects the credentials it serves.
+func TestCopilotExecutor_copilotBaseURL_UnscopedEntry(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", BaseURL: "https://alice.example.com"},
+		{BaseURL: "https://mock.example.com/"},
+	}})
+
+	if got := e.copilotBaseURL(&cliproxyauth.Auth{ID: "alice"}, copilotauth.AccountTypeIndividual); got != "https://alice.example.com" {
+		t.Fatalf("alice base URL = %q, want https://alice.example.com", got)
+	}
+	if got := e.copilotBaseURL(&cliproxyauth.Auth{ID: "carol"}, copilotauth.AccountTypeIndividual); got != "https://mock.example.com" {
+		t.Fatalf("carol base URL = %q, want https://mock.example.com", got)
+	}
+}
+
 // TestCopilotExecutor_getCopilotToken_RehydrateFromStorage tests that tokens are rehydrated from storage.
 func TestCopilotExecutor_getCopilotToken_RehydrateFromStorage(t *testing.T) {
 	e := NewCopilotExecutor(&config.Config{})
@@ -716,3 +784,173 @@ func TestMaskToken(t *testing.T) {
 		})
 	}
 }
+
+// TestCopilotExecutor_FilterUnsupportedParameters verifies the filter is opt-in per entry
+// and resolves the copilot- alias registration.
+func TestCopilotExecutor_FilterUnsupportedParameters(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	clientID := "test-copilot-param-filter"
+	reg.RegisterClient(clientID, "copilot", registry.GenerateCopilotAliases([]*registry.ModelInfo{{
+		ID:                  "param-filter-copilot-model",
+		Object:              "model",
+		Created:             time.Now().Unix(),
+		OwnedBy:             "copilot",
+		SupportedParameters: []string{"temperature", "stream"},
+	}}))
+	defer reg.UnregisterClient(clientID)
+
+	body := []byte(`{"model":"param-filter-copilot-model","messages":[],"temperature":1,"logprobs":true}`)
+
+	disabled := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	if out := disabled.filterUnsupportedParameters(nil, "param-filter-copilot-model", body); string(out) != string(body) {
+		t.Fatalf("expected body unchanged when disabled, got %s", out)
+	}
+
+	enabled := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{FilterUnsupportedParams: true}}})
+	out := enabled.filterUnsupportedParameters(nil, "param-filter-copilot-model", body)
+	if string(out) != `{"model":"param-filter-copilot-model","messages":[],"temperature":1}` {
+		t.Fatalf("expected logprobs removed, got %s", out)
+	}
+
+	scoped := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", FilterUnsupportedParams: true},
+		{Account: "bob"},
+	}})
+	if out := scoped.filterUnsupportedParameters(&cliproxyauth.Auth{ID: "bob"}, "param-filter-copilot-model", body); string(out) != string(body) {
+		t.Fatalf("expected alice's setting not to apply to bob, got %s", out)
+	}
+	if out := scoped.filterUnsupportedParameters(&cliproxyauth.Auth{ID: "alice"}, "param-filter-copilot-model", body); string(out) == string(body) {
+		t.Fatal("expected logprobs removed for alice")
+	}
+}
+
+func TestWithCopilotAliases(t *testing.T) {
+	base := []*registry.ModelInfo{{ID: "gpt-4.1"}, {ID: "claude-sonnet-4"}}
+	countAliases := func(models []*registry.ModelInfo) int {
+		n := 0
+		for _, m := range models {
+			if strings.HasPrefix(m.ID, registry.CopilotModelPrefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	enabled := withCopilotAliases(&config.Config{}, base)
+	if len(enabled) != 2*len(base) || countAliases(enabled) != len(base) {
+		t.Fatalf("expected aliases to double the model list, got %d models (%d aliases)", len(enabled), countAliases(enabled))
+	}
+
+	disabled := withCopilotAliases(&config.Config{DisableCopilotAliases: true}, base)
+	if len(disabled) != len(base) || countAliases(disabled) != 0 {
+		t.Fatalf("expected no aliases when disabled, got %d models (%d aliases)", len(disabled), countAliases(disabled))
+	}
+}
+
+// TestFetchCopilotModels_CacheHitIncludesAliases tests that a warm cache returns the same
+// aliased list as a fresh fetch.
+func TestFetchCopilotModels_CacheHitIncludesAliases(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "copilot-cache-alias-test", Provider: "copilot"}
+	setCachedCopilotModels(auth.ID, []*registry.ModelInfo{{ID: "gpt-4.1"}})
+	t.Cleanup(func() { EvictCopilotModelCache(auth.ID) })
+
+	models := FetchCopilotModels(context.Background(), auth, &config.Config{})
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	if len(ids) != 2 || ids[0] != "gpt-4.1" || ids[1] != registry.CopilotModelPrefix+"gpt-4.1" {
+		t.Fatalf("cached models = %v, want [gpt-4.1 %sgpt-4.1]", ids, registry.CopilotModelPrefix)
+	}
+
+	models = FetchCopilotModels(context.Background(), auth, &config.Config{DisableCopilotAliases: true})
+	if len(models) != 1 {
+		t.Fatalf("expected no aliases when disabled, got %d models", len(models))
+	}
+}
+
+// TestCopilotExecutor_BaseURLOverride tests that a CopilotKey BaseURL redirects the upstream
+// call while the Copilot headers are still applied.
+func TestCopilotExecutor_BaseURLOverride(t *testing.T) {
+	var gotPath string
+	var gotHeader http.Header
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "base-url-auth", BaseURL: srv.URL}}})
+	auth := &cliproxyauth.Auth{ID: "base-url-auth", Metadata: map[string]any{
+		"copilot_token":        "test-copilot-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+	payload := []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hi"}]}`)
+
+	resp, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4.1", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/chat/completions" {
+		t.Fatalf("upstream path = %q, want /chat/completions", gotPath)
+	}
+	if got := gotHeader.Get("Authorization"); got != "Bearer test-copilot-token" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	if got := gotHeader.Get("X-Initiator"); got != "user" {
+		t.Fatalf("X-Initiator = %q, want user", got)
+	}
+	if gotHeader.Get("Openai-Intent") == "" || gotHeader.Get("X-Request-Id") == "" {
+		t.Fatalf("expected Copilot headers, got %v", gotHeader)
+	}
+	if gjson.GetBytes(gotBody, "model").String() != "gpt-4.1" {
+		t.Fatalf("upstream body = %s", gotBody)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "ok" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+// TestCopilotExecutor_ExecuteForwardsToolOutputImage sends a Responses request whose
+// function_call_output carries a screenshot through Execute and checks that the translated
+// upstream body keeps the image as a tool content part and that vision is advertised.
+func TestCopilotExecutor_ExecuteForwardsToolOutputImage(t *testing.T) {
+	var upstreamBody []byte
+	var visionHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamBody, _ = io.ReadAll(r.Body)
+		visionHeader = r.Header.Get("Copilot-Vision-Request")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{BaseURL: srv.URL}}})
+	auth := &cliproxyauth.Auth{ID: "tool-image-auth", Metadata: map[string]any{
+		"copilot_token":        "test-copilot-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+	payload := []byte(`{"model":"gpt-4.1","input":[
+		{"role":"user","content":[{"type":"input_text","text":"what is on screen?"}]},
+		{"type":"function_call","call_id":"call_shot","name":"screenshot","arguments":"{}"},
+		{"type":"function_call_output","call_id":"call_shot","output":[{"type":"input_image","image_url":"data:image/png;base64,AAAA"}]}
+	]}`)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response"), OriginalRequest: payload}
+
+	if _, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4.1", Payload: payload}, opts); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	tool := gjson.GetBytes(upstreamBody, `messages.#(role=="tool")`)
+	if got := tool.Get("content.0.image_url.url").String(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("tool message content = %s, want the screenshot as an image_url part", tool.Get("content").Raw)
+	}
+	if visionHeader != "true" {
+		t.Fatalf("Copilot-Vision-Request = %q, want true", visionHeader)
+	}
+}
@@ -62,7 +62,7 @@ func EvictCopilotGeminiReasoningCache(authID string) {
 
 // InjectReasoning inserts cached reasoning fields back into assistant messages
 // for tool calls (required by Gemini 3 models).
-func (c *geminiReasoningCache) InjectReasoning(body []byte) []byte {
+func (c *geminiReasoningCache) InjectReasoning(logger *log.Logger, body []byte) []byte {
 	// Find assistant messages with tool_calls that are missing reasoning fields
 	messages := gjson.GetBytes(body, "messages")
 	if !messages.Exists() || !messages.IsArray() {
@@ -73,7 +73,7 @@ func (c *geminiReasoningCache) InjectReasoning(body []byte) []byte {
 	defer c.mu.RUnlock()
 
 	if len(c.cache) == 0 {
-		log.Debug("copilot executor: no cached Gemini reasoning available")
+		logger.Debug("copilot executor: no cached Gemini reasoning available")
 		return body
 	}
 
@@ -109,17 +109,17 @@ func (c *geminiReasoningCache) InjectReasoning(body []byte) []byte {
 
 		reasoning := c.cache[callID]
 		if reasoning == nil || (reasoning.Opaque == "" && reasoning.Text == "") {
-			log.Debugf("copilot executor: no cached reasoning for call_id %s", callID)
+			logger.Debugf("copilot executor: no cached reasoning for call_id %s", callID)
 			return true
 		}
 
 		// Check TTL
 		if time.Since(reasoning.createdAt) > geminiReasoningTTL {
-			log.Debugf("copilot executor: cached reasoning for call_id %s expired", callID)
+			logger.Debugf("copilot executor: cached reasoning for call_id %s expired", callID)
 			return true
 		}
 
-		log.Debugf("copilot executor: injecting reasoning for call_id %s (opaque=%d chars, text=%d chars)", callID, len(reasoning.Opaque), len(reasoning.Text))
+		logger.Debugf("copilot executor: injecting reasoning for call_id %s (opaque=%d chars, text=%d chars)", callID, len(reasoning.Opaque), len(reasoning.Text))
 
 		msgPath := fmt.Sprintf("messages.%d", msgIdx)
 		if reasoning.Opaque != "" {
@@ -134,13 +134,13 @@ func (c *geminiReasoningCache) InjectReasoning(body []byte) []byte {
 	})
 
 	if modified {
-		log.Debug("copilot executor: injected cached Gemini reasoning into request")
+		logger.Debug("copilot executor: injected cached Gemini reasoning into request")
 	}
 	return body
 }
 
 // CacheReasoning captures reasoning fields from streaming deltas.
-func (c *geminiReasoningCache) CacheReasoning(data []byte) {
+func (c *geminiReasoningCache) CacheReasoning(logger *log.Logger, data []byte) {
 	delta := gjson.GetBytes(data, "choices.0.delta")
 	if !delta.Exists() {
 		return
@@ -173,7 +173,7 @@ func (c *geminiReasoningCache) CacheReasoning(data []byte) {
 		return
 	}
 
-	log.Debugf("copilot executor: caching Gemini reasoning for call_id %s (opaque=%d chars, text=%d chars)", callID, len(opaque), len(text))
+	logger.Debugf("copilot executor: caching Gemini reasoning for call_id %s (opaque=%d chars, text=%d chars)", callID, len(opaque), len(text))
 
 	if c.cache[callID] == nil {
 		c.cache[callID] = &geminiReasoning{
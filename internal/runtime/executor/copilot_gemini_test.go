@@ -4,6 +4,7 @@
  	"testing"
  	"time"
  
+ 	log "github.com/sirupsen/logrus"
  	"github.com/tidwall/gjson"
  )
  
@@ -12,7 +13,7 @@
  
  	// Cache reasoning from a streaming delta
  	delta := `{"choices":[{"delta":{"tool_calls":[{"id":"call_123"}],"reasoning_opaque":"opaque_data","reasoning_text":"thinking..."}}]}`
- 	cache.CacheReasoning([]byte(delta))
+ 	cache.CacheReasoning(log.StandardLogger(), []byte(delta))
  
  	// Verify it was cached
  	if cache.cache["call_123"] == nil {
@@ -27,7 +28,7 @@
  
  	// Inject into a request body
  	body := `{"messages":[{"role":"assistant","tool_calls":[{"id":"call_123"}]}]}`
- 	result := cache.InjectReasoning([]byte(body))
+ 	result := cache.InjectReasoning(log.StandardLogger(), []byte(body))
  
  	// Verify injection
  	if !gjson.GetBytes(result, "messages.0.reasoning_opaque").Exists() {
@@ -45,8 +46,8 @@
  	chunk1 := `{"choices":[{"delta":{"tool_calls":[{"id":"call_456"}],"reasoning_text":"Hello "}}]}`
  	chunk2 := `{"choices":[{"delta":{"tool_calls":[{"id":"call_456"}],"reasoning_text":"World"}}]}`
  
- 	cache.CacheReasoning([]byte(chunk1))
- 	cache.CacheReasoning([]byte(chunk2))
+ 	cache.CacheReasoning(log.StandardLogger(), []byte(chunk1))
+ 	cache.CacheReasoning(log.StandardLogger(), []byte(chunk2))
  
  	if cache.cache["call_456"].Text != "Hello World" {
  		t.Errorf("Text = %q, want %q", cache.cache["call_456"].Text, "Hello World")
@@ -57,11 +58,11 @@
  	cache := newGeminiReasoningCache()
  
  	delta := `{"choices":[{"delta":{"tool_calls":[{"id":"call_789"}],"reasoning_opaque":"cached"}}]}`
- 	cache.CacheReasoning([]byte(delta))
+ 	cache.CacheReasoning(log.StandardLogger(), []byte(delta))
  
  	// Body already has reasoning_opaque
  	body := `{"messages":[{"role":"assistant","tool_calls":[{"id":"call_789"}],"reasoning_opaque":"existing"}]}`
- 	result := cache.InjectReasoning([]byte(body))
+ 	result := cache.InjectReasoning(log.StandardLogger(), []byte(body))
  
  	// Should keep existing value
  	if gjson.GetBytes(result, "messages.0.reasoning_opaque").String() != "existing" {
@@ -79,7 +80,7 @@
  	}
  
  	body := `{"messages":[{"role":"assistant","tool_calls":[{"id":"expired_call"}]}]}`
- 	result := cache.InjectReasoning([]byte(body))
+ 	result := cache.InjectReasoning(log.StandardLogger(), []byte(body))
  
  	// Should not inject expired reasoning
  	if gjson.GetBytes(result, "messages.0.reasoning_opaque").Exists() {
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// CopilotModelHeaderProfile is the header profile a Copilot request for Model would use.
+type CopilotModelHeaderProfile struct {
+	Model   string `json:"model"`
+	Profile string `json:"profile"`
+}
+
+// CopilotHeaderProfileReport lists the resolved header profile per known model for one
+// copilot-api-key entry.
+type CopilotHeaderProfileReport struct {
+	Account       string                      `json:"account,omitempty"`
+	HeaderProfile string                      `json:"header_profile,omitempty"`
+	Models        []CopilotModelHeaderProfile `json:"models"`
+}
+
+// CopilotHeaderProfiles resolves the header profile of every known model for each
+// copilot-api-key entry in cfg, or for the built-in defaults when none is configured.
+// Known models are the built-in CLI allowlist, the models named in the entry's
+// cli-header-models and vscode-chat-header-models, and the registered Copilot models.
+func CopilotHeaderProfiles(cfg *config.Config) []CopilotHeaderProfileReport {
+	var entries []*config.CopilotKey
+	if cfg != nil {
+		for i := range cfg.CopilotKey {
+			entries = append(entries, &cfg.CopilotKey[i])
+		}
+	}
+	if len(entries) == 0 {
+		entries = []*config.CopilotKey{nil}
+	}
+
+	registered := make([]string, 0)
+	for _, info := range registry.GetGlobalRegistry().GetAvailableModelsByProvider("copilot") {
+		if info != nil {
+			registered = append(registered, info.ID)
+		}
+	}
+
+	reports := make([]CopilotHeaderProfileReport, 0, len(entries))
+	for _, entry := range entries {
+		models := make(map[string]struct{}, len(defaultCopilotCLIHeaderModels)+len(registered))
+		add := func(model string) {
+			if id := strings.TrimPrefix(normalizeModelID(model), registry.CopilotModelPrefix); id != "" {
+				models[id] = struct{}{}
+			}
+		}
+		for model := range defaultCopilotCLIHeaderModels {
+			add(model)
+		}
+		for _, model := range registered {
+			add(model)
+		}
+		report := CopilotHeaderProfileReport{}
+		if entry != nil {
+			report.Account = strings.TrimSpace(entry.Account)
+			report.HeaderProfile = strings.TrimSpace(entry.HeaderProfile)
+			for _, model := range entry.CLIHeaderModels {
+				add(model)
+			}
+			for _, model := range entry.VSCodeChatHeaderModels {
+				add(model)
+			}
+		}
+		report.Models = make([]CopilotModelHeaderProfile, 0, len(models))
+		for model := range models {
+			report.Models = append(report.Models, CopilotModelHeaderProfile{
+				Model:   model,
+				Profile: string(copilotHeaderProfileForModel(entry, model)),
+			})
+		}
+		sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Model < report.Models[j].Model })
+		reports = append(reports, report)
+	}
+	return reports
+}
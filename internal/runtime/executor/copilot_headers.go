@@ -4,9 +4,10 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
-	log "github.com/sirupsen/logrus"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/tidwall/gjson"
 )
 
@@ -49,15 +50,34 @@ func isResponsesAPIVisionContent(part gjson.Result) bool {
 	return part.Get("type").String() == "input_image"
 }
 
+// hasResponsesAPIVisionPart reports whether a Responses API content or output array
+// contains an image part.
+func hasResponsesAPIVisionPart(parts gjson.Result) bool {
+	if !parts.IsArray() {
+		return false
+	}
+	for _, part := range parts.Array() {
+		if isResponsesAPIVisionContent(part) {
+			return true
+		}
+	}
+	return false
+}
+
 type copilotHeaderHints struct {
 	hasVision             bool
+	visionUnsupported     bool
 	userFromPayload       bool
 	lastUserFromPayload   bool
 	agentFromPayload      bool
 	forceAgentFromHeaders bool
 	promptCacheKey        string
+	model                 string
 }
 
+// copilotDefaultIntent is the X-Interaction-Type and Openai-Intent value sent by Copilot CLI.
+const copilotDefaultIntent = "conversation-agent"
+
 type copilotHeaderProfile string
 
 const (
@@ -142,38 +162,160 @@ func copilotHeaderProfileForModel(entry *config.CopilotKey, model string) copilo
 	return copilotHeaderProfileVSCodeChat
 }
 
-func applyCopilotVSCodeChatHeaderProfile(r *http.Request) {
+// copilotVSCodeChatOverridableHeaders lists the vscode-chat profile headers that
+// CopilotKey.VSCodeChatHeaders may override.
+var copilotVSCodeChatOverridableHeaders = map[string]struct{}{
+	"Copilot-Integration-Id": {},
+	"Editor-Plugin-Version":  {},
+	"Editor-Version":         {},
+}
+
+// copilotZeroVSCodeID is the session/machine ID sent when none is configured.
+const copilotZeroVSCodeID = "00000000-0000-0000-0000-000000000000"
+
+// Stable IDs used for vscode-session-id/vscode-machine-id "auto", generated once per process.
+var (
+	copilotAutoVSCodeSessionID = uuid.NewString()
+	copilotAutoVSCodeMachineID = uuid.NewString()
+)
+
+// copilotVSCodeID resolves a configured session/machine ID: empty keeps the zero UUID and
+// "auto" selects the per-process generated one.
+func copilotVSCodeID(configured, auto string) string {
+	configured = strings.TrimSpace(configured)
+	switch {
+	case configured == "":
+		return copilotZeroVSCodeID
+	case strings.EqualFold(configured, "auto"):
+		return auto
+	default:
+		return configured
+	}
+}
+
+func applyCopilotVSCodeChatHeaderProfile(r *http.Request, entry *config.CopilotKey) {
 	// Matches VS Code Copilot Chat extension behavior
 	r.Header.Set("Copilot-Integration-Id", "vscode-chat")
 	r.Header.Set("Editor-Plugin-Version", "copilot-chat/0.35.2")
 	r.Header.Set("Editor-Version", "vscode/1.108.0-insider")
-	r.Header.Set("VScode-SessionId", "00000000-0000-0000-0000-000000000000")
-	r.Header.Set("VScode-MachineId", "00000000-0000-0000-0000-000000000000")
+	r.Header.Set("VScode-SessionId", copilotZeroVSCodeID)
+	r.Header.Set("VScode-MachineId", copilotZeroVSCodeID)
 	r.Header.Set("OpenAI-Intent", "conversation-agent")
+	if entry == nil {
+		return
+	}
+	r.Header.Set("VScode-SessionId", copilotVSCodeID(entry.VSCodeSessionID, copilotAutoVSCodeSessionID))
+	r.Header.Set("VScode-MachineId", copilotVSCodeID(entry.VSCodeMachineID, copilotAutoVSCodeMachineID))
+	for key, value := range entry.VSCodeChatHeaders {
+		name := http.CanonicalHeaderKey(strings.TrimSpace(key))
+		if _, ok := copilotVSCodeChatOverridableHeaders[name]; !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		r.Header.Set(name, strings.TrimSpace(value))
+	}
 }
 
 func applyCopilotCLIHeaderProfile(r *http.Request) {
 	// No-op: defaults are already applied via copilotauth.CopilotHeaders + executor extras.
 }
 
+// copilotKeyConfig returns the default CopilotKey entry, used when no credential is known.
 func (e *CopilotExecutor) copilotKeyConfig() *config.CopilotKey {
-	if e == nil || e.cfg == nil || len(e.cfg.CopilotKey) == 0 {
+	return e.copilotKeyForAuth(nil)
+}
+
+// copilotKeyForAuth resolves the CopilotKey entry that owns the given credential. An
+// entry whose Account matches the auth ID, GitHub username or email wins; otherwise the
+// first entry without an Account is used. It returns nil when every entry is scoped to
+// another account.
+func (e *CopilotExecutor) copilotKeyForAuth(auth *cliproxyauth.Auth) *config.CopilotKey {
+	if entry := e.copilotKeyScopedToAuth(auth); entry != nil {
+		return entry
+	}
+	if e == nil || e.cfg == nil {
+		return nil
+	}
+	for i := range e.cfg.CopilotKey {
+		if strings.TrimSpace(e.cfg.CopilotKey[i].Account) == "" {
+			return &e.cfg.CopilotKey[i]
+		}
+	}
+	return nil
+}
+
+// copilotKeyScopedToAuth returns the CopilotKey entry whose Account matches the auth ID,
+// GitHub username or email, or nil. The credential-bearing Token setting is only
+// taken from this entry, never from an unscoped one.
+func (e *CopilotExecutor) copilotKeyScopedToAuth(auth *cliproxyauth.Auth) *config.CopilotKey {
+	if e == nil || e.cfg == nil {
 		return nil
 	}
-	return &e.cfg.CopilotKey[0]
+	identities := copilotAuthIdentities(auth)
+	for i := range e.cfg.CopilotKey {
+		entry := &e.cfg.CopilotKey[i]
+		account := strings.TrimSpace(entry.Account)
+		if account == "" {
+			continue
+		}
+		for _, identity := range identities {
+			if strings.EqualFold(account, identity) {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+// copilotBaseURL returns the BaseURL override of the entry serving auth, falling back to
+// the endpoint for the account type.
+func (e *CopilotExecutor) copilotBaseURL(auth *cliproxyauth.Auth, accountType copilotauth.AccountType) string {
+	override := ""
+	if entry := e.copilotKeyForAuth(auth); entry != nil {
+		override = entry.BaseURL
+	}
+	return copilotauth.ResolveBaseURL(override, accountType)
 }
 
-func (e *CopilotExecutor) applyCopilotHeaderProfile(r *http.Request, model string) {
-	entry := e.copilotKeyConfig()
+// copilotAuthIdentities lists the identifiers a CopilotKey Account may match for a credential.
+func copilotAuthIdentities(auth *cliproxyauth.Auth) []string {
+	if auth == nil {
+		return nil
+	}
+	var identities []string
+	add := func(value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			identities = append(identities, value)
+		}
+	}
+	add(auth.ID)
+	if auth.Metadata != nil {
+		if v, ok := auth.Metadata["username"].(string); ok {
+			add(v)
+		}
+		if v, ok := auth.Metadata["email"].(string); ok {
+			add(v)
+		}
+	}
+	if storage, ok := auth.Storage.(*copilotauth.CopilotTokenStorage); ok && storage != nil {
+		add(storage.Username)
+		add(storage.Email)
+	}
+	return identities
+}
+
+// applyCopilotHeaderProfile applies the header profile selected for model and returns it.
+func applyCopilotHeaderProfile(r *http.Request, entry *config.CopilotKey, model string) copilotHeaderProfile {
 	profile := copilotHeaderProfileForModel(entry, model)
 	switch profile {
 	case copilotHeaderProfileVSCodeChat:
-		applyCopilotVSCodeChatHeaderProfile(r)
+		applyCopilotVSCodeChatHeaderProfile(r, entry)
 	case copilotHeaderProfileCLI:
 		applyCopilotCLIHeaderProfile(r)
 	default:
+		profile = copilotHeaderProfileCLI
 		applyCopilotCLIHeaderProfile(r)
 	}
+	return profile
 }
 
 func forceAgentCallFromHeaders(headers http.Header) bool {
@@ -195,6 +337,21 @@ func forceAgentCallFromHeaders(headers http.Header) bool {
 	}
 }
 
+// copilotPromptCacheKeyHeader carries a prompt cache key injected by gateways that cannot edit the body.
+const copilotPromptCacheKeyHeader = "X-Prompt-Cache-Key"
+
+// resolvePromptCacheKey returns the prompt cache key for a request. Resolution order:
+// payload prompt_cache_key, payload metadata.prompt_cache_key, then the X-Prompt-Cache-Key header.
+func resolvePromptCacheKey(payload []byte, headers http.Header) string {
+	if key := promptCacheKeyFromPayload(payload); key != "" {
+		return key
+	}
+	if headers == nil {
+		return ""
+	}
+	return strings.TrimSpace(headers.Get(copilotPromptCacheKeyHeader))
+}
+
 func promptCacheKeyFromPayload(payload []byte) string {
 	if v := gjson.GetBytes(payload, "prompt_cache_key"); v.Exists() {
 		if key := strings.TrimSpace(v.String()); key != "" {
@@ -209,10 +366,34 @@ func promptCacheKeyFromPayload(payload []byte) string {
 	return ""
 }
 
-func collectCopilotHeaderHints(payload []byte, headers http.Header) copilotHeaderHints {
+// defaultCopilotHintScanMaxBytes bounds how much of a payload collectCopilotHeaderHints will
+// parse. It is deliberately generous (32 MiB) so only pathological bodies hit the limit.
+const defaultCopilotHintScanMaxBytes = 32 << 20
+
+// copilotHintScanMaxBytes returns the configured hint scan limit or the default.
+func copilotHintScanMaxBytes(entry *config.CopilotKey) int {
+	if entry != nil && entry.HintScanMaxBytes > 0 {
+		return entry.HintScanMaxBytes
+	}
+	return defaultCopilotHintScanMaxBytes
+}
+
+// collectCopilotHeaderHints derives initiator, vision, and cache-key hints from the payload.
+// Payloads larger than maxScanBytes are not parsed: the hints fall back to an agent call
+// without vision, using only the incoming headers.
+func (e *CopilotExecutor) collectCopilotHeaderHints(payload []byte, headers http.Header, maxScanBytes int) copilotHeaderHints {
+	if maxScanBytes > 0 && len(payload) > maxScanBytes {
+		e.log().Debugf("copilot executor: payload of %d bytes exceeds hint scan limit %d, skipping hint collection", len(payload), maxScanBytes)
+		return copilotHeaderHints{
+			agentFromPayload:      true,
+			forceAgentFromHeaders: forceAgentCallFromHeaders(headers),
+			promptCacheKey:        resolvePromptCacheKey(nil, headers),
+		}
+	}
 	hints := copilotHeaderHints{
-		promptCacheKey:        promptCacheKeyFromPayload(payload),
+		promptCacheKey:        resolvePromptCacheKey(payload, headers),
 		forceAgentFromHeaders: forceAgentCallFromHeaders(headers),
+		model:                 gjson.GetBytes(payload, "model").String(),
 	}
 
 	// Conservative checks: any of these fields indicate agent/continuation context.
@@ -265,13 +446,10 @@ func collectCopilotHeaderHints(payload []byte, headers http.Header) copilotHeade
 	if input.IsArray() {
 		arr := input.Array()
 		for i, item := range arr {
-			content := item.Get("content")
-			if content.IsArray() {
-				for _, part := range content.Array() {
-					if isResponsesAPIVisionContent(part) {
-						hints.hasVision = true
-					}
-				}
+			// Tool results (function_call_output) carry their parts under output, e.g. a
+			// screenshot returned by a computer-use tool.
+			if hasResponsesAPIVisionPart(item.Get("content")) || hasResponsesAPIVisionPart(item.Get("output")) {
+				hints.hasVision = true
 			}
 			role := strings.ToLower(strings.TrimSpace(item.Get("role").String()))
 			if role == "user" {
@@ -290,9 +468,35 @@ func collectCopilotHeaderHints(payload []byte, headers http.Header) copilotHeade
 		}
 	}
 
+	// Responses API requests may carry the whole prompt in top-level instructions with an
+	// empty input. Without any agent signal that is still a fresh user turn.
+	if !hints.userFromPayload && isEmptyHintList(messages) && isEmptyHintList(input) &&
+		strings.TrimSpace(gjson.GetBytes(payload, "instructions").String()) != "" {
+		hints.userFromPayload = true
+		hints.lastUserFromPayload = true
+	}
+
+	// Flag image payloads aimed at a model the registry knows cannot accept images.
+	hints.visionUnsupported = hints.hasVision && copilotModelLacksVision(hints.model)
+
 	return hints
 }
 
+// isEmptyHintList reports whether a messages/input field is missing, an empty array, or a
+// blank string.
+func isEmptyHintList(v gjson.Result) bool {
+	switch {
+	case !v.Exists() || v.Type == gjson.Null:
+		return true
+	case v.IsArray():
+		return len(v.Array()) == 0
+	case v.Type == gjson.String:
+		return strings.TrimSpace(v.String()) == ""
+	default:
+		return false
+	}
+}
+
 func (e *CopilotExecutor) forceAgentCallEnabled() bool {
 	if e == nil || e.cfg == nil {
 		return false
@@ -305,6 +509,24 @@ func (e *CopilotExecutor) forceAgentCallEnabled() bool {
 	return false
 }
 
+// forceAgentCallForModel reports whether entry lists the model in ForceAgentCallModels.
+// Comparison uses the de-aliased model (copilot- prefix stripped).
+func forceAgentCallForModel(entry *config.CopilotKey, model string) bool {
+	if entry == nil {
+		return false
+	}
+	m := strings.TrimPrefix(normalizeModelID(model), "copilot-")
+	if m == "" {
+		return false
+	}
+	for _, v := range entry.ForceAgentCallModels {
+		if strings.TrimPrefix(normalizeModelID(v), "copilot-") == m {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *CopilotExecutor) agentInitiatorPersistEnabled() bool {
 	if e == nil || e.cfg == nil {
 		return false
@@ -317,7 +539,7 @@ func (e *CopilotExecutor) agentInitiatorPersistEnabled() bool {
 	return false
 }
 
-func (e *CopilotExecutor) shouldUseAgentInitiator(h copilotHeaderHints) bool {
+func (e *CopilotExecutor) shouldUseAgentInitiator(entry *config.CopilotKey, h copilotHeaderHints) bool {
 	// Policy: ONLY an outbound payload that is literally just a user message
 	// should be marked as X-Initiator=user. Everything else is agent/runtime.
 	//
@@ -329,6 +551,9 @@ func (e *CopilotExecutor) shouldUseAgentInitiator(h copilotHeaderHints) bool {
 	if e != nil && e.forceAgentCallEnabled() {
 		return true
 	}
+	if forceAgentCallForModel(entry, h.model) {
+		return true
+	}
 
 	// If the payload contains any agent/runtime signal, it's agent.
 	if h.agentFromPayload {
@@ -352,8 +577,8 @@ func (e *CopilotExecutor) shouldUseAgentInitiator(h copilotHeaderHints) bool {
 	// as agent even if the payload is identical.
 	if e != nil && e.agentInitiatorPersistEnabled() && h.promptCacheKey != "" {
 		e.mu.Lock()
-		count := e.initiatorCount[h.promptCacheKey]
-		e.initiatorCount[h.promptCacheKey] = count + 1
+		e.initiatorCount.resize(e.initiatorCacheSize())
+		count := e.initiatorCount.increment(h.promptCacheKey)
 		e.mu.Unlock()
 		return count > 0
 	}
@@ -361,36 +586,156 @@ func (e *CopilotExecutor) shouldUseAgentInitiator(h copilotHeaderHints) bool {
 	return false
 }
 
+// defaultCopilotStainlessHeaders are the client-identity headers sent by the Copilot CLI's
+// OpenAI SDK. CopilotKey.StainlessHeaders can override individual values.
+var defaultCopilotStainlessHeaders = []struct {
+	name  string
+	value string
+}{
+	{"X-Stainless-Retry-Count", "0"},
+	{"X-Stainless-Lang", "js"},
+	{"X-Stainless-Package-Version", "5.20.1"},
+	{"X-Stainless-OS", "Linux"},
+	{"X-Stainless-Arch", "arm64"},
+	{"X-Stainless-Runtime", "node"},
+	{"X-Stainless-Runtime-Version", "v22.15.0"},
+}
+
+// copilotStainlessHeaderName canonicalizes a configured Stainless header key. Keys may be
+// given as full header names ("X-Stainless-OS") or without the prefix ("OS").
+func copilotStainlessHeaderName(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return ""
+	}
+	if !strings.HasPrefix(strings.ToLower(key), "x-stainless-") {
+		key = "X-Stainless-" + key
+	}
+	return http.CanonicalHeaderKey(key)
+}
+
+// applyCopilotStainlessHeaders sets the default Stainless headers, then applies any
+// overrides configured on the CopilotKey.
+func applyCopilotStainlessHeaders(r *http.Request, entry *config.CopilotKey) {
+	for _, h := range defaultCopilotStainlessHeaders {
+		r.Header.Set(h.name, h.value)
+	}
+	if entry == nil {
+		return
+	}
+	for key, value := range entry.StainlessHeaders {
+		name := copilotStainlessHeaderName(key)
+		if name == "" || strings.TrimSpace(value) == "" {
+			continue
+		}
+		r.Header.Set(name, strings.TrimSpace(value))
+	}
+}
+
+// stripCopilotRequestHeaders removes the headers matched by patterns. Patterns are
+// case-insensitive header names; a trailing "*" matches any header with that prefix.
+func stripCopilotRequestHeaders(headers http.Header, patterns []string) {
+	if len(patterns) == 0 || headers == nil {
+		return
+	}
+	for name := range headers {
+		lower := strings.ToLower(name)
+		for _, pattern := range patterns {
+			pattern = strings.ToLower(strings.TrimSpace(pattern))
+			if pattern == "" {
+				continue
+			}
+			prefix, isPrefix := strings.CutSuffix(pattern, "*")
+			if (isPrefix && strings.HasPrefix(lower, prefix)) || lower == pattern {
+				headers.Del(name)
+				break
+			}
+		}
+	}
+}
+
+// allowedIncomingHeaders returns a copy of the client headers without those denied by
+// StripRequestHeaders, so a denied header cannot steer the Copilot header hints.
+func (e *CopilotExecutor) allowedIncomingHeaders(incoming http.Header) http.Header {
+	if e == nil || e.cfg == nil || len(e.cfg.StripRequestHeaders) == 0 || incoming == nil {
+		return incoming
+	}
+	allowed := incoming.Clone()
+	stripCopilotRequestHeaders(allowed, e.cfg.StripRequestHeaders)
+	return allowed
+}
+
 // applyCopilotHeaders applies all necessary headers to the request.
 // It handles both Chat Completions format (messages array) and Responses API format (input array).
-func (e *CopilotExecutor) applyCopilotHeaders(r *http.Request, copilotToken string, payload []byte, incoming http.Header) {
-	hints := collectCopilotHeaderHints(payload, incoming)
-	isAgentCall := e.shouldUseAgentInitiator(hints)
-
-	headers := copilotauth.CopilotHeaders(copilotToken, "", hints.hasVision)
+// Per-key settings come from the CopilotKey entry that owns auth. It returns the applied
+// header profile so callers can count it once the request is actually sent.
+func (e *CopilotExecutor) applyCopilotHeaders(r *http.Request, auth *cliproxyauth.Auth, copilotToken string, payload []byte, incoming http.Header) copilotHeaderProfile {
+	if e.cfg != nil {
+		stripCopilotRequestHeaders(r.Header, e.cfg.StripRequestHeaders)
+	}
+	entry := e.copilotKeyForAuth(auth)
+	hints := e.collectCopilotHeaderHints(payload, e.allowedIncomingHeaders(incoming), copilotHintScanMaxBytes(entry))
+	isAgentCall := e.shouldUseAgentInitiator(entry, hints)
+
+	// Images stripped by the vision fallback must not be advertised to upstream.
+	hasVision := hints.hasVision
+	if hints.visionUnsupported && copilotVisionFallback(entry) == copilotVisionFallbackStrip {
+		hasVision = false
+	}
+	headers := copilotauth.CopilotHeaders(copilotToken, "", hasVision)
 	for k, v := range headers {
 		r.Header.Set(k, v)
 	}
 
-	// Align with Copilot CLI defaults
-	r.Header.Set("X-Interaction-Type", "conversation-agent")
-	r.Header.Set("Openai-Intent", "conversation-agent")
-	r.Header.Set("X-Stainless-Retry-Count", "0")
-	r.Header.Set("X-Stainless-Lang", "js")
-	r.Header.Set("X-Stainless-Package-Version", "5.20.1")
-	r.Header.Set("X-Stainless-OS", "Linux")
-	r.Header.Set("X-Stainless-Arch", "arm64")
-	r.Header.Set("X-Stainless-Runtime", "node")
-	r.Header.Set("X-Stainless-Runtime-Version", "v22.15.0")
-	r.Header.Set("User-Agent", copilotauth.CopilotUserAgent)
+	// Align with Copilot CLI defaults unless the key overrides the intent headers.
+	interactionType, openAIIntent := copilotDefaultIntent, copilotDefaultIntent
+	if entry != nil {
+		if entry.InteractionType != "" {
+			interactionType = entry.InteractionType
+		}
+		if entry.OpenAIIntent != "" {
+			openAIIntent = entry.OpenAIIntent
+		}
+	}
+	r.Header.Set("X-Interaction-Type", interactionType)
+	r.Header.Set("Openai-Intent", openAIIntent)
+	applyCopilotStainlessHeaders(r, entry)
+	userAgent := copilotauth.CopilotUserAgent
+	if entry != nil && entry.UserAgent != "" {
+		userAgent = entry.UserAgent
+	}
+	r.Header.Set("User-Agent", userAgent)
 	if isAgentCall {
 		r.Header.Set("X-Initiator", "agent")
-		log.Info("copilot executor: [agent call]")
+		e.log().Info("copilot executor: [agent call]")
 	} else {
 		r.Header.Set("X-Initiator", "user")
-		log.Info("copilot executor: [user call]")
+		e.log().Info("copilot executor: [user call]")
 	}
 
 	// Apply header profile after defaults are set so it can override relevant headers.
-	e.applyCopilotHeaderProfile(r, gjson.GetBytes(payload, "model").String())
+	profile := applyCopilotHeaderProfile(r, entry, gjson.GetBytes(payload, "model").String())
+	applyCopilotKeyHeaders(r, entry)
+	return profile
+}
+
+// copilotOrganizationHeader carries the enterprise organization configured by CopilotKey.OrgID.
+const copilotOrganizationHeader = "Copilot-Organization"
+
+// applyCopilotKeyHeaders sets the organization header and the key's static extra headers.
+// It runs last so extra headers override every default, except Authorization, which always
+// carries the Copilot token.
+func applyCopilotKeyHeaders(r *http.Request, entry *config.CopilotKey) {
+	if entry == nil {
+		return
+	}
+	if entry.OrgID != "" {
+		r.Header.Set(copilotOrganizationHeader, entry.OrgID)
+	}
+	for name, value := range entry.ExtraHeaders {
+		if strings.EqualFold(strings.TrimSpace(name), "Authorization") {
+			continue
+		}
+		r.Header.Set(name, value)
+	}
 }
@@ -1,11 +1,25 @@
 package executor
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
+	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/tidwall/gjson"
 )
 
@@ -215,6 +229,31 @@ func TestApplyCopilotHeaders_XInitiator(t *testing.T) {
 			payload:           `{"input":[{"role":"user","content":[{"type":"input_text","text":"hello"}]}]}`,
 			expectedInitiator: "user",
 		},
+		{
+			name:              "responses - instructions only",
+			payload:           `{"instructions":"You are helpful","input":[]}`,
+			expectedInitiator: "user",
+		},
+		{
+			name:              "responses - instructions without input field",
+			payload:           `{"instructions":"You are helpful"}`,
+			expectedInitiator: "user",
+		},
+		{
+			name:              "responses - instructions plus user input",
+			payload:           `{"instructions":"You are helpful","input":[{"role":"user","content":[{"type":"input_text","text":"hello"}]}]}`,
+			expectedInitiator: "user",
+		},
+		{
+			name:              "responses - instructions with tools",
+			payload:           `{"instructions":"You are helpful","input":[],"tools":[{"type":"function","name":"lookup"}]}`,
+			expectedInitiator: "agent",
+		},
+		{
+			name:              "responses - blank instructions only",
+			payload:           `{"instructions":"  ","input":[]}`,
+			expectedInitiator: "agent",
+		},
 		{
 			name:              "responses - with function_call",
 			payload:           `{"input":[{"role":"user","content":[{"type":"input_text","text":"hello"}]},{"type":"function_call","call_id":"123","name":"test","arguments":"{}"}]}`,
@@ -324,7 +363,7 @@ func TestApplyCopilotHeaders_XInitiator(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			e := NewCopilotExecutor(&config.Config{})
 			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-			e.applyCopilotHeaders(req, "test-token", []byte(tt.payload), nil)
+			e.applyCopilotHeaders(req, nil, "test-token", []byte(tt.payload), nil)
 
 			got := req.Header.Get("X-Initiator")
 			if got != tt.expectedInitiator {
@@ -341,27 +380,70 @@ func TestApplyCopilotHeaders_XInitiator_ForcedByIncomingHeader(t *testing.T) {
 	incoming.Set("force-copilot-agent", "true")
 
 	payload := `{"messages":[{"role":"user","content":"hello"}]}`
-	e.applyCopilotHeaders(req, "test-token", []byte(payload), incoming)
+	e.applyCopilotHeaders(req, nil, "test-token", []byte(payload), incoming)
 
 	if got := req.Header.Get("X-Initiator"); got != "agent" {
 		t.Fatalf("X-Initiator = %q, want agent", got)
 	}
 }
 
+func TestApplyCopilotHeaders_XInitiator_ForcedByModelList(t *testing.T) {
+	cfg := &config.Config{CopilotKey: []config.CopilotKey{{ForceAgentCallModels: []string{"gpt-5.1-codex"}}}}
+
+	tests := []struct {
+		name              string
+		model             string
+		expectedInitiator string
+	}{
+		{name: "listed model forces agent", model: "gpt-5.1-codex", expectedInitiator: "agent"},
+		{name: "copilot alias of listed model forces agent", model: "copilot-gpt-5.1-codex", expectedInitiator: "agent"},
+		{name: "unlisted model stays user", model: "gpt-4.1", expectedInitiator: "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewCopilotExecutor(cfg)
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			payload := `{"model":"` + tt.model + `","messages":[{"role":"user","content":"hello"}]}`
+			e.applyCopilotHeaders(req, nil, "test-token", []byte(payload), nil)
+
+			if got := req.Header.Get("X-Initiator"); got != tt.expectedInitiator {
+				t.Fatalf("X-Initiator = %q, want %q", got, tt.expectedInitiator)
+			}
+		})
+	}
+}
+
+func TestApplyCopilotHeaders_XInitiator_ModelListScopedToAuth(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", ForceAgentCallModels: []string{"gpt-5.1-codex"}},
+		{Account: "bob"},
+	}})
+	payload := `{"model":"gpt-5.1-codex","messages":[{"role":"user","content":"hello"}]}`
+
+	for account, want := range map[string]string{"alice": "agent", "bob": "user"} {
+		req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		e.applyCopilotHeaders(req, &cliproxyauth.Auth{ID: account}, "test-token", []byte(payload), nil)
+		if got := req.Header.Get("X-Initiator"); got != want {
+			t.Fatalf("%s: X-Initiator = %q, want %q", account, got, want)
+		}
+	}
+}
+
 func TestApplyCopilotHeaders_XInitiator_PersistAcrossCalls(t *testing.T) {
 	payload := `{"prompt_cache_key":"thread-1","input":[{"role":"user","content":[{"type":"input_text","text":"hello"}]}]}`
 
 	t.Run("disabled flag keeps user initiator", func(t *testing.T) {
 		e := NewCopilotExecutor(&config.Config{})
 		req1 := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-		e.applyCopilotHeaders(req1, "test-token", []byte(payload), nil)
+		e.applyCopilotHeaders(req1, nil, "test-token", []byte(payload), nil)
 
 		if got := req1.Header.Get("X-Initiator"); got != "user" {
 			t.Fatalf("first call initiator = %q, want user", got)
 		}
 
 		req2 := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-		e.applyCopilotHeaders(req2, "test-token", []byte(payload), nil)
+		e.applyCopilotHeaders(req2, nil, "test-token", []byte(payload), nil)
 
 		if got := req2.Header.Get("X-Initiator"); got != "user" {
 			t.Fatalf("second call initiator = %q, want user when flag disabled", got)
@@ -371,14 +453,14 @@ func TestApplyCopilotHeaders_XInitiator_PersistAcrossCalls(t *testing.T) {
 	t.Run("enabled flag promotes to agent after first", func(t *testing.T) {
 		e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{AgentInitiatorPersist: true}}})
 		req1 := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-		e.applyCopilotHeaders(req1, "test-token", []byte(payload), nil)
+		e.applyCopilotHeaders(req1, nil, "test-token", []byte(payload), nil)
 
 		if got := req1.Header.Get("X-Initiator"); got != "user" {
 			t.Fatalf("first call initiator = %q, want user", got)
 		}
 
 		req2 := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-		e.applyCopilotHeaders(req2, "test-token", []byte(payload), nil)
+		e.applyCopilotHeaders(req2, nil, "test-token", []byte(payload), nil)
 
 		if got := req2.Header.Get("X-Initiator"); got != "agent" {
 			t.Fatalf("second call initiator = %q, want agent when flag enabled", got)
@@ -386,6 +468,87 @@ func TestApplyCopilotHeaders_XInitiator_PersistAcrossCalls(t *testing.T) {
 	})
 }
 
+func TestResolvePromptCacheKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		header  string
+		want    string
+	}{
+		{name: "header only", payload: `{"messages":[]}`, header: "header-key", want: "header-key"},
+		{name: "payload only", payload: `{"prompt_cache_key":"payload-key"}`, want: "payload-key"},
+		{name: "metadata only", payload: `{"metadata":{"prompt_cache_key":"meta-key"}}`, want: "meta-key"},
+		{name: "payload wins over header", payload: `{"prompt_cache_key":"payload-key"}`, header: "header-key", want: "payload-key"},
+		{name: "metadata wins over header", payload: `{"metadata":{"prompt_cache_key":"meta-key"}}`, header: "header-key", want: "meta-key"},
+		{name: "blank header ignored", payload: `{}`, header: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("X-Prompt-Cache-Key", tt.header)
+			}
+			if got := resolvePromptCacheKey([]byte(tt.payload), headers); got != tt.want {
+				t.Fatalf("resolvePromptCacheKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCopilotHeaders_XInitiator_PersistWithHeaderCacheKey(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{AgentInitiatorPersist: true}}})
+	payload := []byte(`{"messages":[{"role":"user","content":"hello"}]}`)
+	incoming := http.Header{}
+	incoming.Set("X-Prompt-Cache-Key", "header-thread")
+
+	req1 := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(req1, nil, "test-token", payload, incoming)
+	if got := req1.Header.Get("X-Initiator"); got != "user" {
+		t.Fatalf("first call initiator = %q, want user", got)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(req2, nil, "test-token", payload, incoming)
+	if got := req2.Header.Get("X-Initiator"); got != "agent" {
+		t.Fatalf("second call initiator = %q, want agent for header-supplied cache key", got)
+	}
+}
+
+func TestApplyCopilotHeaders_XInitiator_PersistCacheEviction(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{AgentInitiatorPersist: true, InitiatorCacheSize: 2}}})
+
+	call := func(key string) string {
+		req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		payload := `{"prompt_cache_key":"` + key + `","messages":[{"role":"user","content":"hello"}]}`
+		e.applyCopilotHeaders(req, nil, "test-token", []byte(payload), nil)
+		return req.Header.Get("X-Initiator")
+	}
+
+	for _, key := range []string{"thread-1", "thread-2", "thread-3"} {
+		if got := call(key); got != "user" {
+			t.Fatalf("first call for %s = %q, want user", key, got)
+		}
+	}
+
+	if got := e.initiatorCount.len(); got != 2 {
+		t.Fatalf("tracked keys = %d, want 2", got)
+	}
+
+	// Recent keys still promote to agent on their second call.
+	if got := call("thread-3"); got != "agent" {
+		t.Fatalf("second call for thread-3 = %q, want agent", got)
+	}
+	if got := call("thread-2"); got != "agent" {
+		t.Fatalf("second call for thread-2 = %q, want agent", got)
+	}
+
+	// The oldest key was evicted and starts over as a user call.
+	if got := call("thread-1"); got != "user" {
+		t.Fatalf("call for evicted thread-1 = %q, want user", got)
+	}
+}
+
 func TestApplyCopilotHeaders_Vision(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -414,6 +577,21 @@ func TestApplyCopilotHeaders_Vision(t *testing.T) {
 			payload:        `{"input":[{"role":"user","content":[{"type":"input_text","text":"describe"},{"type":"input_image","image_url":{"url":"data:image/png;base64,..."}}]}]}`,
 			expectedVision: true,
 		},
+		{
+			name:           "responses - function_call_output with input_image",
+			payload:        `{"input":[{"role":"user","content":[{"type":"input_text","text":"take a screenshot"}]},{"type":"function_call","call_id":"call_1","name":"screenshot","arguments":"{}"},{"type":"function_call_output","call_id":"call_1","output":[{"type":"input_image","image_url":"data:image/png;base64,..."}]}]}`,
+			expectedVision: true,
+		},
+		{
+			name:           "responses - function_call_output with text output",
+			payload:        `{"input":[{"type":"function_call_output","call_id":"call_1","output":"done"}]}`,
+			expectedVision: false,
+		},
+		{
+			name:           "chat completions - tool message with image_url",
+			payload:        `{"messages":[{"role":"user","content":"take a screenshot"},{"role":"tool","tool_call_id":"call_1","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,..."}}]}]}`,
+			expectedVision: true,
+		},
 		// Mixed format tests - both messages[] and input[] present
 		{
 			name:           "mixed format - vision in messages only",
@@ -431,7 +609,7 @@ func TestApplyCopilotHeaders_Vision(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			e := NewCopilotExecutor(&config.Config{})
 			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-			e.applyCopilotHeaders(req, "test-token", []byte(tt.payload), nil)
+			e.applyCopilotHeaders(req, nil, "test-token", []byte(tt.payload), nil)
 
 			got := req.Header.Get("Copilot-Vision-Request")
 			hasVision := got == "true"
@@ -442,6 +620,104 @@ func TestApplyCopilotHeaders_Vision(t *testing.T) {
 	}
 }
 
+func TestApplyCopilotHeaders_IntentOverrides(t *testing.T) {
+	tests := []struct {
+		name            string
+		entry           config.CopilotKey
+		wantInteraction string
+		wantIntent      string
+	}{
+		{
+			name:            "defaults when unset",
+			wantInteraction: "conversation-agent",
+			wantIntent:      "conversation-agent",
+		},
+		{
+			name:            "both overridden",
+			entry:           config.CopilotKey{InteractionType: "inline-edit", OpenAIIntent: "conversation-edits"},
+			wantInteraction: "inline-edit",
+			wantIntent:      "conversation-edits",
+		},
+		{
+			name:            "only interaction type overridden",
+			entry:           config.CopilotKey{InteractionType: "inline-edit"},
+			wantInteraction: "inline-edit",
+			wantIntent:      "conversation-agent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{tt.entry}})
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			e.applyCopilotHeaders(req, nil, "test-token", []byte(`{"messages":[{"role":"user","content":"hi"}]}`), nil)
+
+			if got := req.Header.Get("X-Interaction-Type"); got != tt.wantInteraction {
+				t.Errorf("X-Interaction-Type = %q, want %q", got, tt.wantInteraction)
+			}
+			if got := req.Header.Get("Openai-Intent"); got != tt.wantIntent {
+				t.Errorf("Openai-Intent = %q, want %q", got, tt.wantIntent)
+			}
+		})
+	}
+}
+
+func TestApplyCopilotHeaders_HintScanLimit(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}]}`)
+
+	hints := (&CopilotExecutor{}).collectCopilotHeaderHints(payload, nil, len(payload)-1)
+	if !hints.agentFromPayload || hints.hasVision || hints.model != "" {
+		t.Fatalf("oversized payload hints = %+v, want agent without vision", hints)
+	}
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{HintScanMaxBytes: 64}}})
+	req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(req, nil, "test-token", payload, nil)
+	if got := req.Header.Get("X-Initiator"); got != "agent" {
+		t.Errorf("X-Initiator = %q, want agent", got)
+	}
+	if got := req.Header.Get("Copilot-Vision-Request"); got != "" {
+		t.Errorf("Copilot-Vision-Request = %q, want empty", got)
+	}
+
+	e = NewCopilotExecutor(&config.Config{})
+	req = httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(req, nil, "test-token", payload, nil)
+	if got := req.Header.Get("X-Initiator"); got != "user" {
+		t.Errorf("X-Initiator under default limit = %q, want user", got)
+	}
+	if got := req.Header.Get("Copilot-Vision-Request"); got != "true" {
+		t.Errorf("Copilot-Vision-Request under default limit = %q, want true", got)
+	}
+}
+
+func TestApplyCopilotHeaders_XInitiator_GzipRequestBody(t *testing.T) {
+	payload := []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hi"}]}`)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+
+	e := NewCopilotExecutor(&config.Config{})
+	var initiator string
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.RequestDecompressionMiddleware(0))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		upstream := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		e.applyCopilotHeaders(upstream, nil, "test-token", body, c.Request.Header)
+		initiator = upstream.Header.Get("X-Initiator")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(compressed.Bytes()))
+	req.Header.Set("Content-Encoding", "gzip")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if initiator != "user" {
+		t.Fatalf("X-Initiator = %q, want user for a decompressed user turn", initiator)
+	}
+}
+
 func TestCopilotHeaderProfileForModel(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -615,6 +891,7 @@ func TestApplyCopilotHeaderProfile(t *testing.T) {
 		copilotConfig        []config.CopilotKey
 		expectedIntegration  string
 		expectedEditorPlugin string
+		expectedEditor       string
 	}{
 		{
 			name:                 "cli profile is no-op (headers not overridden)",
@@ -630,13 +907,24 @@ func TestApplyCopilotHeaderProfile(t *testing.T) {
 			expectedIntegration:  "vscode-chat",
 			expectedEditorPlugin: "copilot-chat/0.35.2",
 		},
+		{
+			name:  "vscode-chat headers can be overridden",
+			model: "gemini-2.5-pro",
+			copilotConfig: []config.CopilotKey{{VSCodeChatHeaders: map[string]string{
+				"editor-version":        "vscode/1.110.0",
+				"Editor-Plugin-Version": "copilot-chat/0.40.0",
+			}}},
+			expectedIntegration:  "vscode-chat",
+			expectedEditorPlugin: "copilot-chat/0.40.0",
+			expectedEditor:       "vscode/1.110.0",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := NewCopilotExecutor(&config.Config{CopilotKey: tt.copilotConfig})
 			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
-			e.applyCopilotHeaderProfile(req, tt.model)
+			applyCopilotHeaderProfile(req, e.copilotKeyConfig(), tt.model)
 
 			if got := req.Header.Get("Copilot-Integration-Id"); got != tt.expectedIntegration {
 				t.Errorf("Copilot-Integration-Id = %q, want %q", got, tt.expectedIntegration)
@@ -644,6 +932,332 @@ func TestApplyCopilotHeaderProfile(t *testing.T) {
 			if got := req.Header.Get("Editor-Plugin-Version"); got != tt.expectedEditorPlugin {
 				t.Errorf("Editor-Plugin-Version = %q, want %q", got, tt.expectedEditorPlugin)
 			}
+			if tt.expectedEditor != "" {
+				if got := req.Header.Get("Editor-Version"); got != tt.expectedEditor {
+					t.Errorf("Editor-Version = %q, want %q", got, tt.expectedEditor)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyCopilotHeaderProfile_VSCodeIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       config.CopilotKey
+		wantSession string
+		wantMachine string
+	}{
+		{name: "unset keeps zero UUIDs", wantSession: copilotZeroVSCodeID, wantMachine: copilotZeroVSCodeID},
+		{
+			name:        "explicit IDs",
+			entry:       config.CopilotKey{VSCodeSessionID: "11111111-2222-3333-4444-555555555555", VSCodeMachineID: "machine-abc"},
+			wantSession: "11111111-2222-3333-4444-555555555555",
+			wantMachine: "machine-abc",
+		},
+		{
+			name:        "auto uses per-process UUIDs",
+			entry:       config.CopilotKey{VSCodeSessionID: "auto", VSCodeMachineID: "AUTO"},
+			wantSession: copilotAutoVSCodeSessionID,
+			wantMachine: copilotAutoVSCodeMachineID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{tt.entry}})
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			applyCopilotHeaderProfile(req, e.copilotKeyConfig(), "gemini-2.5-pro")
+			if got := req.Header.Get("VScode-SessionId"); got != tt.wantSession {
+				t.Errorf("VScode-SessionId = %q, want %q", got, tt.wantSession)
+			}
+			if got := req.Header.Get("VScode-MachineId"); got != tt.wantMachine {
+				t.Errorf("VScode-MachineId = %q, want %q", got, tt.wantMachine)
+			}
+		})
+	}
+	if copilotAutoVSCodeSessionID == copilotZeroVSCodeID || copilotAutoVSCodeSessionID == copilotAutoVSCodeMachineID {
+		t.Fatalf("auto IDs must be distinct non-zero UUIDs: session=%s machine=%s", copilotAutoVSCodeSessionID, copilotAutoVSCodeMachineID)
+	}
+}
+
+// scrapeProfileRequests reads cliproxy_requests_by_profile_total for profile from the
+// metrics registry.
+func scrapeProfileRequests(t *testing.T, profile string) float64 {
+	t.Helper()
+	families, err := metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "cliproxy_requests_by_profile_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "profile" && label.GetValue() == profile {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestApplyCopilotHeaderProfile_ReturnsAppliedProfile(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{})
+	tests := map[string]copilotHeaderProfile{
+		"gpt-4":          copilotHeaderProfileCLI,
+		"gemini-2.5-pro": copilotHeaderProfileVSCodeChat,
+	}
+	for model, want := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		if got := applyCopilotHeaderProfile(req, e.copilotKeyConfig(), model); got != want {
+			t.Fatalf("profile for %s = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestApplyCopilotHeaders_StainlessHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		copilotConfig []config.CopilotKey
+		expected      map[string]string
+	}{
+		{
+			name:          "defaults without overrides",
+			copilotConfig: nil,
+			expected: map[string]string{
+				"X-Stainless-Package-Version": "5.20.1",
+				"X-Stainless-OS":              "Linux",
+				"X-Stainless-Arch":            "arm64",
+				"X-Stainless-Runtime-Version": "v22.15.0",
+			},
+		},
+		{
+			name: "overrides win and unset keys keep defaults",
+			copilotConfig: []config.CopilotKey{{StainlessHeaders: map[string]string{
+				"X-Stainless-Package-Version": "6.1.0",
+				"os":                          "MacOS",
+				"Runtime-Version":             "v24.0.0",
+			}}},
+			expected: map[string]string{
+				"X-Stainless-Package-Version": "6.1.0",
+				"X-Stainless-OS":              "MacOS",
+				"X-Stainless-Arch":            "arm64",
+				"X-Stainless-Runtime-Version": "v24.0.0",
+				"X-Stainless-Lang":            "js",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewCopilotExecutor(&config.Config{CopilotKey: tt.copilotConfig})
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			e.applyCopilotHeaders(req, nil, "test-token", []byte(`{"messages":[{"role":"user","content":"hi"}]}`), nil)
+
+			for header, want := range tt.expected {
+				if got := req.Header.Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
 		})
 	}
 }
+
+func TestCopilotKeyForAuth_AppliesOwningEntryProfile(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", HeaderProfile: "cli"},
+		{Account: "bob@example.com", HeaderProfile: "vscode-chat"},
+	}})
+	alice := &cliproxyauth.Auth{ID: "copilot-alice.json", Metadata: map[string]any{"username": "Alice"}}
+	bob := &cliproxyauth.Auth{ID: "copilot-bob.json", Metadata: map[string]any{"email": "bob@example.com"}}
+	payload := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}]}`)
+
+	reqAlice := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(reqAlice, alice, "test-token", payload, nil)
+	if got := reqAlice.Header.Get("Copilot-Integration-Id"); got == "vscode-chat" {
+		t.Fatalf("alice should use the cli profile, got Copilot-Integration-Id %q", got)
+	}
+
+	reqBob := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(reqBob, bob, "test-token", payload, nil)
+	if got := reqBob.Header.Get("Copilot-Integration-Id"); got != "vscode-chat" {
+		t.Fatalf("bob should use the vscode-chat profile, got Copilot-Integration-Id %q", got)
+	}
+}
+
+func TestApplyCopilotHeaders_UserAgentPerKey(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", UserAgent: "copilot/1.0.0-alice"},
+		{Account: "bob", UserAgent: "copilot/2.0.0-bob"},
+		{Account: "carol"},
+	}})
+	payload := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}]}`)
+
+	for id, want := range map[string]string{
+		"alice": "copilot/1.0.0-alice",
+		"bob":   "copilot/2.0.0-bob",
+		"carol": copilotauth.CopilotUserAgent,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		e.applyCopilotHeaders(req, &cliproxyauth.Auth{ID: id}, "token-"+id, payload, nil)
+		if got := req.Header.Get("User-Agent"); got != want {
+			t.Errorf("%s: User-Agent = %q, want %q", id, got, want)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer token-"+id {
+			t.Errorf("%s: Authorization = %q", id, got)
+		}
+	}
+}
+
+func TestCopilotKeyForAuth_FallsBackToUnscopedEntry(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", HeaderProfile: "cli"},
+		{HeaderProfile: "vscode-chat"},
+	}})
+
+	if got := e.copilotKeyForAuth(&cliproxyauth.Auth{ID: "someone-else"}); got != &e.cfg.CopilotKey[1] {
+		t.Fatalf("expected unscoped entry for unmatched credential, got %+v", got)
+	}
+	if got := e.copilotKeyForAuth(&cliproxyauth.Auth{ID: "ALICE"}); got != &e.cfg.CopilotKey[0] {
+		t.Fatalf("expected account match by auth ID, got %+v", got)
+	}
+}
+
+func TestCopilotKeyForAuth_NoEntryForUnmatchedCredential(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", HeaderProfile: "vscode-chat"},
+		{Account: "bob", HeaderProfile: "vscode-chat"},
+	}})
+
+	if got := e.copilotKeyForAuth(&cliproxyauth.Auth{ID: "carol"}); got != nil {
+		t.Fatalf("expected no entry for a credential without a matching account, got %+v", got)
+	}
+	if got := e.copilotKeyForAuth(&cliproxyauth.Auth{ID: "bob"}); got != &e.cfg.CopilotKey[1] {
+		t.Fatalf("expected account match by auth ID, got %+v", got)
+	}
+}
+
+func TestApplyCopilotHeaders_InitiatorLogFollowsModuleLevel(t *testing.T) {
+	std := log.StandardLogger()
+	previousLevel := std.GetLevel()
+	hook := &logtest.Hook{}
+	previousHooks := std.ReplaceHooks(log.LevelHooks{})
+	std.AddHook(hook)
+	std.SetLevel(log.InfoLevel)
+	t.Cleanup(func() {
+		std.ReplaceHooks(previousHooks)
+		std.SetLevel(previousLevel)
+	})
+
+	initiatorLogged := func(cfg *config.Config) bool {
+		hook.Reset()
+		e := NewCopilotExecutor(cfg)
+		req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		e.applyCopilotHeaders(req, nil, "test-token", []byte(`{"messages":[{"role":"user","content":"hi"}]}`), nil)
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == "copilot executor: [user call]" || entry.Message == "copilot executor: [agent call]" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !initiatorLogged(&config.Config{}) {
+		t.Fatal("expected the initiator line at the default info level")
+	}
+	if !initiatorLogged(&config.Config{LogLevels: map[string]string{"copilot": "info"}}) {
+		t.Fatal("expected the initiator line with copilot at info")
+	}
+	if initiatorLogged(&config.Config{LogLevels: map[string]string{"copilot": "warn"}}) {
+		t.Fatal("expected the initiator line suppressed with copilot at warn")
+	}
+}
+
+func TestApplyCopilotHeaders_OrgAndExtraHeaders(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{
+		OrgID: "acme-enterprise",
+		ExtraHeaders: map[string]string{
+			"X-Interaction-Type": "custom-interaction",
+			"Editor-Version":     "custom-editor/1.0",
+			"X-Custom":           "hello",
+			"authorization":      "Bearer stolen",
+		},
+	}}})
+	req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(req, nil, "test-token", []byte(`{"messages":[{"role":"user","content":"hi"}]}`), nil)
+
+	if got := req.Header.Get("Copilot-Organization"); got != "acme-enterprise" {
+		t.Fatalf("Copilot-Organization = %q, want acme-enterprise", got)
+	}
+	for name, want := range map[string]string{
+		"X-Interaction-Type": "custom-interaction",
+		"Editor-Version":     "custom-editor/1.0",
+		"X-Custom":           "hello",
+	} {
+		if got := req.Header.Get(name); got != want {
+			t.Fatalf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer test-token" {
+		t.Fatalf("Authorization = %q, extra headers must not replace the token", got)
+	}
+}
+
+func TestApplyCopilotHeaders_NoOrgHeaderByDefault(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	e.applyCopilotHeaders(req, nil, "test-token", []byte(`{"messages":[]}`), nil)
+	if got := req.Header.Get("Copilot-Organization"); got != "" {
+		t.Fatalf("Copilot-Organization = %q, want unset", got)
+	}
+}
+
+func TestCopilotExecutor_ExecuteStripsDeniedClientHeaders(t *testing.T) {
+	var upstream http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	e := NewCopilotExecutor(&config.Config{
+		StripRequestHeaders: []string{"cookie", "X-INTERNAL-*", "Force-Copilot-Agent", "Authorization", "User-Agent"},
+		CopilotKey:          []config.CopilotKey{{BaseURL: srv.URL}},
+	})
+	auth := &cliproxyauth.Auth{ID: "strip-headers-auth", Metadata: map[string]any{
+		"copilot_token":        "test-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+	client := http.Header{}
+	client.Set("Cookie", "session=secret")
+	client.Set("X-Internal-Auth", "internal-token")
+	client.Set("Force-Copilot-Agent", "true")
+	client.Set("Authorization", "Bearer client-key")
+	client.Set("User-Agent", "client/1.0")
+	payload := []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hi"}]}`)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload, Headers: client}
+
+	if _, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4.1", Payload: payload}, opts); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	for _, name := range []string{"Cookie", "X-Internal-Auth", "Force-Copilot-Agent"} {
+		if got := upstream.Get(name); got != "" {
+			t.Fatalf("%s = %q, want removed", name, got)
+		}
+	}
+	// A denied hint header must not steer the initiator either.
+	if got := upstream.Get("X-Initiator"); got != "user" {
+		t.Fatalf("X-Initiator = %q, want user", got)
+	}
+	// Copilot headers are applied after stripping, even when their names are denied.
+	if got := upstream.Get("Authorization"); got != "Bearer test-token" {
+		t.Fatalf("Authorization = %q, want the Copilot token", got)
+	}
+	if got := upstream.Get("User-Agent"); got != copilotauth.CopilotUserAgent {
+		t.Fatalf("User-Agent = %q, want %q", got, copilotauth.CopilotUserAgent)
+	}
+}
@@ -0,0 +1,247 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultInlineImageMaxSizeMB = 5
+	defaultInlineImageTimeout   = 10 * time.Second
+	defaultInlineImageMaxCount  = 8
+	inlineImageMaxRedirects     = 5
+)
+
+// errInlineImageAddressBlocked is returned when an image URL resolves to a non-public address.
+var errInlineImageAddressBlocked = errors.New("image address is not publicly routable")
+
+// inlineImageAddrAllowed reports whether image downloads may connect to addr. Tests replace
+// it to reach local servers.
+var inlineImageAddrAllowed = isPublicImageAddr
+
+// sharedCGNATPrefix is the carrier-grade NAT range, which IsPrivate does not cover.
+var sharedCGNATPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// inlineRemoteImages replaces http(s) image_url parts in Chat Completions messages with
+// base64 data URLs when the credential's CopilotKey enables inline-remote-images. Each
+// distinct URL is downloaded once; a download that fails, targets a non-public address, is
+// not an image, or exceeds the size or count limit rejects the request with 400. Errors
+// returned to the client do not echo the URL or upstream status.
+func (e *CopilotExecutor) inlineRemoteImages(ctx context.Context, auth *cliproxyauth.Auth, body []byte) ([]byte, error) {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil || !entry.InlineRemoteImages {
+		return body, nil
+	}
+	messages := gjson.GetBytes(body, "messages")
+	if !messages.IsArray() {
+		return body, nil
+	}
+
+	maxSizeMB := entry.InlineImageMaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultInlineImageMaxSizeMB
+	}
+	timeout := parseTimeoutSetting(entry.InlineImageTimeout)
+	if timeout <= 0 {
+		timeout = defaultInlineImageTimeout
+	}
+	maxCount := entry.InlineImageMaxCount
+	if maxCount <= 0 {
+		maxCount = defaultInlineImageMaxCount
+	}
+
+	var client *http.Client
+	inlined := make(map[string]string)
+	out := body
+	for i, msg := range messages.Array() {
+		content := msg.Get("content")
+		if !content.IsArray() {
+			continue
+		}
+		for j, part := range content.Array() {
+			if part.Get("type").String() != "image_url" {
+				continue
+			}
+			url := strings.TrimSpace(part.Get("image_url.url").String())
+			if !isRemoteImageURL(url) {
+				continue
+			}
+			dataURL, ok := inlined[url]
+			if !ok {
+				if len(inlined) >= maxCount {
+					return body, inlineImageError(fmt.Sprintf("request references more than %d remote images", maxCount), "too_many_images")
+				}
+				if client == nil {
+					client = newInlineImageClient(ctx, e.cfg, auth, timeout)
+				}
+				var err error
+				dataURL, err = e.fetchInlineImage(ctx, client, url, int64(maxSizeMB)<<20)
+				if err != nil {
+					return body, err
+				}
+				inlined[url] = dataURL
+			}
+			var errSet error
+			out, errSet = sjson.SetBytes(out, fmt.Sprintf("messages.%d.content.%d.image_url.url", i, j), dataURL)
+			if errSet != nil {
+				return body, errSet
+			}
+		}
+	}
+	if len(inlined) > 0 {
+		e.log().Debugf("copilot executor: inlined %d remote image(s)", len(inlined))
+	}
+	return out, nil
+}
+
+func isRemoteImageURL(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// newInlineImageClient returns a proxy-aware client for image downloads that refuses
+// non-public destinations. Direct connections check the dialed IP, which also covers
+// redirects and DNS rebinding. Every request and redirect target is additionally resolved
+// and checked up front, since a proxy dials on our behalf.
+func newInlineImageClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
+	base := newProxyAwareHTTPClient(ctx, cfg, auth, timeout)
+	transport := base.Transport
+	if transport == nil {
+		dialer := &net.Dialer{Timeout: timeout, Control: inlineImageDialControl}
+		transport = &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		}
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= inlineImageMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", inlineImageMaxRedirects)
+			}
+			return checkInlineImageHost(req.Context(), req.URL.Hostname())
+		},
+	}
+}
+
+// inlineImageDialControl rejects connections to non-public addresses after DNS resolution.
+func inlineImageDialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !inlineImageAddrAllowed(addrPort.Addr()) {
+		return errInlineImageAddressBlocked
+	}
+	return nil
+}
+
+// checkInlineImageHost resolves host and rejects it when any address is not public.
+func checkInlineImageHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !inlineImageAddrAllowed(addr) {
+			return errInlineImageAddressBlocked
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !inlineImageAddrAllowed(addr) {
+			return errInlineImageAddressBlocked
+		}
+	}
+	return nil
+}
+
+// isPublicImageAddr reports whether addr is a globally routable unicast address. Loopback,
+// private, link-local (including cloud metadata endpoints), CGNAT, multicast and
+// unspecified addresses are rejected.
+func isPublicImageAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() {
+		return false
+	}
+	return !addr.IsPrivate() && !addr.IsLinkLocalUnicast() && !sharedCGNATPrefix.Contains(addr)
+}
+
+// fetchInlineImage downloads url and returns it as a data URL. Bodies larger than maxBytes
+// are rejected without being read in full. Failure details are logged, not returned.
+func (e *CopilotExecutor) fetchInlineImage(ctx context.Context, client *http.Client, url string, maxBytes int64) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", inlineImageError("invalid image URL", "invalid_image_url")
+	}
+	if err = checkInlineImageHost(ctx, req.URL.Hostname()); err != nil {
+		e.log().Debugf("copilot executor: refused image download %s: %v", url, err)
+		return "", inlineImageDownloadFailed()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		e.log().Debugf("copilot executor: image download %s failed: %v", url, err)
+		return "", inlineImageDownloadFailed()
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			e.log().Errorf("copilot executor: close image response body error: %v", errClose)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		e.log().Debugf("copilot executor: image download %s failed: status %d", url, resp.StatusCode)
+		return "", inlineImageDownloadFailed()
+	}
+	if resp.ContentLength > maxBytes {
+		return "", inlineImageTooLarge(maxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		e.log().Debugf("copilot executor: image download %s failed: %v", url, err)
+		return "", inlineImageDownloadFailed()
+	}
+	if int64(len(data)) > maxBytes {
+		return "", inlineImageTooLarge(maxBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", inlineImageError("image URL did not return an image", "invalid_image_url")
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func inlineImageDownloadFailed() error {
+	return inlineImageError("failed to download image", "invalid_image_url")
+}
+
+func inlineImageTooLarge(maxBytes int64) error {
+	return inlineImageError(fmt.Sprintf("image exceeds the %d MB limit", maxBytes>>20), "image_too_large")
+}
+
+func inlineImageError(message, code string) error {
+	return statusErr{code: http.StatusBadRequest, msg: string(interfaces.OpenAIErrorBody(http.StatusBadRequest, message, "messages", code))}
+}
@@ -0,0 +1,197 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+var inlineTestPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// allowLoopbackInlineImages lets image downloads reach 127.0.0.1 for the test's duration.
+func allowLoopbackInlineImages(t *testing.T) {
+	t.Helper()
+	previous := inlineImageAddrAllowed
+	inlineImageAddrAllowed = func(addr netip.Addr) bool {
+		return addr.Unmap() == netip.MustParseAddr("127.0.0.1") || previous(addr)
+	}
+	t.Cleanup(func() { inlineImageAddrAllowed = previous })
+}
+
+func newInlineImageServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	allowLoopbackInlineImages(t)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(inlineTestPNG)
+		case "/sniffed":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(inlineTestPNG)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(bytes.Repeat([]byte{0}, 2<<20))
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func inlineImagePayload(urls ...string) []byte {
+	parts := []string{`{"type":"text","text":"describe"}`}
+	for _, url := range urls {
+		parts = append(parts, `{"type":"image_url","image_url":{"url":"`+url+`","detail":"high"}}`)
+	}
+	return []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":[` + strings.Join(parts, ",") + `]}]}`)
+}
+
+func TestCopilotInlineRemoteImages(t *testing.T) {
+	srv, hits := newInlineImageServer(t)
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{InlineRemoteImages: true}}})
+
+	body := inlineImagePayload(srv.URL+"/cat.png", srv.URL+"/cat.png", srv.URL+"/sniffed", "data:image/png;base64,AAAA")
+	out, err := e.inlineRemoteImages(context.Background(), nil, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(inlineTestPNG)
+	content := gjson.GetBytes(out, "messages.0.content").Array()
+	for _, idx := range []int{1, 2, 3} {
+		if got := content[idx].Get("image_url.url").String(); got != want {
+			t.Fatalf("part %d url = %q, want %q", idx, got, want)
+		}
+		if got := content[idx].Get("image_url.detail").String(); got != "high" {
+			t.Fatalf("part %d detail = %q, want high", idx, got)
+		}
+	}
+	if got := content[4].Get("image_url.url").String(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("existing data URL changed to %q", got)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("image server hits = %d, want 2 (duplicate URLs fetched once)", got)
+	}
+}
+
+func TestCopilotInlineRemoteImagesRejects(t *testing.T) {
+	srv, _ := newInlineImageServer(t)
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{InlineRemoteImages: true, InlineImageMaxSizeMB: 1}}})
+
+	tests := map[string]struct {
+		path string
+		code string
+	}{
+		"oversized image":  {path: "/huge.png", code: "image_too_large"},
+		"not an image":     {path: "/page", code: "invalid_image_url"},
+		"missing image":    {path: "/missing.png", code: "invalid_image_url"},
+		"blocked redirect": {path: "/metadata", code: "invalid_image_url"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := e.inlineRemoteImages(context.Background(), nil, inlineImagePayload(srv.URL+tt.path))
+			var se statusErr
+			if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %v", err)
+			}
+			if got := gjson.Get(se.Error(), "error.code").String(); got != tt.code {
+				t.Fatalf("error code = %q, want %q; err = %v", got, tt.code, err)
+			}
+		})
+	}
+}
+
+func TestCopilotInlineRemoteImagesBlocksNonPublicAddress(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(inlineTestPNG)
+	}))
+	defer srv.Close()
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{InlineRemoteImages: true}}})
+
+	for _, url := range []string{srv.URL + "/cat.png", "http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/a.png"} {
+		_, err := e.inlineRemoteImages(context.Background(), nil, inlineImagePayload(url))
+		var se statusErr
+		if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %v", url, err)
+		}
+		if strings.Contains(se.Error(), url) || strings.Contains(se.Error(), "169.254") {
+			t.Fatalf("%s: error echoes the destination: %v", url, err)
+		}
+	}
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("loopback server hits = %d, want 0", got)
+	}
+}
+
+func TestCopilotInlineRemoteImagesMaxCount(t *testing.T) {
+	srv, hits := newInlineImageServer(t)
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{InlineRemoteImages: true, InlineImageMaxCount: 2}}})
+
+	_, err := e.inlineRemoteImages(context.Background(), nil, inlineImagePayload(srv.URL+"/cat.png?1", srv.URL+"/cat.png?2", srv.URL+"/cat.png?3"))
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", err)
+	}
+	if got := gjson.Get(se.Error(), "error.code").String(); got != "too_many_images" {
+		t.Fatalf("error code = %q, want too_many_images", got)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("image server hits = %d, want 2", got)
+	}
+}
+
+func TestIsPublicImageAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"192.168.0.10":     false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fd00:ec2::254":    false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := isPublicImageAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublicImageAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCopilotInlineRemoteImagesDisabled(t *testing.T) {
+	srv, hits := newInlineImageServer(t)
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+
+	body := inlineImagePayload(srv.URL + "/cat.png")
+	out, err := e.inlineRemoteImages(context.Background(), nil, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(out, body) || hits.Load() != 0 {
+		t.Fatalf("body changed or image fetched while disabled: %s", out)
+	}
+}
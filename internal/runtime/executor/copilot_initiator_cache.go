@@ -0,0 +1,82 @@
+package executor
+
+import "container/list"
+
+// defaultCopilotInitiatorCacheSize bounds the number of prompt cache keys tracked for
+// agent-initiator persistence when no CopilotKey configures a size.
+const defaultCopilotInitiatorCacheSize = 4096
+
+// copilotInitiatorCache counts requests per prompt cache key with LRU eviction so that
+// long-running proxies do not accumulate keys without bound. It is not safe for
+// concurrent use; callers guard it with CopilotExecutor.mu.
+type copilotInitiatorCache struct {
+	maxSize int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type copilotInitiatorEntry struct {
+	key   string
+	count uint64
+}
+
+func newCopilotInitiatorCache(maxSize int) *copilotInitiatorCache {
+	return &copilotInitiatorCache{
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// increment bumps the count for key, marks it most recently used and returns the
+// count observed before the increment.
+func (c *copilotInitiatorCache) increment(key string) uint64 {
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*copilotInitiatorEntry)
+		prev := entry.count
+		entry.count++
+		c.order.MoveToFront(elem)
+		return prev
+	}
+	c.entries[key] = c.order.PushFront(&copilotInitiatorEntry{key: key, count: 1})
+	c.evict()
+	return 0
+}
+
+// resize updates the capacity and evicts least recently used keys beyond it.
+func (c *copilotInitiatorCache) resize(maxSize int) {
+	c.maxSize = maxSize
+	c.evict()
+}
+
+func (c *copilotInitiatorCache) evict() {
+	if c.maxSize <= 0 {
+		return
+	}
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*copilotInitiatorEntry).key)
+	}
+}
+
+func (c *copilotInitiatorCache) len() int {
+	return c.order.Len()
+}
+
+// initiatorCacheSize returns the largest configured initiator cache size across
+// CopilotKey entries, falling back to defaultCopilotInitiatorCacheSize.
+func (e *CopilotExecutor) initiatorCacheSize() int {
+	size := 0
+	if e != nil && e.cfg != nil {
+		for i := range e.cfg.CopilotKey {
+			if v := e.cfg.CopilotKey[i].InitiatorCacheSize; v > size {
+				size = v
+			}
+		}
+	}
+	if size <= 0 {
+		return defaultCopilotInitiatorCacheSize
+	}
+	return size
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// copilotNoKeyLabel identifies requests served without a copilot-api-key entry.
+const copilotNoKeyLabel = "none"
+
+// copilotKeyLabel returns a stable identifier for a copilot-api-key entry that is safe to
+// log: "ck-" and the first 8 hex digits of a SHA-256 over the entry's account, or its
+// token source when no account is set. The token itself cannot be recovered from it. The
+// label survives reordering the config, except for entries with neither an account nor a
+// token source: those are labelled by their position, which changes when entries move.
+func (e *CopilotExecutor) copilotKeyLabel(entry *config.CopilotKey) string {
+	if entry == nil {
+		return copilotNoKeyLabel
+	}
+	source := ""
+	switch {
+	case strings.TrimSpace(entry.Account) != "":
+		source = "account:" + strings.ToLower(strings.TrimSpace(entry.Account))
+	case strings.TrimSpace(entry.TokenEnv) != "":
+		source = "token-env:" + strings.TrimSpace(entry.TokenEnv)
+	case strings.TrimSpace(entry.Token) != "":
+		source = "token:" + strings.TrimSpace(entry.Token)
+	case strings.TrimSpace(entry.TokenBase64) != "":
+		source = "token-base64:" + strings.TrimSpace(entry.TokenBase64)
+	default:
+		// Nothing identifies the entry; fall back to its position in the config.
+		for i := range e.cfg.CopilotKey {
+			if &e.cfg.CopilotKey[i] == entry {
+				source = "index:" + strconv.Itoa(i)
+				break
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(source))
+	return "ck-" + hex.EncodeToString(sum[:4])
+}
+
+// traceCopilotKey records which copilot-api-key entry serves a request in the debug log,
+// the request span and, when enabled, the per-key request counter.
+func (e *CopilotExecutor) traceCopilotKey(span trace.Span, auth *cliproxyauth.Auth, model string) {
+	label := e.copilotKeyLabel(e.copilotKeyForAuth(auth))
+	span.SetAttributes(attribute.String("copilot_key", label))
+	e.log().Debugf("copilot executor: model %s served by key %s", model, label)
+	metrics.RecordCopilotKeyRequest(label)
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestCopilotKeyLabel_StableAndRedacted(t *testing.T) {
+	cfg := &config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "Alice", Token: "ghu_alice-secret"},
+		{Token: "ghu_bob-secret"},
+	}}
+	e := NewCopilotExecutor(cfg)
+	alice := e.copilotKeyLabel(&cfg.CopilotKey[0])
+	bob := e.copilotKeyLabel(&cfg.CopilotKey[1])
+
+	if !strings.HasPrefix(alice, "ck-") || len(alice) != len("ck-")+8 {
+		t.Fatalf("label = %q, want ck- and 8 hex digits", alice)
+	}
+	if alice == bob {
+		t.Fatalf("distinct keys share label %q", alice)
+	}
+	for _, label := range []string{alice, bob} {
+		if strings.Contains(label, "secret") || strings.Contains(label, "ghu_") {
+			t.Fatalf("label leaks the token: %q", label)
+		}
+	}
+
+	// Reordering the config and changing the account's case keeps the label.
+	reordered := &config.Config{CopilotKey: []config.CopilotKey{{Token: "ghu_bob-secret"}, {Account: "alice", Token: "ghu_rotated"}}}
+	if got := NewCopilotExecutor(reordered).copilotKeyLabel(&reordered.CopilotKey[1]); got != alice {
+		t.Fatalf("label after reorder = %q, want %q", got, alice)
+	}
+	if got := e.copilotKeyLabel(nil); got != copilotNoKeyLabel {
+		t.Fatalf("label without an entry = %q, want %q", got, copilotNoKeyLabel)
+	}
+}
+
+func TestTraceCopilotKey_LogsAndCounts(t *testing.T) {
+	std := log.StandardLogger()
+	previousLevel := std.GetLevel()
+	hook := &logtest.Hook{}
+	previousHooks := std.ReplaceHooks(log.LevelHooks{})
+	std.AddHook(hook)
+	std.SetLevel(log.DebugLevel)
+	t.Cleanup(func() {
+		std.ReplaceHooks(previousHooks)
+		std.SetLevel(previousLevel)
+	})
+	metrics.SetEnabled(true)
+	metrics.SetCopilotKeyLabel(true)
+	defer metrics.SetEnabled(false)
+	defer metrics.SetCopilotKeyLabel(false)
+
+	cfg := &config.Config{CopilotKey: []config.CopilotKey{{Account: "trace-account", Token: "ghu_trace-secret"}}}
+	e := NewCopilotExecutor(cfg)
+	label := e.copilotKeyLabel(&cfg.CopilotKey[0])
+	_, span := noop.NewTracerProvider().Tracer("test").Start(t.Context(), "copilot")
+	e.traceCopilotKey(span, &cliproxyauth.Auth{ID: "trace-account"}, "gpt-4.1")
+	e.traceCopilotKey(span, &cliproxyauth.Auth{ID: "trace-account"}, "gpt-4.1")
+
+	found := false
+	for _, entry := range hook.AllEntries() {
+		if strings.Contains(entry.Message, "ghu_trace-secret") {
+			t.Fatalf("log leaks the token: %q", entry.Message)
+		}
+		if strings.Contains(entry.Message, "served by key "+label) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("no log line carries key %s", label)
+	}
+
+	families, err := metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "cliproxy_copilot_key_requests_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if m.GetLabel()[0].GetValue() == label {
+				if got := m.GetCounter().GetValue(); got != 2 {
+					t.Fatalf("requests for %s = %v, want 2", label, got)
+				}
+				return
+			}
+		}
+	}
+	t.Fatalf("expected cliproxy_copilot_key_requests_total series for %s", label)
+}
@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestObserveCopilotContextUtilization(t *testing.T) {
+	const model = "ctx-util-test-model"
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("ctx-util-test-client", "copilot", []*registry.ModelInfo{{
+		ID:            model,
+		Object:        "model",
+		Created:       time.Now().Unix(),
+		OwnedBy:       "copilot",
+		ContextLength: 100,
+	}})
+	defer reg.UnregisterClient("ctx-util-test-client")
+
+	metrics.SetEnabled(true)
+	defer metrics.SetEnabled(false)
+
+	body := []byte(`{"messages":[{"role":"user","content":"hello world, how are you today?"}]}`)
+	promptTokens, err := countCopilotPromptTokens(model, body)
+	if err != nil {
+		t.Fatalf("countCopilotPromptTokens: %v", err)
+	}
+	if promptTokens == 0 {
+		t.Fatal("expected non-zero prompt tokens")
+	}
+
+	(&CopilotExecutor{}).observeCopilotContextUtilization(model, body)
+
+	families, err := metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "cliproxy_context_utilization_ratio" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if len(m.GetLabel()) != 1 || m.GetLabel()[0].GetValue() != model {
+				continue
+			}
+			h := m.GetHistogram()
+			if h.GetSampleCount() != 1 {
+				t.Fatalf("expected 1 observation, got %d", h.GetSampleCount())
+			}
+			want := float64(promptTokens) / 100
+			if h.GetSampleSum() != want {
+				t.Fatalf("expected ratio %v, got %v", want, h.GetSampleSum())
+			}
+			return
+		}
+	}
+	t.Fatal("expected cliproxy_context_utilization_ratio series for model")
+}
+
+// TestCopilotExecutor_HeaderProfileCountsOnlySentRequests checks that dry runs and
+// self-tests do not inflate the per-profile request counter.
+func TestCopilotExecutor_HeaderProfileCountsOnlySentRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/models") {
+			_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4.1"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	metrics.SetEnabled(true)
+	defer metrics.SetEnabled(false)
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{BaseURL: srv.URL}}})
+	auth := &cliproxyauth.Auth{ID: "profile-count-auth", Metadata: map[string]any{
+		"copilot_token":        "test-copilot-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+	payload := []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hi"}]}`)
+	execute := func(headers http.Header) {
+		t.Helper()
+		opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload, Headers: headers}
+		if _, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-4.1", Payload: payload}, opts); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+
+	before := scrapeProfileRequests(t, string(copilotHeaderProfileCLI))
+	execute(dryRunHeaders())
+	if _, _, err := e.SelfTest(context.Background(), auth); err != nil {
+		t.Fatalf("SelfTest: %v", err)
+	}
+	if got := scrapeProfileRequests(t, string(copilotHeaderProfileCLI)) - before; got != 0 {
+		t.Fatalf("dry run and self-test counted %v requests, want 0", got)
+	}
+
+	execute(nil)
+	if got := scrapeProfileRequests(t, string(copilotHeaderProfileCLI)) - before; got != 1 {
+		t.Fatalf("sent requests counted = %v, want 1", got)
+	}
+}
@@ -0,0 +1,181 @@
+package executor
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// copilotDispatchPriority orders queued Copilot requests; lower values dispatch first.
+type copilotDispatchPriority int
+
+const (
+	copilotDispatchPriorityUser copilotDispatchPriority = iota
+	copilotDispatchPriorityAgent
+)
+
+// copilotDispatchQueue bounds in-flight requests for a single credential and,
+// once the bound is reached, hands freed slots to user-initiated requests before
+// agent-initiated ones. Requests of equal priority are served FIFO.
+type copilotDispatchQueue struct {
+	mu       sync.Mutex
+	limit    int
+	inflight int
+	waiting  [2]*list.List
+}
+
+func newCopilotDispatchQueue(limit int) *copilotDispatchQueue {
+	return &copilotDispatchQueue{
+		limit:   limit,
+		waiting: [2]*list.List{list.New(), list.New()},
+	}
+}
+
+// Shared dispatch queues keyed by auth ID so that in-flight accounting survives
+// executor recreation on config reload.
+var (
+	sharedCopilotDispatchMu     sync.Mutex
+	sharedCopilotDispatchQueues = make(map[string]*copilotDispatchQueue)
+)
+
+func sharedCopilotDispatchQueue(key string, limit int) *copilotDispatchQueue {
+	sharedCopilotDispatchMu.Lock()
+	defer sharedCopilotDispatchMu.Unlock()
+	q, ok := sharedCopilotDispatchQueues[key]
+	if !ok {
+		q = newCopilotDispatchQueue(limit)
+		sharedCopilotDispatchQueues[key] = q
+		return q
+	}
+	q.setLimit(limit)
+	return q
+}
+
+// acquire blocks until a dispatch slot is available for the given priority or the
+// context is cancelled. The returned release func must be called exactly once.
+func (q *copilotDispatchQueue) acquire(ctx context.Context, priority copilotDispatchPriority) (func(), error) {
+	q.mu.Lock()
+	if q.inflight < q.limit && q.queuedAheadOf(priority) == 0 {
+		q.inflight++
+		q.mu.Unlock()
+		return q.releaseFunc(), nil
+	}
+	ready := make(chan struct{})
+	elem := q.waiting[priority].PushBack(ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return q.releaseFunc(), nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		select {
+		case <-ready:
+			// Slot was granted concurrently with cancellation; hand it back.
+			q.mu.Unlock()
+			q.release()
+		default:
+			q.waiting[priority].Remove(elem)
+			q.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// queuedAheadOf counts waiters that must be served before a new request of the given priority.
+func (q *copilotDispatchQueue) queuedAheadOf(priority copilotDispatchPriority) int {
+	count := 0
+	for p := copilotDispatchPriorityUser; p <= priority; p++ {
+		count += q.waiting[p].Len()
+	}
+	return count
+}
+
+func (q *copilotDispatchQueue) releaseFunc() func() {
+	var once sync.Once
+	return func() { once.Do(q.release) }
+}
+
+func (q *copilotDispatchQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inflight > 0 {
+		q.inflight--
+	}
+	q.dispatchLocked()
+}
+
+func (q *copilotDispatchQueue) setLimit(limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limit = limit
+	q.dispatchLocked()
+}
+
+// dispatchLocked grants free slots to waiters, user priority first. Caller must hold q.mu.
+func (q *copilotDispatchQueue) dispatchLocked() {
+	for q.inflight < q.limit {
+		var ready chan struct{}
+		for p := range q.waiting {
+			if front := q.waiting[p].Front(); front != nil {
+				ready = q.waiting[p].Remove(front).(chan struct{})
+				break
+			}
+		}
+		if ready == nil {
+			return
+		}
+		q.inflight++
+		close(ready)
+	}
+}
+
+// priorityQueueConcurrency returns the priority dispatch queue limit configured on the
+// CopilotKey entry that serves auth. Zero means the queue is disabled.
+func (e *CopilotExecutor) priorityQueueConcurrency(auth *cliproxyauth.Auth) int {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil {
+		return 0
+	}
+	return entry.PriorityQueueConcurrency
+}
+
+// acquireDispatchSlot takes a slot under the credential's MaxConcurrent limit, then waits
+// for a dispatch slot on its priority queue. When neither is configured it returns
+// immediately with a no-op release func.
+func (e *CopilotExecutor) acquireDispatchSlot(ctx context.Context, auth *cliproxyauth.Auth, agent bool) (func(), error) {
+	releaseConcurrency, err := e.acquireConcurrencySlot(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	releaseDispatch, err := e.acquirePriorityQueueSlot(ctx, auth, agent)
+	if err != nil {
+		releaseConcurrency()
+		return nil, err
+	}
+	return func() {
+		releaseDispatch()
+		releaseConcurrency()
+	}, nil
+}
+
+// acquirePriorityQueueSlot waits for a dispatch slot on the credential's priority queue.
+// When the queue is disabled it returns immediately with a no-op release func.
+func (e *CopilotExecutor) acquirePriorityQueueSlot(ctx context.Context, auth *cliproxyauth.Auth, agent bool) (func(), error) {
+	limit := e.priorityQueueConcurrency(auth)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	key := "default"
+	if auth != nil && strings.TrimSpace(auth.ID) != "" {
+		key = strings.TrimSpace(auth.ID)
+	}
+	priority := copilotDispatchPriorityUser
+	if agent {
+		priority = copilotDispatchPriorityAgent
+	}
+	return sharedCopilotDispatchQueue(key, limit).acquire(ctx, priority)
+}
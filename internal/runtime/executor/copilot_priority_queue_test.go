@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func waitForQueued(t *testing.T, q *copilotDispatchQueue, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.Lock()
+		queued := q.waiting[copilotDispatchPriorityUser].Len() + q.waiting[copilotDispatchPriorityAgent].Len()
+		q.mu.Unlock()
+		if queued == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d queued requests", want)
+}
+
+func TestCopilotDispatchQueue_UserJumpsAheadOfAgent(t *testing.T) {
+	q := newCopilotDispatchQueue(1)
+	ctx := context.Background()
+
+	releaseFirst, err := q.acquire(ctx, copilotDispatchPriorityAgent)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	order := make(chan string, 2)
+	dispatch := func(name string, priority copilotDispatchPriority) {
+		release, errAcquire := q.acquire(ctx, priority)
+		if errAcquire != nil {
+			t.Errorf("%s acquire: %v", name, errAcquire)
+			return
+		}
+		order <- name
+		release()
+	}
+
+	go dispatch("agent", copilotDispatchPriorityAgent)
+	waitForQueued(t, q, 1)
+	go dispatch("user", copilotDispatchPriorityUser)
+	waitForQueued(t, q, 2)
+
+	releaseFirst()
+
+	first := <-order
+	second := <-order
+	if first != "user" || second != "agent" {
+		t.Fatalf("expected user before agent, got %s then %s", first, second)
+	}
+}
+
+func TestCopilotDispatchQueue_CancelledWaiterIsRemoved(t *testing.T) {
+	q := newCopilotDispatchQueue(1)
+
+	release, err := q.acquire(context.Background(), copilotDispatchPriorityUser)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, errAcquire := q.acquire(ctx, copilotDispatchPriorityAgent)
+		done <- errAcquire
+	}()
+	waitForQueued(t, q, 1)
+	cancel()
+	if errAcquire := <-done; errAcquire == nil {
+		t.Fatal("expected cancellation error")
+	}
+	release()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inflight != 0 {
+		t.Fatalf("expected no in-flight requests, got %d", q.inflight)
+	}
+	if q.waiting[copilotDispatchPriorityAgent].Len() != 0 {
+		t.Fatal("expected cancelled waiter to be removed")
+	}
+}
+
+func TestCopilotExecutor_AcquireDispatchSlotDisabledByDefault(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	auth := &cliproxyauth.Auth{ID: "priority-queue-disabled"}
+
+	for i := 0; i < 3; i++ {
+		release, err := e.acquireDispatchSlot(context.Background(), auth, true)
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		defer release()
+	}
+
+	sharedCopilotDispatchMu.Lock()
+	_, exists := sharedCopilotDispatchQueues[auth.ID]
+	sharedCopilotDispatchMu.Unlock()
+	if exists {
+		t.Fatal("expected no dispatch queue when priority-queue-concurrency is unset")
+	}
+}
+
+func TestCopilotExecutor_PriorityQueueConcurrencyResolvedPerAuth(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", PriorityQueueConcurrency: 4},
+		{Account: "bob"},
+	}})
+
+	if got := e.priorityQueueConcurrency(&cliproxyauth.Auth{ID: "alice"}); got != 4 {
+		t.Fatalf("alice limit = %d, want 4", got)
+	}
+	if got := e.priorityQueueConcurrency(&cliproxyauth.Auth{ID: "bob"}); got != 0 {
+		t.Fatalf("bob limit = %d, want 0", got)
+	}
+}
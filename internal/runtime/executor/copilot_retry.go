@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	defaultCopilotRetryBaseDelay = 500 * time.Millisecond
+	maxCopilotRetryDelay         = 30 * time.Second
+)
+
+// copilotRetryPolicy returns the retry count and base delay configured on the CopilotKey
+// entry that serves auth. Without a matching entry, retries are disabled.
+func (e *CopilotExecutor) copilotRetryPolicy(auth *cliproxyauth.Auth) (int, time.Duration) {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil {
+		return 0, defaultCopilotRetryBaseDelay
+	}
+	baseDelay := defaultCopilotRetryBaseDelay
+	if entry.RetryBaseDelay != "" {
+		if d, err := time.ParseDuration(entry.RetryBaseDelay); err == nil && d > 0 {
+			baseDelay = d
+		}
+	}
+	return entry.MaxRetries, baseDelay
+}
+
+func isRetryableCopilotStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// copilotRetryDelay honors Retry-After (seconds or HTTP date) and otherwise backs off
+// exponentially from base. The result is capped at maxCopilotRetryDelay.
+func copilotRetryDelay(retryAfter string, base time.Duration, attempt int) time.Duration {
+	delay := time.Duration(0)
+	if retryAfter = strings.TrimSpace(retryAfter); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		} else if at, errParse := http.ParseTime(retryAfter); errParse == nil {
+			delay = time.Until(at)
+		}
+	}
+	if delay <= 0 {
+		delay = base << attempt
+	}
+	if delay <= 0 || delay > maxCopilotRetryDelay {
+		delay = maxCopilotRetryDelay
+	}
+	return delay
+}
+
+// doWithRetry sends req and retries on 429/503 according to auth's configured policy. Retries
+// happen before the response body is handed to the caller, so streaming requests are never
+// retried once bytes have been emitted to the client.
+func (e *CopilotExecutor) doWithRetry(ctx context.Context, auth *cliproxyauth.Auth, client *http.Client, req *http.Request) (*http.Response, error) {
+	maxRetries, baseDelay := e.copilotRetryPolicy(auth)
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+		if err != nil || attempt >= maxRetries || !isRetryableCopilotStatus(resp.StatusCode) || req.GetBody == nil {
+			return resp, err
+		}
+
+		delay := copilotRetryDelay(resp.Header.Get("Retry-After"), baseDelay, attempt)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if errClose := resp.Body.Close(); errClose != nil {
+			e.log().Errorf("copilot executor: close response body error: %v", errClose)
+		}
+		metrics.RecordError("retry")
+		e.log().Debugf("copilot executor: upstream status %d, retry %d/%d in %s", resp.StatusCode, attempt+1, maxRetries, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		body, errBody := req.GetBody()
+		if errBody != nil {
+			return nil, errBody
+		}
+		req.Body = body
+	}
+}
@@ -0,0 +1,164 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func retryCounterValue(t *testing.T) float64 {
+	t.Helper()
+	families, err := metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "cliproxy_errors_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "type" && label.GetValue() == "retry" {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestCopilotExecutor_DoWithRetry_RetriesThenSucceeds(t *testing.T) {
+	metrics.SetEnabled(true)
+	defer metrics.SetEnabled(false)
+
+	var calls atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{MaxRetries: 2, RetryBaseDelay: "1ms"}}})
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, bytes.NewReader([]byte(`{"model":"gpt-4.1"}`)))
+
+	before := retryCounterValue(t)
+	resp, err := e.doWithRetry(context.Background(), nil, server.Client(), req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("upstream calls = %d, want 2", got)
+	}
+	if len(bodies) != 2 || bodies[1] != `{"model":"gpt-4.1"}` {
+		t.Fatalf("expected request body to be replayed on retry, got %v", bodies)
+	}
+	if got := retryCounterValue(t) - before; got != 1 {
+		t.Fatalf("retry counter delta = %v, want 1", got)
+	}
+}
+
+func TestCopilotExecutor_DoWithRetry_StopsAtMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{MaxRetries: 2, RetryBaseDelay: "1ms"}}})
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, bytes.NewReader([]byte(`{}`)))
+
+	resp, err := e.doWithRetry(context.Background(), nil, server.Client(), req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", resp.StatusCode)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("upstream calls = %d, want 3 (1 + 2 retries)", got)
+	}
+}
+
+func TestCopilotExecutor_DoWithRetry_DisabledByDefault(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, bytes.NewReader([]byte(`{}`)))
+
+	resp, err := e.doWithRetry(context.Background(), nil, server.Client(), req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("upstream calls = %d, want 1", got)
+	}
+}
+
+func TestCopilotExecutor_RetryPolicyResolvedPerAuth(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", MaxRetries: 5, RetryBaseDelay: "2s"},
+		{Account: "bob"},
+	}})
+
+	if retries, delay := e.copilotRetryPolicy(&cliproxyauth.Auth{ID: "alice"}); retries != 5 || delay != 2*time.Second {
+		t.Fatalf("alice policy = (%d, %s), want (5, 2s)", retries, delay)
+	}
+	if retries, delay := e.copilotRetryPolicy(&cliproxyauth.Auth{ID: "bob"}); retries != 0 || delay != defaultCopilotRetryBaseDelay {
+		t.Fatalf("bob policy = (%d, %s), want (0, %s)", retries, delay, defaultCopilotRetryBaseDelay)
+	}
+}
+
+func TestCopilotRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	tests := []struct {
+		name       string
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{name: "exponential first attempt", attempt: 0, want: 100 * time.Millisecond},
+		{name: "exponential third attempt", attempt: 2, want: 400 * time.Millisecond},
+		{name: "retry-after seconds", retryAfter: "2", attempt: 0, want: 2 * time.Second},
+		{name: "retry-after capped", retryAfter: "3600", attempt: 0, want: maxCopilotRetryDelay},
+		{name: "invalid retry-after falls back", retryAfter: "soon", attempt: 1, want: 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := copilotRetryDelay(tt.retryAfter, base, tt.attempt); got != tt.want {
+				t.Fatalf("copilotRetryDelay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyCopilotRoutingRules sends req to the Target of the first CopilotKey.RoutingRules
+// entry that matches the request. Conditions are evaluated against the same hints used
+// for the Copilot headers; the prompt is only tokenized when a rule needs its size. The
+// payload model is rewritten too, so translation and header profile selection follow
+// the routed model.
+func (e *CopilotExecutor) applyCopilotRoutingRules(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) cliproxyexecutor.Request {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil || len(entry.RoutingRules) == 0 {
+		return req
+	}
+	model := stripCopilotPrefix(req.Model)
+	hints := e.collectCopilotHeaderHints(req.Payload, e.allowedIncomingHeaders(opts.Headers), copilotHintScanMaxBytes(entry))
+	promptTokens := -1
+	tokens := func() int {
+		if promptTokens < 0 {
+			promptTokens = e.estimateCopilotRoutingTokens(model, req.Payload, opts.SourceFormat)
+		}
+		return promptTokens
+	}
+
+	for _, rule := range entry.RoutingRules {
+		if !copilotRoutingRuleMatches(rule, model, hints, tokens) {
+			continue
+		}
+		if strings.EqualFold(stripCopilotPrefix(rule.Target), model) {
+			return req
+		}
+		e.log().Debugf("copilot executor: routing rule sends %s to %s", model, rule.Target)
+		req.Model = rule.Target
+		if gjson.GetBytes(req.Payload, "model").Exists() {
+			if updated, err := sjson.SetBytes(bytes.Clone(req.Payload), "model", rule.Target); err == nil {
+				req.Payload = updated
+			}
+		}
+		return req
+	}
+	return req
+}
+
+// copilotRoutingRuleMatches reports whether every condition set on rule holds.
+func copilotRoutingRuleMatches(rule config.CopilotRoutingRule, model string, hints copilotHeaderHints, tokens func() int) bool {
+	if len(rule.Models) > 0 {
+		id := strings.ToLower(model)
+		matched := false
+		for _, pattern := range rule.Models {
+			if ok, err := path.Match(pattern, id); err == nil && ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if rule.HasVision != nil && *rule.HasVision != hints.hasVision {
+		return false
+	}
+	if rule.Agent != nil && *rule.Agent != hints.agentFromPayload {
+		return false
+	}
+	if rule.MinPromptTokens > 0 && tokens() < rule.MinPromptTokens {
+		return false
+	}
+	return true
+}
+
+// estimateCopilotRoutingTokens tokenizes the prompt as it will be sent to Copilot. Failures
+// count as an empty prompt so size-based rules do not fire.
+func (e *CopilotExecutor) estimateCopilotRoutingTokens(model string, payload []byte, from sdktranslator.Format) int {
+	body := sdktranslator.TranslateRequest(from, sdktranslator.FromString("openai"), model, bytes.Clone(payload), false)
+	count, err := countCopilotPromptTokens(model, body)
+	if err != nil {
+		e.log().Debugf("copilot executor: routing token estimate skipped: %v", err)
+		return 0
+	}
+	return count
+}
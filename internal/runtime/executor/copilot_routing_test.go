@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func routingRequest(model string, payload string) (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	return cliproxyexecutor.Request{Model: model, Payload: []byte(payload)},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+}
+
+func TestCopilotRoutingRules_Vision(t *testing.T) {
+	vision := true
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{RoutingRules: []config.CopilotRoutingRule{
+		{Models: []string{"o3-*"}, HasVision: &vision, Target: "gpt-4.1"},
+	}}}})
+
+	req, opts := routingRequest("o3-mini", `{"model":"o3-mini","messages":[{"role":"user","content":[{"type":"text","text":"what is this"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}]}`)
+	routed := e.applyCopilotRoutingRules(nil, req, opts)
+	if routed.Model != "gpt-4.1" || gjson.GetBytes(routed.Payload, "model").String() != "gpt-4.1" {
+		t.Fatalf("vision request not routed: model=%q payload=%s", routed.Model, routed.Payload)
+	}
+	if gjson.GetBytes(req.Payload, "model").String() != "o3-mini" {
+		t.Fatalf("original payload modified: %s", req.Payload)
+	}
+
+	req, opts = routingRequest("o3-mini", `{"model":"o3-mini","messages":[{"role":"user","content":"hello"}]}`)
+	if routed := e.applyCopilotRoutingRules(nil, req, opts); routed.Model != "o3-mini" {
+		t.Fatalf("text-only request routed to %q", routed.Model)
+	}
+
+	req, opts = routingRequest("claude-sonnet-4", `{"model":"claude-sonnet-4","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}]}`)
+	if routed := e.applyCopilotRoutingRules(nil, req, opts); routed.Model != "claude-sonnet-4" {
+		t.Fatalf("model outside the rule glob routed to %q", routed.Model)
+	}
+}
+
+func TestCopilotRoutingRules_PromptSize(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{RoutingRules: []config.CopilotRoutingRule{
+		{Models: []string{"gpt-4o"}, MinPromptTokens: 1000, Target: "gpt-4.1"},
+	}}}})
+
+	large := strings.Repeat("lorem ipsum dolor sit amet ", 500)
+	req, opts := routingRequest("copilot-gpt-4o", `{"model":"copilot-gpt-4o","messages":[{"role":"user","content":"`+large+`"}]}`)
+	if routed := e.applyCopilotRoutingRules(nil, req, opts); routed.Model != "gpt-4.1" {
+		t.Fatalf("large prompt not routed: model=%q", routed.Model)
+	}
+
+	req, opts = routingRequest("gpt-4o", `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`)
+	if routed := e.applyCopilotRoutingRules(nil, req, opts); routed.Model != "gpt-4o" {
+		t.Fatalf("small prompt routed to %q", routed.Model)
+	}
+}
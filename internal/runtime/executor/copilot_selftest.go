@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// SelfTest verifies a Copilot credential without generating anything: it checks the
+// CopilotKey header profile settings, resolves the Copilot token, and lists models with
+// the same headers a real request would carry. The model cache is bypassed.
+func (e *CopilotExecutor) SelfTest(ctx context.Context, auth *cliproxyauth.Auth) (int, string, error) {
+	entry := e.copilotKeyForAuth(auth)
+	detail := "header-profile " + copilotSelfTestProfile(entry)
+	if err := validateCopilotHeaderProfile(entry); err != nil {
+		return 0, detail, err
+	}
+
+	copilotToken, accountType, err := e.getCopilotToken(ctx, auth)
+	if err != nil {
+		return 0, detail, fmt.Errorf("copilot token: %w", err)
+	}
+
+	url := e.copilotBaseURL(auth, accountType) + "/models"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, detail, err
+	}
+	e.applyCopilotHeaders(httpReq, auth, copilotToken, nil, nil)
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return 0, detail, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			e.log().Errorf("copilot executor: close self-test response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return 0, detail, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return 0, detail, statusErr{code: httpResp.StatusCode, msg: fmt.Sprintf("list models: status %d: %s", httpResp.StatusCode, strings.TrimSpace(string(data)))}
+	}
+	models := gjson.GetBytes(data, "data")
+	if !models.IsArray() {
+		return 0, detail, fmt.Errorf("list models: response has no data array")
+	}
+	return len(models.Array()), detail, nil
+}
+
+// copilotSelfTestProfile describes the configured default header profile; "auto" means the
+// built-in per-model allowlist decides.
+func copilotSelfTestProfile(entry *config.CopilotKey) string {
+	if entry == nil || strings.TrimSpace(entry.HeaderProfile) == "" {
+		return "auto"
+	}
+	return strings.ToLower(strings.TrimSpace(entry.HeaderProfile))
+}
+
+// validateCopilotHeaderProfile rejects header-profile values that would silently fall back
+// to the allowlist, and models listed under both profile overrides.
+func validateCopilotHeaderProfile(entry *config.CopilotKey) error {
+	if entry == nil {
+		return nil
+	}
+	switch profile := copilotSelfTestProfile(entry); copilotHeaderProfile(profile) {
+	case "auto", copilotHeaderProfileCLI, copilotHeaderProfileVSCodeChat:
+	default:
+		return fmt.Errorf("unknown header-profile %q", entry.HeaderProfile)
+	}
+	cliModels := make(map[string]struct{}, len(entry.CLIHeaderModels))
+	for _, model := range entry.CLIHeaderModels {
+		cliModels[normalizeModelID(model)] = struct{}{}
+	}
+	for _, model := range entry.VSCodeChatHeaderModels {
+		if _, ok := cliModels[normalizeModelID(model)]; ok {
+			return fmt.Errorf("model %q is listed in both cli-header-models and vscode-chat-header-models", model)
+		}
+	}
+	return nil
+}
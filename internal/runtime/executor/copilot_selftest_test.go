@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func newSelfTestUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/models" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"bad credentials"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4.1"},{"id":"claude-sonnet-4"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCopilotSelfTest(t *testing.T) {
+	srv := newSelfTestUpstream(t)
+	auth := &cliproxyauth.Auth{ID: "copilot-selftest", Provider: "copilot"}
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "copilot-selftest", BaseURL: srv.URL, Token: "good-token", HeaderProfile: "vscode-chat"}}})
+	models, detail, err := e.SelfTest(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if models != 2 || detail != "header-profile vscode-chat" {
+		t.Fatalf("models = %d, detail = %q", models, detail)
+	}
+
+	e = NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "copilot-selftest", BaseURL: srv.URL, Token: "revoked-token"}}})
+	_, _, err = e.SelfTest(context.Background(), auth)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+}
+
+func TestCopilotSelfTestRejectsHeaderProfiles(t *testing.T) {
+	for name, entry := range map[string]config.CopilotKey{
+		"unknown profile":   {HeaderProfile: "jetbrains"},
+		"conflicting lists": {CLIHeaderModels: []string{"gpt-4.1"}, VSCodeChatHeaderModels: []string{"GPT-4.1"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			entry.Token = "good-token"
+			e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{entry}})
+			if _, _, err := e.SelfTest(context.Background(), &cliproxyauth.Auth{ID: "copilot-selftest", Provider: "copilot"}); err == nil {
+				t.Fatal("expected a header profile error")
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// requestStreamUsage asks upstream to append a final usage chunk to the stream. It reports
+// whether the option was injected by the proxy rather than requested by the client.
+func requestStreamUsage(body []byte) ([]byte, bool) {
+	if gjson.GetBytes(body, "stream_options.include_usage").Bool() {
+		return body, false
+	}
+	updated, err := sjson.SetBytes(body, "stream_options.include_usage", true)
+	if err != nil {
+		return body, false
+	}
+	return updated, true
+}
+
+// isUsageOnlyChunk reports whether an OpenAI stream chunk carries usage and no choices.
+func isUsageOnlyChunk(data []byte) bool {
+	if !gjson.GetBytes(data, "usage").Exists() {
+		return false
+	}
+	choices := gjson.GetBytes(data, "choices")
+	return !choices.Exists() || len(choices.Array()) == 0
+}
+
+// copilotStreamUsage tracks whether a stream reported usage and accumulates the generated
+// text so output tokens can be estimated when upstream omits the usage chunk.
+type copilotStreamUsage struct {
+	logger   *log.Logger
+	model    string
+	reported bool
+	output   strings.Builder
+}
+
+func newCopilotStreamUsage(logger *log.Logger, model string) *copilotStreamUsage {
+	return &copilotStreamUsage{logger: logger, model: model}
+}
+
+// observe records a decoded data chunk. It returns the parsed usage when the chunk carries it.
+func (u *copilotStreamUsage) observe(data []byte) (usage.Detail, bool) {
+	if gjson.GetBytes(data, "usage").Exists() {
+		u.reported = true
+		return parseOpenAIUsage(data), true
+	}
+	gjson.GetBytes(data, "choices").ForEach(func(_, choice gjson.Result) bool {
+		delta := choice.Get("delta")
+		u.output.WriteString(delta.Get("content").String())
+		u.output.WriteString(delta.Get("reasoning_content").String())
+		delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			u.output.WriteString(call.Get("function.arguments").String())
+			return true
+		})
+		return true
+	})
+	return usage.Detail{}, false
+}
+
+// estimate counts tokens locally for streams that finished without a usage chunk.
+func (u *copilotStreamUsage) estimate(body []byte) (usage.Detail, bool) {
+	if u.reported {
+		return usage.Detail{}, false
+	}
+	enc, err := tokenizerForCodexModel(u.model)
+	if err != nil {
+		u.logger.Debugf("copilot executor: stream usage estimate skipped: %v", err)
+		return usage.Detail{}, false
+	}
+	var detail usage.Detail
+	if text := u.output.String(); text != "" {
+		if count, errCount := enc.Count(text); errCount == nil {
+			detail.OutputTokens = int64(count)
+		}
+	}
+	if count, errCount := countCopilotPromptTokens(u.model, body); errCount == nil {
+		detail.InputTokens = int64(count)
+	}
+	return detail, detail.InputTokens > 0 || detail.OutputTokens > 0
+}
@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// feedCopilotStream runs SSE lines through the stream usage tracker the way ExecuteStream does
+// and returns the usage that would be published.
+func feedCopilotStream(t *testing.T, body []byte, lines []string) (usage.Detail, bool) {
+	t.Helper()
+	tracker := newCopilotStreamUsage(log.StandardLogger(), "gpt-4.1")
+	for _, line := range lines {
+		raw := []byte(line)
+		if !bytes.HasPrefix(raw, dataTag) {
+			continue
+		}
+		if detail, ok := tracker.observe(bytes.TrimSpace(raw[5:])); ok {
+			return detail, true
+		}
+	}
+	return tracker.estimate(body)
+}
+
+func TestCopilotStreamUsage_UsesUpstreamUsageChunk(t *testing.T) {
+	body := []byte(`{"messages":[{"role":"user","content":"hello"}]}`)
+	detail, ok := feedCopilotStream(t, body, []string{
+		`data: {"choices":[{"index":0,"delta":{"content":"Hi there"}}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`,
+		`data: [DONE]`,
+	})
+	if !ok {
+		t.Fatal("expected usage from the final chunk")
+	}
+	if detail.InputTokens != 12 || detail.OutputTokens != 3 {
+		t.Fatalf("usage = %+v, want 12 input / 3 output", detail)
+	}
+}
+
+func TestCopilotStreamUsage_EstimatesWhenUsageMissing(t *testing.T) {
+	body := []byte(`{"messages":[{"role":"user","content":"hello there, how are you?"}]}`)
+	detail, ok := feedCopilotStream(t, body, []string{
+		`data: {"choices":[{"index":0,"delta":{"content":"I am doing "}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"content":"well, thanks for asking."}}]}`,
+		`data: [DONE]`,
+	})
+	if !ok {
+		t.Fatal("expected an estimated usage when upstream omits it")
+	}
+	enc, err := tokenizerForCodexModel("gpt-4.1")
+	if err != nil {
+		t.Fatalf("tokenizer: %v", err)
+	}
+	want, _ := enc.Count("I am doing well, thanks for asking.")
+	if detail.OutputTokens != int64(want) {
+		t.Fatalf("output tokens = %d, want %d", detail.OutputTokens, want)
+	}
+	if detail.InputTokens <= 0 {
+		t.Fatalf("expected estimated input tokens, got %d", detail.InputTokens)
+	}
+}
+
+func TestRequestStreamUsage(t *testing.T) {
+	out, injected := requestStreamUsage([]byte(`{"stream":true}`))
+	if !injected || !gjson.GetBytes(out, "stream_options.include_usage").Bool() {
+		t.Fatalf("expected include_usage injected, got %s", out)
+	}
+	out, injected = requestStreamUsage([]byte(`{"stream_options":{"include_usage":true}}`))
+	if injected {
+		t.Fatalf("client-requested usage must not be reported as injected: %s", out)
+	}
+	if !isUsageOnlyChunk([]byte(`{"choices":[],"usage":{"prompt_tokens":1}}`)) {
+		t.Fatal("expected usage-only chunk")
+	}
+	if isUsageOnlyChunk([]byte(`{"choices":[{"delta":{"content":"x"}}],"usage":{"prompt_tokens":1}}`)) {
+		t.Fatal("chunk with choices must not be treated as usage-only")
+	}
+}
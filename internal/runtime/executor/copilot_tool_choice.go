@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// normalizeToolChoice downgrades tool_choice "required" to "auto" for models that are not
+// listed in the ToolChoiceRequiredModels of the CopilotKey serving auth. Without an
+// allowlist the payload is left untouched; "auto", "none" and named tool choices are never
+// rewritten.
+func (e *CopilotExecutor) normalizeToolChoice(auth *cliproxyauth.Auth, model string, body []byte) []byte {
+	allowlist := toolChoiceRequiredModels(e.copilotKeyForAuth(auth))
+	if len(allowlist) == 0 {
+		return body
+	}
+	m := strings.TrimPrefix(normalizeModelID(model), "copilot-")
+	if _, ok := allowlist[m]; ok {
+		return body
+	}
+	return e.downgradeRequiredToolChoice(body, model)
+}
+
+// downgradeRequiredToolChoice rewrites a string tool_choice of "required" to "auto".
+func (e *CopilotExecutor) downgradeRequiredToolChoice(body []byte, model string) []byte {
+	choice := gjson.GetBytes(body, "tool_choice")
+	if choice.Type != gjson.String || !strings.EqualFold(strings.TrimSpace(choice.String()), "required") {
+		return body
+	}
+	updated, err := sjson.SetBytes(body, "tool_choice", "auto")
+	if err != nil {
+		return body
+	}
+	e.log().Debugf("copilot executor: downgraded tool_choice required to auto for model %s", model)
+	return updated
+}
+
+// toolChoiceRequiredModels returns entry's normalized ToolChoiceRequiredModels.
+func toolChoiceRequiredModels(entry *config.CopilotKey) map[string]struct{} {
+	if entry == nil {
+		return nil
+	}
+	var models map[string]struct{}
+	for _, v := range entry.ToolChoiceRequiredModels {
+		m := strings.TrimPrefix(normalizeModelID(v), "copilot-")
+		if m == "" {
+			continue
+		}
+		if models == nil {
+			models = make(map[string]struct{})
+		}
+		models[m] = struct{}{}
+	}
+	return models
+}
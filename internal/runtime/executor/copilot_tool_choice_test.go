@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/tidwall/gjson"
+)
+
+func TestCopilotExecutor_NormalizeToolChoice(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{
+		ToolChoiceRequiredModels: []string{"gpt-4.1"},
+	}}})
+
+	tests := []struct {
+		name  string
+		model string
+		body  string
+		want  string
+	}{
+		{
+			name:  "required downgraded for unlisted model",
+			model: "claude-sonnet-4",
+			body:  `{"tool_choice":"required"}`,
+			want:  `"auto"`,
+		},
+		{
+			name:  "required kept for listed model",
+			model: "gpt-4.1",
+			body:  `{"tool_choice":"required"}`,
+			want:  `"required"`,
+		},
+		{
+			name:  "required kept for listed model via copilot alias",
+			model: "copilot-gpt-4.1",
+			body:  `{"tool_choice":"required"}`,
+			want:  `"required"`,
+		},
+		{
+			name:  "auto untouched",
+			model: "claude-sonnet-4",
+			body:  `{"tool_choice":"auto"}`,
+			want:  `"auto"`,
+		},
+		{
+			name:  "none untouched",
+			model: "claude-sonnet-4",
+			body:  `{"tool_choice":"none"}`,
+			want:  `"none"`,
+		},
+		{
+			name:  "named function choice untouched",
+			model: "claude-sonnet-4",
+			body:  `{"tool_choice":{"type":"function","function":{"name":"get_weather"}}}`,
+			want:  `{"type":"function","function":{"name":"get_weather"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := e.normalizeToolChoice(nil, tt.model, []byte(tt.body))
+			if got := gjson.GetBytes(out, "tool_choice").Raw; got != tt.want {
+				t.Fatalf("tool_choice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopilotExecutor_NormalizeToolChoiceWithoutAllowlist(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{}}})
+	body := []byte(`{"tool_choice":"required"}`)
+	if out := e.normalizeToolChoice(nil, "claude-sonnet-4", body); string(out) != string(body) {
+		t.Fatalf("expected payload unchanged without allowlist, got %s", out)
+	}
+}
+
+func TestCopilotExecutor_NormalizeToolChoiceUsesEntryServingAuth(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{
+		{Account: "alice", ToolChoiceRequiredModels: []string{"gpt-4.1"}},
+		{Account: "bob", ToolChoiceRequiredModels: []string{"claude-sonnet-4"}},
+	}})
+	body := []byte(`{"tool_choice":"required"}`)
+
+	if got := gjson.GetBytes(e.normalizeToolChoice(&cliproxyauth.Auth{ID: "alice"}, "gpt-4.1", body), "tool_choice").String(); got != "required" {
+		t.Fatalf("alice tool_choice = %q, want required", got)
+	}
+	if got := gjson.GetBytes(e.normalizeToolChoice(&cliproxyauth.Auth{ID: "bob"}, "gpt-4.1", body), "tool_choice").String(); got != "auto" {
+		t.Fatalf("bob tool_choice = %q, want auto", got)
+	}
+}
+
+func TestCopilotExecutor_NormalizeToolChoiceLogFollowsModuleLevel(t *testing.T) {
+	std := log.StandardLogger()
+	previousLevel := std.GetLevel()
+	hook := &logtest.Hook{}
+	previousHooks := std.ReplaceHooks(log.LevelHooks{})
+	std.AddHook(hook)
+	std.SetLevel(log.DebugLevel)
+	t.Cleanup(func() {
+		std.ReplaceHooks(previousHooks)
+		std.SetLevel(previousLevel)
+	})
+
+	downgradeLogged := func(level string) bool {
+		hook.Reset()
+		e := NewCopilotExecutor(&config.Config{
+			LogLevels:  map[string]string{"copilot": level},
+			CopilotKey: []config.CopilotKey{{ToolChoiceRequiredModels: []string{"gpt-4.1"}}},
+		})
+		e.normalizeToolChoice(nil, "claude-sonnet-4", []byte(`{"tool_choice":"required"}`))
+		return len(hook.AllEntries()) > 0
+	}
+
+	if !downgradeLogged("debug") {
+		t.Fatal("expected the downgrade line with copilot at debug")
+	}
+	if downgradeLogged("warn") {
+		t.Fatal("expected the downgrade line suppressed with copilot at warn")
+	}
+}
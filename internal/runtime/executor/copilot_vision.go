@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	copilotVisionFallbackStrip  = "strip"
+	copilotVisionFallbackReject = "reject"
+)
+
+// copilotModelLacksVision reports whether the registry knows model and its provider reported
+// that it does not accept image inputs. Unknown models, and models whose vision support was
+// never reported (essential, fallback and static entries), are assumed to support vision so
+// requests are not altered.
+func copilotModelLacksVision(model string) bool {
+	model = stripCopilotPrefix(model)
+	if model == "" {
+		return false
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(model)
+	return info != nil && info.VisionKnown && !info.SupportsVision
+}
+
+// copilotVisionFallback returns the configured vision fallback for the credential's CopilotKey.
+func copilotVisionFallback(entry *config.CopilotKey) string {
+	if entry == nil {
+		return ""
+	}
+	return entry.VisionFallback
+}
+
+// stripCopilotImageParts removes image_url parts from Chat Completions message content.
+// Messages left without any content parts get an empty string content.
+func stripCopilotImageParts(body []byte) []byte {
+	messages := gjson.GetBytes(body, "messages")
+	if !messages.IsArray() {
+		return body
+	}
+	out := body
+	for i, msg := range messages.Array() {
+		content := msg.Get("content")
+		if !content.IsArray() {
+			continue
+		}
+		kept := make([]string, 0, len(content.Array()))
+		stripped := false
+		for _, part := range content.Array() {
+			if part.Get("type").String() == "image_url" {
+				stripped = true
+				continue
+			}
+			kept = append(kept, part.Raw)
+		}
+		if !stripped {
+			continue
+		}
+		path := fmt.Sprintf("messages.%d.content", i)
+		var err error
+		if len(kept) == 0 {
+			out, err = sjson.SetBytes(out, path, "")
+		} else {
+			out, err = sjson.SetRawBytes(out, path, []byte("["+strings.Join(kept, ",")+"]"))
+		}
+		if err != nil {
+			return body
+		}
+	}
+	return out
+}
+
+// applyVisionFallback enforces the credential's vision fallback when the translated body
+// carries images for a model without vision support.
+func (e *CopilotExecutor) applyVisionFallback(auth *cliproxyauth.Auth, model string, body []byte) ([]byte, error) {
+	entry := e.copilotKeyForAuth(auth)
+	fallback := copilotVisionFallback(entry)
+	if fallback == "" || !e.collectCopilotHeaderHints(body, nil, copilotHintScanMaxBytes(entry)).visionUnsupported {
+		return body, nil
+	}
+	switch fallback {
+	case copilotVisionFallbackReject:
+		msg := interfaces.OpenAIErrorBody(http.StatusBadRequest, fmt.Sprintf("model %s does not support image inputs", model), "messages", "model_not_vision_capable")
+		return body, statusErr{code: http.StatusBadRequest, msg: string(msg)}
+	case copilotVisionFallbackStrip:
+		e.log().Debugf("copilot executor: stripping image parts for non-vision model %s", model)
+		return stripCopilotImageParts(body), nil
+	default:
+		return body, nil
+	}
+}
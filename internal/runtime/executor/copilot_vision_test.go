@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	visionTestModel    = "vision-test-model"
+	nonVisionTestModel = "non-vision-test-model"
+)
+
+func registerVisionTestModels(t *testing.T) {
+	t.Helper()
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("vision-test-client", "copilot", []*registry.ModelInfo{
+		{ID: visionTestModel, Object: "model", Created: time.Now().Unix(), OwnedBy: "copilot", SupportsVision: true, VisionKnown: true},
+		{ID: nonVisionTestModel, Object: "model", Created: time.Now().Unix(), OwnedBy: "copilot", VisionKnown: true},
+	})
+	t.Cleanup(func() { reg.UnregisterClient("vision-test-client") })
+}
+
+func visionPayload(model string) []byte {
+	return []byte(`{"model":"` + model + `","messages":[{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}]}`)
+}
+
+func TestCopilotApplyVisionFallback(t *testing.T) {
+	registerVisionTestModels(t)
+
+	tests := []struct {
+		name       string
+		fallback   string
+		model      string
+		wantStatus int
+		wantImages bool
+	}{
+		{name: "vision model untouched by strip", fallback: "strip", model: visionTestModel, wantImages: true},
+		{name: "vision model untouched by reject", fallback: "reject", model: visionTestModel, wantImages: true},
+		{name: "non-vision model stripped", fallback: "strip", model: nonVisionTestModel, wantImages: false},
+		{name: "non-vision model rejected", fallback: "reject", model: nonVisionTestModel, wantStatus: http.StatusBadRequest},
+		{name: "non-vision model forwarded when unset", fallback: "", model: nonVisionTestModel, wantImages: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{VisionFallback: tt.fallback}}})
+			out, err := e.applyVisionFallback(nil, tt.model, visionPayload(tt.model))
+			if tt.wantStatus != 0 {
+				var se statusErr
+				if !errors.As(err, &se) || se.StatusCode() != tt.wantStatus {
+					t.Fatalf("expected status %d, got %v", tt.wantStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content := gjson.GetBytes(out, "messages.0.content")
+			hasImage := false
+			for _, part := range content.Array() {
+				if part.Get("type").String() == "image_url" {
+					hasImage = true
+				}
+			}
+			if hasImage != tt.wantImages {
+				t.Fatalf("image parts present = %v, want %v; body = %s", hasImage, tt.wantImages, out)
+			}
+			if got := content.Get("0.text").String(); got != "describe" {
+				t.Fatalf("text part = %q, want describe", got)
+			}
+		})
+	}
+}
+
+func TestCopilotApplyVisionFallback_EssentialModelKeepsImages(t *testing.T) {
+	models := (&CopilotExecutor{}).mergeEssentialCopilotModels(nil, time.Now().Unix())
+	if len(models) == 0 {
+		t.Fatal("expected essential Copilot models")
+	}
+	model := models[0].ID
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("vision-essential-client", "copilot", models)
+	t.Cleanup(func() { reg.UnregisterClient("vision-essential-client") })
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{VisionFallback: "reject"}}})
+	out, err := e.applyVisionFallback(nil, model, visionPayload(model))
+	if err != nil {
+		t.Fatalf("unexpected error for essential model %s: %v", model, err)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content.1.type").String(); got != "image_url" {
+		t.Fatalf("image part removed for essential model %s: %s", model, out)
+	}
+}
+
+func TestStripCopilotImageParts_ImageOnlyMessage(t *testing.T) {
+	body := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`)
+	out := stripCopilotImageParts(body)
+	if content := gjson.GetBytes(out, "messages.0.content"); content.Type != gjson.String || content.String() != "" {
+		t.Fatalf("expected empty string content, got %s", content.Raw)
+	}
+}
+
+func TestApplyCopilotHeaders_VisionHeaderFollowsModelCapability(t *testing.T) {
+	registerVisionTestModels(t)
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{VisionFallback: "strip"}}})
+
+	for model, want := range map[string]string{visionTestModel: "true", nonVisionTestModel: ""} {
+		req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		e.applyCopilotHeaders(req, nil, "test-token", visionPayload(model), nil)
+		if got := req.Header.Get("Copilot-Vision-Request"); got != want {
+			t.Fatalf("%s: Copilot-Vision-Request = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestCopilotExecutor_RejectsImagesForNonVisionModel(t *testing.T) {
+	registerVisionTestModels(t)
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{VisionFallback: "reject"}}})
+	auth := &cliproxyauth.Auth{ID: "vision-reject", Metadata: map[string]any{
+		"copilot_token":        "test-copilot-token",
+		"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}}
+
+	_, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   nonVisionTestModel,
+		Payload: visionPayload(nonVisionTestModel),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("expected 400 before contacting upstream, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// providerCredential asks the CredentialProvider attached to ctx for a token for provider.
+// It reports false when no provider is attached, it has no token, or the token has expired,
+// in which case callers use the credentials held by auth. Expiring tokens update the
+// credential expiry metric for auth.
+func providerCredential(ctx context.Context, provider string, auth *cliproxyauth.Auth) (string, bool) {
+	cp, ok := cliproxyauth.CredentialProviderFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	token, expiry, err := cp.Get(ctx, provider)
+	if err != nil {
+		if !errors.Is(err, cliproxyauth.ErrCredentialNotFound) {
+			log.Warnf("%s executor: credential provider failed, using configured credentials: %v", provider, err)
+		}
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", false
+	}
+	if !expiry.IsZero() {
+		if auth != nil {
+			metrics.SetCredentialExpiry(provider, auth.ID, expiry)
+		}
+		if time.Until(expiry) <= 0 {
+			log.Warnf("%s executor: credential provider returned an expired token, using configured credentials", provider)
+			return "", false
+		}
+	}
+	return token, true
+}
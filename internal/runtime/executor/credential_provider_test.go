@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// rotatingCredentialProvider hands out the current token and lets tests rotate it.
+type rotatingCredentialProvider struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	err    error
+	calls  int
+}
+
+func (p *rotatingCredentialProvider) Get(_ context.Context, _ string) (string, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return p.token, p.expiry, p.err
+}
+
+func (p *rotatingCredentialProvider) rotate(token string) {
+	p.mu.Lock()
+	p.token = token
+	p.mu.Unlock()
+}
+
+func withCredentialProvider(p cliproxyauth.CredentialProvider) context.Context {
+	return cliproxyauth.WithCredentialProvider(context.Background(), p)
+}
+
+func TestCodexExecutor_UsesLatestProviderCredential(t *testing.T) {
+	var gotAuth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	provider := &rotatingCredentialProvider{token: "token-1", expiry: time.Now().Add(time.Hour)}
+	ctx := withCredentialProvider(provider)
+	e := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-cred", Attributes: map[string]string{"api_key": "static-key", "base_url": server.URL}}
+	execute := func() {
+		t.Helper()
+		_, err := e.Execute(ctx, auth, cliproxyexecutor.Request{
+			Model:   "gpt-5",
+			Payload: []byte(`{"model":"gpt-5","input":"hello"}`),
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+
+	execute()
+	provider.rotate("token-2")
+	execute()
+
+	want := []string{"Bearer token-1", "Bearer token-2"}
+	if len(gotAuth) != len(want) || gotAuth[0] != want[0] || gotAuth[1] != want[1] {
+		t.Fatalf("Authorization headers = %v, want %v", gotAuth, want)
+	}
+}
+
+func TestProviderCredential_FallsBackToConfiguredCredentials(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "fallback-cred"}
+	tests := []struct {
+		name     string
+		provider *rotatingCredentialProvider
+	}{
+		{name: "not found", provider: &rotatingCredentialProvider{err: cliproxyauth.ErrCredentialNotFound}},
+		{name: "provider error", provider: &rotatingCredentialProvider{err: errors.New("vault sealed")}},
+		{name: "expired token", provider: &rotatingCredentialProvider{token: "stale", expiry: time.Now().Add(-time.Minute)}},
+		{name: "empty token", provider: &rotatingCredentialProvider{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if token, ok := providerCredential(withCredentialProvider(tt.provider), "codex", auth); ok {
+				t.Fatalf("expected fallback, got token %q", token)
+			}
+		})
+	}
+	if _, ok := providerCredential(context.Background(), "codex", auth); ok {
+		t.Fatal("expected fallback without a provider")
+	}
+}
+
+func TestCopilotExecutor_getCopilotToken_CredentialProvider(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{Account: "copilot-cred", Token: "configured-token"}}})
+	auth := &cliproxyauth.Auth{ID: "copilot-cred", Metadata: map[string]any{}}
+	provider := &rotatingCredentialProvider{token: "provider-token-1"}
+	ctx := withCredentialProvider(provider)
+
+	for _, want := range []string{"provider-token-1", "provider-token-2"} {
+		provider.rotate(want)
+		token, _, err := e.getCopilotToken(ctx, auth)
+		if err != nil {
+			t.Fatalf("getCopilotToken: %v", err)
+		}
+		if token != want {
+			t.Fatalf("token = %q, want %q", token, want)
+		}
+	}
+
+	token, _, err := e.getCopilotToken(withCredentialProvider(&cliproxyauth.StaticCredentialProvider{}), auth)
+	if err != nil || token != "configured-token" {
+		t.Fatalf("empty static provider: token = %q, err = %v; want configured token", token, err)
+	}
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// dryRunHeader makes an executor return the upstream request it would send instead of
+// sending it. Used to debug request translation without contacting the provider.
+const dryRunHeader = "X-CLIProxy-Dry-Run"
+
+const dryRunRedacted = "[REDACTED]"
+
+// isDryRunRequest reports whether the incoming request asked for a dry run.
+func isDryRunRequest(incoming http.Header) bool {
+	if incoming == nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(incoming.Get(dryRunHeader))) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// buildDryRunPayload renders the final upstream request as JSON with credentials redacted.
+// The payload is embedded verbatim when it is valid JSON and as a string otherwise.
+func buildDryRunPayload(req *http.Request, body []byte) []byte {
+	headers := make(map[string]string, len(req.Header))
+	for key, values := range req.Header {
+		headers[key] = redactDryRunHeader(key, strings.Join(values, ", "))
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var payload any = string(body)
+	if json.Valid(body) {
+		payload = json.RawMessage(body)
+	}
+	out, err := json.Marshal(struct {
+		DryRun  bool              `json:"dry_run"`
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    any               `json:"body"`
+	}{
+		DryRun:  true,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: headers,
+		Body:    payload,
+	})
+	if err != nil {
+		return []byte(`{"dry_run":true}`)
+	}
+	return out
+}
+
+// redactDryRunHeader hides credential-bearing header values, keeping the auth scheme.
+func redactDryRunHeader(key, value string) string {
+	lowerKey := strings.ToLower(key)
+	switch {
+	case lowerKey == "authorization", lowerKey == "proxy-authorization":
+		if scheme, _, ok := strings.Cut(strings.TrimSpace(value), " "); ok {
+			return scheme + " " + dryRunRedacted
+		}
+		return dryRunRedacted
+	case strings.Contains(lowerKey, "api-key"),
+		strings.Contains(lowerKey, "token"),
+		strings.Contains(lowerKey, "secret"),
+		lowerKey == "cookie":
+		return dryRunRedacted
+	default:
+		return value
+	}
+}
+
+// dryRunStream wraps a dry-run payload in a single-chunk stream.
+func dryRunStream(req *http.Request, body []byte) <-chan cliproxyexecutor.StreamChunk {
+	out := make(chan cliproxyexecutor.StreamChunk, 1)
+	out <- cliproxyexecutor.StreamChunk{Payload: buildDryRunPayload(req, body)}
+	close(out)
+	return out
+}
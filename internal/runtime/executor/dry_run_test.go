@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func dryRunHeaders() http.Header {
+	headers := http.Header{}
+	headers.Set(dryRunHeader, "true")
+	return headers
+}
+
+func TestCopilotExecutor_DryRunReturnsUpstreamRequest(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{
+		ID: "copilot-dry-run",
+		Metadata: map[string]any{
+			"copilot_token":        "secret-copilot-token",
+			"copilot_token_expiry": time.Now().Add(time.Hour).Format(time.RFC3339),
+		},
+	}
+
+	resp, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4.1",
+		Payload: []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Headers: dryRunHeaders()})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	out := gjson.ParseBytes(resp.Payload)
+	if !out.Get("dry_run").Bool() {
+		t.Fatalf("expected dry_run=true, got %s", resp.Payload)
+	}
+	if got := out.Get("url").String(); !strings.HasSuffix(got, "/chat/completions") {
+		t.Fatalf("url = %q, want chat completions endpoint", got)
+	}
+	if got := out.Get("headers.X-Initiator").String(); got != "user" {
+		t.Fatalf("X-Initiator = %q, want user", got)
+	}
+	if auth := out.Get("headers.Authorization").String(); strings.Contains(auth, "secret-copilot-token") {
+		t.Fatalf("expected token to be redacted, got %q", auth)
+	}
+	if got := out.Get("body.messages.0.content").String(); got != "hello" {
+		t.Fatalf("translated body content = %q, want hello", got)
+	}
+	if out.Get("body.stream").Bool() {
+		t.Fatalf("expected non-streaming translated body, got %s", out.Get("body").Raw)
+	}
+}
+
+func TestCopilotExecutor_DryRunHasNoNetworkSideEffects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry run must not make network requests, got %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{BaseURL: server.URL, InlineRemoteImages: true}}})
+	// No Copilot token is cached, so a real request would have to exchange one first.
+	auth := &cliproxyauth.Auth{ID: "copilot-dry-run-offline", Metadata: map[string]any{}}
+	imageURL := server.URL + "/cat.png"
+
+	for _, stream := range []bool{false, true} {
+		req := cliproxyexecutor.Request{
+			Model:   "gpt-4.1",
+			Payload: []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"` + imageURL + `"}}]}]}`),
+		}
+		opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Headers: dryRunHeaders()}
+
+		var payload []byte
+		if stream {
+			chunks, err := e.ExecuteStream(context.Background(), auth, req, opts)
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			for chunk := range chunks {
+				payload = append(payload, chunk.Payload...)
+			}
+		} else {
+			resp, err := e.Execute(context.Background(), auth, req, opts)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			payload = resp.Payload
+		}
+
+		if got := gjson.GetBytes(payload, "body.messages.0.content.0.image_url.url").String(); got != imageURL {
+			t.Fatalf("stream=%v: image url = %q, want the remote URL left for upstream", stream, got)
+		}
+	}
+}
+
+func TestCodexExecutor_DryRunNeverContactsUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry run must not contact upstream, got %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	e := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-dry-run", Attributes: map[string]string{"api_key": "sk-secret", "base_url": server.URL}}
+
+	stream, err := e.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-5",
+		Payload: []byte(`{"model":"gpt-5","input":"hello"}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response"), Headers: dryRunHeaders()})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	var chunks [][]byte
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		chunks = append(chunks, chunk.Payload)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single dry-run chunk, got %d", len(chunks))
+	}
+	out := gjson.ParseBytes(chunks[0])
+	if got := out.Get("url").String(); got != server.URL+"/responses" {
+		t.Fatalf("url = %q, want %s/responses", got, server.URL)
+	}
+	if got := out.Get("headers.Authorization").String(); got != "Bearer [REDACTED]" {
+		t.Fatalf("Authorization = %q, want redacted bearer", got)
+	}
+	if got := out.Get("body.model").String(); got != "gpt-5" {
+		t.Fatalf("body.model = %q, want gpt-5", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -338,9 +339,10 @@ func ValidateThinkingConfig(payload []byte, model string) error {
 	checkField := func(path string) error {
 		if effort := gjson.GetBytes(payload, path); effort.Exists() {
 			if _, ok := util.NormalizeReasoningEffortLevel(model, effort.String()); !ok {
+				message := fmt.Sprintf("unsupported reasoning effort level %q for model %s (supported: %s)", effort.String(), model, strings.Join(levels, ", "))
 				return statusErr{
 					code: http.StatusBadRequest,
-					msg:  fmt.Sprintf("unsupported reasoning effort level %q for model %s (supported: %s)", effort.String(), model, strings.Join(levels, ", ")),
+					msg:  string(interfaces.OpenAIErrorBody(http.StatusBadRequest, message, path, "unsupported_value")),
 				}
 			}
 		}
@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// parseTimeoutSetting parses a Go duration from config. Empty, invalid, or non-positive
+// values disable the timeout.
+func parseTimeoutSetting(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// withRequestTimeout derives a context bounded by timeout. A non-positive timeout returns
+// the parent unchanged with a no-op cancel func.
+func withRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// requestTimeoutErr maps a deadline hit caused by the configured request timeout to a 504.
+// Errors caused by the client context (cancellation or its own deadline) pass through.
+func requestTimeoutErr(parent context.Context, err error, timeout time.Duration) error {
+	if err == nil || timeout <= 0 || parent.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return statusErr{code: http.StatusGatewayTimeout, msg: fmt.Sprintf("upstream request timed out after %s", timeout)}
+}
+
+// idleTimeoutBody wraps a streaming response body and closes it when no data has been
+// read for the idle timeout. The timer resets on every successful read.
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+// newIdleTimeoutBody returns body unchanged when timeout is non-positive.
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration) io.ReadCloser {
+	if body == nil || timeout <= 0 {
+		return body
+	}
+	b := &idleTimeoutBody{body: body, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, b.expire)
+	return b
+}
+
+func (b *idleTimeoutBody) expire() {
+	b.mu.Lock()
+	b.timedOut = true
+	b.mu.Unlock()
+	_ = b.body.Close()
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	timedOut := b.timedOut
+	b.mu.Unlock()
+	if timedOut {
+		return n, statusErr{code: http.StatusGatewayTimeout, msg: fmt.Sprintf("upstream stream idle for %s", b.timeout)}
+	}
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
+
+// copilotTimeouts returns the request and stream idle timeouts for the credential's CopilotKey.
+func (e *CopilotExecutor) copilotTimeouts(auth *cliproxyauth.Auth) (time.Duration, time.Duration) {
+	entry := e.copilotKeyForAuth(auth)
+	if entry == nil {
+		return 0, 0
+	}
+	return parseTimeoutSetting(entry.RequestTimeout), parseTimeoutSetting(entry.StreamIdleTimeout)
+}
+
+// codexTimeouts returns the request and stream idle timeouts for the credential's CodexKey.
+func (e *CodexExecutor) codexTimeouts(auth *cliproxyauth.Auth) (time.Duration, time.Duration) {
+	entry := e.resolveCodexConfig(auth)
+	if entry == nil {
+		return 0, 0
+	}
+	return parseTimeoutSetting(entry.RequestTimeout), parseTimeoutSetting(entry.StreamIdleTimeout)
+}
@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func newTimeoutCodexExecutor(baseURL, requestTimeout, idleTimeout string) (*CodexExecutor, *cliproxyauth.Auth) {
+	cfg := &config.Config{CodexKey: []config.CodexKey{{
+		APIKey:            "test",
+		BaseURL:           baseURL,
+		RequestTimeout:    requestTimeout,
+		StreamIdleTimeout: idleTimeout,
+	}}}
+	auth := &cliproxyauth.Auth{ID: "codex-timeout", Attributes: map[string]string{"api_key": "test", "base_url": baseURL}}
+	return NewCodexExecutor(cfg), auth
+}
+
+func TestCodexExecutor_RequestTimeoutEnforced(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	e, auth := newTimeoutCodexExecutor(server.URL, "50ms", "")
+	start := time.Now()
+	_, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-5",
+		Payload: []byte(`{"model":"gpt-5","input":"hello"}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request took %s, expected the 50ms timeout to abort it", elapsed)
+	}
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504 status error, got %v", err)
+	}
+}
+
+func TestCodexExecutor_StreamIdleTimeoutResetsOnChunk(t *testing.T) {
+	const chunks = 5
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		for i := 0; i < chunks; i++ {
+			_, _ = w.Write([]byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"x\"}\n\n"))
+			flusher.Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+		// Stall after the last chunk so only the idle timeout can end the stream.
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// The stream as a whole outlives the idle timeout; only the final stall exceeds it.
+	e, auth := newTimeoutCodexExecutor(server.URL, "", "120ms")
+	stream, err := e.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-5",
+		Payload: []byte(`{"model":"gpt-5","input":"hello"}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	var payload strings.Builder
+	var streamErr error
+	for chunk := range stream {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+			continue
+		}
+		payload.Write(chunk.Payload)
+	}
+	if got := strings.Count(payload.String(), "response.output_text.delta"); got != chunks {
+		t.Fatalf("expected %d chunks before the idle timeout, got %d", chunks, got)
+	}
+	var se statusErr
+	if !errors.As(streamErr, &se) || se.StatusCode() != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504 idle timeout error, got %v", streamErr)
+	}
+}
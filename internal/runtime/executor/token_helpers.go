@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tiktoken-go/tokenizer"
 )
@@ -342,54 +343,13 @@ func collectOpenAIMessages(messages gjson.Result, segments *[]string) {
 	messages.ForEach(func(_, message gjson.Result) bool {
 		addIfNotEmpty(segments, message.Get("role").String())
 		addIfNotEmpty(segments, message.Get("name").String())
-		collectOpenAIContent(message.Get("content"), segments)
+		util.CollectOpenAIContent(message.Get("content"), segments)
 		collectOpenAIToolCalls(message.Get("tool_calls"), segments)
 		collectOpenAIFunctionCall(message.Get("function_call"), segments)
 		return true
 	})
 }
 
-func collectOpenAIContent(content gjson.Result, segments *[]string) {
-	if !content.Exists() {
-		return
-	}
-	if content.Type == gjson.String {
-		addIfNotEmpty(segments, content.String())
-		return
-	}
-	if content.IsArray() {
-		content.ForEach(func(_, part gjson.Result) bool {
-			partType := part.Get("type").String()
-			switch partType {
-			case "text", "input_text", "output_text":
-				addIfNotEmpty(segments, part.Get("text").String())
-			case "image_url":
-				addIfNotEmpty(segments, part.Get("image_url.url").String())
-			case "input_audio", "output_audio", "audio":
-				addIfNotEmpty(segments, part.Get("id").String())
-			case "tool_result":
-				addIfNotEmpty(segments, part.Get("name").String())
-				collectOpenAIContent(part.Get("content"), segments)
-			default:
-				if part.IsArray() {
-					collectOpenAIContent(part, segments)
-					return true
-				}
-				if part.Type == gjson.JSON {
-					addIfNotEmpty(segments, part.Raw)
-					return true
-				}
-				addIfNotEmpty(segments, part.String())
-			}
-			return true
-		})
-		return
-	}
-	if content.Type == gjson.JSON {
-		addIfNotEmpty(segments, content.Raw)
-	}
-}
-
 func collectOpenAIToolCalls(calls gjson.Result, segments *[]string) {
 	if !calls.Exists() || !calls.IsArray() {
 		return
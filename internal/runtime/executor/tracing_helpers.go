@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// executorTracerName is the instrumentation scope for upstream executor spans.
+const executorTracerName = "github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+
+// traceContextPropagator carries W3C traceparent/tracestate between the client and upstream.
+// It is used directly so propagation works even when no global propagator is installed.
+var traceContextPropagator = propagation.TraceContext{}
+
+// startExecutorSpan starts a client span named "<provider>/<model>". When ctx carries no
+// span, the parent is taken from the incoming traceparent header. Without a configured
+// tracer provider the global no-op tracer is used and the span records nothing.
+func startExecutorSpan(ctx context.Context, provider, model string, incoming http.Header) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() && incoming != nil {
+		ctx = traceContextPropagator.Extract(ctx, propagation.HeaderCarrier(incoming))
+	}
+	return otel.Tracer(executorTracerName).Start(ctx, provider+"/"+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("provider", provider),
+			attribute.String("model", model),
+		),
+	)
+}
+
+// injectTraceContext writes the span context from ctx into the upstream request headers.
+func injectTraceContext(ctx context.Context, headers http.Header) {
+	if headers == nil {
+		return
+	}
+	traceContextPropagator.Inject(ctx, propagation.HeaderCarrier(headers))
+}
+
+// recordSpanUpstreamStatus records the upstream HTTP status and marks non-2xx responses as errors.
+func recordSpanUpstreamStatus(span trace.Span, statusCode int) {
+	span.SetAttributes(attribute.Int("upstream_status", statusCode))
+	if statusCode < 200 || statusCode >= 300 {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+}
+
+// endExecutorSpan records err on the span, if any, and ends it.
+func endExecutorSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func installInMemoryTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	out := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		out[kv.Key] = kv.Value
+	}
+	return out
+}
+
+func TestCodexExecutor_ExecuteRecordsSpanAndPropagatesTraceparent(t *testing.T) {
+	exporter := installInMemoryTracer(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var upstreamTraceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[],\"usage\":{\"input_tokens\":1,\"output_tokens\":1,\"total_tokens\":2}}}\n\n"))
+	}))
+	defer server.Close()
+
+	e := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-trace", Attributes: map[string]string{"api_key": "test", "base_url": server.URL}}
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	_, err := e.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-5",
+		Payload: []byte(`{"model":"gpt-5","input":"hello"}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response"), Headers: incoming})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if !strings.Contains(upstreamTraceparent, traceID) {
+		t.Fatalf("upstream traceparent = %q, want trace id %s", upstreamTraceparent, traceID)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "codex/gpt-5" {
+		t.Fatalf("span name = %q, want codex/gpt-5", span.Name)
+	}
+	if got := span.SpanContext.TraceID().String(); got != traceID {
+		t.Fatalf("span trace id = %s, want %s", got, traceID)
+	}
+	attrs := spanAttributes(span)
+	if got := attrs["upstream_status"].AsInt64(); got != http.StatusOK {
+		t.Fatalf("upstream_status = %d, want 200", got)
+	}
+	if got := attrs["provider"].AsString(); got != "codex" {
+		t.Fatalf("provider = %q, want codex", got)
+	}
+}
+
+func TestCopilotExecutor_AnnotateSpan(t *testing.T) {
+	exporter := installInMemoryTracer(t)
+
+	e := NewCopilotExecutor(&config.Config{CopilotKey: []config.CopilotKey{{HeaderProfile: "vscode-chat"}}})
+	req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	payload := `{"model":"gpt-4.1","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}]}`
+	e.applyCopilotHeaders(req, nil, "test-token", []byte(payload), nil)
+
+	_, span := startExecutorSpan(context.Background(), e.Identifier(), "gpt-4.1", nil)
+	e.annotateCopilotSpan(span, nil, req.Header, "gpt-4.1")
+	endExecutorSpan(span, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := spanAttributes(spans[0])
+	if got := attrs["initiator"].AsString(); got != "user" {
+		t.Fatalf("initiator = %q, want user", got)
+	}
+	if got := attrs["header_profile"].AsString(); got != "vscode-chat" {
+		t.Fatalf("header_profile = %q, want vscode-chat", got)
+	}
+	if !attrs["vision"].AsBool() {
+		t.Fatal("expected vision attribute to be true")
+	}
+}
+
+func TestStartExecutorSpan_NoopWithoutTracerProvider(t *testing.T) {
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, span := startExecutorSpan(context.Background(), "codex", "gpt-5", incoming)
+	defer endExecutorSpan(span, nil)
+	if span.IsRecording() {
+		t.Fatal("expected a non-recording span without a tracer provider")
+	}
+
+	outgoing := http.Header{}
+	injectTraceContext(ctx, outgoing)
+	if got := outgoing.Get("traceparent"); got != incoming.Get("traceparent") {
+		t.Fatalf("traceparent = %q, want incoming value forwarded", got)
+	}
+}
@@ -0,0 +1,17 @@
+// Package anthropic exposes Anthropic Messages API request conversion under a
+// provider-neutral name so that callers routing native /v1/messages payloads
+// through Chat Completions executors can share one entry point.
+package anthropic
+
+import (
+	claudeopenai "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/claude"
+)
+
+// ConvertAnthropicMessagesRequestToOpenAIChatCompletions converts an Anthropic Messages API
+// request into an OpenAI Chat Completions request. It maps system (string or block array),
+// messages whose content is either a string or an array of text/image/tool blocks, tools,
+// and tool_choice. The conversion is shared with the registered Claude→OpenAI translator
+// so both paths produce identical upstream payloads.
+func ConvertAnthropicMessagesRequestToOpenAIChatCompletions(model string, payload []byte, stream bool) []byte {
+	return claudeopenai.ConvertClaudeRequestToOpenAI(model, payload, stream)
+}
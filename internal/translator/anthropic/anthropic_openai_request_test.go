@@ -0,0 +1,117 @@
+package anthropic
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertAnthropicMessagesRequestToOpenAIChatCompletions_StringContent(t *testing.T) {
+	payload := []byte(`{
+		"model": "claude-sonnet-4",
+		"system": "stay concise",
+		"messages": [
+			{"role":"user","content":"hello there"}
+		]
+	}`)
+
+	out := ConvertAnthropicMessagesRequestToOpenAIChatCompletions("copilot-claude-sonnet-4", payload, true)
+
+	if got := gjson.GetBytes(out, "model").String(); got != "copilot-claude-sonnet-4" {
+		t.Fatalf("model = %q, want copilot-claude-sonnet-4", got)
+	}
+	if !gjson.GetBytes(out, "stream").Bool() {
+		t.Fatalf("stream flag not set: %s", out)
+	}
+
+	msgs := gjson.GetBytes(out, "messages")
+	if !msgs.IsArray() || len(msgs.Array()) != 2 {
+		t.Fatalf("messages = %s, want system and user", msgs.Raw)
+	}
+
+	system := msgs.Array()[0]
+	if got := system.Get("role").String(); got != "system" {
+		t.Fatalf("system role = %q, want system", got)
+	}
+	if got := system.Get("content.#(text==\"stay concise\").text").String(); got != "stay concise" {
+		t.Fatalf("system content missing text: %s", system.Raw)
+	}
+
+	user := msgs.Array()[1]
+	if got := user.Get("role").String(); got != "user" {
+		t.Fatalf("user role = %q, want user", got)
+	}
+	content := user.Get("content")
+	text := content.String()
+	if content.IsArray() {
+		text = content.Get("0.text").String()
+	}
+	if text != "hello there" {
+		t.Fatalf("user content = %s, want hello there", content.Raw)
+	}
+}
+
+func TestConvertAnthropicMessagesRequestToOpenAIChatCompletions_BlockContent(t *testing.T) {
+	payload := []byte(`{
+		"model": "claude-sonnet-4",
+		"system": [{"type":"text","text":"you are helpful"}],
+		"messages": [
+			{"role":"user","content":[
+				{"type":"text","text":"what is in this image?"},
+				{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}}
+			]},
+			{"role":"assistant","content":[
+				{"type":"text","text":"let me check"},
+				{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"cat"}}
+			]},
+			{"role":"user","content":[
+				{"type":"tool_result","tool_use_id":"toolu_1","content":"a cat"}
+			]}
+		],
+		"tools": [
+			{"name":"lookup","description":"look things up","input_schema":{"type":"object","properties":{"q":{"type":"string"}}}}
+		],
+		"tool_choice": {"type":"tool","name":"lookup"}
+	}`)
+
+	out := ConvertAnthropicMessagesRequestToOpenAIChatCompletions("gpt-4.1", payload, false)
+
+	msgs := gjson.GetBytes(out, "messages").Array()
+	if len(msgs) != 4 {
+		t.Fatalf("messages count = %d, want 4: %s", len(msgs), gjson.GetBytes(out, "messages").Raw)
+	}
+
+	user := msgs[1]
+	if got := user.Get("content.0.type").String(); got != "text" {
+		t.Fatalf("user content[0].type = %q, want text", got)
+	}
+	if got := user.Get("content.1.type").String(); got != "image_url" {
+		t.Fatalf("user content[1].type = %q, want image_url", got)
+	}
+	if got := user.Get("content.1.image_url.url").String(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("image url = %q, want data URL", got)
+	}
+
+	assistant := msgs[2]
+	if got := assistant.Get("tool_calls.0.function.name").String(); got != "lookup" {
+		t.Fatalf("tool call name = %q, want lookup", got)
+	}
+	if got := assistant.Get("tool_calls.0.function.arguments").String(); got != `{"q":"cat"}` {
+		t.Fatalf("tool call arguments = %q", got)
+	}
+
+	tool := msgs[3]
+	if got := tool.Get("role").String(); got != "tool" {
+		t.Fatalf("tool role = %q, want tool", got)
+	}
+	if got := tool.Get("tool_call_id").String(); got != "toolu_1" {
+		t.Fatalf("tool_call_id = %q, want toolu_1", got)
+	}
+
+	if got := gjson.GetBytes(out, "tools.0.function.name").String(); got != "lookup" {
+		t.Fatalf("tools[0].function.name = %q, want lookup", got)
+	}
+	if got := gjson.GetBytes(out, "tool_choice.function.name").String(); got != "lookup" {
+		t.Fatalf("tool_choice = %s, want function lookup", gjson.GetBytes(out, "tool_choice").Raw)
+	}
+}
@@ -406,6 +406,7 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	out = common.ApplyOpenAISafetySettings(rawJSON, out, "request.safetySettings")
 	return common.AttachDefaultSafetySettings(out, "request.safetySettings")
 }
 
@@ -10,6 +10,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -24,6 +25,7 @@ type ConvertCliToOpenAIParams struct {
 	CreatedAt         int64
 	Model             string
 	FunctionCallIndex int
+	SystemFingerprint string
 }
 
 // ConvertCodexResponseToOpenAI translates a single chunk of a streaming response from the
@@ -66,6 +68,7 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 		(*param).(*ConvertCliToOpenAIParams).ResponseID = rootResult.Get("response.id").String()
 		(*param).(*ConvertCliToOpenAIParams).CreatedAt = rootResult.Get("response.created_at").Int()
 		(*param).(*ConvertCliToOpenAIParams).Model = rootResult.Get("response.model").String()
+		(*param).(*ConvertCliToOpenAIParams).SystemFingerprint = rootResult.Get("response.system_fingerprint").String()
 		return []string{}
 	}
 
@@ -79,6 +82,14 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 	// Extract and set the response ID.
 	template, _ = sjson.Set(template, "id", (*param).(*ConvertCliToOpenAIParams).ResponseID)
 
+	// Carry the upstream system fingerprint, which may only arrive on a later event.
+	if fingerprint := rootResult.Get("response.system_fingerprint").String(); fingerprint != "" {
+		(*param).(*ConvertCliToOpenAIParams).SystemFingerprint = fingerprint
+	}
+	if fingerprint := (*param).(*ConvertCliToOpenAIParams).SystemFingerprint; fingerprint != "" {
+		template, _ = sjson.Set(template, "system_fingerprint", fingerprint)
+	}
+
 	// Extract and set usage metadata (token counts).
 	if usageResult := gjson.GetBytes(rawJSON, "response.usage"); usageResult.Exists() {
 		if outputTokensResult := usageResult.Get("output_tokens"); outputTokensResult.Exists() {
@@ -108,11 +119,12 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 			template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
 			template, _ = sjson.Set(template, "choices.0.delta.content", deltaResult.String())
 		}
-	} else if dataType == "response.completed" {
-		finishReason := "stop"
-		if (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex != -1 {
-			finishReason = "tool_calls"
-		}
+	} else if dataType == "response.completed" || dataType == "response.incomplete" {
+		finishReason := common.ChatFinishReasonFromResponses(
+			rootResult.Get("response.status").String(),
+			rootResult.Get("response.incomplete_details.reason").String(),
+			(*param).(*ConvertCliToOpenAIParams).FunctionCallIndex != -1,
+		)
 		template, _ = sjson.Set(template, "choices.0.finish_reason", finishReason)
 		template, _ = sjson.Set(template, "choices.0.native_finish_reason", finishReason)
 	} else if dataType == "response.output_item.done" {
@@ -167,7 +179,7 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
 	rootResult := gjson.ParseBytes(rawJSON)
 	// Verify this is a response.completed event
-	if rootResult.Get("type").String() != "response.completed" {
+	if eventType := rootResult.Get("type").String(); eventType != "response.completed" && eventType != "response.incomplete" {
 		return ""
 	}
 
@@ -194,6 +206,11 @@ func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, original
 		template, _ = sjson.Set(template, "id", idResult.String())
 	}
 
+	// Extract and set the system fingerprint.
+	if fingerprint := responseResult.Get("system_fingerprint").String(); fingerprint != "" {
+		template, _ = sjson.Set(template, "system_fingerprint", fingerprint)
+	}
+
 	// Extract and set usage metadata (token counts).
 	if usageResult := responseResult.Get("usage"); usageResult.Exists() {
 		if outputTokensResult := usageResult.Get("output_tokens"); outputTokensResult.Exists() {
@@ -293,9 +310,11 @@ func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, original
 	// Extract and set the finish reason based on status
 	if statusResult := responseResult.Get("status"); statusResult.Exists() {
 		status := statusResult.String()
-		if status == "completed" {
-			template, _ = sjson.Set(template, "choices.0.finish_reason", "stop")
-			template, _ = sjson.Set(template, "choices.0.native_finish_reason", "stop")
+		if status == "completed" || status == "incomplete" {
+			hasToolCalls := gjson.Get(template, "choices.0.message.tool_calls").IsArray()
+			finishReason := common.ChatFinishReasonFromResponses(status, responseResult.Get("incomplete_details.reason").String(), hasToolCalls)
+			template, _ = sjson.Set(template, "choices.0.finish_reason", finishReason)
+			template, _ = sjson.Set(template, "choices.0.native_finish_reason", finishReason)
 		}
 	}
 
@@ -0,0 +1,71 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertCodexResponseToOpenAI_FinishReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  string
+	}{
+		{name: "completed", event: `data: {"type":"response.completed","response":{"status":"completed"}}`, want: "stop"},
+		{name: "max output tokens", event: `data: {"type":"response.incomplete","response":{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}}`, want: "length"},
+		{name: "content filter", event: `data: {"type":"response.incomplete","response":{"status":"incomplete","incomplete_details":{"reason":"content_filter"}}}`, want: "content_filter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var param any
+			out := ConvertCodexResponseToOpenAI(context.Background(), "gpt-5", nil, nil, []byte(tt.event), &param)
+			if len(out) != 1 {
+				t.Fatalf("expected one chunk, got %d", len(out))
+			}
+			if got := gjson.Get(out[0], "choices.0.finish_reason").String(); got != tt.want {
+				t.Fatalf("finish_reason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertCodexResponseToOpenAINonStream_FinishReason(t *testing.T) {
+	raw := []byte(`{"type":"response.completed","response":{"id":"resp_1","status":"completed","output":[{"type":"function_call","call_id":"call_1","name":"lookup","arguments":"{}"}]}}`)
+	out := ConvertCodexResponseToOpenAINonStream(context.Background(), "gpt-5", nil, nil, raw, nil)
+	if got := gjson.Get(out, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", got)
+	}
+
+	raw = []byte(`{"type":"response.incomplete","response":{"id":"resp_2","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"output":[]}}`)
+	out = ConvertCodexResponseToOpenAINonStream(context.Background(), "gpt-5", nil, nil, raw, nil)
+	if got := gjson.Get(out, "choices.0.finish_reason").String(); got != "length" {
+		t.Fatalf("finish_reason = %q, want length", got)
+	}
+}
+
+func TestConvertCodexResponseToOpenAI_SystemFingerprint(t *testing.T) {
+	var param any
+	ConvertCodexResponseToOpenAI(context.Background(), "gpt-5", nil, nil, []byte(`data: {"type":"response.created","response":{"id":"resp_1","created_at":1700000000,"system_fingerprint":"fp_abc123"}}`), &param)
+	out := ConvertCodexResponseToOpenAI(context.Background(), "gpt-5", nil, nil, []byte(`data: {"type":"response.output_text.delta","delta":"hi"}`), &param)
+	if len(out) != 1 || gjson.Get(out[0], "system_fingerprint").String() != "fp_abc123" {
+		t.Fatalf("stream chunk lost system_fingerprint: %v", out)
+	}
+
+	raw := []byte(`{"type":"response.completed","response":{"id":"resp_1","status":"completed","system_fingerprint":"fp_abc123","output":[]}}`)
+	if got := gjson.Get(ConvertCodexResponseToOpenAINonStream(context.Background(), "gpt-5", nil, nil, raw, nil), "system_fingerprint").String(); got != "fp_abc123" {
+		t.Fatalf("non-stream system_fingerprint = %q, want fp_abc123", got)
+	}
+
+	// Absent upstream fingerprints are not synthesized.
+	param = nil
+	out = ConvertCodexResponseToOpenAI(context.Background(), "gpt-5", nil, nil, []byte(`data: {"type":"response.output_text.delta","delta":"hi"}`), &param)
+	if gjson.Get(out[0], "system_fingerprint").Exists() {
+		t.Fatalf("unexpected system_fingerprint: %s", out[0])
+	}
+	raw = []byte(`{"type":"response.completed","response":{"id":"resp_2","status":"completed","output":[]}}`)
+	if gjson.Get(ConvertCodexResponseToOpenAINonStream(context.Background(), "gpt-5", nil, nil, raw, nil), "system_fingerprint").Exists() {
+		t.Fatal("unexpected non-stream system_fingerprint")
+	}
+}
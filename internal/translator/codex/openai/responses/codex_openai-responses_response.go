@@ -35,8 +35,8 @@ func ConvertCodexResponseToOpenAIResponses(ctx context.Context, modelName string
 // from a non-streaming OpenAI Chat Completions response.
 func ConvertCodexResponseToOpenAIResponsesNonStream(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
 	rootResult := gjson.ParseBytes(rawJSON)
-	// Verify this is a response.completed event
-	if rootResult.Get("type").String() != "response.completed" {
+	// Verify this is a response.completed or response.incomplete event
+	if eventType := rootResult.Get("type").String(); eventType != "response.completed" && eventType != "response.incomplete" {
 		return ""
 	}
 	responseResult := rootResult.Get("response")
@@ -362,6 +362,7 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		}
 	}
 
+	out = common.ApplyOpenAISafetySettings(rawJSON, out, "request.safetySettings")
 	return common.AttachDefaultSafetySettings(out, "request.safetySettings")
 }
 
@@ -1,10 +1,26 @@
 package common
 
 import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
+// OpenAISafetySettingsPath is the vendor extension OpenAI-format clients use to pass Gemini
+// safety settings through the proxy.
+const OpenAISafetySettingsPath = "extra_body.gemini.safety_settings"
+
+// knownHarmCategories lists the Gemini harm categories accepted from the vendor extension.
+var knownHarmCategories = map[string]struct{}{
+	"HARM_CATEGORY_HARASSMENT":        {},
+	"HARM_CATEGORY_HATE_SPEECH":       {},
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT": {},
+	"HARM_CATEGORY_DANGEROUS_CONTENT": {},
+	"HARM_CATEGORY_CIVIC_INTEGRITY":   {},
+}
+
 // DefaultSafetySettings returns the default Gemini safety configuration we attach to requests.
 func DefaultSafetySettings() []map[string]string {
 	return []map[string]string{
@@ -45,3 +61,36 @@ func AttachDefaultSafetySettings(rawJSON []byte, path string) []byte {
 
 	return out
 }
+
+// ApplyOpenAISafetySettings maps extra_body.gemini.safety_settings from an OpenAI-format
+// request into the Gemini request at path. Entries with unrecognized categories or without
+// a threshold are skipped with a log line. Nothing is written when no entry survives, so the
+// defaults attached afterwards still apply.
+func ApplyOpenAISafetySettings(rawJSON, out []byte, path string) []byte {
+	settings := gjson.GetBytes(rawJSON, OpenAISafetySettingsPath)
+	if !settings.IsArray() {
+		return out
+	}
+	kept := make([]map[string]string, 0, len(settings.Array()))
+	for _, setting := range settings.Array() {
+		category := strings.ToUpper(strings.TrimSpace(setting.Get("category").String()))
+		threshold := strings.ToUpper(strings.TrimSpace(setting.Get("threshold").String()))
+		if _, ok := knownHarmCategories[category]; !ok {
+			log.Warnf("gemini safety settings: ignoring unrecognized category %q", category)
+			continue
+		}
+		if threshold == "" {
+			log.Warnf("gemini safety settings: ignoring %s without threshold", category)
+			continue
+		}
+		kept = append(kept, map[string]string{"category": category, "threshold": threshold})
+	}
+	if len(kept) == 0 {
+		return out
+	}
+	updated, err := sjson.SetBytes(out, path, kept)
+	if err != nil {
+		return out
+	}
+	return updated
+}
@@ -379,6 +379,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	out = common.ApplyOpenAISafetySettings(rawJSON, out, "safetySettings")
 	out = common.AttachDefaultSafetySettings(out, "safetySettings")
 
 	return out
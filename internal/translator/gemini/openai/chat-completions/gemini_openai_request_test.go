@@ -0,0 +1,53 @@
+package chat_completions
+
+import (
+	"testing"
+
+	openaichat "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/chat-completions"
+	"github.com/tidwall/gjson"
+)
+
+const safetySettingsRequest = `{
+	"model": "gemini-2.5-pro",
+	"messages": [{"role": "user", "content": "hi"}],
+	"extra_body": {"gemini": {"safety_settings": [
+		{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_ONLY_HIGH"},
+		{"category": "harm_category_dangerous_content", "threshold": "block_medium_and_above"},
+		{"category": "HARM_CATEGORY_MADE_UP", "threshold": "BLOCK_NONE"}
+	]}}
+}`
+
+func TestConvertOpenAIRequestToGemini_SafetySettingsExtension(t *testing.T) {
+	out := gjson.ParseBytes(ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(safetySettingsRequest), false))
+
+	settings := out.Get("safetySettings").Array()
+	if len(settings) != 2 {
+		t.Fatalf("safetySettings = %s, want the two recognized entries", out.Get("safetySettings").Raw)
+	}
+	if got := settings[0].Get("category").String() + "=" + settings[0].Get("threshold").String(); got != "HARM_CATEGORY_HARASSMENT=BLOCK_ONLY_HIGH" {
+		t.Fatalf("first setting = %s", got)
+	}
+	if got := settings[1].Get("category").String() + "=" + settings[1].Get("threshold").String(); got != "HARM_CATEGORY_DANGEROUS_CONTENT=BLOCK_MEDIUM_AND_ABOVE" {
+		t.Fatalf("second setting = %s", got)
+	}
+	if out.Get("extra_body").Exists() {
+		t.Fatalf("extra_body must not be forwarded to Gemini: %s", out.Raw)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_DefaultSafetySettingsWithoutExtension(t *testing.T) {
+	out := gjson.ParseBytes(ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(`{"messages":[{"role":"user","content":"hi"}]}`), false))
+	if got := len(out.Get("safetySettings").Array()); got != 5 {
+		t.Fatalf("expected default safety settings, got %d entries", got)
+	}
+}
+
+func TestConvertOpenAIRequestToOpenAI_DropsGeminiExtension(t *testing.T) {
+	out := gjson.ParseBytes(openaichat.ConvertOpenAIRequestToOpenAI("gpt-4.1", []byte(safetySettingsRequest), false))
+	if out.Get("extra_body").Exists() {
+		t.Fatalf("gemini extension leaked to OpenAI upstream: %s", out.Raw)
+	}
+	if got := out.Get("messages.0.content").String(); got != "hi" {
+		t.Fatalf("messages altered: %s", out.Raw)
+	}
+}
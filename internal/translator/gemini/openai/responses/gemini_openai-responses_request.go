@@ -418,6 +418,7 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 	}
 
 	result := []byte(out)
+	result = common.ApplyOpenAISafetySettings(rawJSON, result, "safetySettings")
 	result = common.AttachDefaultSafetySettings(result, "safetySettings")
 	return result
 }
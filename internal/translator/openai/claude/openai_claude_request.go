@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -303,7 +304,8 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 		out, _ = sjson.Set(out, "user", user.String())
 	}
 
-	return []byte(out)
+	// Send the output limit under the field the target model accepts.
+	return common.RewriteMaxTokensField(modelName, []byte(out))
 }
 
 func convertClaudeContentPart(part gjson.Result) (string, bool) {
@@ -0,0 +1,111 @@
+package claude
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// anthropicEvent is one parsed "event: <name>\ndata: <json>" SSE event.
+type anthropicEvent struct {
+	name string
+	data gjson.Result
+}
+
+// convertChatStream feeds Chat Completions SSE lines through the registered OpenAI→Claude
+// stream translator and parses the emitted Anthropic events.
+func convertChatStream(t *testing.T, lines []string) []anthropicEvent {
+	t.Helper()
+	var param any
+	var events []anthropicEvent
+	for _, line := range lines {
+		for _, out := range ConvertOpenAIResponseToClaude(context.Background(), "gpt-4.1", []byte(`{"stream":true}`), nil, []byte(line), &param) {
+			for _, block := range strings.Split(strings.TrimSpace(out), "\n\n") {
+				name, data, ok := strings.Cut(block, "\ndata: ")
+				if !ok || !strings.HasPrefix(name, "event: ") {
+					t.Fatalf("malformed event: %q", block)
+				}
+				events = append(events, anthropicEvent{name: strings.TrimPrefix(name, "event: "), data: gjson.Parse(data)})
+			}
+		}
+	}
+	return events
+}
+
+func eventNames(events []anthropicEvent) string {
+	names := make([]string, len(events))
+	for i, ev := range events {
+		names[i] = ev.name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestConvertOpenAIResponseToClaude_StreamText(t *testing.T) {
+	events := convertChatStream(t, []string{
+		`data: {"id":"chatcmpl-1","model":"gpt-4.1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+		`data: {"id":"chatcmpl-1","model":"gpt-4.1","choices":[{"index":0,"delta":{"content":" world"}}]}`,
+		`data: {"id":"chatcmpl-1","model":"gpt-4.1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`data: {"id":"chatcmpl-1","model":"gpt-4.1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+		`data: [DONE]`,
+	})
+
+	want := "message_start,content_block_start,content_block_delta,content_block_delta,content_block_stop,message_delta,message_stop"
+	if got := eventNames(events); got != want {
+		t.Fatalf("events = %s\nwant     %s", got, want)
+	}
+	if got := events[0].data.Get("message.id").String(); got != "chatcmpl-1" {
+		t.Fatalf("message_start id = %q", got)
+	}
+	if got := events[1].data.Get("content_block.type").String(); got != "text" {
+		t.Fatalf("content block type = %q, want text", got)
+	}
+	if got := events[2].data.Get("delta.text").String() + events[3].data.Get("delta.text").String(); got != "Hello world" {
+		t.Fatalf("text deltas = %q", got)
+	}
+	delta := events[5].data
+	if got := delta.Get("delta.stop_reason").String(); got != "end_turn" {
+		t.Fatalf("stop_reason = %q, want end_turn", got)
+	}
+	if delta.Get("usage.input_tokens").Int() != 5 || delta.Get("usage.output_tokens").Int() != 2 {
+		t.Fatalf("unexpected usage: %s", delta.Get("usage").Raw)
+	}
+}
+
+func TestConvertOpenAIResponseToClaude_StreamToolUse(t *testing.T) {
+	events := convertChatStream(t, []string{
+		`data: {"id":"chatcmpl-2","model":"gpt-4.1","choices":[{"index":0,"delta":{"role":"assistant","content":"Checking."}}]}`,
+		`data: {"id":"chatcmpl-2","model":"gpt-4.1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`data: {"id":"chatcmpl-2","model":"gpt-4.1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`data: {"id":"chatcmpl-2","model":"gpt-4.1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`data: {"id":"chatcmpl-2","model":"gpt-4.1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: {"id":"chatcmpl-2","model":"gpt-4.1","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":7,"total_tokens":19}}`,
+		`data: [DONE]`,
+	})
+
+	want := "message_start,content_block_start,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop"
+	if got := eventNames(events); got != want {
+		t.Fatalf("events = %s\nwant     %s", got, want)
+	}
+	toolStart := events[4].data
+	if toolStart.Get("index").Int() != 1 || toolStart.Get("content_block.type").String() != "tool_use" {
+		t.Fatalf("unexpected tool block start: %s", toolStart.Raw)
+	}
+	if toolStart.Get("content_block.id").String() != "call_1" || toolStart.Get("content_block.name").String() != "get_weather" {
+		t.Fatalf("tool block id/name not mapped: %s", toolStart.Raw)
+	}
+	toolDelta := events[5].data
+	if toolDelta.Get("delta.type").String() != "input_json_delta" {
+		t.Fatalf("tool delta type = %q", toolDelta.Get("delta.type").String())
+	}
+	if got := gjson.Parse(toolDelta.Get("delta.partial_json").String()).Get("city").String(); got != "Paris" {
+		t.Fatalf("tool input = %s", toolDelta.Get("delta.partial_json").String())
+	}
+	if got := events[6].data.Get("index").Int(); got != 1 {
+		t.Fatalf("tool block stop index = %d, want 1", got)
+	}
+	if got := events[7].data.Get("delta.stop_reason").String(); got != "tool_use" {
+		t.Fatalf("stop_reason = %q, want tool_use", got)
+	}
+}
@@ -0,0 +1,51 @@
+// Package common holds helpers shared by the OpenAI Chat Completions and Responses translators.
+package common
+
+// ResponsesOutcome describes how a Responses API response ended: its status and, for
+// incomplete responses, the incomplete_details.reason reported to the client.
+type ResponsesOutcome struct {
+	Status           string
+	IncompleteReason string
+}
+
+// chatToResponsesOutcome is the canonical mapping from Chat Completions finish_reason values
+// to Responses API status and incomplete reason.
+var chatToResponsesOutcome = map[string]ResponsesOutcome{
+	"stop":           {Status: "completed"},
+	"tool_calls":     {Status: "completed"},
+	"function_call":  {Status: "completed"},
+	"length":         {Status: "incomplete", IncompleteReason: "max_output_tokens"},
+	"content_filter": {Status: "incomplete", IncompleteReason: "content_filter"},
+}
+
+// responsesIncompleteToChat maps Responses API incomplete_details.reason values back to
+// Chat Completions finish_reason values.
+var responsesIncompleteToChat = map[string]string{
+	"max_output_tokens": "length",
+	"content_filter":    "content_filter",
+}
+
+// ResponsesOutcomeFromChatFinishReason maps a Chat Completions finish_reason to the Responses
+// API outcome. Unknown or empty reasons are treated as a normal completion.
+func ResponsesOutcomeFromChatFinishReason(finishReason string) ResponsesOutcome {
+	if outcome, ok := chatToResponsesOutcome[finishReason]; ok {
+		return outcome
+	}
+	return ResponsesOutcome{Status: "completed"}
+}
+
+// ChatFinishReasonFromResponses maps a Responses API status and incomplete reason to a Chat
+// Completions finish_reason. Completed responses that produced function calls report
+// tool_calls; incomplete responses with an unknown reason report length.
+func ChatFinishReasonFromResponses(status, incompleteReason string, hasToolCalls bool) string {
+	if status == "incomplete" {
+		if reason, ok := responsesIncompleteToChat[incompleteReason]; ok {
+			return reason
+		}
+		return "length"
+	}
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	return "stop"
+}
@@ -0,0 +1,58 @@
+package common
+
+import "testing"
+
+func TestResponsesOutcomeFromChatFinishReason(t *testing.T) {
+	tests := []struct {
+		finishReason string
+		want         ResponsesOutcome
+	}{
+		{"stop", ResponsesOutcome{Status: "completed"}},
+		{"tool_calls", ResponsesOutcome{Status: "completed"}},
+		{"function_call", ResponsesOutcome{Status: "completed"}},
+		{"length", ResponsesOutcome{Status: "incomplete", IncompleteReason: "max_output_tokens"}},
+		{"content_filter", ResponsesOutcome{Status: "incomplete", IncompleteReason: "content_filter"}},
+		{"", ResponsesOutcome{Status: "completed"}},
+		{"something_new", ResponsesOutcome{Status: "completed"}},
+	}
+	for _, tt := range tests {
+		if got := ResponsesOutcomeFromChatFinishReason(tt.finishReason); got != tt.want {
+			t.Fatalf("ResponsesOutcomeFromChatFinishReason(%q) = %+v, want %+v", tt.finishReason, got, tt.want)
+		}
+	}
+}
+
+func TestChatFinishReasonFromResponses(t *testing.T) {
+	tests := []struct {
+		status           string
+		incompleteReason string
+		hasToolCalls     bool
+		want             string
+	}{
+		{"completed", "", false, "stop"},
+		{"completed", "", true, "tool_calls"},
+		{"incomplete", "max_output_tokens", false, "length"},
+		{"incomplete", "max_output_tokens", true, "length"},
+		{"incomplete", "content_filter", false, "content_filter"},
+		{"incomplete", "", false, "length"},
+		{"", "", false, "stop"},
+	}
+	for _, tt := range tests {
+		if got := ChatFinishReasonFromResponses(tt.status, tt.incompleteReason, tt.hasToolCalls); got != tt.want {
+			t.Fatalf("ChatFinishReasonFromResponses(%q, %q, %v) = %q, want %q", tt.status, tt.incompleteReason, tt.hasToolCalls, got, tt.want)
+		}
+	}
+}
+
+func TestFinishReasonRoundTrip(t *testing.T) {
+	for _, reason := range []string{"stop", "length", "content_filter"} {
+		outcome := ResponsesOutcomeFromChatFinishReason(reason)
+		if got := ChatFinishReasonFromResponses(outcome.Status, outcome.IncompleteReason, false); got != reason {
+			t.Fatalf("round trip of %q = %q", reason, got)
+		}
+	}
+	outcome := ResponsesOutcomeFromChatFinishReason("tool_calls")
+	if got := ChatFinishReasonFromResponses(outcome.Status, outcome.IncompleteReason, true); got != "tool_calls" {
+		t.Fatalf("round trip of tool_calls = %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	maxTokensField           = "max_tokens"
+	maxCompletionTokensField = "max_completion_tokens"
+)
+
+// maxCompletionTokensFamilies are the model ID prefixes that reject max_tokens and require
+// max_completion_tokens.
+var maxCompletionTokensFamilies = []string{"o1", "o3", "o4", "gpt-5"}
+
+// MaxTokensFieldForModel returns the output-limit field the upstream expects for model.
+// A registry entry listing only one of the two fields in SupportedParameters decides;
+// otherwise OpenAI reasoning families (o-series, gpt-5) use "max_completion_tokens" and
+// every other model keeps "max_tokens".
+func MaxTokensFieldForModel(model string) string {
+	reg := registry.GetGlobalRegistry()
+	info := reg.GetModelInfo(model)
+	if info == nil {
+		info = reg.GetModelInfo(strings.TrimPrefix(model, registry.CopilotModelPrefix))
+	}
+	if info != nil {
+		var hasMaxTokens, hasMaxCompletion bool
+		for _, param := range info.SupportedParameters {
+			switch strings.ToLower(strings.TrimSpace(param)) {
+			case maxTokensField:
+				hasMaxTokens = true
+			case maxCompletionTokensField:
+				hasMaxCompletion = true
+			}
+		}
+		if hasMaxCompletion != hasMaxTokens {
+			if hasMaxCompletion {
+				return maxCompletionTokensField
+			}
+			return maxTokensField
+		}
+	}
+
+	id := strings.ToLower(strings.TrimSpace(model))
+	id = strings.TrimPrefix(id, registry.CopilotModelPrefix)
+	if slash := strings.LastIndex(id, "/"); slash >= 0 {
+		id = id[slash+1:]
+	}
+	for _, family := range maxCompletionTokensFamilies {
+		if id == family || strings.HasPrefix(id, family+"-") || strings.HasPrefix(id, family+".") {
+			return maxCompletionTokensField
+		}
+	}
+	return maxTokensField
+}
+
+// RewriteMaxTokensField renames max_tokens or max_completion_tokens in a Chat Completions
+// request to the field the target model expects and removes the other. When both are
+// present the value already under the expected field is kept.
+func RewriteMaxTokensField(model string, rawJSON []byte) []byte {
+	want := MaxTokensFieldForModel(model)
+	other := maxTokensField
+	if want == maxTokensField {
+		other = maxCompletionTokensField
+	}
+	otherValue := gjson.GetBytes(rawJSON, other)
+	if !otherValue.Exists() {
+		return rawJSON
+	}
+	out := rawJSON
+	if !gjson.GetBytes(out, want).Exists() {
+		updated, err := sjson.SetRawBytes(out, want, []byte(otherValue.Raw))
+		if err != nil {
+			return rawJSON
+		}
+		out = updated
+	}
+	updated, err := sjson.DeleteBytes(out, other)
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
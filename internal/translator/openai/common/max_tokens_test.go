@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+)
+
+func TestRewriteMaxTokensField(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("max-tokens-test-client", "openai", []*registry.ModelInfo{
+		{ID: "completion-tokens-model", Object: "model", Created: time.Now().Unix(), OwnedBy: "openai", SupportedParameters: []string{"max_completion_tokens"}},
+	})
+	t.Cleanup(func() { reg.UnregisterClient("max-tokens-test-client") })
+
+	tests := []struct {
+		name      string
+		model     string
+		body      string
+		wantField string
+		want      int64
+	}{
+		{name: "reasoning model renames max_tokens", model: "gpt-5-mini", body: `{"max_tokens":100}`, wantField: "max_completion_tokens", want: 100},
+		{name: "o-series via copilot alias", model: "copilot-o3-mini", body: `{"max_tokens":100}`, wantField: "max_completion_tokens", want: 100},
+		{name: "registry parameters decide", model: "completion-tokens-model", body: `{"max_tokens":100}`, wantField: "max_completion_tokens", want: 100},
+		{name: "older model renames max_completion_tokens", model: "gpt-4o", body: `{"max_completion_tokens":200}`, wantField: "max_tokens", want: 200},
+		{name: "both present on reasoning model", model: "gpt-5", body: `{"max_tokens":100,"max_completion_tokens":300}`, wantField: "max_completion_tokens", want: 300},
+		{name: "both present on older model", model: "gpt-4.1", body: `{"max_tokens":100,"max_completion_tokens":300}`, wantField: "max_tokens", want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := RewriteMaxTokensField(tt.model, []byte(tt.body))
+			if got := gjson.GetBytes(out, tt.wantField).Int(); got != tt.want {
+				t.Fatalf("%s = %d, want %d (body %s)", tt.wantField, got, tt.want, out)
+			}
+			other := "max_tokens"
+			if tt.wantField == "max_tokens" {
+				other = "max_completion_tokens"
+			}
+			if gjson.GetBytes(out, other).Exists() {
+				t.Fatalf("%s left in body: %s", other, out)
+			}
+		})
+	}
+
+	if out := RewriteMaxTokensField("gpt-5", []byte(`{"messages":[]}`)); gjson.GetBytes(out, "max_completion_tokens").Exists() {
+		t.Fatalf("limit added to a request without one: %s", out)
+	}
+}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// PromptCacheKey returns the prompt cache key of an OpenAI request: the top-level
+// prompt_cache_key when set, otherwise metadata.prompt_cache_key.
+func PromptCacheKey(root gjson.Result) string {
+	if key := strings.TrimSpace(root.Get("prompt_cache_key").String()); key != "" {
+		return key
+	}
+	return strings.TrimSpace(root.Get("metadata.prompt_cache_key").String())
+}
+
+// PromotePromptCacheKey copies a key found only under metadata.prompt_cache_key to the
+// top-level prompt_cache_key field upstreams use for prompt caching. An existing
+// top-level key is left untouched.
+func PromotePromptCacheKey(rawJSON []byte) []byte {
+	root := gjson.ParseBytes(rawJSON)
+	if strings.TrimSpace(root.Get("prompt_cache_key").String()) != "" {
+		return rawJSON
+	}
+	key := PromptCacheKey(root)
+	if key == "" {
+		return rawJSON
+	}
+	out, err := sjson.SetBytes(rawJSON, "prompt_cache_key", key)
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestPromotePromptCacheKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{name: "metadata promoted", payload: `{"model":"gpt-4.1","metadata":{"prompt_cache_key":"session-1"}}`, want: "session-1"},
+		{name: "top level kept", payload: `{"prompt_cache_key":"session-1","metadata":{"prompt_cache_key":"other"}}`, want: "session-1"},
+		{name: "absent", payload: `{"model":"gpt-4.1"}`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := PromotePromptCacheKey([]byte(tt.payload))
+			if got := gjson.GetBytes(out, "prompt_cache_key").String(); got != tt.want {
+				t.Fatalf("prompt_cache_key = %q, want %q; out = %s", got, tt.want, out)
+			}
+			if tt.want == "" && string(out) != tt.payload {
+				t.Fatalf("payload changed without a key: %s", out)
+			}
+		})
+	}
+}
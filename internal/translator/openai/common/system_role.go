@@ -0,0 +1,54 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// SystemRoleForModel returns the role instructions should be sent under for model, as set by
+// the registry's SystemRole ("developer" or "system"). It returns "" when the model or its
+// role is unknown.
+func SystemRoleForModel(model string) string {
+	reg := registry.GetGlobalRegistry()
+	info := reg.GetModelInfo(model)
+	if info == nil {
+		info = reg.GetModelInfo(strings.TrimPrefix(model, registry.CopilotModelPrefix))
+	}
+	if info == nil {
+		return ""
+	}
+	switch info.SystemRole {
+	case "developer", "system":
+		return info.SystemRole
+	}
+	return ""
+}
+
+// RewriteSystemRoles renames "system" and "developer" Chat Completions messages to the role
+// the target model expects, upgrading system to developer or downgrading developer to system.
+// Roles are left untouched when the model's role is unknown.
+func RewriteSystemRoles(model string, rawJSON []byte) []byte {
+	want := SystemRoleForModel(model)
+	if want == "" {
+		return rawJSON
+	}
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+	out := rawJSON
+	for i, msg := range messages.Array() {
+		role := msg.Get("role").String()
+		if (role != "system" && role != "developer") || role == want {
+			continue
+		}
+		if updated, err := sjson.SetBytes(out, fmt.Sprintf("messages.%d.role", i), want); err == nil {
+			out = updated
+		}
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+)
+
+func registerRoleTestModels(t *testing.T) {
+	t.Helper()
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("system-role-test-client", "openai", []*registry.ModelInfo{
+		{ID: "developer-role-model", Object: "model", Created: time.Now().Unix(), OwnedBy: "openai", SystemRole: "developer"},
+		{ID: "system-role-model", Object: "model", Created: time.Now().Unix(), OwnedBy: "openai", SystemRole: "system"},
+		{ID: "no-role-model", Object: "model", Created: time.Now().Unix(), OwnedBy: "openai"},
+	})
+	t.Cleanup(func() { reg.UnregisterClient("system-role-test-client") })
+}
+
+func TestRewriteSystemRoles(t *testing.T) {
+	registerRoleTestModels(t)
+	body := []byte(`{"messages":[{"role":"system","content":"a"},{"role":"developer","content":"b"},{"role":"user","content":"c"}]}`)
+
+	tests := []struct {
+		model string
+		want  []string
+	}{
+		{model: "developer-role-model", want: []string{"developer", "developer", "user"}},
+		{model: "system-role-model", want: []string{"system", "system", "user"}},
+		{model: "no-role-model", want: []string{"system", "developer", "user"}},
+		{model: "unknown-model", want: []string{"system", "developer", "user"}},
+	}
+	for _, tt := range tests {
+		out := RewriteSystemRoles(tt.model, body)
+		for i, want := range tt.want {
+			if got := gjson.GetBytes(out, fmt.Sprintf("messages.%d.role", i)).String(); got != want {
+				t.Fatalf("%s: messages.%d.role = %q, want %q", tt.model, i, got, want)
+			}
+		}
+	}
+}
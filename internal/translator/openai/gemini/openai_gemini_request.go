@@ -12,6 +12,7 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -117,13 +118,8 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 				}
 
 				// Handle inline data (e.g., images)
-				if inlineData := part.Get("inlineData"); inlineData.Exists() {
-					mimeType := inlineData.Get("mimeType").String()
-					if mimeType == "" {
-						mimeType = "application/octet-stream"
-					}
-					data := inlineData.Get("data").String()
-					imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
+				if inlineData := geminiInlineData(part); inlineData.Exists() {
+					imageURL := geminiInlineDataURL(inlineData)
 
 					contentPart := `{"type":"image_url","image_url":{"url":""}}`
 					contentPart, _ = sjson.Set(contentPart, "image_url.url", imageURL)
@@ -172,15 +168,10 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					}
 
 					// Handle inline data (e.g., images)
-					if inlineData := part.Get("inlineData"); inlineData.Exists() {
+					if inlineData := geminiInlineData(part); inlineData.Exists() {
 						onlyTextContent = false
 
-						mimeType := inlineData.Get("mimeType").String()
-						if mimeType == "" {
-							mimeType = "application/octet-stream"
-						}
-						data := inlineData.Get("data").String()
-						imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
+						imageURL := geminiInlineDataURL(inlineData)
 
 						contentPart := `{"type":"image_url","image_url":{"url":""}}`
 						contentPart, _ = sjson.Set(contentPart, "image_url.url", imageURL)
@@ -262,7 +253,11 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	// Tools mapping: Gemini tools -> OpenAI tools
 	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
 		tools.ForEach(func(_, tool gjson.Result) bool {
-			if functionDeclarations := tool.Get("functionDeclarations"); functionDeclarations.Exists() && functionDeclarations.IsArray() {
+			functionDeclarations := tool.Get("functionDeclarations")
+			if !functionDeclarations.Exists() {
+				functionDeclarations = tool.Get("function_declarations")
+			}
+			if functionDeclarations.Exists() && functionDeclarations.IsArray() {
 				functionDeclarations.ForEach(func(_, funcDecl gjson.Result) bool {
 					openAITool := `{"type":"function","function":{"name":"","description":""}}`
 					openAITool, _ = sjson.Set(openAITool, "function.name", funcDecl.Get("name").String())
@@ -273,6 +268,8 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 						openAITool, _ = sjson.SetRaw(openAITool, "function.parameters", parameters.Raw)
 					} else if parameters := funcDecl.Get("parametersJsonSchema"); parameters.Exists() {
 						openAITool, _ = sjson.SetRaw(openAITool, "function.parameters", parameters.Raw)
+					} else if parameters := funcDecl.Get("parameters_json_schema"); parameters.Exists() {
+						openAITool, _ = sjson.SetRaw(openAITool, "function.parameters", parameters.Raw)
 					}
 
 					out, _ = sjson.SetRaw(out, "tools.-1", openAITool)
@@ -284,8 +281,16 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool choice mapping (Gemini doesn't have direct equivalent, but we can handle it)
-	if toolConfig := root.Get("toolConfig"); toolConfig.Exists() {
-		if functionCallingConfig := toolConfig.Get("functionCallingConfig"); functionCallingConfig.Exists() {
+	toolConfig := root.Get("toolConfig")
+	if !toolConfig.Exists() {
+		toolConfig = root.Get("tool_config")
+	}
+	if toolConfig.Exists() {
+		functionCallingConfig := toolConfig.Get("functionCallingConfig")
+		if !functionCallingConfig.Exists() {
+			functionCallingConfig = toolConfig.Get("function_calling_config")
+		}
+		if functionCallingConfig.Exists() {
 			mode := functionCallingConfig.Get("mode").String()
 			switch mode {
 			case "NONE":
@@ -298,5 +303,27 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
-	return []byte(out)
+	// Send the output limit under the field the target model accepts.
+	return common.RewriteMaxTokensField(modelName, []byte(out))
+}
+
+// geminiInlineData returns a part's inline data, accepting both the camelCase
+// ("inlineData") and snake_case ("inline_data") spellings used by Gemini clients.
+func geminiInlineData(part gjson.Result) gjson.Result {
+	if inlineData := part.Get("inlineData"); inlineData.Exists() {
+		return inlineData
+	}
+	return part.Get("inline_data")
+}
+
+// geminiInlineDataURL renders inline data as a base64 data URL for an OpenAI image_url part.
+func geminiInlineDataURL(inlineData gjson.Result) string {
+	mimeType := inlineData.Get("mimeType").String()
+	if mimeType == "" {
+		mimeType = inlineData.Get("mime_type").String()
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, inlineData.Get("data").String())
 }
@@ -0,0 +1,96 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToOpenAI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		check func(t *testing.T, out gjson.Result)
+	}{
+		{
+			name: "text only with system instruction and model role",
+			input: `{
+				"system_instruction": {"parts": [{"text": "be brief"}]},
+				"contents": [
+					{"role": "user", "parts": [{"text": "hi"}]},
+					{"role": "model", "parts": [{"text": "hello"}]}
+				]
+			}`,
+			check: func(t *testing.T, out gjson.Result) {
+				msgs := out.Get("messages").Array()
+				if len(msgs) != 3 {
+					t.Fatalf("messages count = %d, want 3: %s", len(msgs), out.Get("messages").Raw)
+				}
+				if got := msgs[0].Get("role").String(); got != "system" {
+					t.Fatalf("first role = %q, want system", got)
+				}
+				if got := msgs[0].Get("content.0.text").String(); got != "be brief" {
+					t.Fatalf("system text = %q, want be brief", got)
+				}
+				if got := msgs[1].Get("content").String(); got != "hi" {
+					t.Fatalf("user content = %q, want hi", got)
+				}
+				if got := msgs[2].Get("role").String(); got != "assistant" {
+					t.Fatalf("model role = %q, want assistant", got)
+				}
+			},
+		},
+		{
+			name: "camelCase inline image",
+			input: `{"contents": [{"role": "user", "parts": [
+				{"text": "what is this"},
+				{"inlineData": {"mimeType": "image/png", "data": "AAAA"}}
+			]}]}`,
+			check: func(t *testing.T, out gjson.Result) {
+				content := out.Get("messages.0.content")
+				if got := content.Get("0.text").String(); got != "what is this" {
+					t.Fatalf("text part = %q, want what is this", got)
+				}
+				if got := content.Get("1.image_url.url").String(); got != "data:image/png;base64,AAAA" {
+					t.Fatalf("image url = %q, want data:image/png;base64,AAAA", got)
+				}
+			},
+		},
+		{
+			name: "snake_case inline image and function declarations",
+			input: `{
+				"contents": [{"role": "user", "parts": [{"inline_data": {"mime_type": "image/jpeg", "data": "BBBB"}}]}],
+				"tools": [{"function_declarations": [{"name": "lookup", "description": "find", "parameters": {"type": "object"}}]}],
+				"tool_config": {"function_calling_config": {"mode": "ANY"}}
+			}`,
+			check: func(t *testing.T, out gjson.Result) {
+				if got := out.Get("messages.0.content.0.image_url.url").String(); got != "data:image/jpeg;base64,BBBB" {
+					t.Fatalf("image url = %q, want data:image/jpeg;base64,BBBB", got)
+				}
+				if got := out.Get("tools.0.function.name").String(); got != "lookup" {
+					t.Fatalf("tool name = %q, want lookup", got)
+				}
+				if got := out.Get("tools.0.function.parameters.type").String(); got != "object" {
+					t.Fatalf("tool parameters type = %q, want object", got)
+				}
+				if got := out.Get("tool_choice").String(); got != "required" {
+					t.Fatalf("tool_choice = %q, want required", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := ConvertGeminiRequestToOpenAI("gpt-4.1", []byte(tt.input), true)
+			root := gjson.ParseBytes(out)
+			if got := root.Get("model").String(); got != "gpt-4.1" {
+				t.Fatalf("model = %q, want gpt-4.1", got)
+			}
+			if !root.Get("stream").Bool() {
+				t.Fatal("expected stream=true")
+			}
+			tt.check(t, root)
+		})
+	}
+}
@@ -4,6 +4,9 @@ package chat_completions
 
 import (
 	"bytes"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/common"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
@@ -27,5 +30,28 @@ func ConvertOpenAIRequestToOpenAI(modelName string, inputRawJSON []byte, _ bool)
 		// handling mechanism would be needed.
 		return bytes.Clone(inputRawJSON)
 	}
+	// Gemini-only vendor extensions must not reach OpenAI-compatible upstreams.
+	updatedJSON = stripGeminiExtraBody(updatedJSON)
+	// Upstream prompt caching only reads the top-level prompt_cache_key.
+	updatedJSON = common.PromotePromptCacheKey(updatedJSON)
+	// Match instruction messages to the role the target model expects.
+	updatedJSON = common.RewriteSystemRoles(modelName, updatedJSON)
+	// Send the output limit under the field the target model accepts.
+	updatedJSON = common.RewriteMaxTokensField(modelName, updatedJSON)
 	return updatedJSON
 }
+
+// stripGeminiExtraBody removes extra_body.gemini and drops extra_body if nothing else remains.
+func stripGeminiExtraBody(rawJSON []byte) []byte {
+	if !gjson.GetBytes(rawJSON, "extra_body.gemini").Exists() {
+		return rawJSON
+	}
+	out, err := sjson.DeleteBytes(rawJSON, "extra_body.gemini")
+	if err != nil {
+		return rawJSON
+	}
+	if extra := gjson.GetBytes(out, "extra_body"); extra.IsObject() && len(extra.Map()) == 0 {
+		out, _ = sjson.DeleteBytes(out, "extra_body")
+	}
+	return out
+}
@@ -2,8 +2,10 @@ package responses
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -45,6 +47,29 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 		out, _ = sjson.Set(out, "max_tokens", maxTokens.Int())
 	}
 
+	if temperature := root.Get("temperature"); temperature.Exists() {
+		out, _ = sjson.Set(out, "temperature", temperature.Float())
+	}
+
+	if topP := root.Get("top_p"); topP.Exists() {
+		out, _ = sjson.Set(out, "top_p", topP.Float())
+	}
+
+	// stop may be a single string or an array of strings; both shapes are valid for chat completions.
+	if stop := root.Get("stop"); stop.Exists() && (stop.Type == gjson.String || stop.IsArray()) {
+		out, _ = sjson.SetRaw(out, "stop", stop.Raw)
+	}
+
+	// Forward the prompt cache key so upstream prompt caching keeps working; a key sent only
+	// under metadata is promoted to the top-level field.
+	if promptCacheKey := common.PromptCacheKey(root); promptCacheKey != "" {
+		out, _ = sjson.Set(out, "prompt_cache_key", promptCacheKey)
+	}
+
+	// store asks the upstream to persist the response. Chat Completions upstreams have no
+	// such persistence, so it is deliberately left out; Responses-native upstreams receive
+	// the request untranslated and keep it.
+
 	if parallelToolCalls := root.Get("parallel_tool_calls"); parallelToolCalls.Exists() {
 		out, _ = sjson.Set(out, "parallel_tool_calls", parallelToolCalls.Bool())
 	}
@@ -75,6 +100,10 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 					if content.IsArray() {
 						var messageContent string
 						var toolCalls []interface{}
+						// contentParts mirrors the content as Chat Completions parts; it is only
+						// emitted when an image is present so text-only messages stay plain strings.
+						var contentParts []string
+						hasImage := false
 
 						content.ForEach(func(_, contentItem gjson.Result) bool {
 							contentType := contentItem.Get("type").String()
@@ -90,6 +119,8 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 								} else {
 									messageContent = text
 								}
+								textPart, _ := sjson.Set(`{"type":"text","text":""}`, "text", text)
+								contentParts = append(contentParts, textPart)
 							case "output_text":
 								text := contentItem.Get("text").String()
 								if messageContent != "" {
@@ -97,11 +128,24 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 								} else {
 									messageContent = text
 								}
+								textPart, _ := sjson.Set(`{"type":"text","text":""}`, "text", text)
+								contentParts = append(contentParts, textPart)
+							case "input_image":
+								if imagePart, ok := convertResponsesInputImage(contentItem); ok {
+									contentParts = append(contentParts, imagePart)
+									hasImage = true
+								}
 							}
 							return true
 						})
 
-						if messageContent != "" {
+						if hasImage {
+							contentArray := "[]"
+							for _, part := range contentParts {
+								contentArray, _ = sjson.SetRaw(contentArray, "-1", part)
+							}
+							message, _ = sjson.SetRaw(message, "content", contentArray)
+						} else if messageContent != "" {
 							message, _ = sjson.Set(message, "content", messageContent)
 						}
 
@@ -135,9 +179,25 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 					toolCall, _ = sjson.Set(toolCall, "function.arguments", arguments.String())
 				}
 
+				// Consecutive function_call items are parallel calls from one assistant turn, so
+				// they are merged into a single assistant message whose tool_calls the following
+				// tool messages answer by tool_call_id.
+				messages := gjson.Get(out, "messages").Array()
+				if last := len(messages) - 1; last >= 0 && messages[last].Get("role").String() == "assistant" && messages[last].Get("tool_calls").IsArray() && !messages[last].Get("content").Exists() {
+					out, _ = sjson.SetRaw(out, fmt.Sprintf("messages.%d.tool_calls.-1", last), toolCall)
+					break
+				}
 				assistantMessage, _ = sjson.SetRaw(assistantMessage, "tool_calls.0", toolCall)
 				out, _ = sjson.SetRaw(out, "messages.-1", assistantMessage)
 
+			case "reasoning":
+				// Handle replayed reasoning items by carrying their text as assistant reasoning_content
+				if reasoningText := responsesReasoningItemText(item); reasoningText != "" {
+					assistantMessage := `{"role":"assistant","content":"","reasoning_content":""}`
+					assistantMessage, _ = sjson.Set(assistantMessage, "reasoning_content", reasoningText)
+					out, _ = sjson.SetRaw(out, "messages.-1", assistantMessage)
+				}
+
 			case "function_call_output":
 				// Handle function call output conversion to tool message
 				toolMessage := `{"role":"tool","tool_call_id":"","content":""}`
@@ -146,7 +206,9 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 					toolMessage, _ = sjson.Set(toolMessage, "tool_call_id", callId.String())
 				}
 
-				if output := item.Get("output"); output.Exists() {
+				if output := item.Get("output"); output.IsArray() {
+					toolMessage = setResponsesToolOutputContent(toolMessage, output)
+				} else if output.Exists() {
 					toolMessage, _ = sjson.Set(toolMessage, "content", output.String())
 				}
 
@@ -215,5 +277,90 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 		out, _ = sjson.Set(out, "tool_choice", toolChoice.String())
 	}
 
-	return []byte(out)
+	// Match instruction messages to the role the target model expects, and send the output
+	// limit under the field it accepts.
+	return common.RewriteMaxTokensField(modelName, common.RewriteSystemRoles(modelName, []byte(out)))
+}
+
+// responsesReasoningItemText extracts the text of a Responses API reasoning item.
+// Full reasoning_text content is preferred; summary_text parts are used otherwise.
+func responsesReasoningItemText(item gjson.Result) string {
+	collect := func(parts gjson.Result, partType string) string {
+		var texts []string
+		parts.ForEach(func(_, part gjson.Result) bool {
+			if t := part.Get("type").String(); t != "" && t != partType {
+				return true
+			}
+			if text := part.Get("text").String(); strings.TrimSpace(text) != "" {
+				texts = append(texts, text)
+			}
+			return true
+		})
+		return strings.Join(texts, "\n\n")
+	}
+
+	if content := item.Get("content"); content.IsArray() {
+		if text := collect(content, "reasoning_text"); text != "" {
+			return text
+		}
+	}
+	if summary := item.Get("summary"); summary.IsArray() {
+		return collect(summary, "summary_text")
+	}
+	return ""
+}
+
+// convertResponsesInputImage converts a Responses API input_image part into a Chat Completions
+// image_url part. Both the string form ("image_url":"data:...") and the object form
+// ("image_url":{"url":"..."}) are accepted, and detail is preserved when present.
+// setResponsesToolOutputContent converts a function_call_output output array into the tool
+// message content. Images, e.g. a computer-use screenshot, are kept as image_url parts; a
+// text-only output is joined into a plain string.
+func setResponsesToolOutputContent(toolMessage string, output gjson.Result) string {
+	var texts []string
+	contentParts := "[]"
+	hasImage := false
+	output.ForEach(func(_, part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "input_text", "output_text", "text":
+			text := part.Get("text").String()
+			texts = append(texts, text)
+			textPart, _ := sjson.Set(`{"type":"text","text":""}`, "text", text)
+			contentParts, _ = sjson.SetRaw(contentParts, "-1", textPart)
+		case "input_image":
+			if imagePart, ok := convertResponsesInputImage(part); ok {
+				contentParts, _ = sjson.SetRaw(contentParts, "-1", imagePart)
+				hasImage = true
+			}
+		}
+		return true
+	})
+	if hasImage {
+		toolMessage, _ = sjson.SetRaw(toolMessage, "content", contentParts)
+		return toolMessage
+	}
+	toolMessage, _ = sjson.Set(toolMessage, "content", strings.Join(texts, "\n"))
+	return toolMessage
+}
+
+func convertResponsesInputImage(part gjson.Result) (string, bool) {
+	imageURL := part.Get("image_url")
+	url := imageURL.String()
+	detail := part.Get("detail").String()
+	if imageURL.IsObject() {
+		url = imageURL.Get("url").String()
+		if d := imageURL.Get("detail").String(); d != "" {
+			detail = d
+		}
+	}
+	if strings.TrimSpace(url) == "" {
+		return "", false
+	}
+
+	imagePart := `{"type":"image_url","image_url":{"url":""}}`
+	imagePart, _ = sjson.Set(imagePart, "image_url.url", url)
+	if detail != "" {
+		imagePart, _ = sjson.Set(imagePart, "image_url.detail", detail)
+	}
+	return imagePart, true
 }
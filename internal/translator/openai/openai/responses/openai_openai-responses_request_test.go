@@ -1,8 +1,13 @@
 package responses
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	"github.com/tidwall/gjson"
 )
 
@@ -38,3 +43,280 @@ func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_StringContent(t *t
 		t.Fatalf("user content = %q, want hello there", got)
 	}
 }
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_ReasoningItem(t *testing.T) {
+	payload := []byte(`{
+		"model": "gpt-5-codex",
+		"input": [
+			{"role":"user","content":[{"type":"input_text","text":"fix the bug"}]},
+			{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"Looking at the stack trace."},{"type":"summary_text","text":"The nil check is missing."}]},
+			{"type":"reasoning","id":"rs_2","summary":[],"content":[{"type":"reasoning_text","text":"full chain of thought"}]},
+			{"type":"reasoning","id":"rs_3","summary":[],"encrypted_content":"opaque"}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-5-codex", payload, false)
+
+	msgs := gjson.GetBytes(out, "messages").Array()
+	if len(msgs) != 3 {
+		t.Fatalf("messages count = %d, want 3: %s", len(msgs), gjson.GetBytes(out, "messages").Raw)
+	}
+
+	if got := msgs[0].Get("role").String(); got != "user" {
+		t.Fatalf("messages[0].role = %q, want user", got)
+	}
+	if got := msgs[0].Get("content").String(); got != "fix the bug" {
+		t.Fatalf("messages[0].content = %q, want fix the bug", got)
+	}
+
+	if got := msgs[1].Get("role").String(); got != "assistant" {
+		t.Fatalf("messages[1].role = %q, want assistant", got)
+	}
+	want := "Looking at the stack trace.\n\nThe nil check is missing."
+	if got := msgs[1].Get("reasoning_content").String(); got != want {
+		t.Fatalf("messages[1].reasoning_content = %q, want %q", got, want)
+	}
+
+	if got := msgs[2].Get("reasoning_content").String(); got != "full chain of thought" {
+		t.Fatalf("messages[2].reasoning_content = %q, want full chain of thought", got)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_InputImage(t *testing.T) {
+	payload := []byte(`{
+		"model": "gpt-4.1",
+		"input": [
+			{"role":"user","content":[
+				{"type":"input_text","text":"describe these"},
+				{"type":"input_image","image_url":{"url":"data:image/png;base64,AAAA","detail":"high"}},
+				{"type":"input_image","image_url":"https://example.com/cat.jpg","detail":"low"}
+			]}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", payload, false)
+
+	content := gjson.GetBytes(out, "messages.0.content")
+	if !content.IsArray() {
+		t.Fatalf("content not array: %s", content.Raw)
+	}
+	parts := content.Array()
+	if len(parts) != 3 {
+		t.Fatalf("content parts = %d, want 3: %s", len(parts), content.Raw)
+	}
+
+	if got := parts[0].Get("type").String(); got != "text" {
+		t.Fatalf("parts[0].type = %q, want text", got)
+	}
+	if got := parts[0].Get("text").String(); got != "describe these" {
+		t.Fatalf("parts[0].text = %q, want describe these", got)
+	}
+
+	if got := parts[1].Get("type").String(); got != "image_url" {
+		t.Fatalf("parts[1].type = %q, want image_url", got)
+	}
+	if got := parts[1].Get("image_url.url").String(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("parts[1].image_url.url = %q", got)
+	}
+	if got := parts[1].Get("image_url.detail").String(); got != "high" {
+		t.Fatalf("parts[1].image_url.detail = %q, want high", got)
+	}
+
+	if got := parts[2].Get("image_url.url").String(); got != "https://example.com/cat.jpg" {
+		t.Fatalf("parts[2].image_url.url = %q", got)
+	}
+	if got := parts[2].Get("image_url.detail").String(); got != "low" {
+		t.Fatalf("parts[2].image_url.detail = %q, want low", got)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_GenerationParams(t *testing.T) {
+	payload := []byte(`{
+		"model": "gpt-4.1",
+		"max_output_tokens": 256,
+		"temperature": 0.2,
+		"top_p": 0.9,
+		"stop": ["END", "STOP"],
+		"input": "hello"
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", payload, false)
+
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 256 {
+		t.Fatalf("max_tokens = %d, want 256", got)
+	}
+	if gjson.GetBytes(out, "max_output_tokens").Exists() {
+		t.Fatalf("max_output_tokens must not be forwarded: %s", out)
+	}
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 0.2 {
+		t.Fatalf("temperature = %v, want 0.2", got)
+	}
+	if got := gjson.GetBytes(out, "top_p").Float(); got != 0.9 {
+		t.Fatalf("top_p = %v, want 0.9", got)
+	}
+	stop := gjson.GetBytes(out, "stop")
+	if !stop.IsArray() || len(stop.Array()) != 2 || stop.Array()[1].String() != "STOP" {
+		t.Fatalf("stop = %s, want [\"END\",\"STOP\"]", stop.Raw)
+	}
+
+	out = ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", []byte(`{"stop":"END","input":"hello"}`), false)
+	if got := gjson.GetBytes(out, "stop"); got.Type != gjson.String || got.String() != "END" {
+		t.Fatalf("string stop = %s, want \"END\"", got.Raw)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_OmitsUnsetGenerationParams(t *testing.T) {
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", []byte(`{"input":"hello"}`), false)
+
+	for _, field := range []string{"max_tokens", "temperature", "top_p", "stop"} {
+		if v := gjson.GetBytes(out, field); v.Exists() {
+			t.Fatalf("%s = %s, want omitted", field, v.Raw)
+		}
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_DeveloperRole(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("responses-developer-role-client", "openai", []*registry.ModelInfo{
+		{ID: "developer-role-model", Object: "model", Created: time.Now().Unix(), OwnedBy: "openai", SystemRole: "developer"},
+		{ID: "system-role-model", Object: "model", Created: time.Now().Unix(), OwnedBy: "openai", SystemRole: "system"},
+	})
+	t.Cleanup(func() { reg.UnregisterClient("responses-developer-role-client") })
+
+	payload := []byte(`{"instructions":"be brief","input":[{"role":"developer","content":"use tools"},{"role":"user","content":"hi"}]}`)
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("developer-role-model", payload, false)
+	for i, want := range []string{"developer", "developer", "user"} {
+		if got := gjson.GetBytes(out, fmt.Sprintf("messages.%d.role", i)).String(); got != want {
+			t.Fatalf("developer model: messages.%d.role = %q, want %q", i, got, want)
+		}
+	}
+
+	out = ConvertOpenAIResponsesRequestToOpenAIChatCompletions("system-role-model", payload, false)
+	for i, want := range []string{"system", "system", "user"} {
+		if got := gjson.GetBytes(out, fmt.Sprintf("messages.%d.role", i)).String(); got != want {
+			t.Fatalf("system model: messages.%d.role = %q, want %q", i, got, want)
+		}
+	}
+
+	out = ConvertOpenAIResponsesRequestToOpenAIChatCompletions("unknown-role-model", payload, false)
+	for i, want := range []string{"system", "developer", "user"} {
+		if got := gjson.GetBytes(out, fmt.Sprintf("messages.%d.role", i)).String(); got != want {
+			t.Fatalf("unknown model: messages.%d.role = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_ToolCallIDsRoundTrip(t *testing.T) {
+	payload := []byte(`{
+		"input": [
+			{"role":"user","content":"weather in Paris and Rome?"},
+			{"type":"function_call","call_id":"call_paris","name":"get_weather","arguments":"{\"city\":\"Paris\"}"},
+			{"type":"function_call","call_id":"call_rome","name":"get_weather","arguments":"{\"city\":\"Rome\"}"},
+			{"type":"function_call_output","call_id":"call_paris","output":"sunny"},
+			{"type":"function_call_output","call_id":"call_rome","output":"rainy"}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", payload, false)
+
+	msgs := gjson.GetBytes(out, "messages").Array()
+	if len(msgs) != 4 {
+		t.Fatalf("expected user, assistant and two tool messages, got %d: %s", len(msgs), out)
+	}
+	assistant := msgs[1]
+	if got := assistant.Get("role").String(); got != "assistant" {
+		t.Fatalf("messages.1.role = %q, want assistant", got)
+	}
+	calls := assistant.Get("tool_calls").Array()
+	if len(calls) != 2 {
+		t.Fatalf("expected both calls on one assistant message, got %s", assistant.Raw)
+	}
+	for i, id := range []string{"call_paris", "call_rome"} {
+		if got := calls[i].Get("id").String(); got != id {
+			t.Fatalf("tool_calls.%d.id = %q, want %q", i, got, id)
+		}
+		if got := calls[i].Get("type").String(); got != "function" {
+			t.Fatalf("tool_calls.%d.type = %q, want function", i, got)
+		}
+		tool := msgs[2+i]
+		if tool.Get("role").String() != "tool" || tool.Get("tool_call_id").String() != id {
+			t.Fatalf("messages.%d = %s, want tool message for %s", 2+i, tool.Raw, id)
+		}
+	}
+	if got := calls[1].Get("function.arguments").String(); got != `{"city":"Rome"}` {
+		t.Fatalf("tool_calls.1 arguments = %q", got)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_PromptCacheKey(t *testing.T) {
+	for name, payload := range map[string]string{
+		"top level": `{"input":"hi","prompt_cache_key":"session-1"}`,
+		"metadata":  `{"input":"hi","metadata":{"prompt_cache_key":"session-1"}}`,
+		"top wins":  `{"input":"hi","prompt_cache_key":"session-1","metadata":{"prompt_cache_key":"other"}}`,
+	} {
+		out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", []byte(payload), false)
+		if got := gjson.GetBytes(out, "prompt_cache_key").String(); got != "session-1" {
+			t.Fatalf("%s: prompt_cache_key = %q, want session-1; out = %s", name, got, out)
+		}
+	}
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", []byte(`{"input":"hi"}`), false)
+	if gjson.GetBytes(out, "prompt_cache_key").Exists() {
+		t.Fatalf("prompt_cache_key should be omitted when unset: %s", out)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_Store(t *testing.T) {
+	for _, store := range []string{"true", "false"} {
+		payload := []byte(`{"input":"hi","store":` + store + `}`)
+
+		out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", payload, false)
+		if gjson.GetBytes(out, "store").Exists() {
+			t.Fatalf("store=%s should be dropped for chat completions: %s", store, out)
+		}
+
+		passthrough := translator.Request(constant.OpenaiResponse, constant.OpenaiResponse, "gpt-4.1", payload, false)
+		if got := gjson.GetBytes(passthrough, "store").Raw; got != store {
+			t.Fatalf("store = %q, want %s kept for a Responses upstream; out = %s", got, store, passthrough)
+		}
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_FunctionCallOutputImage(t *testing.T) {
+	payload := []byte(`{
+		"model": "gpt-4.1",
+		"input": [
+			{"type":"function_call","call_id":"call_shot","name":"screenshot","arguments":"{}"},
+			{"type":"function_call_output","call_id":"call_shot","output":[
+				{"type":"input_text","text":"current screen"},
+				{"type":"input_image","image_url":"data:image/png;base64,AAAA"}
+			]},
+			{"type":"function_call_output","call_id":"call_text","output":[
+				{"type":"input_text","text":"line one"},
+				{"type":"input_text","text":"line two"}
+			]}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-4.1", payload, false)
+
+	content := gjson.GetBytes(out, "messages.1.content")
+	if !content.IsArray() {
+		t.Fatalf("tool content not array: %s", content.Raw)
+	}
+	parts := content.Array()
+	if len(parts) != 2 {
+		t.Fatalf("tool content parts = %d, want 2: %s", len(parts), content.Raw)
+	}
+	if got := parts[0].Get("text").String(); got != "current screen" {
+		t.Fatalf("parts[0].text = %q, want current screen", got)
+	}
+	if got := parts[1].Get("image_url.url").String(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("parts[1].image_url.url = %q", got)
+	}
+
+	if got := gjson.GetBytes(out, "messages.2.content").String(); got != "line one\nline two" {
+		t.Fatalf("text-only tool content = %q, want joined text", got)
+	}
+}
@@ -0,0 +1,198 @@
+package responses
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// runResponsesStream feeds recorded Chat Completions SSE lines through the streaming
+// translator and returns the emitted Responses events in order.
+func runResponsesStream(t *testing.T, lines []string) (events []string, payloads []gjson.Result) {
+	t.Helper()
+	request := []byte(`{"model":"gpt-4.1","instructions":"be brief"}`)
+	var param any
+	for _, line := range lines {
+		for _, chunk := range ConvertOpenAIChatCompletionsResponseToOpenAIResponses(context.Background(), "gpt-4.1", request, request, []byte(line), &param) {
+			parts := strings.SplitN(chunk, "\n", 2)
+			if len(parts) != 2 || !strings.HasPrefix(parts[0], "event: ") || !strings.HasPrefix(parts[1], "data: ") {
+				t.Fatalf("malformed event chunk: %q", chunk)
+			}
+			events = append(events, strings.TrimPrefix(parts[0], "event: "))
+			payloads = append(payloads, gjson.Parse(strings.TrimPrefix(parts[1], "data: ")))
+		}
+	}
+	return events, payloads
+}
+
+func assertEventSequence(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("event sequence mismatch\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_TextStream(t *testing.T) {
+	lines := []string{
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+		`data: [DONE]`,
+	}
+
+	events, payloads := runResponsesStream(t, lines)
+	assertEventSequence(t, events, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.completed",
+	})
+
+	if got := payloads[4].Get("delta").String() + payloads[5].Get("delta").String(); got != "Hello" {
+		t.Fatalf("text deltas = %q, want Hello", got)
+	}
+	completed := payloads[len(payloads)-1]
+	if got := completed.Get("response.output.0.content.0.text").String(); got != "Hello" {
+		t.Fatalf("completed output text = %q, want Hello", got)
+	}
+	if got := completed.Get("response.usage.total_tokens").Int(); got != 7 {
+		t.Fatalf("completed usage total_tokens = %d, want 7", got)
+	}
+	if got := completed.Get("response.instructions").String(); got != "be brief" {
+		t.Fatalf("completed instructions = %q, want be brief", got)
+	}
+	for i := 1; i < len(payloads); i++ {
+		if payloads[i].Get("sequence_number").Int() <= payloads[i-1].Get("sequence_number").Int() {
+			t.Fatalf("sequence numbers not increasing at event %d", i)
+		}
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_ToolCallStream(t *testing.T) {
+	lines := []string{
+		`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":""}}]}}]}`,
+		`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"q\":"}}]}}]}`,
+		`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"cat\"}"}}]}}]}`,
+		`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: [DONE]`,
+	}
+
+	events, payloads := runResponsesStream(t, lines)
+	assertEventSequence(t, events, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	})
+
+	if got := payloads[2].Get("item.call_id").String(); got != "call_1" {
+		t.Fatalf("function call_id = %q, want call_1", got)
+	}
+	if got := payloads[5].Get("arguments").String(); got != `{"q":"cat"}` {
+		t.Fatalf("function arguments = %q", got)
+	}
+	if got := payloads[len(payloads)-1].Get("response.output.0.name").String(); got != "lookup" {
+		t.Fatalf("completed function name = %q, want lookup", got)
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_DoneFlushesCompleted(t *testing.T) {
+	lines := []string{
+		`data: {"id":"chatcmpl-3","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"content":"partial"}}]}`,
+		`data: [DONE]`,
+		`data: [DONE]`,
+	}
+
+	events, payloads := runResponsesStream(t, lines)
+	assertEventSequence(t, events, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.completed",
+	})
+	if got := payloads[len(payloads)-1].Get("response.output.0.content.0.text").String(); got != "partial" {
+		t.Fatalf("completed output text = %q, want partial", got)
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_LengthFinishIsIncomplete(t *testing.T) {
+	lines := []string{
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`,
+		`data: [DONE]`,
+	}
+
+	events, payloads := runResponsesStream(t, lines)
+	if got := events[len(events)-1]; got != "response.incomplete" {
+		t.Fatalf("final event = %q, want response.incomplete", got)
+	}
+	final := payloads[len(payloads)-1]
+	if got := final.Get("response.status").String(); got != "incomplete" {
+		t.Fatalf("status = %q, want incomplete", got)
+	}
+	if got := final.Get("response.incomplete_details.reason").String(); got != "max_output_tokens" {
+		t.Fatalf("incomplete reason = %q, want max_output_tokens", got)
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream_FinishReason(t *testing.T) {
+	for finishReason, wantStatus := range map[string]string{"stop": "completed", "tool_calls": "completed", "length": "incomplete", "content_filter": "incomplete"} {
+		raw := []byte(`{"id":"chatcmpl-1","created":1700000000,"model":"gpt-4.1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"` + finishReason + `"}]}`)
+		out := gjson.Parse(ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(context.Background(), "gpt-4.1", nil, nil, raw, nil))
+		if got := out.Get("status").String(); got != wantStatus {
+			t.Fatalf("%s: status = %q, want %q", finishReason, got, wantStatus)
+		}
+		if wantStatus == "completed" && out.Get("incomplete_details.reason").Exists() {
+			t.Fatalf("%s: unexpected incomplete_details %s", finishReason, out.Get("incomplete_details").Raw)
+		}
+	}
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_SystemFingerprint(t *testing.T) {
+	lines := []string{
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"system_fingerprint":"fp_abc123","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"system_fingerprint":"fp_abc123","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+	}
+	_, payloads := runResponsesStream(t, lines)
+	completed := payloads[len(payloads)-1]
+	if got := completed.Get("response.system_fingerprint").String(); got != "fp_abc123" {
+		t.Fatalf("stream system_fingerprint = %q, want fp_abc123", got)
+	}
+
+	raw := []byte(`{"id":"chatcmpl-2","object":"chat.completion","created":1700000000,"system_fingerprint":"fp_abc123","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	out := ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(context.Background(), "gpt-4.1", nil, nil, raw, nil)
+	if got := gjson.Get(out, "system_fingerprint").String(); got != "fp_abc123" {
+		t.Fatalf("non-stream system_fingerprint = %q, want fp_abc123", got)
+	}
+
+	// Without an upstream fingerprint nothing is emitted.
+	_, payloads = runResponsesStream(t, []string{
+		`data: {"id":"chatcmpl-3","object":"chat.completion.chunk","created":1700000000,"choices":[{"index":0,"delta":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+	})
+	if payloads[len(payloads)-1].Get("response.system_fingerprint").Exists() {
+		t.Fatalf("unexpected stream system_fingerprint: %s", payloads[len(payloads)-1].Raw)
+	}
+	out = ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(context.Background(), "gpt-4.1", nil, nil, []byte(`{"id":"chatcmpl-3","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`), nil)
+	if gjson.Get(out, "system_fingerprint").Exists() {
+		t.Fatalf("unexpected non-stream system_fingerprint: %s", out)
+	}
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// codexEncodingCache holds one codec per encoding name. Codecs are immutable, so a single
+// instance is shared by all requests instead of rebuilding the encoding each call.
+var codexEncodingCache sync.Map
+
+// CodexTokenizer returns the shared tiktoken codec for an OpenAI/Codex model.
+func CodexTokenizer(model string) (tokenizer.Codec, error) {
+	return cachedEncoding(codexEncodingForModel(model))
+}
+
+// codexEncodingForModel resolves the tiktoken encoding for a model id, falling back to
+// cl100k_base for empty and unknown models.
+func codexEncodingForModel(model string) tokenizer.Encoding {
+	sanitized := strings.ToLower(strings.TrimSpace(model))
+	switch {
+	case strings.HasPrefix(sanitized, "gpt-5"),
+		strings.HasPrefix(sanitized, "gpt-4.1"),
+		strings.HasPrefix(sanitized, "gpt-4o"):
+		return tokenizer.O200kBase
+	default:
+		return tokenizer.Cl100kBase
+	}
+}
+
+func cachedEncoding(encoding tokenizer.Encoding) (tokenizer.Codec, error) {
+	if cached, ok := codexEncodingCache.Load(encoding); ok {
+		return cached.(tokenizer.Codec), nil
+	}
+	enc, err := tokenizer.Get(encoding)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := codexEncodingCache.LoadOrStore(encoding, enc)
+	return actual.(tokenizer.Codec), nil
+}
+
+// CountTokenizeInput counts tokens for a tokenize request using the encoder that
+// CodexTokenizer selects for model. input may be a string, an array of strings, or a
+// Chat Completions messages array, in which case message contents are concatenated.
+// It returns an error when input has any other shape.
+func CountTokenizeInput(model string, input gjson.Result) (int, error) {
+	var segments []string
+	switch {
+	case input.Type == gjson.String:
+		segments = append(segments, input.String())
+	case input.IsArray():
+		var errShape error
+		input.ForEach(func(_, item gjson.Result) bool {
+			switch {
+			case item.Type == gjson.String:
+				segments = append(segments, item.String())
+			case item.IsObject() && item.Get("content").Exists():
+				CollectOpenAIContent(item.Get("content"), &segments)
+			default:
+				errShape = fmt.Errorf("unsupported input item: %s", item.Raw)
+				return false
+			}
+			return true
+		})
+		if errShape != nil {
+			return 0, errShape
+		}
+	default:
+		return 0, fmt.Errorf("input must be a string or an array")
+	}
+
+	enc, err := CodexTokenizer(model)
+	if err != nil {
+		return 0, fmt.Errorf("tokenizer init failed: %w", err)
+	}
+	text := strings.Join(segments, "\n")
+	if text == "" {
+		return 0, nil
+	}
+	return enc.Count(text)
+}
+
+// CollectOpenAIContent appends the countable text of a Chat Completions message content
+// value to segments: text parts, image URLs, audio IDs and nested tool results.
+func CollectOpenAIContent(content gjson.Result, segments *[]string) {
+	if !content.Exists() {
+		return
+	}
+	if content.Type == gjson.String {
+		appendNonEmpty(segments, content.String())
+		return
+	}
+	if content.IsArray() {
+		content.ForEach(func(_, part gjson.Result) bool {
+			partType := part.Get("type").String()
+			switch partType {
+			case "text", "input_text", "output_text":
+				appendNonEmpty(segments, part.Get("text").String())
+			case "image_url":
+				appendNonEmpty(segments, part.Get("image_url.url").String())
+			case "input_audio", "output_audio", "audio":
+				appendNonEmpty(segments, part.Get("id").String())
+			case "tool_result":
+				appendNonEmpty(segments, part.Get("name").String())
+				CollectOpenAIContent(part.Get("content"), segments)
+			default:
+				if part.IsArray() {
+					CollectOpenAIContent(part, segments)
+					return true
+				}
+				if part.Type == gjson.JSON {
+					appendNonEmpty(segments, part.Raw)
+					return true
+				}
+				appendNonEmpty(segments, part.String())
+			}
+			return true
+		})
+		return
+	}
+	if content.Type == gjson.JSON {
+		appendNonEmpty(segments, content.Raw)
+	}
+}
+
+func appendNonEmpty(segments *[]string, value string) {
+	if segments == nil {
+		return
+	}
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		*segments = append(*segments, trimmed)
+	}
+}
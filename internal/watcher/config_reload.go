@@ -5,6 +5,7 @@ package watcher
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"reflect"
 	"time"
@@ -77,14 +78,40 @@ func (w *Watcher) reloadConfigIfChanged() {
 	}
 }
 
+// ReloadConfig re-reads the config file immediately, bypassing the debounce timer and the
+// content hash check, and fans the result out through the reload callback.
+func (w *Watcher) ReloadConfig() error {
+	w.stopConfigReloadTimer()
+	data, err := os.ReadFile(w.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err = w.reloadConfigFromDisk(); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	w.clientsMutex.Lock()
+	w.lastConfigHash = hex.EncodeToString(sum[:])
+	w.clientsMutex.Unlock()
+	w.persistConfigAsync()
+	return nil
+}
+
 func (w *Watcher) reloadConfig() bool {
+	if err := w.reloadConfigFromDisk(); err != nil {
+		log.Errorf("failed to reload config: %v", err)
+		return false
+	}
+	return true
+}
+
+func (w *Watcher) reloadConfigFromDisk() error {
 	log.Debug("=========================== CONFIG RELOAD ============================")
 	log.Debugf("starting config reload from: %s", w.configPath)
 
 	newConfig, errLoadConfig := config.LoadConfig(w.configPath)
 	if errLoadConfig != nil {
-		log.Errorf("failed to reload config: %v", errLoadConfig)
-		return false
+		return errLoadConfig
 	}
 
 	if w.mirroredAuthDir != "" {
@@ -127,9 +154,11 @@ func (w *Watcher) reloadConfig() bool {
 	}
 
 	authDirChanged := oldConfig == nil || oldConfig.AuthDir != newConfig.AuthDir
-	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix || !reflect.DeepEqual(oldConfig.OAuthModelMappings, newConfig.OAuthModelMappings))
+	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix ||
+		oldConfig.DisableCopilotAliases != newConfig.DisableCopilotAliases ||
+		!reflect.DeepEqual(oldConfig.OAuthModelMappings, newConfig.OAuthModelMappings))
 
 	log.Infof("config successfully reloaded, triggering client reload")
 	w.reloadClients(authDirChanged, affectedOAuthProviders, forceAuthRefresh)
-	return true
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -364,3 +365,39 @@ func equalUpstreamAPIKeys(a, b []config.AmpUpstreamAPIKeyEntry) bool {
 	}
 	return true
 }
+
+// ChangedConfigFields returns the sorted YAML keys of top-level config fields whose values
+// differ between oldCfg and newCfg. Inlined structs (e.g. the SDK config) are flattened.
+func ChangedConfigFields(oldCfg, newCfg *config.Config) []string {
+	if oldCfg == nil || newCfg == nil {
+		return nil
+	}
+	var changed []string
+	collectChangedFields(reflect.ValueOf(*oldCfg), reflect.ValueOf(*newCfg), &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+func collectChangedFields(oldVal, newVal reflect.Value, changed *[]string) {
+	t := oldVal.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") && field.Type.Kind() == reflect.Struct {
+			collectChangedFields(oldVal.Field(i), newVal.Field(i), changed)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if !reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+			*changed = append(*changed, name)
+		}
+	}
+}
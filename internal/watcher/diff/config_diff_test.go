@@ -527,3 +527,33 @@ func TestTrimStrings(t *testing.T) {
 		t.Fatalf("unexpected trimmed strings: %v", out)
 	}
 }
+
+func TestChangedConfigFields(t *testing.T) {
+	oldCfg := &config.Config{
+		Port:       8080,
+		CopilotKey: []config.CopilotKey{{AccountType: "individual"}},
+		SDKConfig:  sdkconfig.SDKConfig{APIKeys: []string{"a"}},
+	}
+	newCfg := &config.Config{
+		Port:           8080,
+		MetricsEnabled: true,
+		CopilotKey:     []config.CopilotKey{{AccountType: "business"}},
+		SDKConfig:      sdkconfig.SDKConfig{APIKeys: []string{"a", "b"}},
+	}
+	got := ChangedConfigFields(oldCfg, newCfg)
+	want := []string{"api-keys", "copilot-api-key", "metrics-enabled"}
+	if len(got) != len(want) {
+		t.Fatalf("ChangedConfigFields = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ChangedConfigFields = %v, want %v", got, want)
+		}
+	}
+	if fields := ChangedConfigFields(oldCfg, oldCfg); len(fields) != 0 {
+		t.Fatalf("expected no changes for identical configs, got %v", fields)
+	}
+	if fields := ChangedConfigFields(nil, newCfg); fields != nil {
+		t.Fatalf("expected nil for nil old config, got %v", fields)
+	}
+}
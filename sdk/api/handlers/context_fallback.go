@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// ContextFallbackHeader reports the originally requested model when a request that
+// overflowed its context window was retried on a larger-context sibling.
+const ContextFallbackHeader = "X-CLIProxy-Context-Fallback"
+
+// contextOverflowMarkers are lower-cased fragments upstreams use when a prompt does not fit
+// the model's context window.
+var contextOverflowMarkers = []string{
+	"context_length_exceeded",
+	"model_max_prompt_tokens_exceeded",
+	"maximum context length",
+	"context window",
+	"prompt is too long",
+	"input is too long",
+}
+
+// isContextOverflowError reports whether err is an upstream rejection of an oversized prompt.
+func isContextOverflowError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch statusFromError(err) {
+	case 0, http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+	default:
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, marker := range contextOverflowMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// estimatePromptTokens sizes the payload with the model's tokenizer. Counting the raw JSON
+// overestimates slightly, which keeps the chosen sibling on the safe side.
+func estimatePromptTokens(model string, payload []byte) int {
+	if enc, err := util.CodexTokenizer(model); err == nil {
+		if count, errCount := enc.Count(string(payload)); errCount == nil {
+			return count
+		}
+	}
+	return len(payload) / 4
+}
+
+// contextFallback rebuilds req and opts for a larger-context sibling of model when
+// LargerContextFallback is enabled and err reports a context overflow. It returns false
+// when no registered sibling fits the prompt.
+func (h *BaseAPIHandler) contextFallback(ctx context.Context, model string, err error, req coreexecutor.Request, opts coreexecutor.Options) ([]string, coreexecutor.Request, coreexecutor.Options, bool) {
+	if h == nil || h.Cfg == nil || !h.Cfg.LargerContextFallback || !isContextOverflowError(err) {
+		return nil, req, opts, false
+	}
+	target, ok := registry.GetGlobalRegistry().FindLargerContextModel(model, estimatePromptTokens(model, req.Payload))
+	if !ok {
+		log.Debugf("model %s context window exceeded and no larger-context sibling is registered", model)
+		return nil, req, opts, false
+	}
+	providers, targetModel, metadata, errMsg := h.getRequestDetails(target.ID)
+	if errMsg != nil {
+		return nil, req, opts, false
+	}
+
+	log.Warnf("model %s context window exceeded, retrying on %s", model, targetModel)
+	if ginCtx, okGin := ctx.Value("gin").(*gin.Context); okGin && ginCtx != nil {
+		ginCtx.Header(ContextFallbackHeader, model)
+	}
+	req.Model = targetModel
+	req.Payload = setPayloadModel(cloneBytes(req.Payload), targetModel)
+	req.Metadata = cloneMetadata(metadata)
+	opts.OriginalRequest = setPayloadModel(cloneBytes(opts.OriginalRequest), targetModel)
+	opts.Metadata = mergeMetadata(cloneMetadata(metadata), requestExecutionMetadata(ctx))
+	return providers, req, opts, true
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// overflowExecutor rejects every request for the small model as exceeding its context window.
+type overflowExecutor struct {
+	mu     sync.Mutex
+	models []string
+}
+
+func (e *overflowExecutor) Identifier() string { return "codex" }
+
+func (e *overflowExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.models = append(e.models, req.Model)
+	e.mu.Unlock()
+	if req.Model == "ctx-small" {
+		return coreexecutor.Response{}, &coreauth.Error{
+			Code:       "context_length_exceeded",
+			Message:    `{"error":{"message":"This model's maximum context length is 8192 tokens.","code":"context_length_exceeded"}}`,
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"model":"` + gjson.GetBytes(req.Payload, "model").String() + `"}`)}, nil
+}
+
+func (e *overflowExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *overflowExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *overflowExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *overflowExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func newContextFallbackHandler(t *testing.T, enabled bool) (*BaseAPIHandler, *overflowExecutor) {
+	t.Helper()
+	executor := &overflowExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "ctx-fallback-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{
+		{ID: "ctx-small", OwnedBy: "ctx-family", ContextLength: 8192},
+		{ID: "ctx-large", OwnedBy: "ctx-family", ContextLength: 128000},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{LargerContextFallback: enabled}, manager), executor
+}
+
+func TestExecuteWithAuthManager_ContextOverflowUsesLargerModel(t *testing.T) {
+	handler, executor := newContextFallbackHandler(t, true)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	prompt := strings.Repeat("lorem ipsum ", 10000)
+	resp, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "ctx-small", []byte(`{"model":"ctx-small","messages":[{"role":"user","content":"`+prompt+`"}]}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "model").String(); got != "ctx-large" {
+		t.Fatalf("upstream payload model = %q, want ctx-large", got)
+	}
+	if last := executor.models[len(executor.models)-1]; last != "ctx-large" {
+		t.Fatalf("executor models = %v, want retry on ctx-large", executor.models)
+	}
+	if got := ginCtx.Writer.Header().Get(ContextFallbackHeader); got != "ctx-small" {
+		t.Fatalf("%s = %q, want ctx-small", ContextFallbackHeader, got)
+	}
+}
+
+func TestExecuteWithAuthManager_ContextOverflowFallbackDisabled(t *testing.T) {
+	handler, executor := newContextFallbackHandler(t, false)
+
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "ctx-small", []byte(`{"model":"ctx-small","messages":[{"role":"user","content":"hi"}]}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected the 400 overflow error, got %+v", errMsg)
+	}
+	for _, model := range executor.models {
+		if model != "ctx-small" {
+			t.Fatalf("request rerouted to %s with the fallback disabled", model)
+		}
+	}
+}
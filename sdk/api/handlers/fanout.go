@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// fanOutCount returns the requested choice count when a Chat Completions request must be
+// fanned out because NFanOutMax is enabled and n > 1.
+func (h *BaseAPIHandler) fanOutCount(handlerType string, rawJSON []byte) (int, bool) {
+	if h == nil || h.Cfg == nil || h.Cfg.NFanOutMax <= 0 || handlerType != constant.OpenAI {
+		return 0, false
+	}
+	n := gjson.GetBytes(rawJSON, "n").Int()
+	if n <= 1 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// upstreamSupportsN reports whether the model a request routes to handles n itself,
+// resolving the override header and aliases the same way execution does.
+func (h *BaseAPIHandler) upstreamSupportsN(ctx context.Context, modelName string) bool {
+	modelName, _ = applyModelOverride(ctx, modelName, nil)
+	normalized, _ := normalizeModelMetadata(util.ResolveAutoModel(h.resolveModelAlias(modelName)))
+	return modelSupportsN(normalized)
+}
+
+// modelSupportsN reports whether the registry lists n among the model's supported
+// parameters. Models without that capability, or without registry parameters, are fanned out.
+func modelSupportsN(model string) bool {
+	reg := registry.GetGlobalRegistry()
+	info := reg.GetModelInfo(model)
+	if info == nil {
+		info = reg.GetModelInfo(strings.TrimPrefix(model, registry.CopilotModelPrefix))
+	}
+	if info == nil {
+		return false
+	}
+	for _, param := range info.SupportedParameters {
+		if strings.EqualFold(strings.TrimSpace(param), "n") {
+			return true
+		}
+	}
+	return false
+}
+
+// fanOutStreamError rejects n > 1 on streaming Chat Completions while fan-out is enabled
+// for the model, because fanned-out streams cannot be merged into one SSE response.
+func (h *BaseAPIHandler) fanOutStreamError(handlerType, model string, rawJSON []byte) *interfaces.ErrorMessage {
+	if _, ok := h.fanOutCount(handlerType, rawJSON); !ok || modelSupportsN(model) {
+		return nil
+	}
+	return fanOutError("n greater than 1 is not supported with stream; set stream to false or n to 1")
+}
+
+func fanOutError(message string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      errors.New(string(interfaces.OpenAIErrorBody(http.StatusBadRequest, message, "n", "invalid_value"))),
+	}
+}
+
+// executeFanOut sends n single-choice copies of a Chat Completions request in parallel and
+// merges them into one response whose choices are indexed 0..n-1 in request order. The
+// first failure cancels the remaining requests and is returned as-is. Each request runs on
+// its own copy of the gin context; response headers and request log entries are copied back
+// once all requests finish.
+func (h *BaseAPIHandler) executeFanOut(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, n int) ([]byte, *interfaces.ErrorMessage) {
+	if n > h.Cfg.NFanOutMax {
+		return nil, fanOutError(fmt.Sprintf("n must be at most %d", h.Cfg.NFanOutMax))
+	}
+	single, err := sjson.DeleteBytes(rawJSON, "n")
+	if err != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+
+	fanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	parent, _ := ctx.Value("gin").(*gin.Context)
+	legs := make([]*gin.Context, n)
+	responses := make([][]byte, n)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr *interfaces.ErrorMessage
+	)
+	for i := 0; i < n; i++ {
+		legCtx := fanCtx
+		if parent != nil {
+			legs[i] = newFanOutLeg(parent)
+			legCtx = context.WithValue(fanCtx, "gin", legs[i])
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, errMsg := h.ExecuteWithAuthManager(legCtx, handlerType, modelName, cloneBytes(single), alt)
+			if errMsg != nil {
+				errOnce.Do(func() {
+					firstErr = errMsg
+					cancel()
+				})
+				return
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+	for _, leg := range legs {
+		mergeFanOutLeg(parent, leg)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return mergeFanOutResponses(responses), nil
+}
+
+// fanOutLegWriter gives a fan-out request its own response header map. Legs never write a
+// body, so everything else is delegated to the client's writer.
+type fanOutLegWriter struct {
+	gin.ResponseWriter
+	header http.Header
+}
+
+func (w *fanOutLegWriter) Header() http.Header { return w.header }
+
+// fanOutLogKeys are the request log entries executors accumulate on the gin context.
+var fanOutLogKeys = []string{"API_UPSTREAM_ATTEMPTS", "API_REQUEST", "API_RESPONSE"}
+
+// newFanOutLeg copies the client's gin context for one fan-out request, with a private
+// header map and empty request log entries.
+func newFanOutLeg(parent *gin.Context) *gin.Context {
+	leg := parent.Copy()
+	leg.Writer = &fanOutLegWriter{ResponseWriter: parent.Writer, header: make(http.Header)}
+	for _, key := range fanOutLogKeys {
+		delete(leg.Keys, key)
+	}
+	return leg
+}
+
+// mergeFanOutLeg copies a finished leg's response headers onto the client context, keeping
+// the first leg's value when legs disagree, and appends its request log entries.
+func mergeFanOutLeg(parent, leg *gin.Context) {
+	if parent == nil || leg == nil {
+		return
+	}
+	for key, values := range leg.Writer.Header() {
+		if len(values) > 0 && parent.Writer.Header().Get(key) == "" {
+			parent.Header(key, values[0])
+		}
+	}
+	if request, ok := leg.Get("API_REQUEST"); ok {
+		if data, isBytes := request.([]byte); isBytes && len(data) > 0 {
+			if existing, exists := parent.Get("API_REQUEST"); exists {
+				if existingBytes, isExistingBytes := existing.([]byte); isExistingBytes && len(existingBytes) > 0 {
+					data = bytes.Join([][]byte{existingBytes, data}, []byte("\n"))
+				}
+			}
+			parent.Set("API_REQUEST", data)
+		}
+	}
+	if response, ok := leg.Get("API_RESPONSE"); ok {
+		if data, isBytes := response.([]byte); isBytes {
+			appendAPIResponse(parent, data)
+		}
+	}
+}
+
+// mergeFanOutResponses combines single-choice Chat Completions responses. The first
+// response supplies id, model and prompt usage; completion tokens are summed.
+func mergeFanOutResponses(responses [][]byte) []byte {
+	out := responses[0]
+	choices := make([]string, 0, len(responses))
+	var completionTokens int64
+	for _, resp := range responses {
+		for _, choice := range gjson.GetBytes(resp, "choices").Array() {
+			updated, err := sjson.Set(choice.Raw, "index", len(choices))
+			if err != nil {
+				updated = choice.Raw
+			}
+			choices = append(choices, updated)
+		}
+		completionTokens += gjson.GetBytes(resp, "usage.completion_tokens").Int()
+	}
+	if merged, err := sjson.SetRawBytes(out, "choices", []byte("["+strings.Join(choices, ",")+"]")); err == nil {
+		out = merged
+	}
+	if gjson.GetBytes(out, "usage").Exists() {
+		promptTokens := gjson.GetBytes(out, "usage.prompt_tokens").Int()
+		if updated, err := sjson.SetBytes(out, "usage.completion_tokens", completionTokens); err == nil {
+			out = updated
+		}
+		if updated, err := sjson.SetBytes(out, "usage.total_tokens", promptTokens+completionTokens); err == nil {
+			out = updated
+		}
+	}
+	return out
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// countingExecutor answers each call with a single-choice completion numbered by call order.
+// When barrier is set, every call waits for the others so legs finish concurrently.
+type countingExecutor struct {
+	mu       sync.Mutex
+	calls    int
+	payloads [][]byte
+	barrier  *sync.WaitGroup
+}
+
+func (e *countingExecutor) Identifier() string { return "codex" }
+
+func (e *countingExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls++
+	call := e.calls
+	e.payloads = append(e.payloads, req.Payload)
+	e.mu.Unlock()
+	if e.barrier != nil {
+		e.barrier.Done()
+		e.barrier.Wait()
+	}
+	// Log the upstream response on the request context the way executors do.
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok {
+		appendAPIResponse(ginCtx, []byte("upstream "+strconv.Itoa(call)))
+	}
+	body := `{"id":"chatcmpl-` + strconv.Itoa(call) + `","object":"chat.completion","model":"fanout-model",` +
+		`"choices":[{"index":0,"message":{"role":"assistant","content":"reply"},"finish_reason":"stop"}],` +
+		`"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}`
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *countingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *countingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *countingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *countingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func newFanOutHandler(t *testing.T, executor coreauth.ProviderExecutor, maxN int) *BaseAPIHandler {
+	t.Helper()
+	return newFanOutHandlerWithModel(t, executor, &sdkconfig.SDKConfig{NFanOutMax: maxN}, &registry.ModelInfo{ID: "fanout-model"})
+}
+
+func newFanOutHandlerWithModel(t *testing.T, executor coreauth.ProviderExecutor, cfg *sdkconfig.SDKConfig, model *registry.ModelInfo) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "fanout-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{model})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(cfg, manager)
+}
+
+func TestExecuteWithAuthManager_FanOutMergesChoices(t *testing.T) {
+	executor := &countingExecutor{}
+	h := newFanOutHandler(t, executor, 4)
+
+	resp, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "fanout-model", []byte(`{"model":"fanout-model","n":3,"messages":[{"role":"user","content":"hi"}]}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if executor.calls != 3 {
+		t.Fatalf("upstream calls = %d, want 3", executor.calls)
+	}
+	for _, payload := range executor.payloads {
+		if gjson.GetBytes(payload, "n").Exists() {
+			t.Fatalf("n forwarded upstream: %s", payload)
+		}
+	}
+	choices := gjson.GetBytes(resp, "choices").Array()
+	if len(choices) != 3 {
+		t.Fatalf("choices = %d, want 3: %s", len(choices), resp)
+	}
+	for i, choice := range choices {
+		if got := choice.Get("index").Int(); got != int64(i) {
+			t.Fatalf("choice %d has index %d", i, got)
+		}
+		if choice.Get("message.content").String() != "reply" {
+			t.Fatalf("choice %d lost its message: %s", i, choice.Raw)
+		}
+	}
+	if got := gjson.GetBytes(resp, "usage.prompt_tokens").Int(); got != 10 {
+		t.Fatalf("prompt_tokens = %d, want 10", got)
+	}
+	if got := gjson.GetBytes(resp, "usage.completion_tokens").Int(); got != 9 {
+		t.Fatalf("completion_tokens = %d, want 9", got)
+	}
+	if got := gjson.GetBytes(resp, "usage.total_tokens").Int(); got != 19 {
+		t.Fatalf("total_tokens = %d, want 19", got)
+	}
+}
+
+func TestExecuteWithAuthManager_FanOutLimits(t *testing.T) {
+	executor := &countingExecutor{}
+	h := newFanOutHandler(t, executor, 2)
+
+	_, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "fanout-model", []byte(`{"model":"fanout-model","n":3}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), `"param":"n"`) {
+		t.Fatalf("expected 400 for n above the limit, got %+v", errMsg)
+	}
+	if executor.calls != 0 {
+		t.Fatalf("upstream called %d times for a rejected request", executor.calls)
+	}
+
+	_, errs := h.ExecuteStreamWithAuthManager(context.Background(), "openai", "fanout-model", []byte(`{"model":"fanout-model","n":2,"stream":true}`), "")
+	errMsg = <-errs
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), "stream") {
+		t.Fatalf("expected 400 for streaming n > 1, got %+v", errMsg)
+	}
+
+	// With fan-out disabled n is forwarded untouched.
+	disabled := newFanOutHandler(t, executor, 0)
+	if _, errMsg = disabled.ExecuteWithAuthManager(context.Background(), "openai", "fanout-model", []byte(`{"model":"fanout-model","n":3}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if executor.calls != 1 || gjson.GetBytes(executor.payloads[0], "n").Int() != 3 {
+		t.Fatalf("expected one upstream call carrying n=3, got %d calls", executor.calls)
+	}
+}
+
+// TestExecuteWithAuthManager_FanOutWithGinContext runs fan-out on a real gin context, where
+// every leg writes response headers and a request log entry at the same time; run it with
+// -race.
+func TestExecuteWithAuthManager_FanOutWithGinContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useTestResponseCache(t)
+	barrier := &sync.WaitGroup{}
+	barrier.Add(4)
+	executor := &countingExecutor{barrier: barrier}
+	cfg := &sdkconfig.SDKConfig{NFanOutMax: 4, FallbackModel: "fanout-model", ResponseCache: sdkconfig.ResponseCacheConfig{Enabled: true}}
+	h := newFanOutHandlerWithModel(t, executor, cfg, &registry.ModelInfo{ID: "fanout-model"})
+
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	resp, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "missing-model", []byte(`{"model":"missing-model","n":4,"temperature":0,"messages":[{"role":"user","content":"fan-out race"}]}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := len(gjson.GetBytes(resp, "choices").Array()); got != 4 {
+		t.Fatalf("choices = %d, want 4", got)
+	}
+	if got := ginCtx.Writer.Header().Get(FallbackHeader); got != "missing-model" {
+		t.Fatalf("%s = %q, want missing-model", FallbackHeader, got)
+	}
+	if got := ginCtx.Writer.Header().Get(CacheHeader); got != "MISS" {
+		t.Fatalf("%s = %q, want MISS", CacheHeader, got)
+	}
+	logged, _ := ginCtx.Get("API_RESPONSE")
+	for call := 1; call <= 4; call++ {
+		if !strings.Contains(string(logged.([]byte)), "upstream "+strconv.Itoa(call)) {
+			t.Fatalf("request log misses leg %d: %s", call, logged)
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_FanOutSkipsModelsSupportingN(t *testing.T) {
+	executor := &countingExecutor{}
+	h := newFanOutHandlerWithModel(t, executor, &sdkconfig.SDKConfig{NFanOutMax: 4}, &registry.ModelInfo{ID: "fanout-model", SupportedParameters: []string{"n", "temperature"}})
+
+	if _, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "fanout-model", []byte(`{"model":"fanout-model","n":3}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if executor.calls != 1 || gjson.GetBytes(executor.payloads[0], "n").Int() != 3 {
+		t.Fatalf("expected one upstream call carrying n=3, got %d calls", executor.calls)
+	}
+
+	streamExecutor := &countingExecutor{}
+	streaming := newFanOutHandlerWithModel(t, streamExecutor, &sdkconfig.SDKConfig{NFanOutMax: 4}, &registry.ModelInfo{ID: "fanout-model", SupportedParameters: []string{"n"}})
+	_, errs := streaming.ExecuteStreamWithAuthManager(context.Background(), "openai", "fanout-model", []byte(`{"model":"fanout-model","n":2,"stream":true}`), "")
+	if errMsg := <-errs; errMsg != nil && strings.Contains(errMsg.Error.Error(), `"param":"n"`) {
+		t.Fatalf("streaming n > 1 rejected for a model that supports n: %+v", errMsg)
+	}
+}
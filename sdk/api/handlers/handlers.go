@@ -6,8 +6,11 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,6 +24,9 @@ import (
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	"golang.org/x/net/context"
 )
 
@@ -46,6 +52,12 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// FallbackHeader reports the originally requested model when FallbackModel was substituted.
+const FallbackHeader = "X-CLIProxy-Fallback"
+
+// ModelOverrideHeader lets a client route an unchanged body to a different model.
+const ModelOverrideHeader = "X-CLIProxy-Model"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -66,39 +78,27 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 		return []byte(trimmed)
 	}
 
-	errType := "invalid_request_error"
 	var code string
 	switch status {
 	case http.StatusUnauthorized:
-		errType = "authentication_error"
 		code = "invalid_api_key"
 	case http.StatusForbidden:
-		errType = "permission_error"
 		code = "insufficient_quota"
 	case http.StatusTooManyRequests:
-		errType = "rate_limit_error"
 		code = "rate_limit_exceeded"
 	case http.StatusNotFound:
-		errType = "invalid_request_error"
 		code = "model_not_found"
 	default:
 		if status >= http.StatusInternalServerError {
-			errType = "server_error"
 			code = "internal_server_error"
 		}
 	}
+	return interfaces.OpenAIErrorBody(status, errText, "", code)
+}
 
-	payload, err := json.Marshal(ErrorResponse{
-		Error: ErrorDetail{
-			Message: errText,
-			Type:    errType,
-			Code:    code,
-		},
-	})
-	if err != nil {
-		return []byte(fmt.Sprintf(`{"error":{"message":%q,"type":"server_error","code":"internal_server_error"}}`, errText))
-	}
-	return payload
+// WriteOpenAIError writes an OpenAI error envelope for a request rejected by the proxy itself.
+func WriteOpenAIError(c *gin.Context, status int, message, param, code string) {
+	c.Data(status, "application/json", interfaces.OpenAIErrorBody(status, message, param, code))
 }
 
 // StreamingKeepAliveInterval returns the SSE keep-alive interval for this server.
@@ -114,6 +114,15 @@ func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// StreamingPingInterval returns the interval of SSE ping comments sent before the first
+// upstream chunk. Returning 0 disables pings (default when unset).
+func StreamingPingInterval(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || cfg.Streaming.PingSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.PingSeconds) * time.Second
+}
+
 // StreamingBootstrapRetries returns how many times a streaming request may be retried before any bytes are sent.
 func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	retries := defaultStreamingBootstrapRetries
@@ -320,10 +329,33 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	if n, ok := h.fanOutCount(handlerType, rawJSON); ok && !h.upstreamSupportsN(ctx, modelName) {
+		return h.executeFanOut(ctx, handlerType, modelName, rawJSON, alt, n)
+	}
+	modelName, rawJSON = applyModelOverride(ctx, modelName, rawJSON)
+	rawJSON = h.applySystemPromptPrefix(handlerType, rawJSON)
+	providers, normalizedModel, metadata, rawJSON, errMsg := h.requestDetailsWithFallback(ctx, modelName, rawJSON)
+	if errMsg == nil {
+		errMsg = h.checkModelRateLimit(normalizedModel)
+	}
+	if errMsg == nil {
+		rawJSON = h.applyModelDefaults(normalizedModel, rawJSON)
+		rawJSON = h.applyMaxTokensClamp(ctx, normalizedModel, rawJSON)
+	}
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	cacheKey, cacheable := h.responseCacheKey(ctx, handlerType, alt, normalizedModel, rawJSON)
+	if cacheable {
+		// Hits make no upstream call, so no usage is recorded for them.
+		if body, ok := sharedResponseCache.get(cacheKey); ok {
+			setCacheHeader(ctx, "HIT")
+			if h.stripReasoningForRoute(ctx) {
+				return stripReasoningFromResponse(handlerType, cloneBytes(body)), nil
+			}
+			return cloneBytes(body), nil
+		}
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -341,6 +373,9 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	if fbProviders, fbReq, fbOpts, ok := h.contextFallback(ctx, normalizedModel, err, req, opts); ok {
+		resp, err = h.AuthManager.Execute(ctx, fbProviders, fbReq, fbOpts)
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -356,13 +391,26 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	if cacheable {
+		ttl, maxEntries := h.responseCacheLimits()
+		sharedResponseCache.put(cacheKey, cloneBytes(resp.Payload), ttl, maxEntries)
+		setCacheHeader(ctx, "MISS")
+	}
+	if h.stripReasoningForRoute(ctx) {
+		return stripReasoningFromResponse(handlerType, cloneBytes(resp.Payload)), nil
+	}
 	return cloneBytes(resp.Payload), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	modelName, rawJSON = applyModelOverride(ctx, modelName, rawJSON)
+	rawJSON = h.applySystemPromptPrefix(handlerType, rawJSON)
+	providers, normalizedModel, metadata, rawJSON, errMsg := h.requestDetailsWithFallback(ctx, modelName, rawJSON)
+	if errMsg == nil {
+		errMsg = h.checkModelRateLimit(normalizedModel)
+	}
 	if errMsg != nil {
 		return nil, errMsg
 	}
@@ -404,7 +452,19 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	modelName, rawJSON = applyModelOverride(ctx, modelName, rawJSON)
+	rawJSON = h.applySystemPromptPrefix(handlerType, rawJSON)
+	providers, normalizedModel, metadata, rawJSON, errMsg := h.requestDetailsWithFallback(ctx, modelName, rawJSON)
+	if errMsg == nil {
+		errMsg = h.fanOutStreamError(handlerType, normalizedModel, rawJSON)
+	}
+	if errMsg == nil {
+		errMsg = h.checkModelRateLimit(normalizedModel)
+	}
+	if errMsg == nil {
+		rawJSON = h.applyModelDefaults(normalizedModel, rawJSON)
+		rawJSON = h.applyMaxTokensClamp(ctx, normalizedModel, rawJSON)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -428,6 +488,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	if fbProviders, fbReq, fbOpts, ok := h.contextFallback(ctx, normalizedModel, err, req, opts); ok {
+		providers, req, opts = fbProviders, fbReq, fbOpts
+		chunks, err = h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	}
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -446,6 +510,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, errChan
 	}
+	stripReasoning := h.stripReasoningForRoute(ctx)
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
@@ -471,6 +536,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 
 	outer:
 		for {
+			var stripper *reasoningStreamStripper
+			if stripReasoning {
+				stripper = newReasoningStreamStripper(handlerType)
+			}
 			for {
 				var chunk coreexecutor.StreamChunk
 				var ok bool
@@ -518,8 +587,14 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					return
 				}
 				if len(chunk.Payload) > 0 {
+					payload := cloneBytes(chunk.Payload)
+					if stripper != nil {
+						if payload = stripper.strip(payload); len(payload) == 0 {
+							continue
+						}
+					}
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					dataChan <- payload
 				}
 			}
 		}
@@ -539,9 +614,161 @@ func statusFromError(err error) int {
 	return 0
 }
 
+// resolveModelAlias returns the target of a configured ModelAliases entry, or the
+// model name unchanged when it is not an alias.
+func (h *BaseAPIHandler) resolveModelAlias(modelName string) string {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelAliases) == 0 {
+		return modelName
+	}
+	if target, ok := h.Cfg.ModelAliases[strings.ToLower(strings.TrimSpace(modelName))]; ok && target != "" {
+		return target
+	}
+	return modelName
+}
+
+// WithModelAliases appends a listing entry for every configured alias whose target is
+// present in models. Alias entries copy the target's metadata with the alias as ID;
+// aliases that collide with an existing model ID are skipped.
+func (h *BaseAPIHandler) WithModelAliases(models []map[string]any) []map[string]any {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelAliases) == 0 || len(models) == 0 {
+		return models
+	}
+	byID := make(map[string]map[string]any, len(models))
+	for _, model := range models {
+		if id, ok := model["id"].(string); ok {
+			byID[strings.ToLower(id)] = model
+		}
+	}
+	aliases := make([]string, 0, len(h.Cfg.ModelAliases))
+	for alias := range h.Cfg.ModelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	out := models
+	for _, alias := range aliases {
+		if _, exists := byID[strings.ToLower(alias)]; exists {
+			continue
+		}
+		target, ok := byID[strings.ToLower(h.Cfg.ModelAliases[alias])]
+		if !ok {
+			continue
+		}
+		entry := make(map[string]any, len(target))
+		for key, value := range target {
+			entry[key] = value
+		}
+		entry["id"] = alias
+		out = append(out, entry)
+	}
+	return out
+}
+
+// IsModelServed reports whether model passes the ServedModels allowlist. The "copilot-"
+// routing prefix is ignored so aliased and bare IDs match the same entries.
+func (h *BaseAPIHandler) IsModelServed(model string) bool {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ServedModels) == 0 {
+		return true
+	}
+	id := strings.ToLower(strings.TrimSpace(model))
+	id = strings.TrimPrefix(id, "models/")
+	id = strings.TrimPrefix(id, registry.CopilotModelPrefix)
+	for _, pattern := range h.Cfg.ServedModels {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if matched, err := path.Match(pattern, id); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterServedModels drops listing entries whose ID (or Gemini-style name) is not served.
+func (h *BaseAPIHandler) FilterServedModels(models []map[string]any) []map[string]any {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ServedModels) == 0 {
+		return models
+	}
+	filtered := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		id, _ := model["id"].(string)
+		if id == "" {
+			id, _ = model["name"].(string)
+		}
+		if h.IsModelServed(id) {
+			filtered = append(filtered, model)
+		}
+	}
+	return filtered
+}
+
+// LookupModel resolves a client-facing model ID to its registry entry. Configured aliases
+// are followed and the "copilot-" routing prefix is dropped when only the bare ID is
+// registered. Models outside the ServedModels allowlist are reported as unknown.
+func (h *BaseAPIHandler) LookupModel(id string) *registry.ModelInfo {
+	id = strings.TrimSpace(id)
+	if id == "" || !h.IsModelServed(id) {
+		return nil
+	}
+	target := h.resolveModelAlias(id)
+	if target != id && !h.IsModelServed(target) {
+		return nil
+	}
+	modelRegistry := registry.GetGlobalRegistry()
+	if info := modelRegistry.GetModelInfo(target); info != nil {
+		return info
+	}
+	if len(target) > len(registry.CopilotModelPrefix) && strings.EqualFold(target[:len(registry.CopilotModelPrefix)], registry.CopilotModelPrefix) {
+		return modelRegistry.GetModelInfo(target[len(registry.CopilotModelPrefix):])
+	}
+	return nil
+}
+
+// requestDetailsWithFallback resolves routing for modelName and, when the model is not
+// registered, retries with the configured FallbackModel. Alias targets and a successful
+// fallback substitution are written to the payload model; the latter also sets the
+// X-CLIProxy-Fallback response header.
+func (h *BaseAPIHandler) requestDetailsWithFallback(ctx context.Context, modelName string, rawJSON []byte) ([]string, string, map[string]any, []byte, *interfaces.ErrorMessage) {
+	if target := h.resolveModelAlias(modelName); target != modelName {
+		rawJSON = setPayloadModel(rawJSON, target)
+	}
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	// Unregistered models are the only 400 getRequestDetails produces.
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || h.Cfg == nil {
+		return providers, normalizedModel, metadata, rawJSON, errMsg
+	}
+	fallback := strings.TrimSpace(h.Cfg.FallbackModel)
+	if fallback == "" || strings.EqualFold(fallback, modelName) {
+		return providers, normalizedModel, metadata, rawJSON, errMsg
+	}
+	fbProviders, fbModel, fbMetadata, fbErr := h.getRequestDetails(fallback)
+	if fbErr != nil {
+		log.Warnf("fallback model %s is not available for unknown model %s", fallback, modelName)
+		return providers, normalizedModel, metadata, rawJSON, errMsg
+	}
+
+	log.Warnf("model %s is not registered, falling back to %s", modelName, fallback)
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(FallbackHeader, modelName)
+	}
+	return fbProviders, fbModel, fbMetadata, setPayloadModel(rawJSON, fallback), nil
+}
+
+// setPayloadModel rewrites the payload model field when the body carries one, so
+// executors that read the model from the payload see the routed name.
+func setPayloadModel(rawJSON []byte, model string) []byte {
+	if gjson.GetBytes(rawJSON, "model").Exists() {
+		if updated, err := sjson.SetBytes(rawJSON, "model", model); err == nil {
+			return updated
+		}
+	}
+	return rawJSON
+}
+
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
-	// Resolve "auto" model to an actual available model first
-	resolvedModelName := util.ResolveAutoModel(modelName)
+	// Rewrite configured client-facing aliases, then resolve "auto" to an actual available model.
+	resolvedModelName := util.ResolveAutoModel(h.resolveModelAlias(modelName))
 
 	// Normalize the model name to handle dynamic thinking suffixes before determining the provider.
 	normalizedModel, metadata = normalizeModelMetadata(resolvedModelName)
@@ -560,6 +787,11 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 		metadata["forced_provider"] = true
 	}
 
+	if !h.IsModelServed(normalizedModel) {
+		body := interfaces.OpenAIErrorBody(http.StatusNotFound, fmt.Sprintf("The model `%s` does not exist or you do not have access to it.", modelName), "model", "model_not_found")
+		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: errors.New(string(body))}
+	}
+
 	// Use the normalizedModel to get the provider name.
 	providers = util.GetProviderName(normalizedModel)
 	if forcedCopilot {
@@ -580,7 +812,8 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	}
 
 	if len(providers) == 0 {
-		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown provider for model %s", modelName)}
+		body := interfaces.OpenAIErrorBody(http.StatusBadRequest, fmt.Sprintf("unknown provider for model %s", modelName), "model", "model_not_found")
+		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(string(body))}
 	}
 
 	// If it's a dynamic model, the normalizedModel was already set to extractedModelName.
@@ -603,6 +836,22 @@ func normalizeModelMetadata(modelName string) (string, map[string]any) {
 	return util.NormalizeThinkingModel(modelName)
 }
 
+// applyModelOverride replaces the requested model with the X-CLIProxy-Model header value,
+// rewriting the payload model field when the body carries one.
+func applyModelOverride(ctx context.Context, modelName string, rawJSON []byte) (string, []byte) {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return modelName, rawJSON
+	}
+	override := strings.TrimSpace(ginCtx.GetHeader(ModelOverrideHeader))
+	if override == "" || override == modelName {
+		return modelName, rawJSON
+	}
+	rawJSON = setPayloadModel(rawJSON, override)
+	log.Debugf("model overridden by %s header: %s -> %s", ModelOverrideHeader, modelName, override)
+	return override, rawJSON
+}
+
 func cloneRequestHeaders(ctx context.Context) http.Header {
 	ginCtx, ok := ctx.Value("gin").(*gin.Context)
 	if !ok || ginCtx == nil || ginCtx.Request == nil || ginCtx.Request.Header == nil {
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newFallbackTestHandler(t *testing.T, fallback string) (*BaseAPIHandler, *captureExecutor) {
+	t.Helper()
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "fallback-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "fallback-target"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{FallbackModel: fallback}, manager), executor
+}
+
+func TestExecuteWithAuthManager_UnknownModelUsesFallback(t *testing.T) {
+	handler, executor := newFallbackTestHandler(t, "fallback-target")
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "renamed-model", []byte(`{"model":"renamed-model"}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if executor.req.Model != "fallback-target" {
+		t.Fatalf("executor model = %q, want fallback-target", executor.req.Model)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "model").String(); got != "fallback-target" {
+		t.Fatalf("payload model = %q, want fallback-target", got)
+	}
+	if got := ginCtx.Writer.Header().Get(FallbackHeader); got != "renamed-model" {
+		t.Fatalf("%s = %q, want renamed-model", FallbackHeader, got)
+	}
+}
+
+func TestExecuteWithAuthManager_UnknownModelWithoutFallback(t *testing.T) {
+	for name, fallback := range map[string]string{"unset": "", "unregistered fallback": "also-missing"} {
+		t.Run(name, func(t *testing.T) {
+			handler, _ := newFallbackTestHandler(t, fallback)
+			_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "renamed-model", []byte(`{"model":"renamed-model"}`), "")
+			if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected 400 for an unknown model, got %+v", errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestGetRequestDetails_ModelAliasRoutesToTarget(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("model-alias-codex", "codex", []*registry.ModelInfo{{ID: "alias-target-model"}})
+	defer reg.UnregisterClient("model-alias-codex")
+
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{ModelAliases: map[string]string{"fast": "alias-target-model"}}}
+
+	providers, normalizedModel, _, err := handler.getRequestDetails("FAST")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if normalizedModel != "alias-target-model" {
+		t.Fatalf("normalized model = %q, want alias-target-model", normalizedModel)
+	}
+	if len(providers) != 1 || providers[0] != "codex" {
+		t.Fatalf("providers = %v, want [codex]", providers)
+	}
+
+	if _, _, _, err = handler.getRequestDetails("unaliased-unknown-model"); err == nil {
+		t.Fatal("expected non-alias unknown model to remain unresolved")
+	}
+}
+
+func TestExecuteWithAuthManager_ModelAliasRewritesPayload(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "model-alias-payload", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "alias-payload-target"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	handler := NewBaseAPIHandlers(&config.SDKConfig{ModelAliases: map[string]string{"fast": "alias-payload-target"}}, manager)
+
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "fast", []byte(`{"model":"fast"}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if executor.req.Model != "alias-payload-target" {
+		t.Fatalf("executor model = %q, want alias-payload-target", executor.req.Model)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "model").String(); got != "alias-payload-target" {
+		t.Fatalf("payload model = %q, want alias-payload-target", got)
+	}
+}
+
+func TestWithModelAliases_AppendsAliasEntries(t *testing.T) {
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{ModelAliases: map[string]string{
+		"smart":   "gpt-5.1",
+		"missing": "not-listed",
+		"gpt-5.1": "gpt-5",
+	}}}
+	models := []map[string]any{
+		{"id": "gpt-5.1", "owned_by": "openai", "context_length": 400000},
+		{"id": "gpt-5", "owned_by": "openai"},
+	}
+
+	got := handler.WithModelAliases(models)
+	if len(got) != 3 {
+		t.Fatalf("expected one alias entry to be appended, got %v", got)
+	}
+	alias := got[2]
+	if alias["id"] != "smart" || alias["owned_by"] != "openai" || alias["context_length"] != 400000 {
+		t.Fatalf("unexpected alias entry: %v", alias)
+	}
+	if models[0]["id"] != "gpt-5.1" {
+		t.Fatalf("target entry was modified: %v", models[0])
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type captureExecutor struct {
+	req  coreexecutor.Request
+	opts coreexecutor.Options
+}
+
+func (e *captureExecutor) Identifier() string { return "copilot" }
+
+func (e *captureExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.req, e.opts = req, opts
+	return coreexecutor.Response{Payload: []byte(`{}`)}, nil
+}
+
+func (e *captureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *captureExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *captureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *captureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func TestExecuteWithAuthManager_ModelOverrideHeaderWins(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "override-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "body-model"}, {ID: "header-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set(ModelOverrideHeader, "header-model")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "body-model", []byte(`{"model":"body-model","messages":[]}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+
+	if executor.req.Model != "header-model" {
+		t.Fatalf("request model = %q, want header-model", executor.req.Model)
+	}
+	// The Copilot executor picks its header profile from the payload model, so the body must
+	// carry the override too.
+	if got := gjson.GetBytes(executor.req.Payload, "model").String(); got != "header-model" {
+		t.Fatalf("payload model = %q, want header-model", got)
+	}
+	if got := gjson.GetBytes(executor.opts.OriginalRequest, "model").String(); got != "header-model" {
+		t.Fatalf("original request model = %q, want header-model", got)
+	}
+}
+
+func TestApplyModelOverride_NoHeader(t *testing.T) {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	model, body := applyModelOverride(ctx, "body-model", []byte(`{"model":"body-model"}`))
+	if model != "body-model" || gjson.GetBytes(body, "model").String() != "body-model" {
+		t.Fatalf("model changed without header: %q %s", model, body)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func assertOpenAIErrorEnvelope(t *testing.T, body []byte, wantType, wantParam, wantCode string) {
+	t.Helper()
+	errObj := gjson.GetBytes(body, "error")
+	for _, key := range []string{"message", "type", "param", "code"} {
+		if !errObj.Get(key).Exists() {
+			t.Fatalf("error.%s missing from %s", key, body)
+		}
+	}
+	if errObj.Get("message").String() == "" {
+		t.Fatalf("error.message empty in %s", body)
+	}
+	if got := errObj.Get("type").String(); got != wantType {
+		t.Fatalf("error.type = %q, want %q", got, wantType)
+	}
+	if got := errObj.Get("param").String(); got != wantParam {
+		t.Fatalf("error.param = %q, want %q", got, wantParam)
+	}
+	if got := errObj.Get("code").String(); got != wantCode {
+		t.Fatalf("error.code = %q, want %q", got, wantCode)
+	}
+}
+
+func TestWriteErrorResponse_UnknownModelUsesOpenAIEnvelope(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "no-such-model", []byte(`{"model":"no-such-model"}`), "")
+	if errMsg == nil {
+		t.Fatal("expected an error for an unknown model")
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	handler.WriteErrorResponse(c, errMsg)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+	assertOpenAIErrorEnvelope(t, recorder.Body.Bytes(), "invalid_request_error", "model", "model_not_found")
+}
+
+func TestWriteOpenAIError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	WriteOpenAIError(c, http.StatusBadRequest, "Invalid request: bad json", "", "")
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", got)
+	}
+	assertOpenAIErrorEnvelope(t, recorder.Body.Bytes(), "invalid_request_error", "", "")
+	if gjson.GetBytes(recorder.Body.Bytes(), "error.param").Type != gjson.Null {
+		t.Fatalf("unset param must be null: %s", recorder.Body.String())
+	}
+}
+
+func TestBuildErrorResponseBody_RateLimit(t *testing.T) {
+	body := BuildErrorResponseBody(http.StatusTooManyRequests, "slow down")
+	assertOpenAIErrorEnvelope(t, body, "rate_limit_error", "", "rate_limit_exceeded")
+}
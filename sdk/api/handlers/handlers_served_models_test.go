@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestFilterServedModels(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ServedModels: []string{"gpt-5*", "Claude-Sonnet-4"}}, nil)
+	models := []map[string]any{
+		{"id": "gpt-5"},
+		{"id": "copilot-gpt-5.1"},
+		{"id": "claude-sonnet-4"},
+		{"id": "gemini-2.5-pro"},
+		{"name": "models/gpt-5-mini"},
+	}
+
+	got := handler.FilterServedModels(models)
+	ids := make([]string, 0, len(got))
+	for _, model := range got {
+		id, _ := model["id"].(string)
+		if id == "" {
+			id, _ = model["name"].(string)
+		}
+		ids = append(ids, id)
+	}
+	want := []string{"gpt-5", "copilot-gpt-5.1", "claude-sonnet-4", "models/gpt-5-mini"}
+	if len(ids) != len(want) {
+		t.Fatalf("served models = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("served models = %v, want %v", ids, want)
+		}
+	}
+
+	if unrestricted := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil).FilterServedModels(models); len(unrestricted) != len(models) {
+		t.Fatalf("empty allowlist must serve everything, got %d of %d", len(unrestricted), len(models))
+	}
+}
+
+func TestExecuteWithAuthManager_ServedModels(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "served-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "gpt-5"}, {ID: "gpt-4.1"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ServedModels: []string{"gpt-5"}}, manager)
+
+	for _, model := range []string{"gpt-5", "copilot-gpt-5"} {
+		if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", model, []byte(`{"model":"`+model+`"}`), ""); errMsg != nil {
+			t.Fatalf("%s: unexpected error: %v", model, errMsg.Error)
+		}
+		if executor.req.Model != "gpt-5" {
+			t.Fatalf("%s: executor model = %q, want gpt-5", model, executor.req.Model)
+		}
+	}
+
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "gpt-4.1", []byte(`{"model":"gpt-4.1"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a non-served model, got %+v", errMsg)
+	}
+	body := BuildErrorResponseBody(errMsg.StatusCode, errMsg.Error.Error())
+	if got := gjson.GetBytes(body, "error.code").String(); got != "model_not_found" {
+		t.Fatalf("error.code = %q, want model_not_found: %s", got, body)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ClampedHeader is set on responses whose request fields were lowered to fit the model.
+const ClampedHeader = "X-CLIProxy-Clamped"
+
+// maxTokensFields are the output-limit fields used by the Chat Completions, Anthropic and
+// Responses request formats.
+var maxTokensFields = []string{"max_tokens", "max_completion_tokens", "max_output_tokens"}
+
+// applyMaxTokensClamp caps the request's output-token limit at the model's registered
+// MaxCompletionTokens when ClampMaxTokens is enabled. Models without a known limit are
+// left untouched.
+func (h *BaseAPIHandler) applyMaxTokensClamp(ctx context.Context, model string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.ClampMaxTokens {
+		return rawJSON
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(model)
+	if info == nil || info.MaxCompletionTokens <= 0 {
+		return rawJSON
+	}
+	limit := int64(info.MaxCompletionTokens)
+	clamped := false
+	for _, field := range maxTokensFields {
+		value := gjson.GetBytes(rawJSON, field)
+		if value.Type != gjson.Number || value.Int() <= limit {
+			continue
+		}
+		updated, err := sjson.SetBytes(rawJSON, field, limit)
+		if err != nil {
+			continue
+		}
+		log.Infof("clamped %s from %d to %d for model %s", field, value.Int(), limit, model)
+		rawJSON = updated
+		clamped = true
+	}
+	if clamped {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Header(ClampedHeader, "max_tokens")
+		}
+	}
+	return rawJSON
+}
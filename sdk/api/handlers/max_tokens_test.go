@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestExecuteWithAuthManager_ClampMaxTokens(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "clamp-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{
+		{ID: "clamp-limited", MaxCompletionTokens: 1000},
+		{ID: "clamp-unknown"},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ClampMaxTokens: true}, manager)
+
+	for _, field := range maxTokensFields {
+		tests := []struct {
+			name        string
+			model       string
+			value       int64
+			want        int64
+			wantClamped bool
+		}{
+			{name: "over limit", model: "clamp-limited", value: 5000, want: 1000, wantClamped: true},
+			{name: "under limit", model: "clamp-limited", value: 500, want: 500},
+			{name: "unknown limit", model: "clamp-unknown", value: 5000, want: 5000},
+		}
+		for _, tt := range tests {
+			t.Run(field+"/"+tt.name, func(t *testing.T) {
+				recorder := httptest.NewRecorder()
+				ginCtx, _ := gin.CreateTestContext(recorder)
+				ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+				ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+				body := []byte(`{"model":"` + tt.model + `","messages":[],"` + field + `":` + strconv.FormatInt(tt.value, 10) + `}`)
+				if _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", tt.model, body, ""); errMsg != nil {
+					t.Fatalf("unexpected error: %+v", errMsg)
+				}
+				if got := gjson.GetBytes(executor.req.Payload, field).Int(); got != tt.want {
+					t.Fatalf("%s = %d, want %d", field, got, tt.want)
+				}
+				if got := gjson.GetBytes(executor.opts.OriginalRequest, field).Int(); got != tt.want {
+					t.Fatalf("original request %s = %d, want %d", field, got, tt.want)
+				}
+				header := ginCtx.Writer.Header().Get(ClampedHeader)
+				if tt.wantClamped && header != "max_tokens" {
+					t.Fatalf("%s = %q, want max_tokens", ClampedHeader, header)
+				}
+				if !tt.wantClamped && header != "" {
+					t.Fatalf("%s set without clamping: %q", ClampedHeader, header)
+				}
+			})
+		}
+	}
+
+	disabled := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	if _, errMsg := disabled.ExecuteWithAuthManager(context.Background(), "openai", "clamp-limited", []byte(`{"model":"clamp-limited","max_tokens":5000}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "max_tokens").Int(); got != 5000 {
+		t.Fatalf("max_tokens clamped while clamp-max-tokens is off: %d", got)
+	}
+}
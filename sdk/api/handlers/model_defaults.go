@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyModelDefaults writes the ModelDefaults configured for model into fields the client
+// left unset. model is the ID after alias resolution, matched case-insensitively; fields the
+// client sent always win.
+func (h *BaseAPIHandler) applyModelDefaults(model string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelDefaults) == 0 {
+		return rawJSON
+	}
+	model = strings.TrimSpace(model)
+	var defaults map[string]any
+	for id, params := range h.Cfg.ModelDefaults {
+		if strings.EqualFold(strings.TrimSpace(id), model) {
+			defaults = params
+			break
+		}
+	}
+	if len(defaults) == 0 {
+		return rawJSON
+	}
+	// Sort paths so nested defaults are written in a stable order.
+	paths := make([]string, 0, len(defaults))
+	for path := range defaults {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "" || gjson.GetBytes(rawJSON, path).Exists() {
+			continue
+		}
+		updated, err := sjson.SetBytes(rawJSON, path, defaults[path])
+		if err != nil {
+			log.Debugf("model defaults: skip %s for %s: %v", path, model, err)
+			continue
+		}
+		rawJSON = updated
+	}
+	return rawJSON
+}
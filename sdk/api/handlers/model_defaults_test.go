@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestExecuteWithAuthManager_ModelDefaults(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "defaults-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "defaults-coder"}, {ID: "defaults-other"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ModelAliases: map[string]string{"coder": "defaults-coder"},
+		ModelDefaults: map[string]map[string]any{
+			"Defaults-Coder": {"temperature": 0.2, "reasoning.effort": "low"},
+		},
+	}, manager)
+
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "defaults-coder", []byte(`{"model":"defaults-coder","messages":[]}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "temperature").Float(); got != 0.2 {
+		t.Fatalf("temperature = %v, want default 0.2", got)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "reasoning.effort").String(); got != "low" {
+		t.Fatalf("reasoning.effort = %q, want default low", got)
+	}
+
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "defaults-coder", []byte(`{"model":"defaults-coder","messages":[],"temperature":1}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "temperature").Float(); got != 1 {
+		t.Fatalf("client temperature overridden: %v", got)
+	}
+
+	// Defaults follow the alias target.
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "coder", []byte(`{"model":"coder","messages":[]}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := gjson.GetBytes(executor.req.Payload, "temperature").Float(); got != 0.2 {
+		t.Fatalf("temperature via alias = %v, want default 0.2", got)
+	}
+
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "defaults-other", []byte(`{"model":"defaults-other","messages":[]}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if gjson.GetBytes(executor.req.Payload, "temperature").Exists() {
+		t.Fatalf("defaults applied to another model: %s", executor.req.Payload)
+	}
+}
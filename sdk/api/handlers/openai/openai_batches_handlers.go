@@ -0,0 +1,576 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	batchEndpoint = "/v1/chat/completions"
+
+	batchStatusInProgress = "in_progress"
+	batchStatusCompleted  = "completed"
+
+	// defaultBatchConcurrency bounds how many batch requests execute at once across all batches.
+	defaultBatchConcurrency = 4
+	// defaultBatchMaxItems bounds the number of requests in one batch.
+	defaultBatchMaxItems = 1000
+	// defaultBatchRetention is how long completed batches are kept.
+	defaultBatchRetention = 24 * time.Hour
+	// maxBatchLineSize bounds a single JSONL input line.
+	maxBatchLineSize = 8 << 20
+)
+
+// batchRequestCounts mirrors the OpenAI batch request_counts object.
+type batchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// batchResponse is the per-request response recorded in the batch output.
+type batchResponse struct {
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// batchItem is one input line and, once processed, its outcome. Outcomes are persisted
+// separately as batchResult lines, so they are not part of the item's JSON.
+type batchItem struct {
+	ID       string          `json:"id"`
+	CustomID string          `json:"custom_id"`
+	Body     json.RawMessage `json:"body"`
+	Done     bool            `json:"-"`
+	Response *batchResponse  `json:"-"`
+}
+
+// batchResult is one line of <dir>/<id>.results.jsonl, appended as each request finishes.
+type batchResult struct {
+	ID       string         `json:"id"`
+	Response *batchResponse `json:"response"`
+}
+
+// batchRecord is the persisted state of a batch. It is written to <dir>/<id>.json when the
+// batch is created and when it completes; per-request results are appended to
+// <dir>/<id>.results.jsonl so processing resumes after a restart.
+type batchRecord struct {
+	ID            string             `json:"id"`
+	Owner         string             `json:"owner,omitempty"`
+	Status        string             `json:"status"`
+	CreatedAt     int64              `json:"created_at"`
+	InProgressAt  int64              `json:"in_progress_at,omitempty"`
+	CompletedAt   int64              `json:"completed_at,omitempty"`
+	RequestCounts batchRequestCounts `json:"request_counts"`
+	Items         []*batchItem       `json:"items"`
+}
+
+// object renders the OpenAI batch object for the record.
+func (r *batchRecord) object() gin.H {
+	out := gin.H{
+		"id":             r.ID,
+		"object":         "batch",
+		"endpoint":       batchEndpoint,
+		"status":         r.Status,
+		"created_at":     r.CreatedAt,
+		"request_counts": r.RequestCounts,
+	}
+	if r.InProgressAt != 0 {
+		out["in_progress_at"] = r.InProgressAt
+	}
+	if r.CompletedAt != 0 {
+		out["completed_at"] = r.CompletedAt
+	}
+	return out
+}
+
+// OpenAIBatchesAPIHandler implements a minimal /v1/batches API. Chat Completions requests
+// submitted as JSONL run through the regular execution path with bounded concurrency, and
+// batch state is persisted on disk so unfinished batches resume after a restart. A batch is
+// only visible to the client that created it, and completed batches are deleted after the
+// retention period.
+type OpenAIBatchesAPIHandler struct {
+	*handlers.BaseAPIHandler
+
+	dir       string
+	sem       chan struct{}
+	maxItems  int
+	retention time.Duration
+
+	mu      sync.Mutex
+	batches map[string]*batchRecord
+	// persistMu serializes writes of batch files.
+	persistMu sync.Mutex
+}
+
+// NewOpenAIBatchesAPIHandler creates a batches handler that stores state under dir, with
+// concurrency, batch size and retention taken from cfg. Batches left unfinished in dir are
+// resumed.
+func NewOpenAIBatchesAPIHandler(apiHandlers *handlers.BaseAPIHandler, dir string, cfg sdkconfig.BatchesConfig) *OpenAIBatchesAPIHandler {
+	concurrency := cfg.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	maxItems := cfg.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	retention := time.Duration(cfg.RetentionHours) * time.Hour
+	if retention <= 0 {
+		retention = defaultBatchRetention
+	}
+	h := &OpenAIBatchesAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		dir:            dir,
+		sem:            make(chan struct{}, concurrency),
+		maxItems:       maxItems,
+		retention:      retention,
+		batches:        make(map[string]*batchRecord),
+	}
+	h.loadBatches()
+	return h
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIBatchesAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// CreateBatch handles POST /v1/batches. The body is JSONL where each line is an OpenAI batch
+// input object with custom_id, method, url, and body; url must be /v1/chat/completions.
+func (h *OpenAIBatchesAPIHandler) CreateBatch(c *gin.Context) {
+	raw, err := c.GetRawData()
+	if err != nil {
+		handlers.WriteOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err), "", "invalid_request_error")
+		return
+	}
+	items, errMsg := parseBatchInput(raw, h.maxItems)
+	if errMsg != "" {
+		handlers.WriteOpenAIError(c, http.StatusBadRequest, errMsg, "input", "invalid_request_error")
+		return
+	}
+	h.pruneExpired()
+
+	now := time.Now().Unix()
+	record := &batchRecord{
+		ID:            "batch_" + randomBatchID(),
+		Owner:         batchOwner(c),
+		Status:        batchStatusInProgress,
+		CreatedAt:     now,
+		InProgressAt:  now,
+		RequestCounts: batchRequestCounts{Total: len(items)},
+		Items:         items,
+	}
+	for _, item := range items {
+		item.ID = "batch_req_" + randomBatchID()
+	}
+	if err = h.persist(record); err != nil {
+		log.Errorf("batches: persist %s: %v", record.ID, err)
+		handlers.WriteOpenAIError(c, http.StatusInternalServerError, "failed to store batch", "", "server_error")
+		return
+	}
+
+	h.mu.Lock()
+	h.batches[record.ID] = record
+	object := record.object()
+	h.mu.Unlock()
+
+	go h.run(record)
+	c.JSON(http.StatusOK, object)
+}
+
+// GetBatch handles GET /v1/batches/:id and reports the batch status and request counts.
+func (h *OpenAIBatchesAPIHandler) GetBatch(c *gin.Context) {
+	h.pruneExpired()
+	h.mu.Lock()
+	record, ok := h.ownedBatch(c)
+	var object gin.H
+	if ok {
+		object = record.object()
+	}
+	h.mu.Unlock()
+	if !ok {
+		handlers.WriteOpenAIError(c, http.StatusNotFound, fmt.Sprintf("No batch found with id '%s'.", c.Param("id")), "id", "not_found")
+		return
+	}
+	c.JSON(http.StatusOK, object)
+}
+
+// GetBatchResults handles GET /v1/batches/:id/results. It returns one JSONL line per
+// finished request in input order, so results can be read while the batch is still running.
+func (h *OpenAIBatchesAPIHandler) GetBatchResults(c *gin.Context) {
+	h.pruneExpired()
+	var buf bytes.Buffer
+	h.mu.Lock()
+	record, ok := h.ownedBatch(c)
+	if ok {
+		for _, item := range record.Items {
+			if !item.Done {
+				continue
+			}
+			line := gin.H{"id": item.ID, "custom_id": item.CustomID, "response": nil, "error": nil}
+			if item.Response != nil {
+				line["response"] = item.Response
+			}
+			encoded, _ := json.Marshal(line)
+			buf.Write(encoded)
+			buf.WriteByte('\n')
+		}
+	}
+	h.mu.Unlock()
+	if !ok {
+		handlers.WriteOpenAIError(c, http.StatusNotFound, fmt.Sprintf("No batch found with id '%s'.", c.Param("id")), "id", "not_found")
+		return
+	}
+	c.Data(http.StatusOK, "application/jsonl", buf.Bytes())
+}
+
+// ownedBatch returns the batch named by the :id parameter when it belongs to the requesting
+// client. Callers must hold h.mu. Batches of other clients are reported as missing.
+func (h *OpenAIBatchesAPIHandler) ownedBatch(c *gin.Context) (*batchRecord, bool) {
+	record, ok := h.batches[c.Param("id")]
+	if !ok || record.Owner != batchOwner(c) {
+		return nil, false
+	}
+	return record, true
+}
+
+// batchOwner identifies the client that authenticated the request by hashing its access
+// provider and principal, so client API keys are never written to batch files.
+func batchOwner(c *gin.Context) string {
+	var provider, principal string
+	if v, exists := c.Get("accessProvider"); exists {
+		provider = fmt.Sprint(v)
+	}
+	if v, exists := c.Get("apiKey"); exists {
+		principal = fmt.Sprint(v)
+	}
+	if provider == "" && principal == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(provider + "\x00" + principal))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseBatchInput validates JSONL input lines and returns the batch items. It returns a
+// non-empty message describing the first invalid line, or the limit when the input holds
+// more than maxItems requests.
+func parseBatchInput(raw []byte, maxItems int) ([]*batchItem, string) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineSize)
+	var items []*batchItem
+	seen := make(map[string]struct{})
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !gjson.ValidBytes(line) {
+			return nil, fmt.Sprintf("line %d is not valid JSON", lineNo)
+		}
+		root := gjson.ParseBytes(line)
+		if method := root.Get("method").String(); method != "" && !strings.EqualFold(method, http.MethodPost) {
+			return nil, fmt.Sprintf("line %d: method must be POST", lineNo)
+		}
+		if url := root.Get("url").String(); url != "" && url != batchEndpoint {
+			return nil, fmt.Sprintf("line %d: url must be %s", lineNo, batchEndpoint)
+		}
+		body := root.Get("body")
+		if !body.IsObject() || body.Get("model").String() == "" {
+			return nil, fmt.Sprintf("line %d: body must be a Chat Completions request with a model", lineNo)
+		}
+		customID := root.Get("custom_id").String()
+		if customID == "" {
+			customID = fmt.Sprintf("request-%d", len(items)+1)
+		}
+		if _, dup := seen[customID]; dup {
+			return nil, fmt.Sprintf("line %d: duplicate custom_id %q", lineNo, customID)
+		}
+		seen[customID] = struct{}{}
+		if len(items) >= maxItems {
+			return nil, fmt.Sprintf("batch input exceeds the limit of %d requests", maxItems)
+		}
+		// Batch requests always execute non-streaming.
+		payload, _ := sjson.DeleteBytes([]byte(body.Raw), "stream")
+		payload, _ = sjson.DeleteBytes(payload, "stream_options")
+		items = append(items, &batchItem{CustomID: customID, Body: payload})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Sprintf("failed to read input: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, "batch input contains no requests"
+	}
+	return items, ""
+}
+
+// run executes every unfinished item of record, bounded by the shared semaphore, and marks
+// the batch completed once all items are done.
+func (h *OpenAIBatchesAPIHandler) run(record *batchRecord) {
+	var wg sync.WaitGroup
+	h.mu.Lock()
+	pending := make([]*batchItem, 0, len(record.Items))
+	for _, item := range record.Items {
+		if !item.Done {
+			pending = append(pending, item)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, item := range pending {
+		h.sem <- struct{}{}
+		wg.Add(1)
+		go func(item *batchItem) {
+			defer wg.Done()
+			defer func() { <-h.sem }()
+			h.execute(record, item)
+		}(item)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	record.Status = batchStatusCompleted
+	record.CompletedAt = time.Now().Unix()
+	h.mu.Unlock()
+	if err := h.persist(record); err != nil {
+		log.Errorf("batches: persist %s: %v", record.ID, err)
+	}
+}
+
+// execute runs one batch item through the regular non-streaming execution path.
+func (h *OpenAIBatchesAPIHandler) execute(record *batchRecord, item *batchItem) {
+	modelName := gjson.GetBytes(item.Body, "model").String()
+	resp, errMsg := h.ExecuteWithAuthManager(context.Background(), h.HandlerType(), modelName, item.Body, "")
+
+	var response *batchResponse
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		response = &batchResponse{StatusCode: status, Body: batchErrorBody(status, errMsg)}
+	} else {
+		body := resp
+		if !json.Valid(body) {
+			body, _ = json.Marshal(string(resp))
+		}
+		response = &batchResponse{StatusCode: http.StatusOK, Body: body}
+	}
+
+	h.mu.Lock()
+	applyBatchResult(record, item, response)
+	h.mu.Unlock()
+
+	if err := h.appendResult(record, batchResult{ID: item.ID, Response: response}); err != nil {
+		log.Errorf("batches: persist result %s/%s: %v", record.ID, item.ID, err)
+	}
+}
+
+// applyBatchResult marks item done with response and updates the batch request counts.
+// Callers must hold h.mu when the record is shared.
+func applyBatchResult(record *batchRecord, item *batchItem, response *batchResponse) {
+	if item.Done {
+		return
+	}
+	item.Done = true
+	item.Response = response
+	if response != nil && response.StatusCode == http.StatusOK {
+		record.RequestCounts.Completed++
+	} else {
+		record.RequestCounts.Failed++
+	}
+}
+
+// batchErrorBody returns the OpenAI error body for a failed batch request.
+func batchErrorBody(status int, errMsg *interfaces.ErrorMessage) json.RawMessage {
+	text := ""
+	if errMsg.Error != nil {
+		text = errMsg.Error.Error()
+	}
+	if json.Valid([]byte(text)) && gjson.Get(text, "error").Exists() {
+		return json.RawMessage(text)
+	}
+	return json.RawMessage(interfaces.OpenAIErrorBody(status, text, "", ""))
+}
+
+// persist writes record to disk atomically. It is a no-op when no directory is configured.
+func (h *OpenAIBatchesAPIHandler) persist(record *batchRecord) error {
+	if h.dir == "" {
+		return nil
+	}
+	h.mu.Lock()
+	data, err := json.Marshal(record)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	h.persistMu.Lock()
+	defer h.persistMu.Unlock()
+	if err = os.MkdirAll(h.dir, 0o700); err != nil {
+		return err
+	}
+	path := filepath.Join(h.dir, record.ID+".json")
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// appendResult appends one finished request to the batch results file. It is a no-op when
+// no directory is configured.
+func (h *OpenAIBatchesAPIHandler) appendResult(record *batchRecord, result batchResult) error {
+	if h.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	h.persistMu.Lock()
+	defer h.persistMu.Unlock()
+	if err = os.MkdirAll(h.dir, 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(batchResultsPath(h.dir, record.ID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// loadResults replays the results file of record onto its items and recomputes the request
+// counts. A truncated last line, left by a crash mid-write, is ignored.
+func loadResults(dir string, record *batchRecord) {
+	record.RequestCounts = batchRequestCounts{Total: len(record.Items)}
+	data, err := os.ReadFile(batchResultsPath(dir, record.ID))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("batches: read results of %s: %v", record.ID, err)
+		}
+		return
+	}
+	byID := make(map[string]*batchItem, len(record.Items))
+	for _, item := range record.Items {
+		byID[item.ID] = item
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var result batchResult
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &result) != nil {
+			continue
+		}
+		if item, ok := byID[result.ID]; ok {
+			applyBatchResult(record, item, result.Response)
+		}
+	}
+}
+
+// pruneExpired deletes completed batches, in memory and on disk, once their retention
+// period has passed.
+func (h *OpenAIBatchesAPIHandler) pruneExpired() {
+	cutoff := time.Now().Add(-h.retention).Unix()
+	var expired []string
+	h.mu.Lock()
+	for id, record := range h.batches {
+		if record.Status == batchStatusCompleted && record.CompletedAt != 0 && record.CompletedAt < cutoff {
+			delete(h.batches, id)
+			expired = append(expired, id)
+		}
+	}
+	h.mu.Unlock()
+	if h.dir == "" {
+		return
+	}
+	h.persistMu.Lock()
+	defer h.persistMu.Unlock()
+	for _, id := range expired {
+		for _, path := range []string{filepath.Join(h.dir, id+".json"), batchResultsPath(h.dir, id)} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Warnf("batches: remove %s: %v", path, err)
+			}
+		}
+	}
+}
+
+func batchResultsPath(dir, id string) string {
+	return filepath.Join(dir, id+".results.jsonl")
+}
+
+// loadBatches restores batches persisted under dir and resumes the unfinished ones.
+func (h *OpenAIBatchesAPIHandler) loadBatches() {
+	if h.dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("batches: read %s: %v", h.dir, err)
+		}
+		return
+	}
+	var resume []*batchRecord
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(h.dir, entry.Name()))
+		if errRead != nil {
+			log.Warnf("batches: read %s: %v", entry.Name(), errRead)
+			continue
+		}
+		var record batchRecord
+		if errDecode := json.Unmarshal(data, &record); errDecode != nil || record.ID == "" {
+			log.Warnf("batches: skip invalid batch file %s", entry.Name())
+			continue
+		}
+		loadResults(h.dir, &record)
+		h.batches[record.ID] = &record
+		if record.Status != batchStatusCompleted {
+			resume = append(resume, &record)
+		}
+	}
+	h.pruneExpired()
+	sort.Slice(resume, func(i, j int) bool { return resume[i].CreatedAt < resume[j].CreatedAt })
+	for _, record := range resume {
+		log.Infof("batches: resuming %s", record.ID)
+		go h.run(record)
+	}
+}
+
+// randomBatchID returns a random hex identifier.
+func randomBatchID() string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
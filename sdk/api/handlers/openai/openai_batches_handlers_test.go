@@ -0,0 +1,328 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// batchStubExecutor answers chat completions with the request model echoed back and fails
+// requests for batch-fail-model.
+type batchStubExecutor struct {
+	calls atomic.Int32
+}
+
+func (e *batchStubExecutor) Identifier() string { return "batch-stub" }
+
+func (e *batchStubExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls.Add(1)
+	if req.Model == "batch-fail-model" {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "bad_request", Message: "upstream rejected", HTTPStatus: http.StatusBadRequest}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"object":"chat.completion","model":"` + req.Model + `","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)}, nil
+}
+
+func (e *batchStubExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *batchStubExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *batchStubExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *batchStubExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func newBatchTestRouter(t *testing.T, dir string) (*gin.Engine, *batchStubExecutor) {
+	t.Helper()
+	return newBatchTestRouterWithConfig(t, dir, sdkconfig.BatchesConfig{MaxConcurrency: 2})
+}
+
+// newBatchTestRouterWithConfig builds a batches router whose requests authenticate as the
+// API key in the X-Test-Key header, the way the access middleware records clients.
+func newBatchTestRouterWithConfig(t *testing.T, dir string, cfg sdkconfig.BatchesConfig) (*gin.Engine, *batchStubExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	executor := &batchStubExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "batch-auth", Provider: "batch-stub", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "batch-model"}, {ID: "batch-fail-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIBatchesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager), dir, cfg)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+			c.Set("accessProvider", "config-api-key")
+		}
+	})
+	router.POST("/v1/batches", h.CreateBatch)
+	router.GET("/v1/batches/:id", h.GetBatch)
+	router.GET("/v1/batches/:id/results", h.GetBatchResults)
+	return router, executor
+}
+
+func serveBatch(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	return serveBatchAs(router, "", method, path, body)
+}
+
+func serveBatchAs(router *gin.Engine, apiKey, method, path, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if apiKey != "" {
+		req.Header.Set("X-Test-Key", apiKey)
+	}
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// waitForBatch polls the batch until it completes and returns the final batch object.
+func waitForBatch(t *testing.T, router *gin.Engine, id string) gjson.Result {
+	t.Helper()
+	return waitForBatchAs(t, router, "", id)
+}
+
+func waitForBatchAs(t *testing.T, router *gin.Engine, apiKey, id string) gjson.Result {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		rr := serveBatchAs(router, apiKey, http.MethodGet, "/v1/batches/"+id, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("poll status = %d, body = %s", rr.Code, rr.Body.String())
+		}
+		batch := gjson.Parse(rr.Body.String())
+		if batch.Get("status").String() == batchStatusCompleted {
+			return batch
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not complete", id)
+	return gjson.Result{}
+}
+
+func readBatchResults(t *testing.T, router *gin.Engine, id string) map[string]gjson.Result {
+	t.Helper()
+	rr := serveBatch(router, http.MethodGet, "/v1/batches/"+id+"/results", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("results status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	results := make(map[string]gjson.Result)
+	scanner := bufio.NewScanner(strings.NewReader(rr.Body.String()))
+	for scanner.Scan() {
+		line := gjson.Parse(scanner.Text())
+		results[line.Get("custom_id").String()] = line
+	}
+	return results
+}
+
+func TestOpenAIBatches_SubmitPollAndResults(t *testing.T) {
+	dir := t.TempDir()
+	router, executor := newBatchTestRouter(t, dir)
+
+	input := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","stream":true,"messages":[{"role":"user","content":"hi"}]}}`,
+		`{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[{"role":"user","content":"hello"}]}}`,
+		`{"custom_id":"c","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-fail-model","messages":[{"role":"user","content":"boom"}]}}`,
+	}, "\n")
+	rr := serveBatch(router, http.MethodPost, "/v1/batches", input)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("submit status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	created := gjson.Parse(rr.Body.String())
+	id := created.Get("id").String()
+	if !strings.HasPrefix(id, "batch_") || created.Get("object").String() != "batch" || created.Get("request_counts.total").Int() != 3 {
+		t.Fatalf("unexpected batch object: %s", rr.Body.String())
+	}
+
+	batch := waitForBatch(t, router, id)
+	if batch.Get("request_counts.completed").Int() != 2 || batch.Get("request_counts.failed").Int() != 1 {
+		t.Fatalf("request counts = %s, want 2 completed and 1 failed", batch.Get("request_counts").Raw)
+	}
+	if got := executor.calls.Load(); got != 3 {
+		t.Fatalf("executor calls = %d, want 3", got)
+	}
+
+	results := readBatchResults(t, router, id)
+	if len(results) != 3 {
+		t.Fatalf("results = %d lines, want 3", len(results))
+	}
+	if got := results["a"].Get("response.status_code").Int(); got != http.StatusOK {
+		t.Fatalf("a status_code = %d, want 200", got)
+	}
+	if got := results["b"].Get("response.body.model").String(); got != "batch-model" {
+		t.Fatalf("b model = %q, want batch-model", got)
+	}
+	if got := results["c"].Get("response.status_code").Int(); got != http.StatusBadRequest {
+		t.Fatalf("c status_code = %d, want 400", got)
+	}
+	if !results["c"].Get("response.body.error").Exists() {
+		t.Fatalf("c body = %s, want an OpenAI error", results["c"].Get("response.body").Raw)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, id+".json")); err != nil {
+		t.Fatalf("expected persisted batch file: %v", err)
+	}
+	persisted, err := os.ReadFile(filepath.Join(dir, id+".results.jsonl"))
+	if err != nil {
+		t.Fatalf("expected persisted results file: %v", err)
+	}
+	if lines := strings.Count(string(persisted), "\n"); lines != 3 {
+		t.Fatalf("results file has %d lines, want one per request", lines)
+	}
+}
+
+func TestOpenAIBatches_ResumesPersistedBatch(t *testing.T) {
+	dir := t.TempDir()
+	record := batchRecord{
+		ID:            "batch_resume",
+		Status:        batchStatusInProgress,
+		CreatedAt:     time.Now().Unix(),
+		RequestCounts: batchRequestCounts{Total: 2},
+		Items: []*batchItem{
+			{ID: "batch_req_1", CustomID: "done", Body: json.RawMessage(`{"model":"batch-model"}`)},
+			{ID: "batch_req_2", CustomID: "pending", Body: json.RawMessage(`{"model":"batch-model","messages":[]}`)},
+		},
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, record.ID+".json"), data, 0o600); err != nil {
+		t.Fatalf("write batch file: %v", err)
+	}
+	// The finished request is followed by a truncated line, as left by a crash mid-write.
+	results := `{"id":"batch_req_1","response":{"status_code":200,"body":{"cached":true}}}` + "\n" + `{"id":"batch_req_2","resp`
+	if err = os.WriteFile(filepath.Join(dir, record.ID+".results.jsonl"), []byte(results), 0o600); err != nil {
+		t.Fatalf("write results file: %v", err)
+	}
+
+	router, executor := newBatchTestRouter(t, dir)
+	batch := waitForBatch(t, router, record.ID)
+	if batch.Get("request_counts.completed").Int() != 2 {
+		t.Fatalf("request counts = %s, want 2 completed", batch.Get("request_counts").Raw)
+	}
+	if got := executor.calls.Load(); got != 1 {
+		t.Fatalf("executor calls = %d, want only the pending request", got)
+	}
+	got := readBatchResults(t, router, record.ID)
+	if !got["done"].Get("response.body.cached").Bool() || got["pending"].Get("response.status_code").Int() != http.StatusOK {
+		t.Fatalf("unexpected results: %v", got)
+	}
+}
+
+func TestOpenAIBatches_RejectsInvalidInput(t *testing.T) {
+	router, _ := newBatchTestRouter(t, "")
+	for name, input := range map[string]string{
+		"empty":         "",
+		"invalid json":  "{not json",
+		"wrong url":     `{"custom_id":"a","url":"/v1/embeddings","body":{"model":"batch-model"}}`,
+		"missing model": `{"custom_id":"a","body":{"messages":[]}}`,
+		"duplicate ids": `{"custom_id":"a","body":{"model":"batch-model"}}` + "\n" + `{"custom_id":"a","body":{"model":"batch-model"}}`,
+	} {
+		if rr := serveBatch(router, http.MethodPost, "/v1/batches", input); rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", name, rr.Code)
+		}
+	}
+	if rr := serveBatch(router, http.MethodGet, "/v1/batches/batch_missing", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown batch status = %d, want 404", rr.Code)
+	}
+}
+
+func TestOpenAIBatches_RejectsTooManyItems(t *testing.T) {
+	router, executor := newBatchTestRouterWithConfig(t, "", sdkconfig.BatchesConfig{MaxItems: 2})
+	line := `{"custom_id":"%s","body":{"model":"batch-model"}}`
+	input := strings.Join([]string{
+		strings.Replace(line, "%s", "a", 1),
+		strings.Replace(line, "%s", "b", 1),
+		strings.Replace(line, "%s", "c", 1),
+	}, "\n")
+	rr := serveBatch(router, http.MethodPost, "/v1/batches", input)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "limit of 2") {
+		t.Fatalf("status = %d, body = %s, want 400 naming the limit", rr.Code, rr.Body.String())
+	}
+	if got := executor.calls.Load(); got != 0 {
+		t.Fatalf("executor calls = %d for a rejected batch", got)
+	}
+}
+
+func TestOpenAIBatches_OnlyOwnerCanRead(t *testing.T) {
+	router, _ := newBatchTestRouter(t, t.TempDir())
+	rr := serveBatchAs(router, "key-a", http.MethodPost, "/v1/batches", `{"custom_id":"a","body":{"model":"batch-model"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("submit status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	id := gjson.Get(rr.Body.String(), "id").String()
+
+	for _, path := range []string{"/v1/batches/" + id, "/v1/batches/" + id + "/results"} {
+		if rr = serveBatchAs(router, "key-b", http.MethodGet, path, ""); rr.Code != http.StatusNotFound {
+			t.Fatalf("other client GET %s status = %d, want 404", path, rr.Code)
+		}
+		if rr = serveBatch(router, http.MethodGet, path, ""); rr.Code != http.StatusNotFound {
+			t.Fatalf("unauthenticated GET %s status = %d, want 404", path, rr.Code)
+		}
+		if rr = serveBatchAs(router, "key-a", http.MethodGet, path, ""); rr.Code != http.StatusOK {
+			t.Fatalf("owner GET %s status = %d, want 200", path, rr.Code)
+		}
+	}
+	waitForBatchAs(t, router, "key-a", id)
+}
+
+func TestOpenAIBatches_DeletesExpiredBatches(t *testing.T) {
+	dir := t.TempDir()
+	completedAt := time.Now().Add(-2 * time.Hour).Unix()
+	record := batchRecord{
+		ID:            "batch_expired",
+		Status:        batchStatusCompleted,
+		CreatedAt:     completedAt,
+		CompletedAt:   completedAt,
+		RequestCounts: batchRequestCounts{Total: 1},
+		Items:         []*batchItem{{ID: "batch_req_1", CustomID: "a", Body: json.RawMessage(`{"model":"batch-model"}`)}},
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, record.ID+".json"), data, 0o600); err != nil {
+		t.Fatalf("write batch file: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, record.ID+".results.jsonl"), []byte(`{"id":"batch_req_1","response":{"status_code":200,"body":{}}}`+"\n"), 0o600); err != nil {
+		t.Fatalf("write results file: %v", err)
+	}
+
+	router, _ := newBatchTestRouterWithConfig(t, dir, sdkconfig.BatchesConfig{RetentionHours: 1})
+	if rr := serveBatch(router, http.MethodGet, "/v1/batches/"+record.ID, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expired batch status = %d, want 404", rr.Code)
+	}
+	for _, name := range []string{record.ID + ".json", record.ID + ".results.jsonl"} {
+		if _, errStat := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(errStat) {
+			t.Fatalf("expected %s to be deleted, stat err = %v", name, errStat)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/gin-gonic/gin"
@@ -58,9 +59,16 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 // OpenAIModels handles the /v1/models endpoint.
 // It returns a list of available AI models with their capabilities
 // and specifications in OpenAI-compatible format.
+//
+// The optional provider and owned_by query parameters narrow the list to models
+// served by the given providers or owned by the given vendors. Both accept
+// comma-separated, case-insensitive values; when both are set a model must match each.
+// Configured model aliases are listed after filtering, next to their targets.
 func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 	// Get all available models
-	allModels := h.Models()
+	allModels := h.FilterServedModels(h.Models())
+	allModels = filterModelsByQuery(allModels, modelFilterValues(c, "provider"), modelFilterValues(c, "owned_by"))
+	allModels = h.WithModelAliases(allModels)
 
 	c.JSON(http.StatusOK, gin.H{
 		"object": "list",
@@ -68,6 +76,70 @@ func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 	})
 }
 
+// OpenAIModel handles the /v1/models/{model} endpoint and returns a single model object.
+// Aliases are listed under the requested ID; unknown models answer with a 404 error.
+func (h *OpenAIAPIHandler) OpenAIModel(c *gin.Context) {
+	id := strings.TrimPrefix(c.Param("model"), "/")
+	info := h.LookupModel(id)
+	if info == nil {
+		handlers.WriteOpenAIError(c, http.StatusNotFound, fmt.Sprintf("The model '%s' does not exist", id), "model", "model_not_found")
+		return
+	}
+	model := registry.ToOpenAIModelMap(info)
+	if !strings.EqualFold(id, info.ID) && !strings.EqualFold(id, registry.CopilotModelPrefix+info.ID) {
+		model["id"] = id
+	}
+	c.JSON(http.StatusOK, model)
+}
+
+// modelFilterValues collects the lower-cased, comma-separated values of a query parameter.
+// Repeated parameters are merged.
+func modelFilterValues(c *gin.Context, key string) map[string]struct{} {
+	values := make(map[string]struct{})
+	for _, raw := range c.QueryArray(key) {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.ToLower(strings.TrimSpace(part))
+			if part != "" {
+				values[part] = struct{}{}
+			}
+		}
+	}
+	return values
+}
+
+// filterModelsByQuery keeps models whose registry providers intersect providers and whose
+// owned_by is listed in owners. An empty filter set does not restrict the result.
+func filterModelsByQuery(models []map[string]any, providers, owners map[string]struct{}) []map[string]any {
+	if len(providers) == 0 && len(owners) == 0 {
+		return models
+	}
+	modelRegistry := registry.GetGlobalRegistry()
+	filtered := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		if len(owners) > 0 {
+			owner, _ := model["owned_by"].(string)
+			if _, ok := owners[strings.ToLower(strings.TrimSpace(owner))]; !ok {
+				continue
+			}
+		}
+		if len(providers) > 0 {
+			id, _ := model["id"].(string)
+			matched := false
+			for _, provider := range modelRegistry.GetModelProviders(id) {
+				if _, ok := providers[strings.ToLower(provider)]; ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		filtered = append(filtered, model)
+	}
+	return filtered
+}
+
 // ChatCompletions handles the /v1/chat/completions endpoint.
 // It determines whether the request is for a streaming or non-streaming response
 // and calls the appropriate handler based on the model provider.
@@ -78,12 +150,7 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 	rawJSON, err := c.GetRawData()
 	// If data retrieval fails, return a 400 Bad Request error.
 	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+		handlers.WriteOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "", "")
 		return
 	}
 
@@ -133,12 +200,7 @@ func (h *OpenAIAPIHandler) Completions(c *gin.Context) {
 	rawJSON, err := c.GetRawData()
 	// If data retrieval fails, return a 400 Bad Request error.
 	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+		handlers.WriteOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "", "")
 		return
 	}
 
@@ -425,12 +487,7 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "Streaming not supported",
-				Type:    "server_error",
-			},
-		})
+		handlers.WriteOpenAIError(c, http.StatusInternalServerError, "Streaming not supported", "", "")
 		return
 	}
 
@@ -445,6 +502,9 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 		c.Header("Access-Control-Allow-Origin", "*")
 	}
 
+	pinger := h.NewStreamPinger()
+	defer pinger.Stop()
+
 	// Peek at the first chunk to determine success or failure before setting headers
 	for {
 		select {
@@ -457,6 +517,11 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 				errChan = nil
 				continue
 			}
+			if pinger.Started() {
+				// Pings already committed the stream; report the error inside it.
+				h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, nil, handlers.PendingError(errMsg))
+				return
+			}
 			// Upstream failed immediately. Return proper error status and JSON.
 			h.WriteErrorResponse(c, errMsg)
 			if errMsg != nil {
@@ -466,6 +531,7 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			}
 			return
 		case chunk, ok := <-dataChan:
+			pinger.Stop()
 			if !ok {
 				// Stream closed without data? Send DONE or just headers.
 				setSSEHeaders()
@@ -484,6 +550,8 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			// Continue streaming the rest
 			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
 			return
+		case <-pinger.C:
+			pinger.Ping(c, flusher, setSSEHeaders)
 		}
 	}
 }
@@ -525,12 +593,7 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "Streaming not supported",
-				Type:    "server_error",
-			},
-		})
+		handlers.WriteOpenAIError(c, http.StatusInternalServerError, "Streaming not supported", "", "")
 		return
 	}
 
@@ -548,6 +611,9 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 		c.Header("Access-Control-Allow-Origin", "*")
 	}
 
+	pinger := h.NewStreamPinger()
+	defer pinger.Stop()
+
 	// Peek at the first chunk
 	for {
 		select {
@@ -560,6 +626,11 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 				errChan = nil
 				continue
 			}
+			if pinger.Started() {
+				// Pings already committed the stream; report the error inside it.
+				h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, nil, handlers.PendingError(errMsg))
+				return
+			}
 			h.WriteErrorResponse(c, errMsg)
 			if errMsg != nil {
 				cliCancel(errMsg.Error)
@@ -568,6 +639,7 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			}
 			return
 		case chunk, ok := <-dataChan:
+			pinger.Stop()
 			if !ok {
 				setSSEHeaders()
 				_, _ = fmt.Fprintf(c.Writer, "data: [DONE]\n\n")
@@ -619,6 +691,8 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 				cliCancel(err)
 			}, convertedChan, errChan)
 			return
+		case <-pinger.C:
+			pinger.Ping(c, flusher, setSSEHeaders)
 		}
 	}
 }
@@ -0,0 +1,209 @@
+package openai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestOpenAIModels_FiltersByProviderAndOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("models-filter-copilot", "copilot", []*registry.ModelInfo{
+		{ID: "filter-gpt", Object: "model", OwnedBy: "openai"},
+		{ID: "filter-gemini-shared", Object: "model", OwnedBy: "google"},
+	})
+	reg.RegisterClient("models-filter-gemini", "gemini", []*registry.ModelInfo{
+		{ID: "filter-gemini-shared", Object: "model", OwnedBy: "google"},
+		{ID: "filter-gemini-only", Object: "model", OwnedBy: "Google"},
+	})
+	reg.RegisterClient("models-filter-claude", "claude", []*registry.ModelInfo{
+		{ID: "filter-claude", Object: "model", OwnedBy: "anthropic"},
+	})
+	defer reg.UnregisterClient("models-filter-copilot")
+	defer reg.UnregisterClient("models-filter-gemini")
+	defer reg.UnregisterClient("models-filter-claude")
+
+	h := NewOpenAIAPIHandler(&handlers.BaseAPIHandler{})
+	router := gin.New()
+	router.GET("/v1/models", h.OpenAIModels)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "no filter",
+			query: "",
+			want:  []string{"filter-claude", "filter-gemini-only", "filter-gemini-shared", "filter-gpt"},
+		},
+		{
+			name:  "single provider",
+			query: "?provider=copilot",
+			want:  []string{"filter-gemini-shared", "filter-gpt"},
+		},
+		{
+			name:  "provider is case-insensitive and comma-separated",
+			query: "?provider=CLAUDE,%20Gemini",
+			want:  []string{"filter-claude", "filter-gemini-only", "filter-gemini-shared"},
+		},
+		{
+			name:  "owned_by is case-insensitive",
+			query: "?owned_by=google",
+			want:  []string{"filter-gemini-only", "filter-gemini-shared"},
+		},
+		{
+			name:  "owned_by accepts multiple values",
+			query: "?owned_by=openai,anthropic",
+			want:  []string{"filter-claude", "filter-gpt"},
+		},
+		{
+			name:  "provider and owned_by combine",
+			query: "?provider=copilot&owned_by=google",
+			want:  []string{"filter-gemini-shared"},
+		},
+		{
+			name:  "unknown provider",
+			query: "?provider=unknown",
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/models"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+
+			var body struct {
+				Object string           `json:"object"`
+				Data   []map[string]any `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("unmarshal response: %v", err)
+			}
+			if body.Object != "list" {
+				t.Fatalf("object = %q, want list", body.Object)
+			}
+			got := make([]string, 0, len(body.Data))
+			for _, model := range body.Data {
+				id, _ := model["id"].(string)
+				got = append(got, id)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("models = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("models = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAIModels_ListsModelAliases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("models-alias-copilot", "copilot", []*registry.ModelInfo{
+		{ID: "alias-list-flash", Object: "model", OwnedBy: "google"},
+	})
+	defer reg.UnregisterClient("models-alias-copilot")
+
+	h := NewOpenAIAPIHandler(&handlers.BaseAPIHandler{Cfg: &config.SDKConfig{
+		ModelAliases: map[string]string{"fast": "alias-list-flash"},
+	}})
+	router := gin.New()
+	router.GET("/v1/models", h.OpenAIModels)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models?provider=copilot", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	var alias map[string]any
+	for _, model := range body.Data {
+		if model["id"] == "fast" {
+			alias = model
+		}
+	}
+	if alias == nil {
+		t.Fatalf("expected alias fast in model list, got %v", body.Data)
+	}
+	if alias["owned_by"] != "google" {
+		t.Fatalf("alias owned_by = %v, want google", alias["owned_by"])
+	}
+}
+
+func TestOpenAIModel_SingleModelLookup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("single-model-copilot", "copilot", []*registry.ModelInfo{
+		{ID: "single-gpt", Object: "model", OwnedBy: "openai", ContextLength: 128000},
+	})
+	defer reg.UnregisterClient("single-model-copilot")
+
+	h := NewOpenAIAPIHandler(&handlers.BaseAPIHandler{Cfg: &config.SDKConfig{
+		ModelAliases: map[string]string{"single-fast": "single-gpt"},
+	}})
+	router := gin.New()
+	router.GET("/v1/models/*model", h.OpenAIModel)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantID     string
+	}{
+		{name: "known model", path: "/v1/models/single-gpt", wantStatus: http.StatusOK, wantID: "single-gpt"},
+		{name: "copilot prefixed", path: "/v1/models/copilot-single-gpt", wantStatus: http.StatusOK, wantID: "single-gpt"},
+		{name: "configured alias", path: "/v1/models/single-fast", wantStatus: http.StatusOK, wantID: "single-fast"},
+		{name: "unknown model", path: "/v1/models/single-missing", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if tt.wantStatus != http.StatusOK {
+				errObj, _ := body["error"].(map[string]any)
+				if errObj["code"] != "model_not_found" || errObj["param"] != "model" {
+					t.Fatalf("unexpected error body: %s", w.Body.String())
+				}
+				return
+			}
+			if body["id"] != tt.wantID || body["object"] != "model" {
+				t.Fatalf("unexpected model body: %s", w.Body.String())
+			}
+			if body["context_length"] != float64(128000) {
+				t.Fatalf("context_length = %v, want 128000", body["context_length"])
+			}
+		})
+	}
+}
@@ -58,7 +58,7 @@ func (h *OpenAIResponsesAPIHandler) Models() []map[string]any {
 func (h *OpenAIResponsesAPIHandler) OpenAIResponsesModels(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"object": "list",
-		"data":   h.Models(),
+		"data":   h.FilterServedModels(h.Models()),
 	})
 }
 
@@ -72,12 +72,7 @@ func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
 	rawJSON, err := c.GetRawData()
 	// If data retrieval fails, return a 400 Bad Request error.
 	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+		handlers.WriteOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "", "")
 		return
 	}
 
@@ -130,12 +125,7 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "Streaming not supported",
-				Type:    "server_error",
-			},
-		})
+		handlers.WriteOpenAIError(c, http.StatusInternalServerError, "Streaming not supported", "", "")
 		return
 	}
 
@@ -151,6 +141,9 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 		c.Header("Access-Control-Allow-Origin", "*")
 	}
 
+	pinger := h.NewStreamPinger()
+	defer pinger.Stop()
+
 	// Peek at the first chunk
 	for {
 		select {
@@ -163,6 +156,11 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 				errChan = nil
 				continue
 			}
+			if pinger.Started() {
+				// Pings already committed the stream; report the error inside it.
+				h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, nil, handlers.PendingError(errMsg))
+				return
+			}
 			// Upstream failed immediately. Return proper error status and JSON.
 			h.WriteErrorResponse(c, errMsg)
 			if errMsg != nil {
@@ -172,6 +170,7 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 			}
 			return
 		case chunk, ok := <-dataChan:
+			pinger.Stop()
 			if !ok {
 				// Stream closed without data? Send headers and done.
 				setSSEHeaders()
@@ -195,6 +194,8 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 			// Continue
 			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
 			return
+		case <-pinger.C:
+			pinger.Ping(c, flusher, setSSEHeaders)
 		}
 	}
 }
@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// slowStreamExecutor waits before sending its only chunk, like an upstream still processing
+// a long prompt. When fail is set it reports an error instead.
+type slowStreamExecutor struct {
+	delay time.Duration
+	fail  bool
+}
+
+func (e *slowStreamExecutor) Identifier() string { return "ping-stub" }
+
+func (e *slowStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *slowStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.delay):
+		}
+		if e.fail {
+			ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream", Message: "upstream failed", HTTPStatus: http.StatusBadGateway}}
+			return
+		}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"choices":[{"index":0,"delta":{"content":"hi"}}]}`)}
+	}()
+	return ch, nil
+}
+
+func (e *slowStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *slowStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *slowStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func newPingTestRouter(t *testing.T, executor *slowStreamExecutor, pingSeconds int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "ping-auth", Provider: "ping-stub", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "ping-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	cfg := &sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{PingSeconds: pingSeconds}}
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+	return router
+}
+
+func serveStream(router *gin.Engine) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	body := `{"model":"ping-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	return rr
+}
+
+func TestChatCompletionsStream_PingsUntilFirstChunk(t *testing.T) {
+	router := newPingTestRouter(t, &slowStreamExecutor{delay: 1500 * time.Millisecond}, 1)
+	rr := serveStream(router)
+
+	body := rr.Body.String()
+	pingAt := strings.Index(body, ": ping\n\n")
+	dataAt := strings.Index(body, "data: ")
+	if pingAt < 0 || dataAt < 0 || pingAt > dataAt {
+		t.Fatalf("expected a ping before the first data chunk, got %q", body)
+	}
+	if strings.Contains(body[dataAt:], ": ping") {
+		t.Fatalf("ping sent after data started flowing: %q", body)
+	}
+	if !strings.Contains(body, `"content":"hi"`) || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("unexpected stream body: %q", body)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+}
+
+func TestChatCompletionsStream_ErrorAfterPingStaysInStream(t *testing.T) {
+	router := newPingTestRouter(t, &slowStreamExecutor{delay: 1500 * time.Millisecond, fail: true}, 1)
+	rr := serveStream(router)
+
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.HasPrefix(body, ": ping\n\n") {
+		t.Fatalf("expected a committed stream starting with a ping, got %d %q", rr.Code, body)
+	}
+	if !strings.Contains(body, "data: {\"error\"") || !strings.Contains(body, "upstream failed") {
+		t.Fatalf("expected an SSE error event, got %q", body)
+	}
+}
+
+func TestChatCompletionsStream_NoPingsWhenDisabled(t *testing.T) {
+	router := newPingTestRouter(t, &slowStreamExecutor{delay: 50 * time.Millisecond}, 0)
+	rr := serveStream(router)
+	if body := rr.Body.String(); strings.Contains(body, ": ping") {
+		t.Fatalf("ping sent while ping-seconds is 0: %q", body)
+	}
+}
@@ -0,0 +1,51 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// Tokenize handles the /v1/tokenize endpoint.
+// It accepts {"model": ..., "input": ...} where input is a string, an array of strings,
+// or a Chat Completions messages array, and returns {"tokens": n}. A "messages" field is
+// accepted in place of input so chat payloads can be counted as-is.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Tokenize(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeTokenizeError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) || !gjson.ParseBytes(rawJSON).IsObject() {
+		writeTokenizeError(c, "Invalid request: body must be a JSON object")
+		return
+	}
+
+	input := gjson.GetBytes(rawJSON, "input")
+	if !input.Exists() {
+		input = gjson.GetBytes(rawJSON, "messages")
+	}
+	if !input.Exists() {
+		writeTokenizeError(c, "Invalid request: input is required")
+		return
+	}
+
+	count, err := util.CountTokenizeInput(gjson.GetBytes(rawJSON, "model").String(), input)
+	if err != nil {
+		writeTokenizeError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": count})
+}
+
+func writeTokenizeError(c *gin.Context, message string) {
+	handlers.WriteOpenAIError(c, http.StatusBadRequest, message, "", "")
+}
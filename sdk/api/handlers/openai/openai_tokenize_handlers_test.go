@@ -0,0 +1,85 @@
+package openai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+func TestOpenAITokenize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewOpenAIAPIHandler(&handlers.BaseAPIHandler{})
+	router := gin.New()
+	router.POST("/v1/tokenize", h.Tokenize)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantTokens int
+	}{
+		{
+			name:       "plain string",
+			body:       `{"model":"gpt-4","input":"hello world"}`,
+			wantStatus: http.StatusOK,
+			wantTokens: 2,
+		},
+		{
+			name:       "array of strings",
+			body:       `{"model":"gpt-4","input":["hello world","hello world"]}`,
+			wantStatus: http.StatusOK,
+			wantTokens: 5,
+		},
+		{
+			name:       "messages array",
+			body:       `{"model":"gpt-4","messages":[{"role":"system","content":"hello world"},{"role":"user","content":[{"type":"text","text":"hello world"}]}]}`,
+			wantStatus: http.StatusOK,
+			wantTokens: 5,
+		},
+		{
+			name:       "malformed json",
+			body:       `{"model":"gpt-4","input":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing input",
+			body:       `{"model":"gpt-4"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported input type",
+			body:       `{"model":"gpt-4","input":42}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/tokenize", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Tokens int `json:"tokens"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unmarshal response: %v", err)
+			}
+			if resp.Tokens != tt.wantTokens {
+				t.Fatalf("tokens = %d, want %d", resp.Tokens, tt.wantTokens)
+			}
+		})
+	}
+}
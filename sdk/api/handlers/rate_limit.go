@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// rejectionRecorder receives the kind of each request rejected before dispatch.
+var rejectionRecorder atomic.Value
+
+// SetRejectionRecorder installs fn to observe requests the handlers reject before dispatch,
+// keyed by kind (e.g. "rate_limited"). The metrics module installs its error counter here.
+func SetRejectionRecorder(fn func(kind string)) {
+	rejectionRecorder.Store(fn)
+}
+
+func recordRejection(kind string) {
+	if fn, ok := rejectionRecorder.Load().(func(string)); ok && fn != nil {
+		fn(kind)
+	}
+}
+
+// modelTokenBucket is a token bucket holding up to rpm tokens, refilled at rpm per minute.
+type modelTokenBucket struct {
+	rpm    int
+	tokens float64
+	last   time.Time
+}
+
+// modelRateLimiter tracks one bucket per model. Buckets are shared across handler instances
+// so limits keep their state when the configuration is reloaded.
+type modelRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*modelTokenBucket
+	now     func() time.Time
+}
+
+var sharedModelRateLimiter = &modelRateLimiter{buckets: make(map[string]*modelTokenBucket), now: time.Now}
+
+// allow takes a token from model's bucket. When the bucket is empty it reports how long
+// until the next token is available.
+func (l *modelRateLimiter) allow(model string, rpm int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	bucket, ok := l.buckets[model]
+	if !ok || bucket.rpm != rpm {
+		bucket = &modelTokenBucket{rpm: rpm, tokens: float64(rpm), last: now}
+		l.buckets[model] = bucket
+	}
+	perSecond := float64(rpm) / 60
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(float64(rpm), bucket.tokens+elapsed*perSecond)
+	}
+	bucket.last = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	return false, wait
+}
+
+// checkModelRateLimit enforces ModelRateLimits for the resolved model. Rejections are
+// answered with a 429 OpenAI error carrying Retry-After in whole seconds.
+func (h *BaseAPIHandler) checkModelRateLimit(model string) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelRateLimits) == 0 {
+		return nil
+	}
+	key := strings.ToLower(strings.TrimSpace(model))
+	rpm, ok := h.Cfg.ModelRateLimits[key]
+	if !ok || rpm <= 0 {
+		return nil
+	}
+	allowed, wait := sharedModelRateLimiter.allow(key, rpm)
+	if allowed {
+		return nil
+	}
+	recordRejection("rate_limited")
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	addon := http.Header{}
+	addon.Set("Retry-After", strconv.Itoa(retryAfter))
+	message := fmt.Sprintf("Rate limit of %d requests per minute reached for model %s", rpm, model)
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      errors.New(string(interfaces.OpenAIErrorBody(http.StatusTooManyRequests, message, "model", "rate_limit_exceeded"))),
+		Addon:      addon,
+	}
+}
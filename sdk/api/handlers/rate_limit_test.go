@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestExecuteWithAuthManager_ModelRateLimit(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "rate-limit-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "rate-limited-model"}, {ID: "unlimited-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	var rejections []string
+	SetRejectionRecorder(func(kind string) { rejections = append(rejections, kind) })
+	t.Cleanup(func() { SetRejectionRecorder(nil) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ModelAliases:    map[string]string{"rl-alias": "rate-limited-model"},
+		ModelRateLimits: map[string]int{"rate-limited-model": 2},
+	}, manager)
+
+	// The alias shares the target's bucket because the limiter keys on the resolved model.
+	for i, model := range []string{"rate-limited-model", "rl-alias"} {
+		if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", model, []byte(`{}`), ""); errMsg != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, errMsg.Error)
+		}
+	}
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "rate-limited-model", []byte(`{}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 past the limit, got %+v", errMsg)
+	}
+	if retryAfter := errMsg.Addon.Get("Retry-After"); retryAfter == "" || retryAfter == "0" {
+		t.Fatalf("Retry-After = %q, want a positive number of seconds", retryAfter)
+	}
+	if !strings.Contains(errMsg.Error.Error(), `"code":"rate_limit_exceeded"`) {
+		t.Fatalf("unexpected error body: %s", errMsg.Error)
+	}
+	if len(rejections) != 1 || rejections[0] != "rate_limited" {
+		t.Fatalf("rejections = %v, want one rate_limited", rejections)
+	}
+
+	if _, errMsg = handler.ExecuteWithAuthManager(context.Background(), "openai", "unlimited-model", []byte(`{}`), ""); errMsg != nil {
+		t.Fatalf("models without a limit must not be throttled: %v", errMsg.Error)
+	}
+}
+
+func TestExecuteCountWithAuthManager_ModelRateLimit(t *testing.T) {
+	executor := &captureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "count-rate-limit-auth", Provider: "copilot", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "count-limited-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ModelRateLimits: map[string]int{"count-limited-model": 1},
+	}, manager)
+
+	// Count requests reach upstream, so they draw from the same bucket as generation. The
+	// capture executor does not implement counting, so only a 429 counts as a rejection here.
+	if _, errMsg := handler.ExecuteCountWithAuthManager(context.Background(), "claude", "count-limited-model", []byte(`{}`), ""); errMsg != nil && errMsg.StatusCode == http.StatusTooManyRequests {
+		t.Fatalf("first count request was rate limited: %v", errMsg.Error)
+	}
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "count-limited-model", []byte(`{}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("generation after count: expected 429, got %+v", errMsg)
+	}
+	_, errMsg = handler.ExecuteCountWithAuthManager(context.Background(), "claude", "count-limited-model", []byte(`{}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("count past the limit: expected 429, got %+v", errMsg)
+	}
+	if retryAfter := errMsg.Addon.Get("Retry-After"); retryAfter == "" || retryAfter == "0" {
+		t.Fatalf("Retry-After = %q, want a positive number of seconds", retryAfter)
+	}
+}
+
+func TestModelRateLimiter_Refills(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := &modelRateLimiter{buckets: make(map[string]*modelTokenBucket), now: func() time.Time { return now }}
+
+	if ok, _ := limiter.allow("m", 1); !ok {
+		t.Fatal("first request must pass")
+	}
+	ok, wait := limiter.allow("m", 1)
+	if ok || wait != time.Minute {
+		t.Fatalf("second request: ok=%v wait=%v, want rejection with 1m wait", ok, wait)
+	}
+	now = now.Add(30 * time.Second)
+	if ok, wait = limiter.allow("m", 1); ok || wait != 30*time.Second {
+		t.Fatalf("half refilled: ok=%v wait=%v, want rejection with 30s wait", ok, wait)
+	}
+	now = now.Add(30 * time.Second)
+	if ok, _ = limiter.allow("m", 1); !ok {
+		t.Fatal("request after a full refill must pass")
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// CacheHeader reports whether a response came from the response cache ("HIT") or was
+// fetched upstream and stored ("MISS").
+const CacheHeader = "X-CLIProxy-Cache"
+
+const (
+	defaultResponseCacheTTL        = 300 * time.Second
+	defaultResponseCacheMaxEntries = 1000
+)
+
+type responseCacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// responseCache is an LRU of response bodies with a per-entry expiry. It is shared across
+// handler instances so cached responses survive configuration reloads.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+var sharedResponseCache = &responseCache{entries: make(map[string]*list.Element), order: list.New(), now: time.Now}
+
+// get returns the body cached under key unless it has expired.
+func (c *responseCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.body, true
+}
+
+// put stores body under key for ttl, evicting the least recently used entries beyond
+// maxEntries.
+func (c *responseCache) put(key string, body []byte, ttl time.Duration, maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*responseCacheEntry)
+		entry.body, entry.expires = body, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&responseCacheEntry{key: key, body: body, expires: expires})
+	for c.order.Len() > maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*responseCacheEntry).key)
+	}
+}
+
+// responseCacheKey returns the cache key for a non-streaming request, or false when the
+// cache is off or the request is not deterministic: temperature must be 0 and no tools
+// may be declared. The key is scoped to the authenticated client so one client is never
+// served a response produced for another.
+func (h *BaseAPIHandler) responseCacheKey(ctx context.Context, handlerType, alt, model string, rawJSON []byte) (string, bool) {
+	if h == nil || h.Cfg == nil || !h.Cfg.ResponseCache.Enabled {
+		return "", false
+	}
+	root := gjson.ParseBytes(rawJSON)
+	temperature := root.Get("temperature")
+	if !temperature.Exists() {
+		temperature = root.Get("generationConfig.temperature")
+	}
+	if temperature.Type != gjson.Number || temperature.Float() != 0 {
+		return "", false
+	}
+	for _, field := range []string{"tools", "functions"} {
+		if tools := root.Get(field); tools.IsArray() && len(tools.Array()) > 0 {
+			return "", false
+		}
+	}
+	// Re-encoding sorts object keys, so equivalent payloads share a key.
+	var payload any
+	if err := json.Unmarshal(rawJSON, &payload); err != nil {
+		return "", false
+	}
+	normalized, err := json.Marshal(payload)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.New()
+	sum.Write([]byte(responseCachePrincipal(ctx) + "\n" + handlerType + "\n" + alt + "\n" + model + "\n"))
+	sum.Write(normalized)
+	return hex.EncodeToString(sum.Sum(nil)), true
+}
+
+// responseCachePrincipal identifies the client that authenticated the request, combining
+// the access provider with its principal (the client API key for config-api-key access).
+func responseCachePrincipal(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	var provider, principal string
+	if v, exists := ginCtx.Get("accessProvider"); exists {
+		provider = fmt.Sprint(v)
+	}
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		principal = fmt.Sprint(v)
+	}
+	return provider + "\x00" + principal
+}
+
+// responseCacheLimits returns the configured TTL and size, applying defaults.
+func (h *BaseAPIHandler) responseCacheLimits() (time.Duration, int) {
+	ttl, maxEntries := defaultResponseCacheTTL, defaultResponseCacheMaxEntries
+	if seconds := h.Cfg.ResponseCache.TTLSeconds; seconds > 0 {
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if h.Cfg.ResponseCache.MaxEntries > 0 {
+		maxEntries = h.Cfg.ResponseCache.MaxEntries
+	}
+	return ttl, maxEntries
+}
+
+func setCacheHeader(ctx context.Context, value string) {
+	if ctx == nil {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(CacheHeader, value)
+	}
+}
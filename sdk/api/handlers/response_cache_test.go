@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// useTestResponseCache swaps in an empty response cache driven by the returned clock.
+func useTestResponseCache(t *testing.T) *time.Time {
+	t.Helper()
+	now := time.Unix(0, 0)
+	previous := sharedResponseCache
+	sharedResponseCache = &responseCache{entries: make(map[string]*list.Element), order: list.New(), now: func() time.Time { return now }}
+	t.Cleanup(func() { sharedResponseCache = previous })
+	return &now
+}
+
+func newResponseCacheHandler(t *testing.T, executor *countingExecutor, cfg sdkconfig.ResponseCacheConfig) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "response-cache-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "cache-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{ResponseCache: cfg}, manager)
+}
+
+// executeCached runs a non-streaming chat request and returns the response cache header.
+func executeCached(t *testing.T, h *BaseAPIHandler, body string) string {
+	t.Helper()
+	return executeCachedAs(t, h, "", body)
+}
+
+// executeCachedAs is executeCached for a request authenticated with apiKey.
+func executeCachedAs(t *testing.T, h *BaseAPIHandler, apiKey, body string) string {
+	t.Helper()
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if apiKey != "" {
+		ginCtx.Set("apiKey", apiKey)
+		ginCtx.Set("accessProvider", "config-api-key")
+	}
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	if _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "cache-model", []byte(body), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	return ginCtx.Writer.Header().Get(CacheHeader)
+}
+
+func TestResponseCache_Hit(t *testing.T) {
+	useTestResponseCache(t)
+	executor := &countingExecutor{}
+	h := newResponseCacheHandler(t, executor, sdkconfig.ResponseCacheConfig{Enabled: true})
+
+	if got := executeCached(t, h, `{"model":"cache-model","temperature":0,"messages":[{"role":"user","content":"hi"}]}`); got != "MISS" {
+		t.Fatalf("first request %s = %q, want MISS", CacheHeader, got)
+	}
+	// Key order does not matter.
+	if got := executeCached(t, h, `{"messages":[{"content":"hi","role":"user"}],"temperature":0,"model":"cache-model"}`); got != "HIT" {
+		t.Fatalf("repeated request %s = %q, want HIT", CacheHeader, got)
+	}
+	if executor.calls != 1 {
+		t.Fatalf("upstream calls = %d, want 1", executor.calls)
+	}
+
+	if got := executeCached(t, h, `{"model":"cache-model","temperature":0,"messages":[{"role":"user","content":"other"}]}`); got != "MISS" {
+		t.Fatalf("different prompt %s = %q, want MISS", CacheHeader, got)
+	}
+}
+
+func TestResponseCache_ScopedToClient(t *testing.T) {
+	useTestResponseCache(t)
+	executor := &countingExecutor{}
+	h := newResponseCacheHandler(t, executor, sdkconfig.ResponseCacheConfig{Enabled: true})
+	body := `{"model":"cache-model","temperature":0,"messages":[{"role":"user","content":"hi"}]}`
+
+	if got := executeCachedAs(t, h, "tenant-a", body); got != "MISS" {
+		t.Fatalf("tenant-a first request %s = %q, want MISS", CacheHeader, got)
+	}
+	if got := executeCachedAs(t, h, "tenant-b", body); got != "MISS" {
+		t.Fatalf("tenant-b request %s = %q, want MISS", CacheHeader, got)
+	}
+	if got := executeCachedAs(t, h, "tenant-a", body); got != "HIT" {
+		t.Fatalf("tenant-a repeated request %s = %q, want HIT", CacheHeader, got)
+	}
+	if executor.calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", executor.calls)
+	}
+}
+
+func TestResponseCache_SkipsNonDeterministicRequests(t *testing.T) {
+	useTestResponseCache(t)
+	executor := &countingExecutor{}
+	h := newResponseCacheHandler(t, executor, sdkconfig.ResponseCacheConfig{Enabled: true})
+
+	for _, body := range []string{
+		`{"model":"cache-model","temperature":0.7,"messages":[{"role":"user","content":"hi"}]}`,
+		`{"model":"cache-model","messages":[{"role":"user","content":"hi"}]}`,
+		`{"model":"cache-model","temperature":0,"tools":[{"type":"function","function":{"name":"f"}}],"messages":[{"role":"user","content":"hi"}]}`,
+	} {
+		executor.calls = 0
+		for i := 0; i < 2; i++ {
+			if got := executeCached(t, h, body); got != "" {
+				t.Fatalf("%s = %q for an uncacheable request: %s", CacheHeader, got, body)
+			}
+		}
+		if executor.calls != 2 {
+			t.Fatalf("upstream calls = %d, want 2 for %s", executor.calls, body)
+		}
+	}
+
+	disabled := newResponseCacheHandler(t, executor, sdkconfig.ResponseCacheConfig{})
+	if got := executeCached(t, disabled, `{"model":"cache-model","temperature":0,"messages":[]}`); got != "" {
+		t.Fatalf("%s = %q while the cache is disabled", CacheHeader, got)
+	}
+}
+
+func TestResponseCache_TTLExpiry(t *testing.T) {
+	now := useTestResponseCache(t)
+	executor := &countingExecutor{}
+	h := newResponseCacheHandler(t, executor, sdkconfig.ResponseCacheConfig{Enabled: true, TTLSeconds: 60})
+	body := `{"model":"cache-model","temperature":0,"messages":[{"role":"user","content":"hi"}]}`
+
+	executeCached(t, h, body)
+	*now = now.Add(59 * time.Second)
+	if got := executeCached(t, h, body); got != "HIT" {
+		t.Fatalf("%s = %q before the TTL, want HIT", CacheHeader, got)
+	}
+	*now = now.Add(time.Second)
+	if got := executeCached(t, h, body); got != "MISS" {
+		t.Fatalf("%s = %q after the TTL, want MISS", CacheHeader, got)
+	}
+	if executor.calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", executor.calls)
+	}
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	useTestResponseCache(t)
+	cache := sharedResponseCache
+	cache.put("a", []byte("1"), time.Minute, 2)
+	cache.put("b", []byte("2"), time.Minute, 2)
+	cache.get("a")
+	cache.put("c", []byte("3"), time.Minute, 2)
+
+	if _, ok := cache.get("b"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Fatalf("entry %q evicted", key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// StreamPinger emits SSE ping comments (": ping\n\n") while a streaming handler waits for
+// the first upstream chunk. C is nil when pings are disabled, so selecting on it never fires.
+type StreamPinger struct {
+	C       <-chan time.Time
+	ticker  *time.Ticker
+	started bool
+}
+
+// NewStreamPinger returns a pinger using the configured streaming ping interval.
+func (h *BaseAPIHandler) NewStreamPinger() *StreamPinger {
+	if h == nil {
+		return newStreamPinger(0)
+	}
+	return newStreamPinger(StreamingPingInterval(h.Cfg))
+}
+
+func newStreamPinger(interval time.Duration) *StreamPinger {
+	p := &StreamPinger{}
+	if interval > 0 {
+		p.ticker = time.NewTicker(interval)
+		p.C = p.ticker.C
+	}
+	return p
+}
+
+// Ping commits the SSE headers on the first call and writes one ping comment.
+func (p *StreamPinger) Ping(c *gin.Context, flusher http.Flusher, setHeaders func()) {
+	if !p.started {
+		p.started = true
+		if setHeaders != nil {
+			setHeaders()
+		}
+	}
+	_, _ = c.Writer.Write([]byte(": ping\n\n"))
+	flusher.Flush()
+}
+
+// Started reports whether a ping was written, meaning the response status and headers are
+// already committed and errors must be reported inside the stream.
+func (p *StreamPinger) Started() bool {
+	return p != nil && p.started
+}
+
+// Stop ends pinging. It is safe to call more than once.
+func (p *StreamPinger) Stop() {
+	if p == nil || p.ticker == nil {
+		return
+	}
+	p.ticker.Stop()
+	p.ticker = nil
+	p.C = nil
+}
+
+// PendingError returns a closed channel carrying errMsg, so an error received before the
+// first chunk can be handed to ForwardStream once pings have committed the stream.
+func PendingError(errMsg *interfaces.ErrorMessage) <-chan *interfaces.ErrorMessage {
+	ch := make(chan *interfaces.ErrorMessage, 1)
+	ch <- errMsg
+	close(ch)
+	return ch
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// stripReasoningForRoute reports whether StripReasoningFromResponse lists the path of the
+// client request carried by ctx.
+func (h *BaseAPIHandler) stripReasoningForRoute(ctx context.Context) bool {
+	if h == nil || h.Cfg == nil || len(h.Cfg.StripReasoningFromResponse) == 0 || ctx == nil {
+		return false
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil || ginCtx.Request.URL == nil {
+		return false
+	}
+	path := strings.TrimSuffix(ginCtx.Request.URL.Path, "/")
+	for _, route := range h.Cfg.StripReasoningFromResponse {
+		if strings.TrimSuffix(strings.TrimSpace(route), "/") == path {
+			return true
+		}
+	}
+	return false
+}
+
+// stripReasoningFromResponse removes reasoning from a non-streaming client response:
+// reasoning_content from Chat Completions messages and reasoning items from Responses output.
+func stripReasoningFromResponse(handlerType string, payload []byte) []byte {
+	switch handlerType {
+	case constant.OpenAI:
+		return stripChatReasoning(payload, "message")
+	case constant.OpenaiResponse:
+		return stripResponsesReasoningItems(payload, "output")
+	default:
+		return payload
+	}
+}
+
+// reasoningStreamStripper removes reasoning from the chunks of one client stream. It keeps
+// the state needed across chunks, so a new one is used for every upstream attempt.
+type reasoningStreamStripper struct {
+	handlerType string
+	// roleSent records that a chat chunk carrying the assistant role was forwarded.
+	roleSent bool
+	// dropped lists the output_index values of removed Responses reasoning items.
+	dropped []int64
+}
+
+func newReasoningStreamStripper(handlerType string) *reasoningStreamStripper {
+	return &reasoningStreamStripper{handlerType: handlerType}
+}
+
+// strip removes reasoning from one streaming chunk. It returns nil when the whole chunk
+// only carried reasoning and should not be forwarded.
+func (s *reasoningStreamStripper) strip(chunk []byte) []byte {
+	switch s.handlerType {
+	case constant.OpenAI:
+		return s.stripChatChunk(chunk)
+	case constant.OpenaiResponse:
+		return s.stripResponsesEvent(chunk)
+	default:
+		return chunk
+	}
+}
+
+// stripChatChunk drops reasoning deltas. A chunk left without content is dropped too,
+// unless it is the first to announce the assistant role.
+func (s *reasoningStreamStripper) stripChatChunk(chunk []byte) []byte {
+	stripped := stripChatReasoning(chunk, "delta")
+	roles := gjson.GetBytes(stripped, "choices.#.delta.role").Array()
+	if !bytes.Equal(stripped, chunk) && chatChunkIsEmpty(stripped) && (s.roleSent || len(roles) == 0) {
+		return nil
+	}
+	if len(roles) > 0 {
+		s.roleSent = true
+	}
+	return stripped
+}
+
+// chatChunkIsEmpty reports whether a chat chunk carries nothing but empty deltas: no usage,
+// no finish_reason and no delta field other than the role with a non-empty value.
+func chatChunkIsEmpty(chunk []byte) bool {
+	if usage := gjson.GetBytes(chunk, "usage"); usage.Exists() && usage.Type != gjson.Null {
+		return false
+	}
+	empty := true
+	gjson.GetBytes(chunk, "choices").ForEach(func(_, choice gjson.Result) bool {
+		if finish := choice.Get("finish_reason"); finish.Exists() && finish.Type != gjson.Null {
+			empty = false
+			return false
+		}
+		choice.Get("delta").ForEach(func(key, value gjson.Result) bool {
+			if key.String() == "role" || value.Type == gjson.Null || (value.Type == gjson.String && value.String() == "") {
+				return true
+			}
+			empty = false
+			return false
+		})
+		return empty
+	})
+	return empty
+}
+
+// chatReasoningFields are the reasoning fields upstreams attach to Chat Completions messages
+// and deltas; Copilot sends reasoning_text and reasoning_opaque instead of reasoning_content.
+var chatReasoningFields = []string{"reasoning_content", "reasoning_text", "reasoning_opaque"}
+
+// stripChatReasoning deletes the chatReasoningFields of choices[].<field>.
+func stripChatReasoning(payload []byte, field string) []byte {
+	choices := gjson.GetBytes(payload, "choices")
+	if !choices.IsArray() {
+		return payload
+	}
+	for i, choice := range choices.Array() {
+		for _, name := range chatReasoningFields {
+			if !choice.Get(field + "." + name).Exists() {
+				continue
+			}
+			path := "choices." + strconv.Itoa(i) + "." + field + "." + name
+			if updated, err := sjson.DeleteBytes(payload, path); err == nil {
+				payload = updated
+			}
+		}
+	}
+	return payload
+}
+
+// stripResponsesReasoningItems drops items of type "reasoning" from the output array at path.
+func stripResponsesReasoningItems(payload []byte, path string) []byte {
+	output := gjson.GetBytes(payload, path)
+	if !output.IsArray() {
+		return payload
+	}
+	kept := make([]string, 0, len(output.Array()))
+	removed := false
+	for _, item := range output.Array() {
+		if item.Get("type").String() == "reasoning" {
+			removed = true
+			continue
+		}
+		kept = append(kept, item.Raw)
+	}
+	if !removed {
+		return payload
+	}
+	updated, err := sjson.SetRawBytes(payload, path, []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return payload
+	}
+	return updated
+}
+
+// stripResponsesEvent filters one Responses SSE event ("event: ...\ndata: {...}").
+// Reasoning events and output_item events for reasoning items are dropped, reasoning items
+// are removed from the response snapshot carried by lifecycle events, and the output_index
+// of later items is shifted down so the indices the client sees have no gaps.
+func (s *reasoningStreamStripper) stripResponsesEvent(chunk []byte) []byte {
+	lines := bytes.Split(chunk, []byte("\n"))
+	for i, line := range lines {
+		data, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r"), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		eventType := gjson.GetBytes(data, "type").String()
+		switch {
+		case strings.HasPrefix(eventType, "response.reasoning"):
+			return nil
+		case eventType == "response.output_item.added" || eventType == "response.output_item.done":
+			if gjson.GetBytes(data, "item.type").String() == "reasoning" {
+				s.dropOutputIndex(gjson.GetBytes(data, "output_index"))
+				return nil
+			}
+		}
+		changed := false
+		if gjson.GetBytes(data, "response.output").IsArray() {
+			data = stripResponsesReasoningItems(data, "response.output")
+			changed = true
+		}
+		if index := gjson.GetBytes(data, "output_index"); index.Exists() {
+			if shifted := s.shiftOutputIndex(index.Int()); shifted != index.Int() {
+				if updated, err := sjson.SetBytes(data, "output_index", shifted); err == nil {
+					data = updated
+					changed = true
+				}
+			}
+		}
+		if changed {
+			lines[i] = append([]byte("data: "), data...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// dropOutputIndex records the output_index of a removed reasoning item.
+func (s *reasoningStreamStripper) dropOutputIndex(index gjson.Result) {
+	if !index.Exists() {
+		return
+	}
+	for _, dropped := range s.dropped {
+		if dropped == index.Int() {
+			return
+		}
+	}
+	s.dropped = append(s.dropped, index.Int())
+}
+
+// shiftOutputIndex maps an upstream output_index to its position once the removed
+// reasoning items are gone.
+func (s *reasoningStreamStripper) shiftOutputIndex(index int64) int64 {
+	shifted := index
+	for _, dropped := range s.dropped {
+		if dropped < index {
+			shifted--
+		}
+	}
+	return shifted
+}
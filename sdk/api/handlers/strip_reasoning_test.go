@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// fixedResponseExecutor returns a canned non-streaming payload and stream chunks.
+type fixedResponseExecutor struct {
+	payload string
+	chunks  []string
+}
+
+func (e *fixedResponseExecutor) Identifier() string { return "codex" }
+
+func (e *fixedResponseExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: []byte(e.payload)}, nil
+}
+
+func (e *fixedResponseExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk, len(e.chunks))
+	for _, chunk := range e.chunks {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (e *fixedResponseExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *fixedResponseExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *fixedResponseExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func newStripReasoningHandler(t *testing.T, executor *fixedResponseExecutor, routes ...string) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "strip-reasoning-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "strip-reasoning-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{StripReasoningFromResponse: routes}, manager)
+}
+
+func routeContext(path string) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, path, nil)
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func collectStream(t *testing.T, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) []string {
+	t.Helper()
+	var out []string
+	for chunk := range data {
+		out = append(out, string(chunk))
+	}
+	for errMsg := range errs {
+		if errMsg != nil {
+			t.Fatalf("stream error: %+v", errMsg)
+		}
+	}
+	return out
+}
+
+func TestStripReasoningFromResponse_NonStream(t *testing.T) {
+	chat := &fixedResponseExecutor{payload: `{"choices":[{"index":0,"message":{"role":"assistant","content":"hi","reasoning_content":"thinking"}}]}`}
+	h := newStripReasoningHandler(t, chat, "/v1/chat/completions")
+
+	resp, errMsg := h.ExecuteWithAuthManager(routeContext("/v1/chat/completions"), "openai", "strip-reasoning-model", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if gjson.GetBytes(resp, "choices.0.message.reasoning_content").Exists() || gjson.GetBytes(resp, "choices.0.message.content").String() != "hi" {
+		t.Fatalf("unexpected chat response: %s", resp)
+	}
+
+	// Routes that are not listed keep reasoning.
+	resp, _ = h.ExecuteWithAuthManager(routeContext("/v1/messages"), "openai", "strip-reasoning-model", []byte(`{}`), "")
+	if !gjson.GetBytes(resp, "choices.0.message.reasoning_content").Exists() {
+		t.Fatalf("reasoning removed on an unlisted route: %s", resp)
+	}
+
+	responses := &fixedResponseExecutor{payload: `{"object":"response","output":[{"type":"reasoning","id":"rs_1","summary":[]},{"type":"message","id":"msg_1","content":[{"type":"output_text","text":"hi"}]}]}`}
+	h = newStripReasoningHandler(t, responses, "/v1/responses")
+	resp, errMsg = h.ExecuteWithAuthManager(routeContext("/v1/responses"), "openai-response", "strip-reasoning-model", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	output := gjson.GetBytes(resp, "output").Array()
+	if len(output) != 1 || output[0].Get("type").String() != "message" {
+		t.Fatalf("unexpected responses output: %s", resp)
+	}
+}
+
+func TestStripReasoningFromResponse_Stream(t *testing.T) {
+	chat := &fixedResponseExecutor{chunks: []string{
+		`{"choices":[{"index":0,"delta":{"reasoning_content":"thinking"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"hi"}}]}`,
+	}}
+	h := newStripReasoningHandler(t, chat, "/v1/chat/completions")
+	data, errs := h.ExecuteStreamWithAuthManager(routeContext("/v1/chat/completions"), "openai", "strip-reasoning-model", []byte(`{}`), "")
+	chunks := collectStream(t, data, errs)
+	if len(chunks) != 1 || gjson.Get(chunks[0], "choices.0.delta.content").String() != "hi" {
+		t.Fatalf("unexpected chat chunks: %v", chunks)
+	}
+
+	responses := &fixedResponseExecutor{chunks: []string{
+		"event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"output_index\":0,\"item\":{\"type\":\"reasoning\",\"id\":\"rs_1\"}}",
+		"event: response.reasoning_summary_text.delta\ndata: {\"type\":\"response.reasoning_summary_text.delta\",\"delta\":\"thinking\"}",
+		"event: response.output_item.done\ndata: {\"type\":\"response.output_item.done\",\"output_index\":0,\"item\":{\"type\":\"reasoning\",\"id\":\"rs_1\"}}",
+		"event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"output_index\":1,\"delta\":\"hi\"}",
+		"event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"output\":[{\"type\":\"reasoning\",\"id\":\"rs_1\"},{\"type\":\"message\",\"id\":\"msg_1\"}]}}",
+	}}
+	h = newStripReasoningHandler(t, responses, "/v1/responses")
+	data, errs = h.ExecuteStreamWithAuthManager(routeContext("/v1/responses"), "openai-response", "strip-reasoning-model", []byte(`{}`), "")
+	chunks = collectStream(t, data, errs)
+	if len(chunks) != 2 {
+		t.Fatalf("expected the text delta and completed events, got %v", chunks)
+	}
+	if !strings.HasPrefix(chunks[0], "event: response.output_text.delta\n") {
+		t.Fatalf("unexpected first event: %s", chunks[0])
+	}
+	completed := strings.TrimPrefix(strings.SplitN(chunks[1], "\n", 2)[1], "data: ")
+	output := gjson.Get(completed, "response.output").Array()
+	if len(output) != 1 || output[0].Get("type").String() != "message" {
+		t.Fatalf("reasoning left in completed response: %s", chunks[1])
+	}
+}
+
+func TestStripReasoningFromResponse_CopilotReasoningFields(t *testing.T) {
+	chat := &fixedResponseExecutor{payload: `{"choices":[{"index":0,"message":{"role":"assistant","content":"hi","reasoning_text":"thinking","reasoning_opaque":"enc"}}]}`}
+	h := newStripReasoningHandler(t, chat, "/v1/chat/completions")
+
+	resp, errMsg := h.ExecuteWithAuthManager(routeContext("/v1/chat/completions"), "openai", "strip-reasoning-model", []byte(`{}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	message := gjson.GetBytes(resp, "choices.0.message")
+	if message.Get("reasoning_text").Exists() || message.Get("reasoning_opaque").Exists() || message.Get("content").String() != "hi" {
+		t.Fatalf("unexpected chat response: %s", resp)
+	}
+}
+
+func TestReasoningStreamStripper_DropsReasoningOnlyChatChunks(t *testing.T) {
+	stripper := newReasoningStreamStripper("openai")
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":null,"reasoning_text":"step one"}}]}`,
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":null,"reasoning_text":"step two"}}]}`,
+		`{"choices":[{"index":0,"delta":{"reasoning_opaque":"enc"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"hi"}}]}`,
+		`{"choices":[{"index":0,"delta":{"reasoning_content":""},"finish_reason":"stop"}]}`,
+	}
+	var out []string
+	for _, chunk := range chunks {
+		if stripped := stripper.strip([]byte(chunk)); stripped != nil {
+			out = append(out, string(stripped))
+		}
+	}
+
+	if len(out) != 3 {
+		t.Fatalf("forwarded chunks = %v, want the role, content and finish chunks", out)
+	}
+	if gjson.Get(out[0], "choices.0.delta.role").String() != "assistant" || gjson.Get(out[0], "choices.0.delta.reasoning_text").Exists() {
+		t.Fatalf("unexpected role chunk: %s", out[0])
+	}
+	if gjson.Get(out[1], "choices.0.delta.content").String() != "hi" {
+		t.Fatalf("unexpected content chunk: %s", out[1])
+	}
+	if gjson.Get(out[2], "choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("unexpected finish chunk: %s", out[2])
+	}
+}
+
+func TestReasoningStreamStripper_ReindexesResponsesOutput(t *testing.T) {
+	stripper := newReasoningStreamStripper("openai-response")
+	events := []string{
+		"event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"output_index\":0,\"item\":{\"type\":\"reasoning\",\"id\":\"rs_1\"}}",
+		"event: response.output_item.done\ndata: {\"type\":\"response.output_item.done\",\"output_index\":0,\"item\":{\"type\":\"reasoning\",\"id\":\"rs_1\"}}",
+		"event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"output_index\":1,\"item\":{\"type\":\"function_call\",\"id\":\"fc_1\"}}",
+		"event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"output_index\":2,\"item\":{\"type\":\"reasoning\",\"id\":\"rs_2\"}}",
+		"event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"output_index\":3,\"item\":{\"type\":\"message\",\"id\":\"msg_1\"}}",
+		"event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"output_index\":3,\"delta\":\"hi\"}",
+	}
+	var indices []int64
+	for _, event := range events {
+		stripped := stripper.strip([]byte(event))
+		if stripped == nil {
+			continue
+		}
+		data := strings.TrimPrefix(strings.SplitN(string(stripped), "\n", 2)[1], "data: ")
+		indices = append(indices, gjson.Get(data, "output_index").Int())
+	}
+
+	want := []int64{0, 1, 1}
+	if len(indices) != len(want) {
+		t.Fatalf("output indices = %v, want %v", indices, want)
+	}
+	for i := range want {
+		if indices[i] != want[i] {
+			t.Fatalf("output indices = %v, want %v", indices, want)
+		}
+	}
+}
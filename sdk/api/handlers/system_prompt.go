@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applySystemPromptPrefix prepends the configured SystemPromptPrefix to the leading system
+// prompt of Chat Completions and Responses requests before they are translated. Requests
+// whose system prompt already starts with the prefix are returned unchanged.
+func (h *BaseAPIHandler) applySystemPromptPrefix(handlerType string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil {
+		return rawJSON
+	}
+	prefix := strings.TrimSpace(h.Cfg.SystemPromptPrefix)
+	if prefix == "" {
+		return rawJSON
+	}
+	switch handlerType {
+	case constant.OpenAI:
+		return prefixChatSystemPrompt(rawJSON, prefix)
+	case constant.OpenaiResponse:
+		return prefixResponsesSystemPrompt(rawJSON, prefix)
+	default:
+		return rawJSON
+	}
+}
+
+// prefixChatSystemPrompt merges prefix into a leading system/developer message or inserts a
+// new system message at the start of messages.
+func prefixChatSystemPrompt(rawJSON []byte, prefix string) []byte {
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+	first := messages.Get("0")
+	if isSystemRole(first.Get("role").String()) {
+		return prefixMessageContent(rawJSON, "messages.0.content", first.Get("content"), prefix, "text")
+	}
+	entries := make([]string, 0, len(messages.Array())+1)
+	system, _ := sjson.Set(`{"role":"system"}`, "content", prefix)
+	entries = append(entries, system)
+	for _, msg := range messages.Array() {
+		entries = append(entries, msg.Raw)
+	}
+	updated, err := sjson.SetRawBytes(rawJSON, "messages", []byte("["+strings.Join(entries, ",")+"]"))
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+// prefixResponsesSystemPrompt merges prefix into instructions, or into a leading system input
+// item when instructions are absent, and otherwise sets instructions to prefix.
+func prefixResponsesSystemPrompt(rawJSON []byte, prefix string) []byte {
+	instructions := gjson.GetBytes(rawJSON, "instructions")
+	if instructions.Type == gjson.String && instructions.String() != "" {
+		return prefixMessageContent(rawJSON, "instructions", instructions, prefix, "input_text")
+	}
+	first := gjson.GetBytes(rawJSON, "input.0")
+	if first.Exists() && isSystemRole(first.Get("role").String()) {
+		return prefixMessageContent(rawJSON, "input.0.content", first.Get("content"), prefix, "input_text")
+	}
+	updated, err := sjson.SetBytes(rawJSON, "instructions", prefix)
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+// prefixMessageContent prepends prefix to string content or inserts a text part of partType
+// ahead of array content.
+func prefixMessageContent(rawJSON []byte, path string, content gjson.Result, prefix, partType string) []byte {
+	var (
+		updated []byte
+		err     error
+	)
+	switch {
+	case content.Type == gjson.String:
+		text := content.String()
+		if strings.HasPrefix(text, prefix) {
+			return rawJSON
+		}
+		if text != "" {
+			text = prefix + "\n\n" + text
+		} else {
+			text = prefix
+		}
+		updated, err = sjson.SetBytes(rawJSON, path, text)
+	case content.IsArray():
+		parts := content.Array()
+		if len(parts) > 0 && strings.HasPrefix(parts[0].Get("text").String(), prefix) {
+			return rawJSON
+		}
+		part, _ := sjson.Set(`{"type":"`+partType+`"}`, "text", prefix)
+		entries := make([]string, 0, len(parts)+1)
+		entries = append(entries, part)
+		for _, p := range parts {
+			entries = append(entries, p.Raw)
+		}
+		updated, err = sjson.SetRawBytes(rawJSON, path, []byte("["+strings.Join(entries, ",")+"]"))
+	default:
+		updated, err = sjson.SetBytes(rawJSON, path, prefix)
+	}
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+func isSystemRole(role string) bool {
+	return role == "system" || role == "developer"
+}
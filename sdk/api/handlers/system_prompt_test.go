@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+const testSystemPromptPrefix = "House rules apply."
+
+func newSystemPromptHandler() *BaseAPIHandler {
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{SystemPromptPrefix: testSystemPromptPrefix}, nil)
+}
+
+func TestApplySystemPromptPrefix_ChatCompletions(t *testing.T) {
+	h := newSystemPromptHandler()
+	tests := []struct {
+		name        string
+		body        string
+		wantPath    string
+		want        string
+		wantMessage int
+	}{
+		{
+			name:        "inserts system message",
+			body:        `{"messages":[{"role":"user","content":"hi"}]}`,
+			wantPath:    "messages.0.content",
+			want:        testSystemPromptPrefix,
+			wantMessage: 2,
+		},
+		{
+			name:        "merges into string system message",
+			body:        `{"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"hi"}]}`,
+			wantPath:    "messages.0.content",
+			want:        testSystemPromptPrefix + "\n\nBe brief.",
+			wantMessage: 2,
+		},
+		{
+			name:        "prepends text part to array content",
+			body:        `{"messages":[{"role":"developer","content":[{"type":"text","text":"Be brief."}]},{"role":"user","content":"hi"}]}`,
+			wantPath:    "messages.0.content.0.text",
+			want:        testSystemPromptPrefix,
+			wantMessage: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := h.applySystemPromptPrefix("openai", []byte(tt.body))
+			if got := gjson.GetBytes(out, tt.wantPath).String(); got != tt.want {
+				t.Fatalf("%s = %q, want %q; body = %s", tt.wantPath, got, tt.want, out)
+			}
+			if got := len(gjson.GetBytes(out, "messages").Array()); got != tt.wantMessage {
+				t.Fatalf("messages = %d, want %d", got, tt.wantMessage)
+			}
+			if role := gjson.GetBytes(out, "messages.0.role").String(); role != "system" && role != "developer" {
+				t.Fatalf("leading role = %q, want system", role)
+			}
+		})
+	}
+}
+
+func TestApplySystemPromptPrefix_Responses(t *testing.T) {
+	h := newSystemPromptHandler()
+
+	out := h.applySystemPromptPrefix("openai-response", []byte(`{"instructions":"Be brief.","input":"hi"}`))
+	if got := gjson.GetBytes(out, "instructions").String(); got != testSystemPromptPrefix+"\n\nBe brief." {
+		t.Fatalf("instructions = %q", got)
+	}
+
+	out = h.applySystemPromptPrefix("openai-response", []byte(`{"input":[{"role":"system","content":[{"type":"input_text","text":"Be brief."}]},{"role":"user","content":"hi"}]}`))
+	if got := gjson.GetBytes(out, "input.0.content.0"); got.Get("type").String() != "input_text" || got.Get("text").String() != testSystemPromptPrefix {
+		t.Fatalf("leading system part = %s", got.Raw)
+	}
+	if gjson.GetBytes(out, "instructions").Exists() {
+		t.Fatalf("instructions must not be added when a system input item is present: %s", out)
+	}
+
+	out = h.applySystemPromptPrefix("openai-response", []byte(`{"input":[{"role":"user","content":"hi"}]}`))
+	if got := gjson.GetBytes(out, "instructions").String(); got != testSystemPromptPrefix {
+		t.Fatalf("instructions = %q, want prefix", got)
+	}
+}
+
+func TestApplySystemPromptPrefix_Idempotent(t *testing.T) {
+	h := newSystemPromptHandler()
+	bodies := map[string]string{
+		"openai":          `{"messages":[{"role":"system","content":"House rules apply.\n\nBe brief."},{"role":"user","content":"hi"}]}`,
+		"openai-response": `{"instructions":"House rules apply.","input":"hi"}`,
+	}
+	for handlerType, body := range bodies {
+		once := h.applySystemPromptPrefix(handlerType, []byte(body))
+		if string(once) != body {
+			t.Fatalf("%s: body with existing prefix changed: %s", handlerType, once)
+		}
+	}
+
+	first := h.applySystemPromptPrefix("openai", []byte(`{"messages":[{"role":"user","content":"hi"}]}`))
+	second := h.applySystemPromptPrefix("openai", first)
+	if string(first) != string(second) {
+		t.Fatalf("second application changed the body:\n%s\n%s", first, second)
+	}
+}
+
+func TestApplySystemPromptPrefix_IgnoresOtherFormats(t *testing.T) {
+	h := newSystemPromptHandler()
+	body := `{"messages":[{"role":"user","content":"hi"}]}`
+	if out := h.applySystemPromptPrefix("claude", []byte(body)); string(out) != body {
+		t.Fatalf("claude body changed: %s", out)
+	}
+}
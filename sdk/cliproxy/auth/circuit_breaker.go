@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultCircuitCooldown is used when a breaker threshold is set without a cooldown.
+const defaultCircuitCooldown = 30 * time.Second
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitRecorder receives the kind of each circuit breaker transition that opens a circuit.
+var circuitRecorder atomic.Value
+
+// SetCircuitRecorder installs fn to observe provider circuits opening, keyed by kind
+// ("circuit_open"). The metrics module installs its error counter here.
+func SetCircuitRecorder(fn func(kind string)) {
+	circuitRecorder.Store(fn)
+}
+
+func recordCircuit(kind string) {
+	if fn, ok := circuitRecorder.Load().(func(string)); ok && fn != nil {
+		fn(kind)
+	}
+}
+
+// providerCircuit is the breaker state of one provider.
+type providerCircuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+	// probing is set while the single half-open trial request is in flight.
+	probing bool
+}
+
+// circuitBreaker short-circuits requests to providers whose upstream keeps failing.
+// A provider's circuit opens after threshold consecutive failed requests, rejects
+// requests for cooldown, then lets one trial request through: success closes the
+// circuit again, failure re-opens it for another cooldown. A request is judged once,
+// after credential rotation, so one failing credential does not open the circuit while
+// another credential of the provider still serves the request.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*providerCircuit
+	now       func() time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{circuits: make(map[string]*providerCircuit), now: time.Now}
+}
+
+// configure updates the threshold and cooldown. A threshold of zero disables the
+// breaker and resets every circuit.
+func (b *circuitBreaker) configure(threshold int, cooldown time.Duration) {
+	if threshold < 0 {
+		threshold = 0
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCircuitCooldown
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+	b.cooldown = cooldown
+	if threshold == 0 {
+		b.circuits = make(map[string]*providerCircuit)
+	}
+}
+
+// allow reports whether a request to provider may proceed. When the cooldown of an
+// open circuit has elapsed the caller becomes the half-open trial request.
+func (b *circuitBreaker) allow(provider string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 {
+		return true
+	}
+	c := b.circuits[provider]
+	if c == nil {
+		return true
+	}
+	switch c.state {
+	case circuitOpen:
+		if b.now().Sub(c.openedAt) < b.cooldown {
+			return false
+		}
+		b.transition(provider, c, circuitHalfOpen)
+		c.probing = true
+		return true
+	case circuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the circuit of provider with the final outcome of one request.
+func (b *circuitBreaker) record(provider string, err error) {
+	outcome := circuitOutcomeOf(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 {
+		return
+	}
+	c := b.circuits[provider]
+	switch outcome {
+	case circuitNeutral:
+		if c != nil && c.state == circuitHalfOpen {
+			c.probing = false
+		}
+	case circuitHealthy:
+		if c == nil {
+			return
+		}
+		if c.state != circuitClosed {
+			b.transition(provider, c, circuitClosed)
+		}
+		c.failures = 0
+		c.probing = false
+	case circuitFailure:
+		if c == nil {
+			c = &providerCircuit{}
+			b.circuits[provider] = c
+		}
+		c.failures++
+		c.probing = false
+		if c.state == circuitHalfOpen || (c.state == circuitClosed && c.failures >= b.threshold) {
+			c.openedAt = b.now()
+			b.transition(provider, c, circuitOpen)
+		}
+	}
+}
+
+// release gives up a half-open trial slot without judging the upstream, e.g. when no
+// credential could be picked for the trial request.
+func (b *circuitBreaker) release(provider string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuits[provider]; c != nil && c.state == circuitHalfOpen {
+		c.probing = false
+	}
+}
+
+// transition moves c into next and records circuits opening. Callers hold b.mu.
+func (b *circuitBreaker) transition(provider string, c *providerCircuit, next circuitState) {
+	log.Infof("circuit breaker: provider %s %s -> %s", provider, c.state, next)
+	c.state = next
+	if next == circuitOpen {
+		recordCircuit("circuit_open")
+	}
+}
+
+type circuitOutcome int
+
+const (
+	circuitHealthy circuitOutcome = iota
+	circuitFailure
+	circuitNeutral
+)
+
+// circuitOutcomeOf classifies an execution error. Only upstream 5xx responses, 408s and
+// timeouts count as failures; a client 4xx still proves the upstream is answering, and a
+// request the client cancelled says nothing about upstream health.
+func circuitOutcomeOf(err error) circuitOutcome {
+	if err == nil {
+		return circuitHealthy
+	}
+	if errors.Is(err, context.Canceled) {
+		return circuitNeutral
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return circuitFailure
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return circuitFailure
+	}
+	var se cliproxyexecutor.StatusError
+	if errors.As(err, &se) && se != nil {
+		if status := se.StatusCode(); status > 0 {
+			if status >= http.StatusInternalServerError || status == http.StatusRequestTimeout {
+				return circuitFailure
+			}
+			return circuitHealthy
+		}
+	}
+	// Errors without a status never reached a response, e.g. connection refused.
+	return circuitFailure
+}
+
+// circuitOpenError is returned for requests short-circuited by an open circuit. Its
+// message is a complete OpenAI error body so handlers pass it through unchanged.
+func circuitOpenError(provider string) *Error {
+	message := fmt.Sprintf("provider %s is temporarily unavailable after repeated upstream failures; retry later", strings.TrimSpace(provider))
+	return &Error{
+		Message:    string(interfaces.OpenAIErrorBody(http.StatusServiceUnavailable, message, "", "circuit_open")),
+		Retryable:  true,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+// SetCircuitBreakerConfig configures the per-provider circuit breaker. A threshold of zero
+// disables it; a non-positive cooldown falls back to 30 seconds.
+func (m *Manager) SetCircuitBreakerConfig(threshold int, cooldown time.Duration) {
+	if m == nil {
+		return
+	}
+	m.breaker.configure(threshold, cooldown)
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func newTestCircuitBreaker(threshold int, cooldown time.Duration) (*circuitBreaker, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	b := newCircuitBreaker()
+	b.now = func() time.Time { return now }
+	b.configure(threshold, cooldown)
+	return b, &now
+}
+
+func TestCircuitBreaker_OpensCoolsDownAndRecovers(t *testing.T) {
+	var opened atomic.Int32
+	SetCircuitRecorder(func(kind string) {
+		if kind == "circuit_open" {
+			opened.Add(1)
+		}
+	})
+	t.Cleanup(func() { SetCircuitRecorder(nil) })
+
+	b, now := newTestCircuitBreaker(3, time.Minute)
+	upstreamDown := &Error{Message: "bad gateway", HTTPStatus: http.StatusBadGateway}
+
+	for i := 0; i < 2; i++ {
+		if !b.allow("copilot") {
+			t.Fatalf("request %d rejected before threshold", i)
+		}
+		b.record("copilot", upstreamDown)
+	}
+	b.record("copilot", context.DeadlineExceeded)
+	if b.allow("copilot") {
+		t.Fatal("expected circuit to open after 3 consecutive failures")
+	}
+	if !b.allow("codex") {
+		t.Fatal("other providers must not be affected")
+	}
+	if got := opened.Load(); got != 1 {
+		t.Fatalf("circuit_open recorded %d times, want 1", got)
+	}
+
+	*now = now.Add(time.Minute)
+	if !b.allow("copilot") {
+		t.Fatal("expected half-open trial request after cooldown")
+	}
+	if b.allow("copilot") {
+		t.Fatal("only one trial request may be in flight while half-open")
+	}
+	b.record("copilot", upstreamDown)
+	if b.allow("copilot") {
+		t.Fatal("failed trial request must re-open the circuit")
+	}
+	if got := opened.Load(); got != 2 {
+		t.Fatalf("circuit_open recorded %d times, want 2", got)
+	}
+
+	*now = now.Add(time.Minute)
+	if !b.allow("copilot") {
+		t.Fatal("expected another trial request after the second cooldown")
+	}
+	b.record("copilot", nil)
+	for i := 0; i < 3; i++ {
+		if !b.allow("copilot") {
+			t.Fatalf("request %d rejected after recovery", i)
+		}
+	}
+}
+
+func TestCircuitBreaker_IgnoresClientErrors(t *testing.T) {
+	b, _ := newTestCircuitBreaker(2, time.Minute)
+	for _, err := range []error{
+		&Error{Message: "bad request", HTTPStatus: http.StatusBadRequest},
+		&Error{Message: "unauthorized", HTTPStatus: http.StatusUnauthorized},
+		&Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests},
+		context.Canceled,
+		&Error{Message: "bad request", HTTPStatus: http.StatusBadRequest},
+	} {
+		b.record("copilot", err)
+	}
+	if !b.allow("copilot") {
+		t.Fatal("client errors must not open the circuit")
+	}
+
+	// A 4xx between upstream failures proves the upstream answers and resets the count.
+	b.record("copilot", &Error{Message: "down", HTTPStatus: http.StatusServiceUnavailable})
+	b.record("copilot", &Error{Message: "bad request", HTTPStatus: http.StatusBadRequest})
+	b.record("copilot", &Error{Message: "down", HTTPStatus: http.StatusServiceUnavailable})
+	if !b.allow("copilot") {
+		t.Fatal("failures separated by a client error are not consecutive")
+	}
+}
+
+func TestCircuitBreaker_DisabledByDefault(t *testing.T) {
+	b := newCircuitBreaker()
+	for i := 0; i < 10; i++ {
+		b.record("copilot", errors.New("connection refused"))
+	}
+	if !b.allow("copilot") {
+		t.Fatal("breaker without a threshold must never open")
+	}
+}
+
+// failingExecutor fails every call with status so the manager feeds the breaker.
+type failingExecutor struct {
+	mockProviderExecutor
+	status int
+	calls  atomic.Int32
+}
+
+func (e *failingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.calls.Add(1)
+	return cliproxyexecutor.Response{}, &Error{Message: "upstream failed", HTTPStatus: e.status}
+}
+
+func TestManager_CircuitBreakerShortCircuitsProvider(t *testing.T) {
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	mgr.SetCircuitBreakerConfig(1, time.Minute)
+	executor := &failingExecutor{mockProviderExecutor: mockProviderExecutor{id: "breaker-test"}, status: http.StatusInternalServerError}
+	mgr.RegisterExecutor(executor)
+	if _, err := mgr.Register(context.Background(), &Auth{ID: "breaker-auth", Provider: "breaker-test"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{"forced_provider": true}}
+	req := cliproxyexecutor.Request{Model: "breaker-model"}
+
+	if _, err := mgr.Execute(context.Background(), []string{"breaker-test"}, req, opts); err == nil {
+		t.Fatal("expected the upstream failure to be returned")
+	}
+	_, err := mgr.Execute(context.Background(), []string{"breaker-test"}, req, opts)
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.HTTPStatus != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want a 503 from the open circuit", err)
+	}
+	if code := gjson.Get(authErr.Error(), "error.code").String(); code != "circuit_open" {
+		t.Fatalf("error body = %s, want an OpenAI error with code circuit_open", authErr.Error())
+	}
+	if got := executor.calls.Load(); got != 1 {
+		t.Fatalf("executor calls = %d, want 1", got)
+	}
+}
+
+// perAuthExecutor fails calls for the auth IDs in failing and succeeds for the rest.
+type perAuthExecutor struct {
+	mockProviderExecutor
+	failing map[string]bool
+	calls   sync.Map
+}
+
+func (e *perAuthExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	n, _ := e.calls.LoadOrStore(auth.ID, new(atomic.Int32))
+	n.(*atomic.Int32).Add(1)
+	if e.failing[auth.ID] {
+		return cliproxyexecutor.Response{}, &Error{Message: "upstream failed", HTTPStatus: http.StatusInternalServerError}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{}`)}, nil
+}
+
+func TestManager_CircuitBreakerIgnoresFailoverToHealthyCredential(t *testing.T) {
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	mgr.SetCircuitBreakerConfig(1, time.Minute)
+	executor := &perAuthExecutor{mockProviderExecutor: mockProviderExecutor{id: "breaker-failover"}, failing: map[string]bool{"breaker-bad": true}}
+	mgr.RegisterExecutor(executor)
+	for _, id := range []string{"breaker-bad", "breaker-good"} {
+		if _, err := mgr.Register(context.Background(), &Auth{ID: id, Provider: "breaker-failover"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	var opened atomic.Int32
+	SetCircuitRecorder(func(string) { opened.Add(1) })
+	t.Cleanup(func() { SetCircuitRecorder(nil) })
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{"forced_provider": true}}
+	req := cliproxyexecutor.Request{Model: "breaker-model"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := mgr.Execute(context.Background(), []string{"breaker-failover"}, req, opts); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+	}
+	if n, ok := executor.calls.Load("breaker-bad"); !ok || n.(*atomic.Int32).Load() == 0 {
+		t.Fatal("expected the failing credential to be tried")
+	}
+	if got := opened.Load(); got != 0 {
+		t.Fatalf("circuit opened %d time(s) although every request succeeded on a healthy credential", got)
+	}
+}
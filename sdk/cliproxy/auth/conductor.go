@@ -123,6 +123,12 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// Optional credential provider consulted by executors for upstream tokens.
+	credProvider CredentialProvider
+
+	// breaker short-circuits providers whose upstream keeps failing.
+	breaker *circuitBreaker
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -142,6 +148,7 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		hook:            hook,
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
+		breaker:         newCircuitBreaker(),
 	}
 }
 
@@ -171,6 +178,20 @@ func (m *Manager) SetRoundTripperProvider(p RoundTripperProvider) {
 	m.mu.Unlock()
 }
 
+// SetCredentialProvider registers a provider that executors consult for upstream tokens
+// before falling back to the credentials held by the auth entry.
+func (m *Manager) SetCredentialProvider(p CredentialProvider) {
+	m.mu.Lock()
+	m.credProvider = p
+	m.mu.Unlock()
+}
+
+func (m *Manager) credentialProviderFor() CredentialProvider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.credProvider
+}
+
 // SetRetryConfig updates retry attempts and cooldown wait interval.
 func (m *Manager) SetRetryConfig(retry int, maxRetryInterval time.Duration) {
 	if m == nil {
@@ -382,6 +403,9 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 	if provider == "" {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
+	if !m.breaker.allow(provider) {
+		return cliproxyexecutor.Response{}, circuitOpenError(provider)
+	}
 	routeModel := req.Model
 	tried := make(map[string]struct{})
 	var lastErr error
@@ -389,8 +413,11 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
+				// Every credential failed: judge the provider once, by the last attempt.
+				m.breaker.record(provider, lastErr)
 				return cliproxyexecutor.Response{}, lastErr
 			}
+			m.breaker.release(provider)
 			return cliproxyexecutor.Response{}, errPick
 		}
 
@@ -403,6 +430,9 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if cp := m.credentialProviderFor(); cp != nil {
+			execCtx = WithCredentialProvider(execCtx, cp)
+		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
@@ -421,6 +451,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			lastErr = errExec
 			continue
 		}
+		m.breaker.record(provider, nil)
 		m.MarkResult(execCtx, result)
 		return resp, nil
 	}
@@ -430,6 +461,9 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 	if provider == "" {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
+	if !m.breaker.allow(provider) {
+		return cliproxyexecutor.Response{}, circuitOpenError(provider)
+	}
 	routeModel := req.Model
 	tried := make(map[string]struct{})
 	var lastErr error
@@ -437,8 +471,11 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
+				// Every credential failed: judge the provider once, by the last attempt.
+				m.breaker.record(provider, lastErr)
 				return cliproxyexecutor.Response{}, lastErr
 			}
+			m.breaker.release(provider)
 			return cliproxyexecutor.Response{}, errPick
 		}
 
@@ -451,6 +488,9 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if cp := m.credentialProviderFor(); cp != nil {
+			execCtx = WithCredentialProvider(execCtx, cp)
+		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
@@ -469,6 +509,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 			lastErr = errExec
 			continue
 		}
+		m.breaker.record(provider, nil)
 		m.MarkResult(execCtx, result)
 		return resp, nil
 	}
@@ -478,6 +519,9 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 	if provider == "" {
 		return nil, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
+	if !m.breaker.allow(provider) {
+		return nil, circuitOpenError(provider)
+	}
 	routeModel := req.Model
 	tried := make(map[string]struct{})
 	var lastErr error
@@ -485,8 +529,11 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
+				// Every credential failed: judge the provider once, by the last attempt.
+				m.breaker.record(provider, lastErr)
 				return nil, lastErr
 			}
+			m.breaker.release(provider)
 			return nil, errPick
 		}
 
@@ -499,6 +546,9 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if cp := m.credentialProviderFor(); cp != nil {
+			execCtx = WithCredentialProvider(execCtx, cp)
+		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
@@ -527,11 +577,13 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 					if errors.As(chunk.Err, &se) && se != nil {
 						rerr.HTTPStatus = se.StatusCode()
 					}
+					m.breaker.record(streamProvider, chunk.Err)
 					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
 				}
 				out <- chunk
 			}
 			if !failed {
+				m.breaker.record(streamProvider, nil)
 				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 			}
 		}(execCtx, auth.Clone(), provider, chunks)
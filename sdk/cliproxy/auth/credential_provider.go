@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialProviderContextKey is an unexported context key type to avoid collisions.
+type credentialProviderContextKey struct{}
+
+// WithCredentialProvider returns a copy of ctx carrying cp, the way the Manager exposes its
+// CredentialProvider to executors.
+func WithCredentialProvider(ctx context.Context, cp CredentialProvider) context.Context {
+	return context.WithValue(ctx, credentialProviderContextKey{}, cp)
+}
+
+// CredentialProviderFromContext returns the CredentialProvider attached to ctx, if any.
+func CredentialProviderFromContext(ctx context.Context) (CredentialProvider, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	cp, ok := ctx.Value(credentialProviderContextKey{}).(CredentialProvider)
+	return cp, ok && cp != nil
+}
+
+// ErrCredentialNotFound reports that a CredentialProvider has no token for a provider.
+// Executors then use the credentials held by the auth entry.
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialProvider supplies upstream tokens at request time, so tokens kept in an external
+// secrets manager can rotate without a restart. Implementations should cache as needed; Get
+// is called for every upstream request. A zero expiry means the token does not expire.
+type CredentialProvider interface {
+	Get(ctx context.Context, provider string) (token string, expiry time.Time, err error)
+}
+
+// StaticCredentialProvider serves fixed tokens keyed by provider. Providers without an entry
+// report ErrCredentialNotFound, so the zero value defers entirely to configured credentials.
+type StaticCredentialProvider struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+// NewStaticCredentialProvider creates a provider serving tokens keyed by provider name.
+func NewStaticCredentialProvider(tokens map[string]string) *StaticCredentialProvider {
+	p := &StaticCredentialProvider{tokens: make(map[string]string, len(tokens))}
+	for provider, token := range tokens {
+		p.tokens[strings.ToLower(strings.TrimSpace(provider))] = token
+	}
+	return p
+}
+
+// Set replaces the token served for provider.
+func (p *StaticCredentialProvider) Set(provider, token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tokens == nil {
+		p.tokens = make(map[string]string)
+	}
+	p.tokens[strings.ToLower(strings.TrimSpace(provider))] = token
+}
+
+// Get returns the token configured for provider.
+func (p *StaticCredentialProvider) Get(_ context.Context, provider string) (string, time.Time, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	token := p.tokens[strings.ToLower(strings.TrimSpace(provider))]
+	if token == "" {
+		return "", time.Time{}, ErrCredentialNotFound
+	}
+	return token, time.Time{}, nil
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// ctxCapturingExecutor records the context each Execute call receives.
+type ctxCapturingExecutor struct {
+	mockProviderExecutor
+	ctx context.Context
+}
+
+func (e *ctxCapturingExecutor) Execute(ctx context.Context, _ *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.ctx = ctx
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestManager_ExposesCredentialProviderToExecutors(t *testing.T) {
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	executor := &ctxCapturingExecutor{mockProviderExecutor: mockProviderExecutor{id: "copilot"}}
+	mgr.RegisterExecutor(executor)
+	if _, err := mgr.Register(context.Background(), &Auth{ID: "cred-auth", Provider: "copilot"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	provider := NewStaticCredentialProvider(map[string]string{"Copilot": "secret"})
+	mgr.SetCredentialProvider(provider)
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{"forced_provider": true}}
+	if _, err := mgr.Execute(context.Background(), []string{"copilot"}, cliproxyexecutor.Request{Model: "any-model"}, opts); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	got, ok := CredentialProviderFromContext(executor.ctx)
+	if !ok || got != provider {
+		t.Fatalf("executor context provider = %v, want the registered provider", got)
+	}
+}
+
+func TestCredentialProviderFromContext_IgnoresStringKey(t *testing.T) {
+	provider := NewStaticCredentialProvider(nil)
+	ctx := context.WithValue(context.Background(), "cliproxy.credentialprovider", provider)
+	if got, ok := CredentialProviderFromContext(ctx); ok {
+		t.Fatalf("provider from string key = %v, want none", got)
+	}
+	if got, ok := CredentialProviderFromContext(WithCredentialProvider(ctx, provider)); !ok || got != provider {
+		t.Fatalf("provider = %v, want the attached provider", got)
+	}
+}
+
+func TestStaticCredentialProvider(t *testing.T) {
+	provider := NewStaticCredentialProvider(map[string]string{" Codex ": "codex-token"})
+	token, expiry, err := provider.Get(context.Background(), "codex")
+	if err != nil || token != "codex-token" || !expiry.IsZero() {
+		t.Fatalf("Get(codex) = %q, %v, %v", token, expiry, err)
+	}
+	if _, _, err = provider.Get(context.Background(), "copilot"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Fatalf("Get(copilot) error = %v, want ErrCredentialNotFound", err)
+	}
+	provider.Set("copilot", "copilot-token")
+	if token, _, _ = provider.Get(context.Background(), "copilot"); token != "copilot-token" {
+		t.Fatalf("Get(copilot) after Set = %q", token)
+	}
+	var empty StaticCredentialProvider
+	if _, _, err = empty.Get(context.Background(), "codex"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Fatalf("zero value error = %v, want ErrCredentialNotFound", err)
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Self-test statuses reported per credential.
+const (
+	SelfTestPass    = "pass"
+	SelfTestFail    = "fail"
+	SelfTestSkipped = "skipped"
+)
+
+// SelfTester is implemented by executors that can verify a credential with a read-only
+// upstream call, such as listing models. It returns the number of models the upstream
+// reported and a short human readable detail.
+type SelfTester interface {
+	SelfTest(ctx context.Context, auth *Auth) (models int, detail string, err error)
+}
+
+// SelfTestResult reports the self-test outcome of one credential.
+type SelfTestResult struct {
+	Provider  string `json:"provider"`
+	AuthID    string `json:"auth_id"`
+	Label     string `json:"label,omitempty"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Models    int    `json:"models,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SelfTest runs the executor self-test for every enabled credential, sorted by provider
+// and auth ID. Credentials whose executor does not implement SelfTester are skipped.
+func (m *Manager) SelfTest(ctx context.Context) []SelfTestResult {
+	auths := m.List()
+	sort.Slice(auths, func(i, j int) bool {
+		if auths[i].Provider != auths[j].Provider {
+			return auths[i].Provider < auths[j].Provider
+		}
+		return auths[i].ID < auths[j].ID
+	})
+
+	results := make([]SelfTestResult, 0, len(auths))
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled {
+			continue
+		}
+		result := SelfTestResult{Provider: auth.Provider, AuthID: auth.ID, Label: auth.Label, Status: SelfTestSkipped}
+		tester, ok := m.executorFor(auth.Provider).(SelfTester)
+		if !ok {
+			result.Detail = "executor does not support self-test"
+			results = append(results, result)
+			continue
+		}
+		execCtx := ctx
+		if rt := m.roundTripperFor(auth); rt != nil {
+			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+		}
+		if cp := m.credentialProviderFor(); cp != nil {
+			execCtx = WithCredentialProvider(execCtx, cp)
+		}
+		start := time.Now()
+		models, detail, err := tester.SelfTest(execCtx, auth)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		result.Models = models
+		result.Detail = detail
+		if err != nil {
+			result.Status = SelfTestFail
+			result.Error = err.Error()
+		} else {
+			result.Status = SelfTestPass
+		}
+		results = append(results, result)
+	}
+	return results
+}
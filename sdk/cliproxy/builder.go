@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -47,6 +48,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// credentialProvider supplies upstream tokens to executors at request time.
+	credentialProvider coreauth.CredentialProvider
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -137,6 +141,13 @@ func (b *Builder) WithCoreAuthManager(mgr *coreauth.Manager) *Builder {
 	return b
 }
 
+// WithCredentialProvider sets the provider executors consult for upstream tokens, e.g. one
+// backed by a secrets manager. Without it, tokens come from the config and auth files.
+func (b *Builder) WithCredentialProvider(provider coreauth.CredentialProvider) *Builder {
+	b.credentialProvider = provider
+	return b
+}
+
 // WithServerOptions appends server configuration options used during construction.
 func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	b.serverOptions = append(b.serverOptions, opts...)
@@ -160,6 +171,7 @@ func (b *Builder) Build() (*Service, error) {
 	if b.configPath == "" {
 		return nil, fmt.Errorf("cliproxy: configuration path is required")
 	}
+	registerMetricsRecorders()
 
 	tokenProvider := b.tokenProvider
 	if tokenProvider == nil {
@@ -211,11 +223,18 @@ func (b *Builder) Build() (*Service, error) {
 			selector = &coreauth.RoundRobinSelector{}
 		}
 
-		coreManager = coreauth.NewManager(tokenStore, selector, nil)
+		authHook := metrics.NewAuthHook()
+		coreManager = coreauth.NewManager(tokenStore, selector, authHook)
+		authHook.SetAuthLookup(coreManager.GetByID)
 	}
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetOAuthModelMappings(b.cfg.OAuthModelMappings)
+	credentialProvider := b.credentialProvider
+	if credentialProvider == nil {
+		credentialProvider = &coreauth.StaticCredentialProvider{}
+	}
+	coreManager.SetCredentialProvider(credentialProvider)
 
 	service := &Service{
 		cfg:            b.cfg,
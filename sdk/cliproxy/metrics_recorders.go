@@ -0,0 +1,23 @@
+package cliproxy
+
+import (
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+var registerMetricsRecordersOnce sync.Once
+
+// registerMetricsRecorders routes handler rejections, provider circuits opening and
+// translation failures into the metrics registry. The SDK packages expose recorder hooks
+// so the metrics package does not depend on the layers it instruments.
+func registerMetricsRecorders() {
+	registerMetricsRecordersOnce.Do(func() {
+		handlers.SetRejectionRecorder(metrics.RecordError)
+		coreauth.SetCircuitRecorder(metrics.RecordError)
+		sdktranslator.SetFailureRecorder(metrics.RecordTranslationError)
+	})
+}
@@ -0,0 +1,73 @@
+package cliproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	modelregistry "github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+)
+
+// newMetricsScraper enables metrics with the SDK recorders installed and returns a
+// function that scrapes /metrics.
+func newMetricsScraper(t *testing.T) func() string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	if err := metrics.New().Register(modules.Context{Engine: engine, Config: &internalconfig.Config{MetricsEnabled: true}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	t.Cleanup(func() { metrics.SetEnabled(false) })
+	registerMetricsRecorders()
+	return func() string {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+}
+
+func TestMetricsRecorders_RecordHandlerRejections(t *testing.T) {
+	scrape := newMetricsScraper(t)
+
+	reg := modelregistry.GetGlobalRegistry()
+	reg.RegisterClient("recorders-rl-client", "copilot", []*modelregistry.ModelInfo{{ID: "recorders-rl-model"}})
+	defer reg.UnregisterClient("recorders-rl-client")
+
+	h := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{ModelRateLimits: map[string]int{"recorders-rl-model": 1}}, coreauth.NewManager(nil, nil, nil))
+	// The first request consumes the only token; the second is rejected before dispatch.
+	_, _ = h.ExecuteWithAuthManager(context.Background(), "openai", "recorders-rl-model", []byte(`{}`), "")
+	_, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "recorders-rl-model", []byte(`{}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %+v", errMsg)
+	}
+	want := `cliproxy_errors_total{type="rate_limited"} 1`
+	if body := scrape(); !strings.Contains(body, want) {
+		t.Fatalf("expected %s in scrape, got:\n%s", want, body)
+	}
+}
+
+func TestMetricsRecorders_RecordTranslationErrors(t *testing.T) {
+	scrape := newMetricsScraper(t)
+
+	// A truncated Responses body cannot be translated into a Chat Completions request.
+	_ = sdktranslator.TranslateRequest(sdktranslator.FormatOpenAIResponse, sdktranslator.FormatOpenAI, "gpt-4.1", []byte(`{"model":"gpt-4.1","input":[`), false)
+	// Well-formed bodies are not counted.
+	_ = sdktranslator.TranslateRequest(sdktranslator.FormatOpenAIResponse, sdktranslator.FormatOpenAI, "gpt-4.1", []byte(`{"model":"gpt-4.1","input":"hi"}`), false)
+
+	want := `cliproxy_translation_errors_total{direction="request",format="openai-response->openai"} 1`
+	if body := scrape(); !strings.Contains(body, want) {
+		t.Fatalf("expected %s in scrape, got:\n%s", want, body)
+	}
+}
@@ -323,6 +323,14 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	}
 	maxInterval := time.Duration(cfg.MaxRetryInterval) * time.Second
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
+	s.coreManager.SetCircuitBreakerConfig(cfg.CircuitBreaker.FailureThreshold, cfg.CircuitBreaker.CooldownDuration())
+}
+
+func (s *Service) applyRegistryConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	registry.GetGlobalRegistry().SetStrictModelValidation(cfg.StrictModelValidation)
 }
 
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
@@ -445,6 +453,7 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	s.applyRegistryConfig(s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -560,6 +569,7 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 
 		s.applyRetryConfig(newCfg)
+		s.applyRegistryConfig(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -577,6 +587,9 @@ func (s *Service) Run(ctx context.Context) error {
 		return fmt.Errorf("cliproxy: failed to create watcher: %w", err)
 	}
 	s.watcher = watcherWrapper
+	if s.server != nil {
+		s.server.SetConfigReloadHandler(watcherWrapper.ReloadConfig)
+	}
 	s.ensureAuthUpdateQueue(ctx)
 	if s.authUpdates != nil {
 		watcherWrapper.SetAuthUpdateQueue(s.authUpdates)
@@ -823,7 +836,11 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		cancel()
 		if len(models) == 0 {
 			log.Warnf("copilot: using static fallback models for auth %s", a.ID)
-			models = registry.GetCopilotModels()
+			if s.cfg != nil && s.cfg.DisableCopilotAliases {
+				models = registry.GetCopilotBaseModels()
+			} else {
+				models = registry.GetCopilotModels()
+			}
 		}
 	case "qwen":
 		models = registry.GetQwenModels()
@@ -902,6 +919,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 						if providerKey == "" {
 							providerKey = "openai-compatibility"
 						}
+						ms = applyModelPricing(ms, s.cfg.ModelPricing)
+						ms = applyModelDeprecations(ms, s.cfg.ModelDeprecations)
+						ms = applyModelSystemRoles(ms, s.cfg.ModelSystemRoles)
 						GlobalModelRegistry().RegisterClient(a.ID, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
 					} else {
 						// Ensure stale registrations are cleared when model list becomes empty.
@@ -918,6 +938,11 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		}
 	}
 	models = applyOAuthModelMappings(s.cfg, provider, authKind, models)
+	if s.cfg != nil {
+		models = applyModelPricing(models, s.cfg.ModelPricing)
+		models = applyModelDeprecations(models, s.cfg.ModelDeprecations)
+		models = applyModelSystemRoles(models, s.cfg.ModelSystemRoles)
+	}
 	if len(models) > 0 {
 		key := provider
 		if key == "" {
@@ -1103,6 +1128,78 @@ func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 	return filtered
 }
 
+// applyModelPricing attaches configured per-million-token prices to matching models.
+// Matched models are copied so shared static model definitions are never mutated.
+func applyModelPricing(models []*ModelInfo, pricing map[string]config.ModelPrice) []*ModelInfo {
+	if len(models) == 0 || len(pricing) == 0 {
+		return models
+	}
+	out := make([]*ModelInfo, 0, len(models))
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		price, ok := pricing[strings.ToLower(strings.TrimSpace(model.ID))]
+		if !ok {
+			out = append(out, model)
+			continue
+		}
+		clone := *model
+		clone.InputPricePerMillion = price.Input
+		clone.OutputPricePerMillion = price.Output
+		out = append(out, &clone)
+	}
+	return out
+}
+
+// applyModelDeprecations marks models listed under model-deprecations as deprecated.
+// Matched models are copied so shared static model definitions are never mutated.
+func applyModelDeprecations(models []*ModelInfo, deprecations map[string]config.ModelDeprecation) []*ModelInfo {
+	if len(models) == 0 || len(deprecations) == 0 {
+		return models
+	}
+	out := make([]*ModelInfo, 0, len(models))
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		deprecation, ok := deprecations[strings.ToLower(strings.TrimSpace(model.ID))]
+		if !ok {
+			out = append(out, model)
+			continue
+		}
+		clone := *model
+		clone.Deprecated = true
+		clone.DeprecationDate = deprecation.Date
+		clone.ReplacementModel = deprecation.Replacement
+		out = append(out, &clone)
+	}
+	return out
+}
+
+// applyModelSystemRoles sets the instruction role configured under model-system-roles.
+// Matched models are copied so shared static model definitions are never mutated.
+func applyModelSystemRoles(models []*ModelInfo, roles map[string]string) []*ModelInfo {
+	if len(models) == 0 || len(roles) == 0 {
+		return models
+	}
+	out := make([]*ModelInfo, 0, len(models))
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		role, ok := roles[strings.ToLower(strings.TrimSpace(model.ID))]
+		if !ok {
+			out = append(out, model)
+			continue
+		}
+		clone := *model
+		clone.SystemRole = role
+		out = append(out, &clone)
+	}
+	return out
+}
+
 func applyModelPrefixes(models []*ModelInfo, prefix string, forceModelPrefix bool) []*ModelInfo {
 	trimmedPrefix := strings.TrimSpace(prefix)
 	if trimmedPrefix == "" || len(models) == 0 {
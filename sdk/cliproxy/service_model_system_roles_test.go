@@ -0,0 +1,25 @@
+package cliproxy
+
+import "testing"
+
+func TestApplyModelSystemRoles(t *testing.T) {
+	shared := &ModelInfo{ID: "O3"}
+	models := []*ModelInfo{shared, {ID: "gpt-4o"}, {ID: "gpt-4.1"}}
+
+	out := applyModelSystemRoles(models, map[string]string{"o3": "developer", "gpt-4o": "system"})
+	if len(out) != 3 {
+		t.Fatalf("expected 3 models, got %d", len(out))
+	}
+	if out[0].SystemRole != "developer" {
+		t.Fatalf("expected developer role for %q, got %q", out[0].ID, out[0].SystemRole)
+	}
+	if out[1].SystemRole != "system" {
+		t.Fatalf("expected system role for %q, got %q", out[1].ID, out[1].SystemRole)
+	}
+	if out[2].SystemRole != "" {
+		t.Fatalf("expected unlisted model to keep an unknown role, got %q", out[2].SystemRole)
+	}
+	if shared.SystemRole != "" {
+		t.Fatalf("expected shared model definition to stay unmodified, got %q", shared.SystemRole)
+	}
+}
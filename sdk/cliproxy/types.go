@@ -89,6 +89,7 @@ type WatcherWrapper struct {
 	snapshotAuths         func() []*coreauth.Auth
 	setUpdateQueue        func(queue chan<- watcher.AuthUpdate)
 	dispatchRuntimeUpdate func(update watcher.AuthUpdate) bool
+	reloadConfig          func() error
 }
 
 // Start proxies to the underlying watcher Start implementation.
@@ -115,6 +116,14 @@ func (w *WatcherWrapper) SetConfig(cfg *config.Config) {
 	w.setConfig(cfg)
 }
 
+// ReloadConfig forces an immediate reload of the config file through the watcher.
+func (w *WatcherWrapper) ReloadConfig() error {
+	if w == nil || w.reloadConfig == nil {
+		return nil
+	}
+	return w.reloadConfig()
+}
+
 // DispatchRuntimeAuthUpdate forwards runtime auth updates (e.g., websocket providers)
 // into the watcher-managed auth update queue when available.
 // Returns true if the update was enqueued successfully.
@@ -17,6 +17,7 @@ type Record struct {
 	AuthIndex   string
 	Source      string
 	RequestedAt time.Time
+	Duration    time.Duration
 	Failed      bool
 	Detail      Detail
 }
@@ -13,10 +13,14 @@ type AccessProvider = internalconfig.AccessProvider
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type BatchesConfig = internalconfig.BatchesConfig
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
 type ModelNameMapping = internalconfig.ModelNameMapping
+type ModelPrice = internalconfig.ModelPrice
+type ModelDeprecation = internalconfig.ModelDeprecation
 type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadModelRule = internalconfig.PayloadModelRule
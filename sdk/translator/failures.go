@@ -0,0 +1,56 @@
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+)
+
+// Translation directions reported to the failure recorder.
+const (
+	DirectionRequest  = "request"
+	DirectionResponse = "response"
+)
+
+// failureRecorder receives the direction and "from->to" format pair of each failed translation.
+var failureRecorder atomic.Value
+
+// SetFailureRecorder installs fn to observe failed translations. A request translation fails
+// when its input or output is not valid JSON or its output is empty; a non-streaming response
+// translation fails when it turns a non-empty upstream body into empty or invalid JSON.
+// Streaming chunks are not checked because translators legitimately emit nothing for some
+// upstream events. The metrics module installs its translation error counter here.
+func SetFailureRecorder(fn func(direction, format string)) {
+	failureRecorder.Store(fn)
+}
+
+func loadFailureRecorder() func(string, string) {
+	fn, _ := failureRecorder.Load().(func(string, string))
+	return fn
+}
+
+func formatPair(from, to Format) string {
+	return from.String() + "->" + to.String()
+}
+
+// checkRequestTranslation reports a failed request translation from -> to.
+func checkRequestTranslation(from, to Format, in, out []byte) {
+	fn := loadFailureRecorder()
+	if fn == nil {
+		return
+	}
+	if len(out) == 0 || !json.Valid(out) || (len(in) > 0 && !json.Valid(in)) {
+		fn(DirectionRequest, formatPair(from, to))
+	}
+}
+
+// checkResponseTranslation reports a failed non-streaming response translation from -> to.
+func checkResponseTranslation(from, to Format, in []byte, out string) {
+	fn := loadFailureRecorder()
+	if fn == nil || len(in) == 0 {
+		return
+	}
+	if trimmed := strings.TrimSpace(out); trimmed == "" || !json.Valid([]byte(trimmed)) {
+		fn(DirectionResponse, formatPair(from, to))
+	}
+}
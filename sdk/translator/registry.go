@@ -46,7 +46,9 @@ func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byt
 
 	if byTarget, ok := r.requests[from]; ok {
 		if fn, isOk := byTarget[to]; isOk && fn != nil {
-			return fn(model, rawJSON, stream)
+			out := fn(model, rawJSON, stream)
+			checkRequestTranslation(from, to, rawJSON, out)
+			return out
 		}
 	}
 	return rawJSON
@@ -85,7 +87,9 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
-			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			out := fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			checkResponseTranslation(from, to, rawJSON, out)
+			return out
 		}
 	}
 	return string(rawJSON)